@@ -10,17 +10,21 @@ import (
 )
 
 func Command(settings *conf.Settings) *cobra.Command {
-	return &cobra.Command{
+	var save bool
+	cmd := &cobra.Command{
 		Use:   "benchmark",
 		Short: "Run BirdNET inference benchmark",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runBenchmark(settings)
+			return runBenchmark(settings, save)
 		},
 	}
+	cmd.Flags().BoolVar(&save, "save", false, "Save the recommended batch size for file analysis to the configuration file")
+	return cmd
 }
 
-func runBenchmark(settings *conf.Settings) error {
+func runBenchmark(settings *conf.Settings, save bool) error {
 	var xnnpackResults, standardResults benchmarkResults
+	useXNNPACK := settings.BirdNET.UseXNNPACK
 
 	// First run with XNNPACK
 	fmt.Println("🚀 Testing with XNNPACK delegate:")
@@ -73,6 +77,27 @@ func runBenchmark(settings *conf.Settings) error {
 		fmt.Printf("System Rating: %s, %s\n", rating, description)
 	}
 
+	// Pick the batch size for file analysis with the configured delegate
+	settings.BirdNET.UseXNNPACK = useXNNPACK
+	fmt.Println("\n📦 Testing batch sizes for file analysis:")
+	batchSize, err := runBatchBenchmark(settings)
+	if err != nil {
+		return fmt.Errorf("❌ batch size benchmark failed: %w", err)
+	}
+	fmt.Printf("\n📦 Best batch size for file analysis: %d\n", batchSize)
+
+	// The configuration file is only changed on request
+	if !save {
+		fmt.Printf("Set birdnet.batchsize to %d in config.yaml, or run the benchmark with --save to save it\n", batchSize)
+		return nil
+	}
+	settings.BirdNET.BatchSize = batchSize
+	if err := conf.SaveSettings(); err != nil {
+		fmt.Printf("⚠️ Failed to save batch size, set birdnet.batchsize to %d in config.yaml: %v\n", batchSize, err)
+	} else {
+		fmt.Printf("✅ Saved birdnet.batchsize %d to config.yaml\n", batchSize)
+	}
+
 	return nil
 }
 
@@ -91,8 +116,8 @@ func runInferenceBenchmark(settings *conf.Settings, results *benchmarkResults) e
 	}
 	defer bn.Delete()
 
-	// Generate 3 seconds of silent audio (48000 * 3 samples)
-	sampleSize := 48000 * 3
+	// Generate an analysis window of silent audio at the model sample rate
+	sampleSize := birdnet.ActiveModel().Samples()
 	silentChunk := make([]float32, sampleSize)
 
 	// Run for 30 seconds
@@ -150,3 +175,68 @@ func getPerformanceRating(inferenceTime float64) (rating, description string) {
 		return "🚀 Superb", "System will perform exceptionally well"
 	}
 }
+
+// batchSizes are the batch sizes tested for file analysis
+var batchSizes = []int{1, 2, 4, 8, 16, 32, 64}
+
+// runBatchBenchmark measures the throughput of batched inference for each batch size and
+// returns the batch size to use for file analysis
+func runBatchBenchmark(settings *conf.Settings) (int, error) {
+	bn, err := birdnet.NewBirdNET(settings)
+	if err != nil {
+		return 0, fmt.Errorf("failed to initialize BirdNET: %w", err)
+	}
+	defer bn.Delete()
+
+	silentChunk := make([]float32, birdnet.ActiveModel().Samples())
+	throughput := make(map[int]float64, len(batchSizes))
+
+	fmt.Printf("Batch size     Throughput\n")
+	fmt.Printf("─────────────  ──────────────────────\n")
+	for _, size := range batchSizes {
+		batch := make([][]float32, size)
+		for i := range batch {
+			batch[i] = silentChunk
+		}
+
+		// Run each batch size for 5 seconds after a warm-up inference which allocates the tensors
+		if _, err := bn.PredictBatch(batch); err != nil {
+			// Models without a batch dimension can only be analyzed one chunk at a time
+			if size == 1 {
+				return 0, fmt.Errorf("prediction failed: %w", err)
+			}
+			fmt.Printf("%-13d  ❌ Failed: %v\n", size, err)
+			break
+		}
+
+		duration := 5 * time.Second
+		startTime := time.Now()
+		chunks := 0
+		for time.Since(startTime) < duration {
+			if _, err := bn.PredictBatch(batch); err != nil {
+				return 0, fmt.Errorf("prediction failed: %w", err)
+			}
+			chunks += size
+		}
+		throughput[size] = float64(chunks) / time.Since(startTime).Seconds()
+		fmt.Printf("%-13d  %6.2f chunks/sec\n", size, throughput[size])
+	}
+	fmt.Printf("─────────────  ──────────────────────\n")
+
+	return pickBatchSize(throughput), nil
+}
+
+// pickBatchSize returns the smallest batch size with a throughput within 5% of the best one,
+// larger batches use more memory and increase the latency of progress updates for little gain
+func pickBatchSize(throughput map[int]float64) int {
+	var best float64
+	for _, chunksPerSecond := range throughput {
+		best = max(best, chunksPerSecond)
+	}
+	for _, size := range batchSizes {
+		if throughput[size] >= 0.95*best {
+			return size
+		}
+	}
+	return 1
+}
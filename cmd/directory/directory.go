@@ -18,8 +18,8 @@ import (
 func Command(settings *conf.Settings) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "directory [path]",
-		Short: "Analyze all *.wav files in a directory",
-		Long:  "Provide a directory path to analyze all *.wav files within it.",
+		Short: "Analyze all audio files in a directory",
+		Long:  "Provide a directory path to analyze all audio files within it. Supported formats are WAV, FLAC and AIFF, and MP3, Ogg/Opus and M4A/AAC when FFmpeg is installed.",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Create a context that can be cancelled
@@ -75,6 +75,7 @@ func setupFlags(cmd *cobra.Command, settings *conf.Settings) error {
 	cmd.Flags().BoolVarP(&settings.Input.Watch, "watch", "w", false, "Watch directory for new files")
 	cmd.Flags().StringVarP(&settings.Output.File.Path, "output", "o", viper.GetString("output.file.path"), "Path to output directory")
 	cmd.Flags().StringVar(&settings.Output.File.Type, "type", viper.GetString("output.file.type"), "Output type: table, csv")
+	cmd.Flags().BoolVar(&settings.Input.SplitChannels, "split-channels", false, "Analyze each channel of multi-channel files separately")
 
 	if err := viper.BindPFlags(cmd.Flags()); err != nil {
 		return fmt.Errorf("error binding flags: %w", err)
@@ -19,7 +19,7 @@ func Command(settings *conf.Settings) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "file [input.wav]",
 		Short: "Analyze an audio file",
-		Long:  `Analyze a single audio file for bird calls and songs.`,
+		Long:  "Analyze a single audio file for bird calls and songs. Supported formats are WAV, FLAC and AIFF, and MP3, Ogg/Opus and M4A/AAC when FFmpeg is installed.",
 		Args:  cobra.ExactArgs(1), // the command expects exactly one argument
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Create a context that can be cancelled
@@ -67,6 +67,7 @@ func setupFlags(cmd *cobra.Command, settings *conf.Settings) error {
 
 	cmd.Flags().StringVarP(&settings.Output.File.Path, "output", "o", viper.GetString("output.file.path"), "Path to output directory")
 	cmd.Flags().StringVar(&settings.Output.File.Type, "type", viper.GetString("output.file.type"), "Output type: table, csv")
+	cmd.Flags().BoolVar(&settings.Input.SplitChannels, "split-channels", false, "Analyze each channel of multi-channel files separately")
 
 	if err := viper.BindPFlags(cmd.Flags()); err != nil {
 		return fmt.Errorf("error binding flags: %w", err)
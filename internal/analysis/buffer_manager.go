@@ -45,12 +45,10 @@ func (m *BufferManager) AddMonitor(source string) {
 
 // RemoveMonitor safely stops and removes a monitor for a source
 func (m *BufferManager) RemoveMonitor(source string) {
-	// Get the monitor's quit channel
-	if quitChan, exists := m.monitors.Load(source); exists {
+	// Get and remove the monitor's quit channel atomically so concurrent removals close it only once
+	if quitChan, exists := m.monitors.LoadAndDelete(source); exists {
 		// Signal the monitor to stop
 		close(quitChan.(chan struct{}))
-		// Remove from the map
-		m.monitors.Delete(source)
 	}
 }
 
@@ -24,13 +24,13 @@ type ControlMonitor struct {
 	restartChan      chan struct{}
 	notificationChan chan handlers.Notification
 	bufferManager    *BufferManager
+	sourceManager    *myaudio.SourceManager
 	proc             *processor.Processor
-	audioLevelChan   chan myaudio.AudioLevelData
 	bn               *birdnet.BirdNET
 }
 
 // NewControlMonitor creates a new ControlMonitor instance
-func NewControlMonitor(wg *sync.WaitGroup, controlChan chan string, quitChan, restartChan chan struct{}, notificationChan chan handlers.Notification, bufferManager *BufferManager, proc *processor.Processor) *ControlMonitor {
+func NewControlMonitor(wg *sync.WaitGroup, controlChan chan string, quitChan, restartChan chan struct{}, notificationChan chan handlers.Notification, bufferManager *BufferManager, sourceManager *myaudio.SourceManager, proc *processor.Processor) *ControlMonitor {
 	return &ControlMonitor{
 		wg:               wg,
 		controlChan:      controlChan,
@@ -38,8 +38,8 @@ func NewControlMonitor(wg *sync.WaitGroup, controlChan chan string, quitChan, re
 		restartChan:      restartChan,
 		notificationChan: notificationChan,
 		bufferManager:    bufferManager,
+		sourceManager:    sourceManager,
 		proc:             proc,
-		audioLevelChan:   make(chan myaudio.AudioLevelData),
 		bn:               proc.Bn,
 	}
 }
@@ -70,8 +70,8 @@ func (cm *ControlMonitor) handleControlSignal(signal string) {
 		cm.handleReloadBirdnet()
 	case "reconfigure_mqtt":
 		cm.handleReconfigureMQTT()
-	case "reconfigure_rtsp_sources":
-		cm.handleReconfigureRTSP()
+	case "reconfigure_audio_sources", "reconfigure_rtsp_sources":
+		cm.handleReconfigureAudioSources()
 	case "reconfigure_birdweather":
 		cm.handleReconfigureBirdWeather()
 	default:
@@ -101,6 +101,9 @@ func (cm *ControlMonitor) handleReloadBirdnet() {
 	log.Printf("\033[32m✅ BirdNET model reloaded successfully\033[0m")
 	cm.notifySuccess("BirdNET model reloaded successfully")
 
+	// The analysis window of the reloaded model may differ from the previous one
+	myaudio.ResizeAnalysisBuffers()
+
 	// Rebuild range filter after model reload
 	if err := birdnet.BuildRangeFilter(cm.bn); err != nil {
 		log.Printf("\033[31m❌ Error rebuilding range filter after model reload: %v\033[0m", err)
@@ -155,27 +158,18 @@ func (cm *ControlMonitor) handleReconfigureMQTT() {
 	}
 }
 
-// handleReconfigureRTSP reconfigures RTSP sources
-func (cm *ControlMonitor) handleReconfigureRTSP() {
-	log.Printf("\033[32m🔄 Reconfiguring RTSP sources...\033[0m")
+// handleReconfigureAudioSources starts and stops audio sources to match the configured source list
+func (cm *ControlMonitor) handleReconfigureAudioSources() {
+	log.Printf("\033[32m🔄 Reconfiguring audio sources...\033[0m")
 	settings := conf.Setting()
 
-	// Prepare the list of active sources
-	var sources []string
-	if len(settings.Realtime.RTSP.URLs) > 0 {
-		sources = append(sources, settings.Realtime.RTSP.URLs...)
-	}
-	if settings.Realtime.Audio.Source != "" {
-		sources = append(sources, "malgo")
+	if err := reconfigureAudioSources(settings, cm.sourceManager); err != nil {
+		log.Printf("\033[31m❌ Error reconfiguring audio sources: %v\033[0m", err)
+		cm.notifyError("Failed to reconfigure audio sources", err)
+		return
 	}
 
-	// Update the analysis buffer monitors
-	cm.bufferManager.UpdateMonitors(sources)
-
-	// Reconfigure RTSP streams
-	myaudio.ReconfigureRTSPStreams(settings, cm.wg, cm.quitChan, cm.restartChan, cm.audioLevelChan)
-
-	log.Printf("\033[32m✅ RTSP sources reconfigured successfully\033[0m")
+	log.Printf("\033[32m✅ Audio sources reconfigured successfully\033[0m")
 	cm.notifySuccess("Audio capture reconfigured successfully")
 }
 
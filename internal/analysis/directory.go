@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/myaudio"
 )
 
 // cleanupProcessingFiles removes all .processing files from the output directory
@@ -43,19 +44,21 @@ func isProcessed(path, outputPath string, processedFiles map[string]bool) bool {
 	// Get the base filename without extension
 	baseName := filepath.Base(path)
 
-	// Check for output files
-	outputPathCSV := filepath.Join(outputPath, baseName+".csv")
-	outputPathTable := filepath.Join(outputPath, baseName+".txt")
+	// Check for output files, including the first channel output of split channel analysis
+	outputPaths := []string{
+		filepath.Join(outputPath, baseName+".csv"),
+		filepath.Join(outputPath, baseName+".txt"),
+		filepath.Join(outputPath, baseName+".ch1.csv"),
+		filepath.Join(outputPath, baseName+".ch1.txt"),
+	}
 	outputPathProcessing := filepath.Join(outputPath, baseName+".processing")
 
 	// Check if any of the output files exist
-	if _, err := os.Stat(outputPathCSV); err == nil {
-		processedFiles[path] = true
-		return true
-	}
-	if _, err := os.Stat(outputPathTable); err == nil {
-		processedFiles[path] = true
-		return true
+	for _, outputFile := range outputPaths {
+		if _, err := os.Stat(outputFile); err == nil {
+			processedFiles[path] = true
+			return true
+		}
 	}
 
 	// Check for processing lock file
@@ -192,12 +195,7 @@ func processFile(path string, settings *conf.Settings, processedFiles map[string
 
 	// Create processing lock file
 	outputPath := filepath.Join(settings.Output.File.Path, filepath.Base(path))
-	ext := strings.ToLower(filepath.Ext(outputPath))
-	if ext == ".wav" {
-		outputPath = outputPath[:len(outputPath)-4]
-	} else if ext == ".flac" {
-		outputPath = outputPath[:len(outputPath)-5]
-	}
+	outputPath = strings.TrimSuffix(outputPath, filepath.Ext(outputPath))
 	lockFile := outputPath + ".processing"
 
 	// Try to create lock file
@@ -278,9 +276,8 @@ func scanDirectory(watchDir string, settings *conf.Settings, processedFiles map[
 			return nil
 		}
 
-		// Check for both .wav and .flac files (case-insensitive)
-		ext := strings.ToLower(filepath.Ext(d.Name()))
-		if ext == ".wav" || ext == ".flac" {
+		// Check for supported audio files (case-insensitive)
+		if myaudio.IsSupportedAudioFile(d.Name()) {
 			wasProcessed, err := processFile(path, settings, processedFiles, ctx)
 			if err != nil {
 				if errors.Is(err, context.Canceled) {
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -48,14 +47,14 @@ func FileAnalysis(settings *conf.Settings, ctx context.Context) error {
 		// For other errors with partial results, write them
 		if len(notes) > 0 {
 			fmt.Printf("\n\033[33m⚠️  Writing partial results before exiting due to error\033[0m\n")
-			if writeErr := writeResults(settings, notes); writeErr != nil {
+			if writeErr := writeResults(settings, notes, audioInfo.NumChannels); writeErr != nil {
 				return fmt.Errorf("analysis error: %w; failed to write partial results: %w", err, writeErr)
 			}
 		}
 		return err
 	}
 
-	return writeResults(settings, notes)
+	return writeResults(settings, notes, audioInfo.NumChannels)
 }
 
 // validateAudioFile checks if the provided file path is a valid audio file.
@@ -76,8 +75,7 @@ func validateAudioFile(filePath string) error {
 	}
 
 	// Check file extension (case-insensitive)
-	ext := strings.ToLower(filepath.Ext(filePath))
-	if ext != ".wav" && ext != ".flac" {
+	if !myaudio.IsSupportedAudioFile(filePath) {
 		return fmt.Errorf("\033[31m❌ Invalid audio file %s: unsupported audio format: %s\033[0m", filepath.Base(filePath), filepath.Ext(filePath))
 	}
 
@@ -214,11 +212,25 @@ func monitorProgress(ctx context.Context, doneChan chan struct{}, filename strin
 	}
 }
 
-// processChunk handles the processing of a single audio chunk
-func processChunk(ctx context.Context, chunk audioChunk, settings *conf.Settings,
+// processBatch handles the processing of a batch of audio chunks with a single inference
+func processBatch(ctx context.Context, batch []audioChunk, settings *conf.Settings,
 	resultChan chan<- []datastore.Note, errorChan chan<- error) error {
 
-	notes, err := bn.ProcessChunk(chunk.Data, chunk.FilePosition)
+	// Chunks are resampled from the capture sample rate to the sample rate of the model
+	data := make([][]float32, len(batch))
+	positions := make([]time.Time, len(batch))
+	var err error
+	for i := range batch {
+		if data[i], err = myaudio.ResampleToModel(batch[i].Data); err != nil {
+			break
+		}
+		positions[i] = batch[i].FilePosition
+	}
+
+	var batchNotes [][]datastore.Note
+	if err == nil {
+		batchNotes, err = processChunks(data, positions)
+	}
 	if err != nil {
 		// Block until we can send the error or context is cancelled
 		select {
@@ -231,25 +243,59 @@ func processChunk(ctx context.Context, chunk audioChunk, settings *conf.Settings
 		return err
 	}
 
-	// Filter notes based on included species list
-	var filteredNotes []datastore.Note
-	for i := range notes {
-		if settings.IsSpeciesIncluded(notes[i].ScientificName) {
-			filteredNotes = append(filteredNotes, notes[i])
+	// Results are sent for each chunk of the batch
+	for i, notes := range batchNotes {
+		// Filter notes based on included species list
+		var filteredNotes []datastore.Note
+		for j := range notes {
+			if settings.IsSpeciesIncluded(notes[j].ScientificName) {
+				// Tag notes with the channel they were detected on when channels are analyzed separately
+				if settings.Input.SplitChannels {
+					notes[j].Source = observation.ChannelSource(notes[j].Source, batch[i].Channel)
+				}
+				filteredNotes = append(filteredNotes, notes[j])
+			}
+		}
+
+		// Block until we can send results or context is cancelled
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case resultChan <- filteredNotes:
+		}
+	}
+	return nil
+}
+
+// unbatched is set once the model failed to analyze a batch of chunks, after which chunks are
+// analyzed one at a time
+var unbatched atomic.Bool
+
+// processChunks analyzes a batch of chunks with a single inference, or one chunk at a time if the
+// model cannot be resized to the batch size
+func processChunks(data [][]float32, positions []time.Time) ([][]datastore.Note, error) {
+	if len(data) > 1 && !unbatched.Load() {
+		batchNotes, err := bn.ProcessChunks(data, positions)
+		if !errors.Is(err, birdnet.ErrBatchSize) {
+			return batchNotes, err
 		}
+		unbatched.Store(true)
+		fmt.Printf("\n\033[33m⚠️  Batch analysis failed, analyzing one chunk at a time: %v\033[0m\n", err)
 	}
 
-	// Block until we can send results or context is cancelled
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	case resultChan <- filteredNotes:
-		return nil
+	batchNotes := make([][]datastore.Note, len(data))
+	for i := range data {
+		notes, err := bn.ProcessChunk(data[i], positions[i])
+		if err != nil {
+			return nil, err
+		}
+		batchNotes[i] = notes
 	}
+	return batchNotes, nil
 }
 
 // startWorkers initializes and starts the worker goroutines for audio analysis
-func startWorkers(ctx context.Context, numWorkers int, chunkChan chan audioChunk,
+func startWorkers(ctx context.Context, numWorkers int, batchChan chan []audioChunk,
 	resultChan chan []datastore.Note, errorChan chan error, settings *conf.Settings) {
 
 	for i := 0; i < numWorkers; i++ {
@@ -263,7 +309,7 @@ func startWorkers(ctx context.Context, numWorkers int, chunkChan chan audioChunk
 				}
 			}()
 
-			for chunk := range chunkChan {
+			for batch := range batchChan {
 				select {
 				case <-ctx.Done():
 					select {
@@ -274,7 +320,7 @@ func startWorkers(ctx context.Context, numWorkers int, chunkChan chan audioChunk
 				default:
 				}
 
-				if err := processChunk(ctx, chunk, settings, resultChan, errorChan); err != nil {
+				if err := processBatch(ctx, batch, settings, resultChan, errorChan); err != nil {
 					if settings.Debug {
 						fmt.Printf("DEBUG: Worker %d encountered error: %v\n", workerID, err)
 					}
@@ -289,6 +335,7 @@ func startWorkers(ctx context.Context, numWorkers int, chunkChan chan audioChunk
 type audioChunk struct {
 	Data         []float32
 	FilePosition time.Time
+	Channel      int // zero based channel index, used when channels are analyzed separately
 }
 
 func processAudioFile(settings *conf.Settings, audioInfo *myaudio.AudioInfo, ctx context.Context) ([]datastore.Note, error) {
@@ -299,6 +346,13 @@ func processAudioFile(settings *conf.Settings, audioInfo *myaudio.AudioInfo, ctx
 		settings.BirdNET.Overlap,
 	)
 
+	// Each channel is analyzed in full when channels are split
+	numChannels := 1
+	if settings.Input.SplitChannels {
+		numChannels = audioInfo.NumChannels
+		totalChunks *= numChannels
+	}
+
 	// Calculate audio duration
 	duration := time.Duration(float64(audioInfo.TotalSamples) / float64(audioInfo.SampleRate) * float64(time.Second))
 
@@ -311,13 +365,18 @@ func processAudioFile(settings *conf.Settings, audioInfo *myaudio.AudioInfo, ctx
 	// Set number of workers to 1
 	numWorkers := 1
 
+	// Chunks are analyzed in batches, results of a batch arrive together so timeouts
+	// scale with the batch size
+	batchSize := max(1, settings.BirdNET.BatchSize)
+	timeout := time.Duration(batchSize) * 5 * time.Second
+
 	if settings.Debug {
-		fmt.Printf("DEBUG: Starting analysis with %d total chunks and %d workers\n", totalChunks, numWorkers)
+		fmt.Printf("DEBUG: Starting analysis with %d total chunks, %d workers and batches of %d chunks\n", totalChunks, numWorkers, batchSize)
 	}
 
 	// Create buffered channels for processing
-	chunkChan := make(chan audioChunk, 4)
-	resultChan := make(chan []datastore.Note, 4)
+	batchChan := make(chan []audioChunk, 4)
+	resultChan := make(chan []datastore.Note, 4*batchSize)
 	errorChan := make(chan error, 1)
 	doneChan := make(chan struct{})
 
@@ -333,7 +392,7 @@ func processAudioFile(settings *conf.Settings, audioInfo *myaudio.AudioInfo, ctx
 	defer shutdown()
 
 	// Start worker goroutines
-	startWorkers(ctx, numWorkers, chunkChan, resultChan, errorChan, settings)
+	startWorkers(ctx, numWorkers, batchChan, resultChan, errorChan, settings)
 
 	// Start progress monitoring goroutine
 	go monitorProgress(ctx, doneChan, filename, duration, totalChunks, &chunkCount, startTime)
@@ -372,7 +431,7 @@ func processAudioFile(settings *conf.Settings, audioInfo *myaudio.AudioInfo, ctx
 				processingError = err
 				processingErrorMutex.Unlock()
 				return
-			case <-time.After(5 * time.Second):
+			case <-time.After(timeout):
 				if settings.Debug {
 					fmt.Printf("DEBUG: Timeout waiting for chunk %d results\n", i)
 				}
@@ -388,22 +447,21 @@ func processAudioFile(settings *conf.Settings, audioInfo *myaudio.AudioInfo, ctx
 		}
 	}()
 
-	// Initialize filePosition before the loop
-	filePosition := time.Time{}
+	// Initialize file position of each channel before the loop
+	filePositions := make([]time.Time, numChannels)
 
-	// Read and send audio chunks with timing information
-	err := myaudio.ReadAudioFileBuffered(settings, func(chunkData []float32) error {
-		chunk := audioChunk{
-			Data:         chunkData,
-			FilePosition: filePosition,
+	// Send a batch of audio chunks for processing
+	var batch []audioChunk
+	sendBatch := func() error {
+		if len(batch) == 0 {
+			return nil
 		}
 
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
-		case chunkChan <- chunk:
-			// Update predStart for next chunk
-			filePosition = filePosition.Add(time.Duration((3.0 - bn.Settings.BirdNET.Overlap) * float64(time.Second)))
+		case batchChan <- batch:
+			batch = nil
 			return nil
 		case <-doneChan:
 			processingErrorMutex.Lock()
@@ -413,15 +471,46 @@ func processAudioFile(settings *conf.Settings, audioInfo *myaudio.AudioInfo, ctx
 				return err
 			}
 			return ctx.Err() // Return context error if no processing error
-		case <-time.After(5 * time.Second):
-			return fmt.Errorf("timeout sending chunk to processing")
+		case <-time.After(timeout):
+			return fmt.Errorf("timeout sending chunks to processing")
+		}
+	}
+
+	// Collect audio chunks with timing information into batches
+	sendChunk := func(channel int, chunkData []float32) error {
+		batch = append(batch, audioChunk{
+			Data:         chunkData,
+			FilePosition: filePositions[channel],
+			Channel:      channel,
+		})
+
+		// Update predStart for next chunk
+		filePositions[channel] = filePositions[channel].Add(time.Duration(birdnet.ActiveModel().Step(bn.Settings.BirdNET.Overlap) * float64(time.Second)))
+
+		if len(batch) < batchSize {
+			return nil
 		}
-	})
+		return sendBatch()
+	}
+
+	var err error
+	if settings.Input.SplitChannels {
+		err = myaudio.ReadAudioFileChannels(settings, sendChunk)
+	} else {
+		err = myaudio.ReadAudioFileBuffered(settings, func(chunkData []float32) error {
+			return sendChunk(0, chunkData)
+		})
+	}
+
+	// Send the last partial batch
+	if err == nil {
+		err = sendBatch()
+	}
 
 	if settings.Debug {
 		fmt.Println("DEBUG: Finished reading audio file")
 	}
-	close(chunkChan)
+	close(batchChan)
 
 	if settings.Debug {
 		fmt.Println("DEBUG: Waiting for processing to complete")
@@ -480,8 +569,9 @@ func processAudioFile(settings *conf.Settings, audioInfo *myaudio.AudioInfo, ctx
 	return allNotes, nil
 }
 
-// writeResults writes the notes to the output file based on the configuration.
-func writeResults(settings *conf.Settings, notes []datastore.Note) error {
+// writeResults writes the notes of a file with numChannels channels to the output file based on
+// the configuration.
+func writeResults(settings *conf.Settings, notes []datastore.Note, numChannels int) error {
 	// Prepare the output file path if OutputDir is specified in the configuration.
 	var outputFile string
 	if settings.Output.File.Path != "" {
@@ -492,13 +582,13 @@ func writeResults(settings *conf.Settings, notes []datastore.Note) error {
 	// Output the notes based on the desired output type in the configuration.
 	// If OutputType is not specified or if it's set to "table", output as a table format.
 	if settings.Output.File.Type == "" || settings.Output.File.Type == "table" {
-		if err := observation.WriteNotesTable(settings, notes, outputFile); err != nil {
+		if err := observation.WriteNotesTable(settings, notes, outputFile, numChannels); err != nil {
 			return fmt.Errorf("failed to write notes table: %w", err)
 		}
 	}
 	// If OutputType is set to "csv", output as CSV format.
 	if settings.Output.File.Type == "csv" {
-		if err := observation.WriteNotesCsv(settings, notes, outputFile); err != nil {
+		if err := observation.WriteNotesCsv(settings, notes, outputFile, numChannels); err != nil {
 			return fmt.Errorf("failed to write notes CSV: %w", err)
 		}
 	}
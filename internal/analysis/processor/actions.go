@@ -21,6 +21,7 @@ import (
 	"github.com/tphakala/birdnet-go/internal/mqtt"
 	"github.com/tphakala/birdnet-go/internal/myaudio"
 	"github.com/tphakala/birdnet-go/internal/observation"
+	"github.com/tphakala/birdnet-go/internal/spectrogram"
 )
 
 type Action interface {
@@ -39,13 +40,18 @@ type DatabaseAction struct {
 	Ds           datastore.Interface
 	Note         datastore.Note
 	Results      []datastore.Results
+	Embedding    []float32 // Embedding of the detection for similarity search, nil if not extracted
 	EventTracker *EventTracker
+	clipData     []byte     // PCM data of the audio clip, read from the capture buffer if nil
+	clipStart    time.Time  // Start time of the audio clip, its length is Note.ClipDuration
 	mu           sync.Mutex // Protect concurrent access to Note and Results
 }
 
 type SaveAudioAction struct {
 	Settings     *conf.Settings
 	ClipName     string
+	Source       string                // ID of the audio source the clip was recorded from
+	Metadata     *myaudio.ClipMetadata // detection metadata embedded into the clip
 	pcmData      []byte
 	EventTracker *EventTracker
 	mu           sync.Mutex // Protect concurrent access to pcmData
@@ -109,25 +115,34 @@ func (a *DatabaseAction) Execute(data interface{}) error {
 		return nil
 	}
 
-	// Save note to database
+	// Save note to database, along with its embedding
+	if a.Embedding != nil {
+		a.Note.Embedding = &datastore.NoteEmbedding{Vector: datastore.EncodeEmbedding(a.Embedding)}
+	}
 	if err := a.Ds.Save(&a.Note, a.Results); err != nil {
 		log.Printf("Failed to save note and results to database: %v", err)
 		return err
 	}
 
 	// Save audio clip to file if enabled
-	if a.Settings.Realtime.Audio.Export.Enabled {
-		// export audio clip from capture buffer
-		pcmData, err := myaudio.ReadSegmentFromCaptureBuffer(a.Note.Source, a.Note.BeginTime, 15)
-		if err != nil {
-			log.Printf("Failed to read audio segment from buffer: %v", err)
-			return err
+	if a.Settings.Realtime.Audio.Export.Enabled && a.Note.ClipDuration > 0 {
+		// export audio clip from capture buffer unless the clip was read already
+		pcmData := a.clipData
+		if pcmData == nil {
+			var err error
+			pcmData, err = myaudio.ReadSegmentFromCaptureBuffer(a.Note.Source, a.clipStart, int(a.Note.ClipDuration/time.Second))
+			if err != nil {
+				log.Printf("Failed to read audio segment from buffer: %v", err)
+				return err
+			}
 		}
 
 		// Create a SaveAudioAction and execute it
 		saveAudioAction := &SaveAudioAction{
 			Settings: a.Settings,
 			ClipName: a.Note.ClipName,
+			Source:   a.Note.Source,
+			Metadata: newClipMetadata(a.Settings, &a.Note, a.clipStart),
 			pcmData:  pcmData,
 		}
 
@@ -145,6 +160,28 @@ func (a *DatabaseAction) Execute(data interface{}) error {
 	return nil
 }
 
+// newClipMetadata returns the metadata embedded into the audio clip of a detection starting at clipStart
+func newClipMetadata(settings *conf.Settings, note *datastore.Note, clipStart time.Time) *myaudio.ClipMetadata {
+	model := birdnet.ModelVersion()
+	if settings.BirdNET.ModelPath != "" {
+		// Only the file name of a custom model is recorded, not its location
+		model = filepath.Base(model)
+	}
+
+	return &myaudio.ClipMetadata{
+		CommonName:     note.CommonName,
+		ScientificName: note.ScientificName,
+		Confidence:     note.Confidence,
+		Time:           note.BeginTime,
+		ClipStart:      clipStart,
+		Latitude:       note.Latitude,
+		Longitude:      note.Longitude,
+		NodeName:       note.SourceNode,
+		Model:          model,
+		Software:       strings.TrimSpace("BirdNET-Go " + settings.Version),
+	}
+}
+
 // Execute saves the audio clip to a file
 func (a *SaveAudioAction) Execute(data interface{}) error {
 	a.mu.Lock()
@@ -159,13 +196,30 @@ func (a *SaveAudioAction) Execute(data interface{}) error {
 		return err
 	}
 
-	if a.Settings.Realtime.Audio.Export.Type == "wav" {
-		if err := myaudio.SavePCMDataToWAV(outputPath, a.pcmData); err != nil {
+	// Reduce background noise of the clip if enabled for export by the filters of the source
+	pcmData := a.pcmData
+	if filters := a.Settings.Realtime.SourceFilters(a.Source); filters.NoiseReduction.Export {
+		denoised, err := myaudio.ReduceNoise(pcmData, &filters.NoiseReduction)
+		if err != nil {
+			log.Printf("error reducing noise of audio clip: %s\n", err)
+		} else {
+			pcmData = denoised
+		}
+	}
+
+	switch a.Settings.Realtime.Audio.Export.Type {
+	case "wav":
+		if err := myaudio.SavePCMDataToWAV(outputPath, pcmData, a.Metadata); err != nil {
 			log.Printf("error saving audio clip to WAV: %s\n", err)
 			return err
 		}
-	} else {
-		if err := myaudio.ExportAudioWithFFmpeg(a.pcmData, outputPath, &a.Settings.Realtime.Audio); err != nil {
+	case "flac":
+		if err := myaudio.SavePCMDataToFLAC(outputPath, pcmData, conf.SampleRate, conf.NumChannels, a.Metadata); err != nil {
+			log.Printf("error saving audio clip to FLAC: %s\n", err)
+			return err
+		}
+	default:
+		if err := myaudio.ExportAudioWithFFmpeg(pcmData, outputPath, &a.Settings.Realtime.Audio, a.Metadata); err != nil {
 			log.Printf("error exporting audio clip with FFmpeg: %s\n", err)
 			return err
 		}
@@ -174,6 +228,9 @@ func (a *SaveAudioAction) Execute(data interface{}) error {
 	if a.Settings.Debug {
 		log.Printf("Saved audio clip to %s\n", outputPath)
 	}
+
+	// Render the spectrogram in the background before the dashboard requests it
+	spectrogram.Enqueue(outputPath)
 	return nil
 }
 
@@ -13,10 +13,15 @@ var DogBarkFilterTimeLimit = time.Duration(conf.Setting().Realtime.DogBarkFilter
 
 // Check if the species should be filtered based on the last dog bark timestamp.
 func (p *Processor) CheckDogBarkFilter(species string, lastDogBark time.Time) bool {
+	return p.checkDogBarkFilterAt(species, lastDogBark, time.Now())
+}
+
+// checkDogBarkFilterAt checks the dog bark filter at the given time of the audio source
+func (p *Processor) checkDogBarkFilterAt(species string, lastDogBark, now time.Time) bool {
 	species = strings.ToLower(species)
 	for _, s := range p.Settings.Realtime.DogBarkFilter.Species {
 		if s == species {
-			return time.Since(lastDogBark) <= DogBarkFilterTimeLimit
+			return now.Sub(lastDogBark) <= DogBarkFilterTimeLimit
 		}
 	}
 	return false
@@ -18,14 +18,15 @@ const (
 )
 
 // EventBehaviorFunc defines the signature for functions that determine the behavior of an event.
-// It returns true if the event is allowed to be processed based on the given last event time and timeout.
-type EventBehaviorFunc func(lastEventTime time.Time, timeout time.Duration) bool
+// It returns true if the event is allowed to be processed based on the given last event time, current time and timeout.
+type EventBehaviorFunc func(lastEventTime, now time.Time, timeout time.Duration) bool
 
 // EventHandler holds the state and behavior for a specific event type.
 type EventHandler struct {
 	LastEventTime map[string]time.Time // Tracks the last event time for each species
 	Timeout       time.Duration        // The minimum time interval between events
 	BehaviorFunc  EventBehaviorFunc    // Function that defines the event handling behavior
+	Clock         func() time.Time     // Returns the current time, wall clock unless audio is replayed
 	Mutex         sync.Mutex           // Mutex to ensure thread-safe access
 }
 
@@ -35,6 +36,7 @@ func NewEventHandler(timeout time.Duration, behaviorFunc EventBehaviorFunc) *Eve
 		LastEventTime: make(map[string]time.Time),
 		Timeout:       timeout,
 		BehaviorFunc:  behaviorFunc,
+		Clock:         time.Now,
 	}
 }
 
@@ -44,9 +46,10 @@ func (h *EventHandler) ShouldHandleEvent(species string) bool {
 	h.Mutex.Lock()
 	defer h.Mutex.Unlock()
 
+	now := h.Clock()
 	lastTime, exists := h.LastEventTime[species]
-	if !exists || h.BehaviorFunc(lastTime, h.Timeout) {
-		h.LastEventTime[species] = time.Now()
+	if !exists || h.BehaviorFunc(lastTime, now, h.Timeout) {
+		h.LastEventTime[species] = now
 		return true
 	}
 	return false
@@ -61,8 +64,8 @@ func (h *EventHandler) ResetEvent(species string) {
 
 // StandardEventBehavior is a default behavior function that allows an event to be handled
 // if the current time is greater than the last event time plus the timeout.
-func StandardEventBehavior(lastEventTime time.Time, timeout time.Duration) bool {
-	return time.Since(lastEventTime) >= timeout
+func StandardEventBehavior(lastEventTime, now time.Time, timeout time.Duration) bool {
+	return now.Sub(lastEventTime) >= timeout
 }
 
 // EventTracker manages event handling for different species across multiple event types.
@@ -93,6 +96,16 @@ func NewEventTracker(interval time.Duration) *EventTracker {
 	}
 }
 
+// NewEventTrackerWithClock creates an event tracker which measures intervals with the given clock,
+// used for audio analyzed from recordings where event times come from the recording
+func NewEventTrackerWithClock(interval time.Duration, clock func() time.Time) *EventTracker {
+	et := NewEventTracker(interval)
+	for _, handler := range et.Handlers {
+		handler.Clock = clock
+	}
+	return et
+}
+
 // TrackEvent checks if an event for a given species and event type should be processed.
 // It utilizes the respective event handler to make this determination.
 func (et *EventTracker) TrackEvent(species string, eventType EventType) bool {
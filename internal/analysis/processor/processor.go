@@ -43,6 +43,9 @@ type Processor struct {
 	lastDogDetectionLog map[string]time.Time
 	dogDetectionMutex   sync.Mutex
 	detectionMutex      sync.RWMutex // Mutex to protect LastDogDetection and LastHumanDetection maps
+	recordings          map[string]*recordingState
+	clockEventTrackers  map[string]*EventTracker // event trackers of replay sources, keyed by source
+	recordingMutex      sync.RWMutex             // Mutex to protect access to recordings and clockEventTrackers
 	controlChan         chan string
 }
 
@@ -57,8 +60,11 @@ type DynamicThreshold struct {
 
 type Detections struct {
 	pcmData3s []byte              // 3s PCM data containing the detection
+	clipData  []byte              // PCM data of the audio clip, read from the capture buffer if nil
+	clipStart time.Time           // Start time of the audio clip
 	Note      datastore.Note      // Note containing highest match
 	Results   []datastore.Results // Full BirdNET prediction results
+	embedding []float32           // Embedding of the analyzed audio, nil if not extracted
 }
 
 // PendingDetection struct represents a single detection held in memory,
@@ -91,6 +97,8 @@ func New(settings *conf.Settings, ds datastore.Interface, bn *birdnet.BirdNET, m
 		DynamicThresholds:   make(map[string]*DynamicThreshold),
 		pendingDetections:   make(map[string]PendingDetection),
 		lastDogDetectionLog: make(map[string]time.Time),
+		recordings:          make(map[string]*recordingState),
+		clockEventTrackers:  make(map[string]*EventTracker),
 	}
 
 	// Start the detection processor
@@ -137,6 +145,14 @@ func (p *Processor) processDetections(item *queue.Results) {
 	// TODO: make this configurable
 	const delay = 15 * time.Second
 
+	// All audio of the source has been analyzed, its last detections will not be matched again
+	if item.EndOfSource {
+		p.pendingMutex.Lock()
+		p.flushPendingDetections(item.Source, true)
+		p.pendingMutex.Unlock()
+		return
+	}
+
 	// processResults() returns a slice of detections, we iterate through each and process them
 	// detections are put into pendingDetections map where they are held until flush deadline is reached
 	// once deadline is reached detections are delivered to workers for actions (save to db etc) processing
@@ -145,30 +161,35 @@ func (p *Processor) processDetections(item *queue.Results) {
 		detection := detectionResults[i]
 		commonName := strings.ToLower(detection.Note.CommonName)
 		confidence := detection.Note.Confidence
+		key := p.pendingDetectionKey(item.Source, commonName)
 
 		// Lock the mutex to ensure thread-safe access to shared resources
 		p.pendingMutex.Lock()
 
-		if existing, exists := p.pendingDetections[commonName]; exists {
+		if existing, exists := p.pendingDetections[key]; exists {
 			// Update the existing detection if it's already in pendingDetections map
 			if confidence > existing.Confidence {
 				existing.Detection = detection
 				existing.Confidence = confidence
 				existing.Source = item.Source
-				existing.LastUpdated = time.Now()
 			}
+			existing.LastUpdated = p.sourceNow(item.Source)
 			existing.Count++
-			p.pendingDetections[commonName] = existing
+			p.holdUntilClipCaptured(&existing)
+			p.pendingDetections[key] = existing
 		} else {
 			// Create a new pending detection if it doesn't exist
-			p.pendingDetections[commonName] = PendingDetection{
+			pending := PendingDetection{
 				Detection:     detection,
 				Confidence:    confidence,
 				Source:        item.Source,
 				FirstDetected: item.StartTime,
+				LastUpdated:   p.sourceNow(item.Source),
 				FlushDeadline: item.StartTime.Add(delay),
 				Count:         1,
 			}
+			p.holdUntilClipCaptured(&pending)
+			p.pendingDetections[key] = pending
 		}
 
 		// Update the dynamic threshold for this species if enabled
@@ -179,6 +200,43 @@ func (p *Processor) processDetections(item *queue.Results) {
 	}
 }
 
+// clipWindow returns the start time and length in seconds of the audio clip of a pending detection.
+// In fixed mode the clip has the configured length starting at the pre-roll before the first
+// detection, in event mode it spans the first to the last detection plus pre-roll and post-roll,
+// bounded by the maximum clip length.
+func (p *Processor) clipWindow(item *PendingDetection) (time.Time, int) {
+	clip := &p.Settings.Realtime.Audio.Export.Clip
+	start := item.FirstDetected.Add(-time.Duration(clip.PreRoll) * time.Second)
+	if clip.Mode != "event" {
+		return start, clip.Length
+	}
+
+	end := item.LastUpdated.Add(time.Duration(clip.PostRoll) * time.Second)
+	length := int(math.Ceil(end.Sub(start).Seconds()))
+	return start, min(max(length, 1), clip.MaxLength)
+}
+
+// holdUntilClipCaptured extends the flush deadline of a pending detection until its audio clip
+// has been captured, in event mode this keeps the detection pending while the event continues
+func (p *Processor) holdUntilClipCaptured(item *PendingDetection) {
+	if !p.Settings.Realtime.Audio.Export.Enabled {
+		return
+	}
+	start, length := p.clipWindow(item)
+	if end := start.Add(time.Duration(length) * time.Second); end.After(item.FlushDeadline) {
+		item.FlushDeadline = end
+	}
+}
+
+// pendingDetectionKey returns the key of a detection in the pending detections map, detections
+// of recordings and replays are held apart from realtime detections of the same species
+func (p *Processor) pendingDetectionKey(source, commonName string) string {
+	if p.hasSourceClock(source) {
+		return source + "|" + commonName
+	}
+	return commonName
+}
+
 // processResults processes the results from the BirdNET prediction and returns a list of detections.
 func (p *Processor) processResults(item *queue.Results) []Detections {
 	var detections []Detections
@@ -188,6 +246,10 @@ func (p *Processor) processResults(item *queue.Results) []Detections {
 		p.Metrics.BirdNET.SetProcessTime(float64(item.ElapsedTime.Milliseconds()))
 	}
 
+	// Measure the loudness of the analyzed audio, this also keeps the noise floor estimate
+	// of the source up to date
+	levels := myaudio.MeasureSignalLevels(item.Source, item.PCMdata)
+
 	// Process each result in item.Results
 	for _, result := range item.Results {
 		var confidenceThreshold float32
@@ -237,26 +299,46 @@ func (p *Processor) processResults(item *queue.Results) []Detections {
 			p.addSpeciesToDynamicThresholds(speciesLowercase, baseThreshold)
 		}
 
-		// Create file name for audio clip
-		clipName := p.generateClipName(scientificName, result.Confidence)
+		// Create file name for audio clip, recordings and replays are named after the recording time
+		hasSourceClock := p.hasSourceClock(item.Source)
+		clipTime := time.Now()
+		if hasSourceClock {
+			clipTime = item.StartTime
+		}
+		clipName := p.generateClipName(scientificName, result.Confidence, clipTime)
 
 		// set begin and end time for note
 		// TODO: adjust end time based on detection pending delay
 		beginTime, endTime := item.StartTime, item.StartTime.Add(15*time.Second)
 
 		note := observation.New(p.Settings, beginTime, endTime, result.Species, float64(result.Confidence), item.Source, clipName, item.ElapsedTime)
+		if hasSourceClock {
+			// Detections of recordings are dated by the recording time instead of the wall clock
+			note.Date = item.StartTime.Format("2006-01-02")
+			note.Time = item.StartTime.Format("15:04:05")
+		}
+		note.RMSLevel = roundLevel(levels.RMS)
+		note.PeakLevel = roundLevel(levels.Peak)
+		note.SNR = roundLevel(levels.SNR)
 
 		// Detection passed all filters, process it
 		detections = append(detections, Detections{
 			pcmData3s: item.PCMdata,
 			Note:      note,
 			Results:   item.Results,
+			embedding: item.Embedding,
 		})
 	}
 
 	return detections
 }
 
+// roundLevel rounds a level in dB to one decimal place for storing it on a note
+func roundLevel(level float64) *float64 {
+	rounded := math.Round(level*10) / 10
+	return &rounded
+}
+
 // handleDogDetection handles the detection of dog barks and updates the last detection timestamp.
 func (p *Processor) handleDogDetection(item *queue.Results, speciesLowercase string, result datastore.Results) {
 	if p.Settings.Realtime.DogBarkFilter.Enabled && strings.Contains(speciesLowercase, "dog") &&
@@ -296,8 +378,8 @@ func (p *Processor) getBaseConfidenceThreshold(speciesLowercase string) float32
 	return float32(p.Settings.BirdNET.Threshold)
 }
 
-// generateClipName generates a clip name for the given scientific name and confidence.
-func (p *Processor) generateClipName(scientificName string, confidence float32) string {
+// generateClipName generates a clip name for the given scientific name, confidence and detection time.
+func (p *Processor) generateClipName(scientificName string, confidence float32, currentTime time.Time) string {
 	// Replace whitespaces with underscores and convert to lowercase
 	formattedName := strings.ToLower(strings.ReplaceAll(scientificName, " ", "_"))
 
@@ -305,9 +387,6 @@ func (p *Processor) generateClipName(scientificName string, confidence float32)
 	normalizedConfidence := confidence * 100
 	formattedConfidence := fmt.Sprintf("%.0fp", normalizedConfidence)
 
-	// Get the current time
-	currentTime := time.Now()
-
 	// Format the timestamp in ISO 8601 format
 	timestamp := currentTime.Format("20060102T150405Z")
 
@@ -352,8 +431,9 @@ func (p *Processor) shouldDiscardDetection(item *PendingDetection, minDetections
 		p.detectionMutex.RLock()
 		lastDogDetection := p.LastDogDetection[item.Source]
 		p.detectionMutex.RUnlock()
-		if p.CheckDogBarkFilter(item.Detection.Note.CommonName, lastDogDetection) ||
-			p.CheckDogBarkFilter(item.Detection.Note.ScientificName, lastDogDetection) {
+		now := p.sourceNow(item.Source)
+		if p.checkDogBarkFilterAt(item.Detection.Note.CommonName, lastDogDetection, now) ||
+			p.checkDogBarkFilterAt(item.Detection.Note.ScientificName, lastDogDetection, now) {
 			return true, "recent dog bark"
 		}
 	}
@@ -367,6 +447,28 @@ func (p *Processor) processApprovedDetection(item *PendingDetection, species str
 		species, item.Source, item.Count)
 
 	item.Detection.Note.BeginTime = item.FirstDetected
+
+	if p.Settings.Realtime.Audio.Export.Enabled {
+		clipStart, clipLength := p.clipWindow(item)
+
+		// Audio of recordings and replays may be written faster than realtime, so the clip is read
+		// from the capture buffer now before it is overwritten by the following audio. The clock
+		// of a finished recording does not advance, so the clip ends at the current position.
+		if p.hasSourceClock(item.Source) {
+			clipLength = min(clipLength, int((p.sourceNow(item.Source).Sub(clipStart)-1)/time.Second))
+			if clipLength > 0 {
+				clipData, err := myaudio.ReadSegmentFromCaptureBuffer(item.Source, clipStart, clipLength)
+				if err != nil {
+					log.Printf("Failed to read audio segment of source %s: %v", item.Source, err)
+				}
+				item.Detection.clipData = clipData
+			}
+		}
+
+		item.Detection.clipStart = clipStart
+		item.Detection.Note.ClipDuration = time.Duration(clipLength) * time.Second
+	}
+
 	actionList := p.getActionsForItem(&item.Detection)
 	for _, action := range actionList {
 		workerQueue <- Task{Type: TaskTypeAction, Detection: item.Detection, Action: action}
@@ -381,33 +483,15 @@ func (p *Processor) processApprovedDetection(item *PendingDetection, species str
 // pendingDetectionsFlusher runs a goroutine that periodically checks the pending detections
 // and flushes them to the worker queue if their deadline has passed.
 func (p *Processor) pendingDetectionsFlusher() {
-	// Calculate minimum detections based on overlap setting
-	segmentLength := math.Max(0.1, 3.0-p.Settings.BirdNET.Overlap)
-	minDetections := int(math.Max(1, 3/segmentLength))
-
 	go func() {
 		ticker := time.NewTicker(1 * time.Second)
 		defer ticker.Stop()
 
 		for {
 			<-ticker.C
-			now := time.Now()
 
 			p.pendingMutex.Lock()
-			for species := range p.pendingDetections {
-				item := p.pendingDetections[species]
-				if now.After(item.FlushDeadline) {
-					if shouldDiscard, reason := p.shouldDiscardDetection(&item, minDetections); shouldDiscard {
-						log.Printf("Discarding detection of %s from source %s due to %s\n",
-							species, item.Source, reason)
-						delete(p.pendingDetections, species)
-						continue
-					}
-
-					p.processApprovedDetection(&item, species)
-					delete(p.pendingDetections, species)
-				}
-			}
+			p.flushPendingDetections("", false)
 			p.pendingMutex.Unlock()
 
 			p.cleanUpDynamicThresholds()
@@ -415,6 +499,42 @@ func (p *Processor) pendingDetectionsFlusher() {
 	}()
 }
 
+// minDetections returns the minimum number of matches required to approve a detection,
+// calculated from the model window and the overlap setting
+func (p *Processor) minDetections() int {
+	model := birdnet.ActiveModel()
+	segmentLength := math.Max(0.1, model.Step(p.Settings.BirdNET.Overlap))
+	return int(math.Max(1, model.WindowSeconds/segmentLength))
+}
+
+// flushPendingDetections approves or discards pending detections whose flush deadline has passed
+// on the clock of their source. Only detections of the given source are flushed if source is set,
+// and all of them regardless of their deadline if force is true. The caller must hold pendingMutex.
+func (p *Processor) flushPendingDetections(source string, force bool) {
+	minDetections := p.minDetections()
+
+	for key := range p.pendingDetections {
+		item := p.pendingDetections[key]
+		if source != "" && item.Source != source {
+			continue
+		}
+		if !force && !p.sourceNow(item.Source).After(item.FlushDeadline) {
+			continue
+		}
+
+		species := strings.ToLower(item.Detection.Note.CommonName)
+		if shouldDiscard, reason := p.shouldDiscardDetection(&item, minDetections); shouldDiscard {
+			log.Printf("Discarding detection of %s from source %s due to %s\n",
+				species, item.Source, reason)
+			delete(p.pendingDetections, key)
+			continue
+		}
+
+		p.processApprovedDetection(&item, species)
+		delete(p.pendingDetections, key)
+	}
+}
+
 // Helper function to check if a slice contains a string (case-insensitive)
 func contains(slice []string, item string) bool {
 	for _, s := range slice {
@@ -483,17 +603,26 @@ func parseCommandParams(params []string, detection *Detections) map[string]inter
 func (p *Processor) getDefaultActions(detection *Detections) []Action {
 	var actions []Action
 
+	// Recordings and replays track event frequency on their own clock
+	eventTracker := p.EventTracker
+	if tracker := p.sourceEventTracker(detection.Note.Source); tracker != nil {
+		eventTracker = tracker
+	}
+
 	// Append various default actions based on the application settings
 	if p.Settings.Realtime.Log.Enabled {
-		actions = append(actions, &LogAction{Settings: p.Settings, EventTracker: p.EventTracker, Note: detection.Note})
+		actions = append(actions, &LogAction{Settings: p.Settings, EventTracker: eventTracker, Note: detection.Note})
 	}
 
 	if p.Settings.Output.SQLite.Enabled || p.Settings.Output.MySQL.Enabled {
 		actions = append(actions, &DatabaseAction{
 			Settings:     p.Settings,
-			EventTracker: p.EventTracker,
+			EventTracker: eventTracker,
 			Note:         detection.Note,
 			Results:      detection.Results,
+			Embedding:    detection.embedding,
+			clipData:     detection.clipData,
+			clipStart:    detection.clipStart,
 			Ds:           p.Ds})
 	}
 
@@ -503,7 +632,7 @@ func (p *Processor) getDefaultActions(detection *Detections) []Action {
 		if bwClient != nil {
 			actions = append(actions, &BirdWeatherAction{
 				Settings:     p.Settings,
-				EventTracker: p.EventTracker,
+				EventTracker: eventTracker,
 				BwClient:     bwClient,
 				Note:         detection.Note,
 				pcmData:      detection.pcmData3s})
@@ -517,7 +646,7 @@ func (p *Processor) getDefaultActions(detection *Detections) []Action {
 			actions = append(actions, &MqttAction{
 				Settings:       p.Settings,
 				MqttClient:     mqttClient,
-				EventTracker:   p.EventTracker,
+				EventTracker:   eventTracker,
 				Note:           detection.Note,
 				BirdImageCache: p.BirdImageCache,
 			})
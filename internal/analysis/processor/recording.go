@@ -0,0 +1,227 @@
+// recording.go
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/analysis/queue"
+	"github.com/tphakala/birdnet-go/internal/birdnet"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/myaudio"
+)
+
+const (
+	// recordingWriteBlockSize is the size of capture buffer writes, the capture buffer size is a
+	// multiple of it so writes never cross the end of the buffer
+	recordingWriteBlockSize = 2048
+
+	// recordingTrailingSilence is appended to recordings so pending detections at the end of the
+	// recording have complete audio clips
+	recordingTrailingSilence = 15 * time.Second
+)
+
+// recordingState holds the clock of a recording which is analyzed faster than realtime
+type recordingState struct {
+	position     time.Time     // recording time at the end of the audio written so far
+	eventTracker *EventTracker // event tracker running on the recording clock
+}
+
+// isRecording reports whether the source is a recording being analyzed by ProcessRecording
+func (p *Processor) isRecording(source string) bool {
+	p.recordingMutex.RLock()
+	defer p.recordingMutex.RUnlock()
+	_, exists := p.recordings[source]
+	return exists
+}
+
+// hasSourceClock reports whether the audio source runs on its own clock instead of the wall
+// clock, which is the case for recordings and replayed files
+func (p *Processor) hasSourceClock(source string) bool {
+	return p.isRecording(source) || myaudio.HasSourceClock(source)
+}
+
+// sourceNow returns the current time of an audio source, which is the recording position for
+// recordings, the replay clock for replay sources and the wall clock for realtime sources
+func (p *Processor) sourceNow(source string) time.Time {
+	p.recordingMutex.RLock()
+	state, exists := p.recordings[source]
+	p.recordingMutex.RUnlock()
+	if exists {
+		return state.position
+	}
+	return myaudio.SourceNow(source)
+}
+
+// sourceEventTracker returns the event tracker running on the clock of the source, or nil
+// for sources on the wall clock which share the processor event tracker
+func (p *Processor) sourceEventTracker(source string) *EventTracker {
+	p.recordingMutex.Lock()
+	defer p.recordingMutex.Unlock()
+	if state, exists := p.recordings[source]; exists {
+		return state.eventTracker
+	}
+	if !myaudio.HasSourceClock(source) {
+		delete(p.clockEventTrackers, source)
+		return nil
+	}
+	tracker, exists := p.clockEventTrackers[source]
+	if !exists {
+		tracker = NewEventTrackerWithClock(time.Duration(p.Settings.Realtime.Interval)*time.Second, func() time.Time {
+			return myaudio.SourceNow(source)
+		})
+		p.clockEventTrackers[source] = tracker
+	}
+	return tracker
+}
+
+// setRecordingPosition advances the clock of a recording
+func (p *Processor) setRecordingPosition(source string, position time.Time) {
+	p.recordingMutex.Lock()
+	defer p.recordingMutex.Unlock()
+	if state, exists := p.recordings[source]; exists {
+		state.position = position
+	}
+}
+
+// ProcessRecording analyzes an audio file recorded at startTime through the realtime detection
+// pipeline. Detections get the same thresholds, filters and actions as realtime detections, but
+// their times are derived from startTime and the position in the file instead of the wall clock.
+// Only one recording can be processed per source at a time.
+func (p *Processor) ProcessRecording(ctx context.Context, path, source string, startTime time.Time) error {
+	p.recordingMutex.Lock()
+	if _, exists := p.recordings[source]; exists {
+		p.recordingMutex.Unlock()
+		return fmt.Errorf("a recording from source %s is already being processed", source)
+	}
+	state := &recordingState{position: startTime}
+	state.eventTracker = NewEventTrackerWithClock(time.Duration(p.Settings.Realtime.Interval)*time.Second, func() time.Time {
+		return p.sourceNow(source)
+	})
+	p.recordings[source] = state
+	p.recordingMutex.Unlock()
+
+	defer func() {
+		p.recordingMutex.Lock()
+		delete(p.recordings, source)
+		p.recordingMutex.Unlock()
+		myaudio.ResetSignalLevels(source)
+	}()
+
+	// Clips are read from a capture buffer which runs on the recording clock
+	if err := myaudio.AllocateCaptureBuffer(myaudio.CaptureBufferSeconds(), conf.SampleRate, conf.BitDepth/8, source); err != nil {
+		return fmt.Errorf("failed to allocate capture buffer for recording: %w", err)
+	}
+	defer func() {
+		if err := myaudio.RemoveCaptureBuffer(source); err != nil {
+			log.Printf("❌ Failed to remove capture buffer of recording %s: %v", source, err)
+		}
+	}()
+	if err := myaudio.SetCaptureBufferClock(source, func() time.Time { return p.sourceNow(source) }); err != nil {
+		return err
+	}
+
+	// Read the file with a copy of the settings so the realtime configuration is not modified
+	settings := *p.Settings
+	settings.Input.Path = path
+
+	writer := &recordingWriter{p: p, source: source, startTime: startTime}
+	step := int(birdnet.ActiveModel().Step(p.Settings.BirdNET.Overlap) * conf.SampleRate)
+	chunkIndex := 0
+
+	err := myaudio.ReadAudioFileBuffered(&settings, func(chunk []float32) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// Only the part of the chunk which does not overlap the previous chunk is new audio
+		chunkStart := chunkIndex * step
+		chunkIndex++
+		if newSamples := chunkStart + len(chunk) - writer.samples; newSamples > 0 {
+			writer.write(myaudio.ConvertFloat32ToPCM16(chunk[len(chunk)-newSamples:]))
+		}
+
+		predictStart := time.Now()
+		input, err := myaudio.ResampleToModel(chunk)
+		if err != nil {
+			return fmt.Errorf("error resampling recording chunk: %w", err)
+		}
+		results, embedding, err := p.Bn.PredictWithEmbedding([][]float32{input})
+		if err != nil {
+			return fmt.Errorf("error predicting recording chunk: %w", err)
+		}
+
+		item := queue.Results{
+			StartTime:   startTime.Add(samplesToDuration(chunkStart)),
+			ElapsedTime: time.Since(predictStart),
+			PCMdata:     myaudio.ConvertFloat32ToPCM16(chunk),
+			Results:     results,
+			Embedding:   embedding,
+			Source:      source,
+		}
+		p.processDetections(&item)
+
+		p.pendingMutex.Lock()
+		p.flushPendingDetections(source, false)
+		p.pendingMutex.Unlock()
+		return nil
+	})
+
+	// Pad the recording with silence so clips of the last detections can be completed,
+	// then flush all remaining detections
+	silence := make([]byte, int(recordingTrailingSilence.Seconds())*conf.SampleRate*conf.BitDepth/8+recordingWriteBlockSize)
+	writer.write(silence)
+
+	p.pendingMutex.Lock()
+	if err != nil {
+		// Discard detections of recordings which failed to process completely
+		for key, item := range p.pendingDetections {
+			if item.Source == source {
+				delete(p.pendingDetections, key)
+			}
+		}
+	} else {
+		p.flushPendingDetections(source, true)
+	}
+	p.pendingMutex.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("error processing recording %s: %w", source, err)
+	}
+	return nil
+}
+
+// recordingWriter writes PCM data of a recording to its capture buffer in aligned blocks
+// and advances the recording clock accordingly
+type recordingWriter struct {
+	p         *Processor
+	source    string
+	startTime time.Time
+	samples   int    // number of samples received
+	written   int    // number of samples written to the capture buffer
+	pending   []byte // data not yet written because it is less than a block
+}
+
+// write appends PCM data to the capture buffer
+func (w *recordingWriter) write(data []byte) {
+	const bytesPerSample = conf.BitDepth / 8
+
+	w.samples += len(data) / bytesPerSample
+	w.pending = append(w.pending, data...)
+
+	for len(w.pending) >= recordingWriteBlockSize {
+		w.written += recordingWriteBlockSize / bytesPerSample
+		w.p.setRecordingPosition(w.source, w.startTime.Add(samplesToDuration(w.written)))
+		if err := myaudio.WriteToCaptureBuffer(w.source, w.pending[:recordingWriteBlockSize]); err != nil {
+			log.Printf("❌ Failed to write recording %s to capture buffer: %v", w.source, err)
+		}
+		w.pending = w.pending[recordingWriteBlockSize:]
+	}
+}
+
+// samplesToDuration returns the duration of the given number of samples at the analysis sample rate
+func samplesToDuration(samples int) time.Duration {
+	return time.Duration(samples) * time.Second / time.Duration(conf.SampleRate)
+}
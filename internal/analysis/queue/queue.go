@@ -11,9 +11,11 @@ type Results struct {
 	StartTime   time.Time           // Time when the analysis started
 	PCMdata     []byte              // Raw PCM audio data
 	Results     []datastore.Results // Slice of analysis results
+	Embedding   []float32           // Embedding of the analyzed audio, nil if not extracted
 	ElapsedTime time.Duration       // Time taken for analysis
 	ClipName    string              // Name of the audio clip
 	Source      string              // Source of the audio data, RSTP URL or audio card name
+	EndOfSource bool                // true for the last message of a source whose audio has ended, carries no results
 }
 
 // Copy creates a deep copy of the Results struct
@@ -27,14 +29,20 @@ func (r Results) Copy() Results { //nolint:gocritic // This is a copy function,
 		ElapsedTime: r.ElapsedTime,
 		ClipName:    r.ClipName,
 		Source:      r.Source,
+		EndOfSource: r.EndOfSource,
 	}
 
 	// Deep copy PCMdata
 	if r.PCMdata != nil {
-		newCopy.PCMdata = make([]byte, len(r.PCMdata))
+		newCopy.PCMdata = make([]byte, 0, len(r.PCMdata))
 		newCopy.PCMdata = append(newCopy.PCMdata, r.PCMdata...)
 	}
 
+	// Deep copy Embedding
+	if r.Embedding != nil {
+		newCopy.Embedding = append([]float32(nil), r.Embedding...)
+	}
+
 	// Deep copy Results slice
 	if r.Results != nil {
 		newCopy.Results = make([]datastore.Results, len(r.Results))
@@ -21,6 +21,7 @@ import (
 	"github.com/tphakala/birdnet-go/internal/httpcontroller"
 	"github.com/tphakala/birdnet-go/internal/httpcontroller/handlers"
 	"github.com/tphakala/birdnet-go/internal/myaudio"
+	"github.com/tphakala/birdnet-go/internal/spectrogram"
 	"github.com/tphakala/birdnet-go/internal/telemetry"
 	"github.com/tphakala/birdnet-go/internal/weather"
 )
@@ -88,26 +89,8 @@ func RealtimeAnalysis(settings *conf.Settings, notificationChan chan handlers.No
 	// Initialize audioLevelChan, used to visualize audio levels on web ui
 	audioLevelChan = make(chan myaudio.AudioLevelData, 100)
 
-	// Prepare sources list
-	var sources []string
-	if len(settings.Realtime.RTSP.URLs) > 0 || settings.Realtime.Audio.Source != "" {
-		if len(settings.Realtime.RTSP.URLs) > 0 {
-			sources = settings.Realtime.RTSP.URLs
-		}
-		if settings.Realtime.Audio.Source != "" {
-			// We'll add malgo to sources only if device initialization succeeds
-			// This will be handled in CaptureAudio
-			sources = append(sources, "malgo")
-		}
-
-		// Initialize buffers for all audio sources
-		if err := initializeBuffers(sources); err != nil {
-			// If buffer initialization fails, log the error but continue
-			// Some sources might still work
-			log.Printf("⚠️  Error initializing buffers: %v", err)
-			log.Println("⚠️  Some audio sources might not be available.")
-		}
-	} else {
+	// Report configured audio sources, buffers are allocated per source when capture starts
+	if len(settings.Realtime.AudioSources()) == 0 {
 		log.Println("⚠️  Starting without active audio sources. You can configure audio devices or RTSP streams through the web interface.")
 	}
 
@@ -128,6 +111,13 @@ func RealtimeAnalysis(settings *conf.Settings, notificationChan chan handlers.No
 		birdImageCache = nil
 	}
 
+	// Report the utilization of the BirdNET interpreter pool
+	bn.SetMetrics(metrics.BirdNET)
+
+	// Render spectrograms of new clips in the background
+	stopSpectrogramQueue := spectrogram.StartQueue(settings, metrics.Spectrogram)
+	defer stopSpectrogramQueue()
+
 	// Initialize processor
 	proc := processor.New(settings, dataStore, bn, metrics, birdImageCache)
 
@@ -141,18 +131,51 @@ func RealtimeAnalysis(settings *conf.Settings, notificationChan chan handlers.No
 	// Initialize the buffer manager
 	bufferManager := NewBufferManager(bn, quitChan, &wg)
 
-	// Start buffer monitors for each audio source only if we have active sources
-	if len(settings.Realtime.RTSP.URLs) > 0 || settings.Realtime.Audio.Source != "" {
-		bufferManager.UpdateMonitors(sources)
-	} else {
-		log.Println("⚠️  Starting without active audio sources. You can configure audio devices or RTSP streams through the web interface.")
+	// Initialize the audio source manager, each source is captured and analyzed independently
+	sourceManager := myaudio.NewSourceManager(settings, &wg, quitChan, audioLevelChan)
+	onStart, onStop := bufferManager.AddMonitor, bufferManager.RemoveMonitor
+
+	// Record all sources continuously into the audio archive
+	if settings.Realtime.Audio.Archive.Enabled {
+		archiver := myaudio.NewArchiver(&settings.Realtime.Audio, dataStore)
+		defer archiver.StopAll()
+		onStart = func(sourceID string) {
+			bufferManager.AddMonitor(sourceID)
+			archiver.Start(sourceID)
+		}
+		onStop = func(sourceID string) {
+			archiver.Stop(sourceID)
+			bufferManager.RemoveMonitor(sourceID)
+		}
 	}
+	sourceManager.SetSourceHooks(onStart, onStop)
+
+	// Compute live spectra of captured audio for the web interface
+	myaudio.EnableLiveSpectrum(&settings.Realtime.Dashboard.LiveSpectrum)
 
-	// start audio capture
-	startAudioCapture(&wg, settings, quitChan, restartChan, audioLevelChan)
+	// Allow listening to the live audio of sources in the web interface
+	myaudio.EnableLiveListen(&settings.Realtime.Audio)
+
+	// Monitor audio sources for silence, clipping and dropouts, alerts are shown in the web interface
+	stopHealthMonitor := myaudio.StartHealthMonitor(&settings.Realtime.Audio.Health, metrics.AudioHealth, func(alert myaudio.HealthAlert) {
+		httpServer.Handlers.SSE.SendNotification(handlers.Notification{Message: alert.Message, Type: alert.Type})
+	})
+	defer stopHealthMonitor()
+
+	// start the raw PCM ingest server for network microphones
+	if settings.Realtime.Ingest.Enabled {
+		ingestServer := myaudio.NewIngestServer(&settings.Realtime.Ingest, sourceManager, quitChan, audioLevelChan)
+		if err := ingestServer.Start(); err != nil {
+			log.Printf("❌ Error starting ingest server: %v", err)
+		}
+	}
+
+	// start audio capture and buffer monitors for all configured sources
+	reconfigureAudioSources(settings, sourceManager)
 
 	// start cleanup of clips
-	if conf.Setting().Realtime.Audio.Export.Retention.Policy != "none" {
+	if conf.Setting().Realtime.Audio.Export.Retention.Policy != "none" ||
+		(conf.Setting().Realtime.Audio.Archive.Enabled && conf.Setting().Realtime.Audio.Archive.Retention.Policy != "none") {
 		startClipCleanupMonitor(&wg, quitChan, dataStore)
 	}
 
@@ -165,7 +188,7 @@ func RealtimeAnalysis(settings *conf.Settings, notificationChan chan handlers.No
 	startTelemetryEndpoint(&wg, settings, metrics, quitChan)
 
 	// start control monitor for hot reloads
-	startControlMonitor(&wg, controlChan, quitChan, restartChan, notificationChan, bufferManager, proc)
+	startControlMonitor(&wg, controlChan, quitChan, restartChan, notificationChan, bufferManager, sourceManager, proc)
 
 	// start quit signal monitor
 	monitorCtrlC(quitChan)
@@ -188,15 +211,20 @@ func RealtimeAnalysis(settings *conf.Settings, notificationChan chan handlers.No
 		case <-restartChan:
 			// Handle the restart signal.
 			fmt.Println("🔄 Restarting audio capture")
-			startAudioCapture(&wg, settings, quitChan, restartChan, audioLevelChan)
+			sourceManager.StopAll()
+			reconfigureAudioSources(settings, sourceManager)
 		}
 	}
 }
 
-// startAudioCapture initializes and starts the audio capture routine in a new goroutine.
-func startAudioCapture(wg *sync.WaitGroup, settings *conf.Settings, quitChan, restartChan chan struct{}, audioLevelChan chan myaudio.AudioLevelData) {
-	// waitgroup is managed within CaptureAudio
-	go myaudio.CaptureAudio(settings, wg, quitChan, restartChan, audioLevelChan)
+// reconfigureAudioSources starts and stops audio sources so that they match the configured
+// source list. Sources which did not change keep running.
+func reconfigureAudioSources(settings *conf.Settings, sourceManager *myaudio.SourceManager) error {
+	err := sourceManager.Reconcile(settings.Realtime.AudioSources())
+	if err != nil {
+		log.Printf("⚠️  Error starting audio sources: %v", err)
+	}
+	return err
 }
 
 // startClipCleanupMonitor initializes and starts the clip cleanup monitoring routine in a new goroutine.
@@ -289,6 +317,13 @@ func clipCleanupMonitor(quitChan chan struct{}, dataStore datastore.Interface) {
 					log.Println("Error cleaning up clips: ", err)
 				}
 			}
+
+			// archive segment cleanup
+			if conf.Setting().Realtime.Audio.Archive.Enabled {
+				if err := diskmanager.ArchiveCleanup(quitChan, dataStore); err != nil {
+					log.Println("Error cleaning up audio archive: ", err)
+				}
+			}
 		}
 	}
 }
@@ -358,28 +393,7 @@ func initBirdImageCache(ds datastore.Interface, metrics *telemetry.Metrics) *ima
 }
 
 // startControlMonitor handles various control signals for realtime analysis mode
-func startControlMonitor(wg *sync.WaitGroup, controlChan chan string, quitChan, restartChan chan struct{}, notificationChan chan handlers.Notification, bufferManager *BufferManager, proc *processor.Processor) {
-	monitor := NewControlMonitor(wg, controlChan, quitChan, restartChan, notificationChan, bufferManager, proc)
+func startControlMonitor(wg *sync.WaitGroup, controlChan chan string, quitChan, restartChan chan struct{}, notificationChan chan handlers.Notification, bufferManager *BufferManager, sourceManager *myaudio.SourceManager, proc *processor.Processor) {
+	monitor := NewControlMonitor(wg, controlChan, quitChan, restartChan, notificationChan, bufferManager, sourceManager, proc)
 	monitor.Start()
 }
-
-// initializeBuffers handles initialization of all audio-related buffers
-func initializeBuffers(sources []string) error {
-	var initErrors []string
-
-	// Initialize analysis buffers
-	if err := myaudio.InitAnalysisBuffers(conf.BufferSize*3, sources); err != nil { // 3x buffer size to avoid underruns
-		initErrors = append(initErrors, fmt.Sprintf("failed to initialize analysis buffers: %v", err))
-	}
-
-	// Initialize capture buffers
-	if err := myaudio.InitCaptureBuffers(60, conf.SampleRate, conf.BitDepth/8, sources); err != nil {
-		initErrors = append(initErrors, fmt.Sprintf("failed to initialize capture buffers: %v", err))
-	}
-
-	if len(initErrors) > 0 {
-		return fmt.Errorf("buffer initialization errors: %s", strings.Join(initErrors, "; "))
-	}
-
-	return nil
-}
@@ -2,6 +2,7 @@
 package api
 
 import (
+	"context"
 	"crypto/rand"
 	"log"
 	"net/http"
@@ -10,6 +11,7 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/patrickmn/go-cache"
+	"github.com/tphakala/birdnet-go/internal/analysis/processor"
 	"github.com/tphakala/birdnet-go/internal/conf"
 	"github.com/tphakala/birdnet-go/internal/datastore"
 	"github.com/tphakala/birdnet-go/internal/imageprovider"
@@ -24,11 +26,14 @@ type Controller struct {
 	Settings            *conf.Settings
 	BirdImageCache      *imageprovider.BirdImageCache
 	SunCalc             *suncalc.SunCalc
+	Processor           *processor.Processor // Detection processor, used to analyze uploaded recordings
 	logger              *log.Logger
 	controlChan         chan string
 	speciesExcludeMutex sync.RWMutex // Mutex for species exclude list operations
 	settingsMutex       sync.RWMutex // Mutex for settings operations
 	detectionCache      *cache.Cache // Cache for detection queries
+	ingestQueue         chan ingestUpload
+	ingestCancel        context.CancelFunc // Stops the upload ingest worker
 }
 
 // New creates a new API controller
@@ -84,6 +89,8 @@ func (c *Controller) initRoutes() {
 		{"control routes", c.initControlRoutes},
 		{"auth routes", c.initAuthRoutes},
 		{"media routes", c.initMediaRoutes},
+		{"ingest routes", c.initIngestRoutes},
+		{"archive routes", c.initArchiveRoutes},
 	}
 
 	for _, initializer := range routeInitializers {
@@ -121,6 +128,11 @@ func (c *Controller) Shutdown() {
 	// Currently, only the system component needs cleanup
 	StopCPUMonitoring()
 
+	// Stop processing uploaded recordings
+	if c.ingestCancel != nil {
+		c.ingestCancel()
+	}
+
 	// Log shutdown
 	c.Debug("API Controller shutting down, CPU monitoring stopped")
 }
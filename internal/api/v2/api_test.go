@@ -187,6 +187,16 @@ func (m *MockDataStore) CountSearchResults(query string) (int64, error) {
 	return args.Get(0).(int64), args.Error(1)
 }
 
+func (m *MockDataStore) SearchNotesBySignal(query string, filter *datastore.SignalFilter, sortAscending bool, limit, offset int) ([]datastore.Note, error) {
+	args := m.Called(query, filter, sortAscending, limit, offset)
+	return args.Get(0).([]datastore.Note), args.Error(1)
+}
+
+func (m *MockDataStore) CountSignalSearchResults(query string, filter *datastore.SignalFilter) (int64, error) {
+	args := m.Called(query, filter)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 func (m *MockDataStore) Transaction(fc func(tx *gorm.DB) error) error {
 	args := m.Called(fc)
 	return args.Error(0)
@@ -257,6 +267,36 @@ func (m *MockDataStore) GetDetectionTrends(period string, limit int) ([]datastor
 	return args.Get(0).([]datastore.DailyAnalyticsData), args.Error(1)
 }
 
+func (m *MockDataStore) SaveAudioSegment(segment *datastore.AudioSegment) error {
+	args := m.Called(segment)
+	return args.Error(0)
+}
+
+func (m *MockDataStore) GetAudioSegments(source string, start, end time.Time) ([]datastore.AudioSegment, error) {
+	args := m.Called(source, start, end)
+	return args.Get(0).([]datastore.AudioSegment), args.Error(1)
+}
+
+func (m *MockDataStore) GetAudioSegmentsBefore(before time.Time, limit int) ([]datastore.AudioSegment, error) {
+	args := m.Called(before, limit)
+	return args.Get(0).([]datastore.AudioSegment), args.Error(1)
+}
+
+func (m *MockDataStore) DeleteAudioSegment(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockDataStore) GetNoteEmbedding(noteID string) ([]float32, error) {
+	args := m.Called(noteID)
+	return args.Get(0).([]float32), args.Error(1)
+}
+
+func (m *MockDataStore) GetSimilarNotes(noteID, startDate, endDate, species string, limit int) ([]datastore.SimilarNote, error) {
+	args := m.Called(noteID, startDate, endDate, species, limit)
+	return args.Get(0).([]datastore.SimilarNote), args.Error(1)
+}
+
 // MockImageProvider is a mock implementation of imageprovider.ImageProvider interface
 type MockImageProvider struct {
 	mock.Mock
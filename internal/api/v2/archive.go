@@ -0,0 +1,125 @@
+// internal/api/v2/archive.go
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/myaudio"
+)
+
+// archiveMaxRange is the longest time range which can be downloaded from the audio archive at once
+const archiveMaxRange = 10 * time.Minute
+
+// ArchiveSegment is a segment of the audio archive as returned by the API
+type ArchiveSegment struct {
+	ID        uint      `json:"id"`
+	Source    string    `json:"source"` // source ID with stream credentials removed
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	Size      int64     `json:"size"`
+}
+
+// initArchiveRoutes registers the audio archive endpoints
+func (c *Controller) initArchiveRoutes() {
+	archiveGroup := c.Group.Group("/archive", c.AuthMiddleware)
+
+	archiveGroup.GET("/segments", c.GetArchiveSegments)
+	archiveGroup.GET("/audio", c.GetArchiveAudio)
+}
+
+// archiveRange parses and validates the source, start and end query parameters
+func (c *Controller) archiveRange(ctx echo.Context) (source string, start, end time.Time, err error) {
+	if !c.Settings.Realtime.Audio.Archive.Enabled {
+		return "", time.Time{}, time.Time{}, errors.New("audio archive is not enabled")
+	}
+
+	source = ctx.QueryParam("source")
+	if source == "" {
+		return "", time.Time{}, time.Time{}, errors.New("source parameter is required")
+	}
+
+	start, err = time.Parse(time.RFC3339, ctx.QueryParam("start"))
+	if err != nil {
+		return "", time.Time{}, time.Time{}, fmt.Errorf("invalid start time, expected RFC 3339: %w", err)
+	}
+	end, err = time.Parse(time.RFC3339, ctx.QueryParam("end"))
+	if err != nil {
+		return "", time.Time{}, time.Time{}, fmt.Errorf("invalid end time, expected RFC 3339: %w", err)
+	}
+	if !end.After(start) {
+		return "", time.Time{}, time.Time{}, errors.New("end time must be after start time")
+	}
+
+	return source, start, end, nil
+}
+
+// GetArchiveSegments handles GET /api/v2/archive/segments
+// Lists the archive segments of a source overlapping the time range given by "start" and "end"
+func (c *Controller) GetArchiveSegments(ctx echo.Context) error {
+	source, start, end, err := c.archiveRange(ctx)
+	if err != nil {
+		return c.HandleError(ctx, err, "Invalid archive query", http.StatusBadRequest)
+	}
+
+	segments, err := c.DS.GetAudioSegments(source, start, end)
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to get archive segments", http.StatusInternalServerError)
+	}
+
+	response := make([]ArchiveSegment, 0, len(segments))
+	for i := range segments {
+		response = append(response, ArchiveSegment{
+			ID:        segments[i].ID,
+			Source:    conf.SanitizeStreamUrl(segments[i].Source),
+			StartTime: segments[i].StartTime,
+			EndTime:   segments[i].EndTime,
+			Size:      segments[i].Size,
+		})
+	}
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// GetArchiveAudio handles GET /api/v2/archive/audio
+// Streams the archived audio of a source between "start" and "end" as WAV, gaps in the archive
+// are filled with silence
+func (c *Controller) GetArchiveAudio(ctx echo.Context) error {
+	source, start, end, err := c.archiveRange(ctx)
+	if err != nil {
+		return c.HandleError(ctx, err, "Invalid archive query", http.StatusBadRequest)
+	}
+	if end.Sub(start) > archiveMaxRange {
+		return c.HandleError(ctx, fmt.Errorf("range exceeds %s", archiveMaxRange),
+			"Requested time range is too long", http.StatusBadRequest)
+	}
+
+	segments, err := c.DS.GetAudioSegments(source, start, end)
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to get archive segments", http.StatusInternalServerError)
+	}
+	if len(segments) == 0 {
+		return c.HandleError(ctx, errors.New("no archived audio in range"),
+			"No archived audio found for the requested range", http.StatusNotFound)
+	}
+
+	samples := int(end.Sub(start) * time.Duration(conf.SampleRate) / time.Second)
+	filename := fmt.Sprintf("archive_%s.wav", start.UTC().Format("20060102T150405Z"))
+
+	resp := ctx.Response()
+	resp.Header().Set(echo.HeaderContentType, "audio/wav")
+	resp.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+	resp.WriteHeader(http.StatusOK)
+
+	if err := myaudio.WriteWAVHeader(resp, samples*conf.BitDepth/8); err != nil {
+		return err
+	}
+	if err := myaudio.WriteArchiveRange(resp, segments, start, end); err != nil {
+		// Headers have been sent, the error can only be logged
+		c.logger.Printf("Error streaming archived audio of %s: %v", source, err)
+	}
+	return nil
+}
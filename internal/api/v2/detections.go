@@ -2,7 +2,9 @@
 package api
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
@@ -33,6 +35,7 @@ func (c *Controller) initDetectionRoutes() {
 	detectionGroup.DELETE("/:id", c.DeleteDetection)
 	detectionGroup.POST("/:id/review", c.ReviewDetection)
 	detectionGroup.POST("/:id/lock", c.LockDetection)
+	detectionGroup.GET("/:id/similar", c.GetSimilarDetections)
 	detectionGroup.POST("/ignore", c.IgnoreSpecies)
 }
 
@@ -48,11 +51,21 @@ type DetectionResponse struct {
 	ScientificName string   `json:"scientificName"`
 	CommonName     string   `json:"commonName"`
 	Confidence     float64  `json:"confidence"`
+	ClipDuration   float64  `json:"clipDuration"`
+	RMSLevel       *float64 `json:"rmsLevel,omitempty"`  // dBFS
+	PeakLevel      *float64 `json:"peakLevel,omitempty"` // dBFS
+	SNR            *float64 `json:"snr,omitempty"`       // dB
 	Verified       string   `json:"verified"`
 	Locked         bool     `json:"locked"`
 	Comments       []string `json:"comments,omitempty"`
 }
 
+// SimilarDetectionResponse represents a detection similar to another detection in the API response
+type SimilarDetectionResponse struct {
+	DetectionResponse
+	Similarity float64 `json:"similarity"` // cosine similarity of the embeddings, from -1 to 1
+}
+
 // DetectionRequest represents the query parameters for listing detections
 type DetectionRequest struct {
 	Comment       string `json:"comment,omitempty"`
@@ -83,6 +96,16 @@ func (c *Controller) GetDetections(ctx echo.Context) error {
 	offset, _ := strconv.Atoi(ctx.QueryParam("offset"))
 	queryType := ctx.QueryParam("queryType") // "hourly", "species", "search", or "all"
 
+	// Optional signal level filters
+	signalFilter, err := parseSignalFilter(ctx)
+	if err != nil {
+		return c.HandleError(ctx, err, "Invalid signal level filter", http.StatusBadRequest)
+	}
+	if !signalFilter.IsEmpty() && (queryType == "hourly" || queryType == "species") {
+		return c.HandleError(ctx, fmt.Errorf("query type %s does not support signal level filters", queryType),
+			"Signal level filters can only be used with search and all queries", http.StatusBadRequest)
+	}
+
 	// Set default values and enforce maximum limit
 	if numResults <= 0 {
 		numResults = 100
@@ -97,16 +120,17 @@ func (c *Controller) GetDetections(ctx echo.Context) error {
 	}
 
 	var notes []datastore.Note
-	var err error
 	var totalResults int64
 
 	// Get notes based on query type
-	switch queryType {
-	case "hourly":
+	switch {
+	case !signalFilter.IsEmpty():
+		notes, totalResults, err = c.getSignalDetections(search, signalFilter, numResults, offset)
+	case queryType == "hourly":
 		notes, totalResults, err = c.getHourlyDetections(date, hour, duration, numResults, offset)
-	case "species":
+	case queryType == "species":
 		notes, totalResults, err = c.getSpeciesDetections(species, date, hour, duration, numResults, offset)
-	case "search":
+	case queryType == "search":
 		notes, totalResults, err = c.getSearchDetections(search, numResults, offset)
 	default: // "all" or any other value
 		notes, totalResults, err = c.getAllDetections(numResults, offset)
@@ -131,6 +155,10 @@ func (c *Controller) GetDetections(ctx echo.Context) error {
 			ScientificName: note.ScientificName,
 			CommonName:     note.CommonName,
 			Confidence:     note.Confidence,
+			ClipDuration:   note.ClipDuration.Seconds(),
+			RMSLevel:       note.RMSLevel,
+			PeakLevel:      note.PeakLevel,
+			SNR:            note.SNR,
 			Locked:         note.Locked,
 		}
 
@@ -275,6 +303,70 @@ func (c *Controller) getSearchDetections(search string, numResults, offset int)
 	return notes, totalCount, nil
 }
 
+// getSignalDetections handles search and all queries filtered by signal levels, the search term
+// is empty for all queries
+func (c *Controller) getSignalDetections(search string, filter *datastore.SignalFilter, numResults, offset int) ([]datastore.Note, int64, error) {
+	// Generate a cache key based on parameters
+	cacheKey := fmt.Sprintf("signal:%s:%s:%d:%d", search, filter, numResults, offset)
+
+	// Check if data is in cache
+	if cachedData, found := c.detectionCache.Get(cacheKey); found {
+		cachedResult := cachedData.(struct {
+			Notes []datastore.Note
+			Total int64
+		})
+		return cachedResult.Notes, cachedResult.Total, nil
+	}
+
+	// If not in cache, query the database
+	notes, err := c.DS.SearchNotesBySignal(search, filter, false, numResults, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	totalCount, err := c.DS.CountSignalSearchResults(search, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Cache the results
+	c.detectionCache.Set(cacheKey, struct {
+		Notes []datastore.Note
+		Total int64
+	}{notes, totalCount}, cache.DefaultExpiration)
+
+	return notes, totalCount, nil
+}
+
+// parseSignalFilter parses the minSnr, maxSnr, minRms, maxRms, minPeak and maxPeak query
+// parameters, levels are given in dB
+func parseSignalFilter(ctx echo.Context) (*datastore.SignalFilter, error) {
+	filter := &datastore.SignalFilter{}
+	bounds := []struct {
+		param string
+		value **float64
+	}{
+		{"minSnr", &filter.MinSNR},
+		{"maxSnr", &filter.MaxSNR},
+		{"minRms", &filter.MinRMS},
+		{"maxRms", &filter.MaxRMS},
+		{"minPeak", &filter.MinPeak},
+		{"maxPeak", &filter.MaxPeak},
+	}
+	for _, b := range bounds {
+		raw := ctx.QueryParam(b.param)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+			return nil, fmt.Errorf("invalid %s value: %s", b.param, raw)
+		}
+		*b.value = &value
+	}
+	return filter, nil
+}
+
 // getAllDetections handles default/all query type logic
 func (c *Controller) getAllDetections(numResults, offset int) ([]datastore.Note, int64, error) {
 	// Generate a cache key based on parameters
@@ -330,6 +422,10 @@ func (c *Controller) GetDetection(ctx echo.Context) error {
 		ScientificName: note.ScientificName,
 		CommonName:     note.CommonName,
 		Confidence:     note.Confidence,
+		ClipDuration:   note.ClipDuration.Seconds(),
+		RMSLevel:       note.RMSLevel,
+		PeakLevel:      note.PeakLevel,
+		SNR:            note.SNR,
 		Locked:         note.Locked,
 	}
 
@@ -381,6 +477,10 @@ func (c *Controller) GetRecentDetections(ctx echo.Context) error {
 			ScientificName: note.ScientificName,
 			CommonName:     note.CommonName,
 			Confidence:     note.Confidence,
+			ClipDuration:   note.ClipDuration.Seconds(),
+			RMSLevel:       note.RMSLevel,
+			PeakLevel:      note.PeakLevel,
+			SNR:            note.SNR,
 			Locked:         note.Locked,
 		}
 
@@ -400,6 +500,100 @@ func (c *Controller) GetRecentDetections(ctx echo.Context) error {
 	return ctx.JSON(http.StatusOK, detections)
 }
 
+// similarDetectionsDays is the length of the default date range searched for similar detections
+const similarDetectionsDays = 30
+
+// GetSimilarDetections returns the past detections most similar to a detection by the cosine
+// similarity of their embeddings, most similar first. The start_date, end_date and species query
+// parameters limit the detections compared.
+func (c *Controller) GetSimilarDetections(ctx echo.Context) error {
+	id := ctx.Param("id")
+	if _, err := strconv.ParseUint(id, 10, 32); err != nil {
+		return c.HandleError(ctx, err, "Invalid detection ID", http.StatusBadRequest)
+	}
+
+	limit, _ := strconv.Atoi(ctx.QueryParam("limit"))
+	if limit <= 0 {
+		limit = 10
+	}
+	limit = min(limit, 100)
+
+	// Candidates are limited to a date range, by default the last 30 days, and optionally a species
+	startDate := ctx.QueryParam("start_date")
+	endDate := ctx.QueryParam("end_date")
+	species := ctx.QueryParam("species")
+	for _, date := range []string{startDate, endDate} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return c.HandleError(ctx, err, "Invalid date format. Use YYYY-MM-DD", http.StatusBadRequest)
+		}
+	}
+	switch {
+	case startDate == "" && endDate == "":
+		endDate = time.Now().Format("2006-01-02")
+		startDate = time.Now().AddDate(0, 0, -similarDetectionsDays).Format("2006-01-02")
+	case startDate == "":
+		end, _ := time.Parse("2006-01-02", endDate)
+		startDate = end.AddDate(0, 0, -similarDetectionsDays).Format("2006-01-02")
+	case endDate == "":
+		start, _ := time.Parse("2006-01-02", startDate)
+		endDate = start.AddDate(0, 0, similarDetectionsDays).Format("2006-01-02")
+	}
+	if startDate > endDate {
+		return c.HandleError(ctx, fmt.Errorf("start date %s is after end date %s", startDate, endDate),
+			"Start date must not be after end date", http.StatusBadRequest)
+	}
+
+	similar, err := c.DS.GetSimilarNotes(id, startDate, endDate, species, limit)
+	if errors.Is(err, datastore.ErrNoEmbedding) {
+		return c.HandleError(ctx, err, "Detection has no embedding, enable embeddings with a model that provides them", http.StatusNotFound)
+	}
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to get similar detections", http.StatusInternalServerError)
+	}
+
+	detections := []SimilarDetectionResponse{}
+	for i := range similar {
+		note := &similar[i].Note
+		detection := DetectionResponse{
+			ID:             note.ID,
+			Date:           note.Date,
+			Time:           note.Time,
+			Source:         note.Source,
+			BeginTime:      note.BeginTime.Format(time.RFC3339),
+			EndTime:        note.EndTime.Format(time.RFC3339),
+			SpeciesCode:    note.SpeciesCode,
+			ScientificName: note.ScientificName,
+			CommonName:     note.CommonName,
+			Confidence:     note.Confidence,
+			ClipDuration:   note.ClipDuration.Seconds(),
+			RMSLevel:       note.RMSLevel,
+			PeakLevel:      note.PeakLevel,
+			SNR:            note.SNR,
+			Locked:         note.Locked,
+		}
+
+		// Handle verification status
+		switch note.Verified {
+		case "correct":
+			detection.Verified = "correct"
+		case "false_positive":
+			detection.Verified = "false_positive"
+		default:
+			detection.Verified = "unverified"
+		}
+
+		detections = append(detections, SimilarDetectionResponse{
+			DetectionResponse: detection,
+			Similarity:        similar[i].Similarity,
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, detections)
+}
+
 // DeleteDetection deletes a detection by ID
 func (c *Controller) DeleteDetection(ctx echo.Context) error {
 	idStr := ctx.Param("id")
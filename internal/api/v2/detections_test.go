@@ -0,0 +1,115 @@
+// detections_test.go: Package api provides tests for the detection endpoints.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// TestGetDetectionsSignalFilter tests that detections are filtered by signal levels and include their measurements
+func TestGetDetectionsSignalFilter(t *testing.T) {
+	e, mockDS, controller := setupTestEnvironment(t)
+
+	snr, rms, peak := 18.5, -42.1, -20.3
+	notes := []datastore.Note{{
+		ID:         1,
+		CommonName: "American Robin",
+		Confidence: 0.85,
+		BeginTime:  time.Now(),
+		EndTime:    time.Now(),
+		SNR:        &snr,
+		RMSLevel:   &rms,
+		PeakLevel:  &peak,
+	}}
+
+	isExpectedFilter := mock.MatchedBy(func(f *datastore.SignalFilter) bool {
+		return f.MinSNR != nil && *f.MinSNR == 10 && f.MaxRMS != nil && *f.MaxRMS == -30 &&
+			f.MaxSNR == nil && f.MinRMS == nil && f.MinPeak == nil && f.MaxPeak == nil
+	})
+	mockDS.On("SearchNotesBySignal", "robin", isExpectedFilter, false, 100, 0).Return(notes, nil)
+	mockDS.On("CountSignalSearchResults", "robin", isExpectedFilter).Return(int64(1), nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/detections?queryType=search&search=robin&minSnr=10&maxRms=-30", http.NoBody)
+	rec := httptest.NewRecorder()
+	require.NoError(t, controller.GetDetections(e.NewContext(req, rec)))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var response struct {
+		Data  []DetectionResponse `json:"data"`
+		Total int64               `json:"total"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, int64(1), response.Total)
+	require.Len(t, response.Data, 1)
+	assert.Equal(t, &snr, response.Data[0].SNR)
+	assert.Equal(t, &rms, response.Data[0].RMSLevel)
+	assert.Equal(t, &peak, response.Data[0].PeakLevel)
+	mockDS.AssertExpectations(t)
+}
+
+// TestGetDetectionsSignalFilterErrors tests that invalid and unsupported signal level filters are rejected
+func TestGetDetectionsSignalFilterErrors(t *testing.T) {
+	e, mockDS, controller := setupTestEnvironment(t)
+
+	for _, query := range []string{
+		"?minSnr=loud",
+		"?maxPeak=NaN",
+		"?queryType=hourly&date=2024-05-01&hour=05&minSnr=10",
+		"?queryType=species&species=robin&maxRms=-30",
+	} {
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(httptest.NewRequest(http.MethodGet, "/api/v2/detections"+query, http.NoBody), rec)
+		require.NoError(t, controller.GetDetections(ctx))
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+	mockDS.AssertExpectations(t)
+}
+
+// TestGetSimilarDetections tests that similar detections are returned with their similarity
+func TestGetSimilarDetections(t *testing.T) {
+	e, mockDS, controller := setupTestEnvironment(t)
+
+	similar := []datastore.SimilarNote{
+		{Note: datastore.Note{ID: 7, CommonName: "American Robin", Verified: "correct"}, Similarity: 0.93},
+		{Note: datastore.Note{ID: 3, CommonName: "American Robin"}, Similarity: 0.81},
+	}
+	today := time.Now().Format("2006-01-02")
+	monthAgo := time.Now().AddDate(0, 0, -30).Format("2006-01-02")
+	mockDS.On("GetSimilarNotes", "5", "2024-05-01", "2024-05-31", "Turdus migratorius", 2).Return(similar, nil)
+	mockDS.On("GetSimilarNotes", "6", monthAgo, today, "", 10).Return([]datastore.SimilarNote(nil), datastore.ErrNoEmbedding)
+
+	get := func(id, query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(httptest.NewRequest(http.MethodGet, "/api/v2/detections/"+id+"/similar"+query, http.NoBody), rec)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(id)
+		require.NoError(t, controller.GetSimilarDetections(ctx))
+		return rec
+	}
+
+	rec := get("5", "?limit=2&start_date=2024-05-01&species=Turdus+migratorius")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var response []SimilarDetectionResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	require.Len(t, response, 2)
+	assert.Equal(t, uint(7), response[0].ID)
+	assert.Equal(t, "correct", response[0].Verified)
+	assert.InDelta(t, 0.93, response[0].Similarity, 1e-9)
+	assert.Equal(t, "unverified", response[1].Verified)
+
+	// Detections without an embedding and invalid IDs are rejected
+	assert.Equal(t, http.StatusNotFound, get("6", "").Code)
+	assert.Equal(t, http.StatusBadRequest, get("robin", "").Code)
+	assert.Equal(t, http.StatusBadRequest, get("5", "?start_date=May").Code)
+	assert.Equal(t, http.StatusBadRequest, get("5", "?start_date=2024-06-01&end_date=2024-05-01").Code)
+	mockDS.AssertExpectations(t)
+}
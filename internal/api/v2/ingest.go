@@ -0,0 +1,170 @@
+// internal/api/v2/ingest.go
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/myaudio"
+)
+
+const (
+	// ingestMaxUploadSize is the maximum size of an uploaded recording
+	ingestMaxUploadSize = 1 << 30
+
+	// ingestQueueSize is the number of uploads which can wait for analysis
+	ingestQueueSize = 32
+
+	// ingestSourcePrefix is prepended to station IDs to form the audio source of uploads
+	ingestSourcePrefix = "upload:"
+)
+
+// ingestUpload is a recording waiting for analysis
+type ingestUpload struct {
+	path      string    // temporary file holding the upload
+	source    string    // audio source of the detections
+	startTime time.Time // recording start time
+}
+
+// IngestResponse is returned when an upload has been queued for analysis
+type IngestResponse struct {
+	Status    string    `json:"status"`
+	Source    string    `json:"source"`
+	StartTime time.Time `json:"start_time"`
+	Queued    int       `json:"queued"`
+}
+
+// initIngestRoutes registers the recording upload endpoint and starts the analysis worker
+func (c *Controller) initIngestRoutes() {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.ingestCancel = cancel
+	c.ingestQueue = make(chan ingestUpload, ingestQueueSize)
+	go c.runIngestWorker(ctx)
+
+	c.Group.POST("/ingest", c.IngestRecording, c.ingestAuthMiddleware)
+}
+
+// ingestAuthMiddleware accepts the shared ingest token as bearer token, which lets unattended
+// recorders upload without a user login, and otherwise falls back to the regular authentication
+func (c *Controller) ingestAuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	authenticated := c.AuthMiddleware(next)
+	return func(ctx echo.Context) error {
+		token := c.Settings.Realtime.Ingest.Token
+		if token != "" {
+			if bearer, found := strings.CutPrefix(ctx.Request().Header.Get("Authorization"), "Bearer "); found &&
+				subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) == 1 {
+				return next(ctx)
+			}
+		}
+		return authenticated(ctx)
+	}
+}
+
+// IngestRecording handles POST /api/v2/ingest
+// Accepts a WAV or FLAC recording as multipart form with the fields "file", "start" (RFC 3339
+// recording start time) and "station" (station ID), and queues it for analysis
+func (c *Controller) IngestRecording(ctx echo.Context) error {
+	if c.Processor == nil {
+		return c.HandleError(ctx, errors.New("processor not available"),
+			"Recording analysis is not available", http.StatusServiceUnavailable)
+	}
+
+	station := ctx.FormValue("station")
+	if !myaudio.IsValidStationName(station) {
+		return c.HandleError(ctx, fmt.Errorf("invalid station ID %q", station),
+			"Station ID must be 1-64 characters of letters, digits, '.', '_' or '-'", http.StatusBadRequest)
+	}
+
+	startTime, err := time.Parse(time.RFC3339, ctx.FormValue("start"))
+	if err != nil {
+		return c.HandleError(ctx, err, "Start time must be in RFC 3339 format, e.g. 2024-05-01T05:30:00Z", http.StatusBadRequest)
+	}
+	if startTime.After(time.Now()) {
+		return c.HandleError(ctx, errors.New("start time is in the future"), "Start time must not be in the future", http.StatusBadRequest)
+	}
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		return c.HandleError(ctx, err, "Missing recording file", http.StatusBadRequest)
+	}
+	if fileHeader.Size > ingestMaxUploadSize {
+		return c.HandleError(ctx, errors.New("file too large"), "Recording exceeds the maximum upload size", http.StatusRequestEntityTooLarge)
+	}
+	ext := strings.ToLower(filepath.Ext(fileHeader.Filename))
+	if ext != ".wav" && ext != ".flac" {
+		return c.HandleError(ctx, fmt.Errorf("unsupported file type %q", ext), "Only WAV and FLAC recordings are supported", http.StatusBadRequest)
+	}
+
+	path, err := saveIngestUpload(fileHeader, ext)
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to store uploaded recording", http.StatusInternalServerError)
+	}
+
+	upload := ingestUpload{path: path, source: ingestSourcePrefix + station, startTime: startTime}
+	select {
+	case c.ingestQueue <- upload:
+	default:
+		os.Remove(path)
+		return c.HandleError(ctx, errors.New("ingest queue is full"), "Too many recordings waiting for analysis, retry later", http.StatusServiceUnavailable)
+	}
+
+	c.Debug("Queued recording from station %s starting at %s", station, startTime.Format(time.RFC3339))
+	return ctx.JSON(http.StatusAccepted, IngestResponse{
+		Status:    "queued",
+		Source:    upload.source,
+		StartTime: startTime,
+		Queued:    len(c.ingestQueue),
+	})
+}
+
+// saveIngestUpload copies an uploaded file to a temporary file and returns its path
+func saveIngestUpload(fileHeader *multipart.FileHeader, ext string) (string, error) {
+	src, err := fileHeader.Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	dst, err := os.CreateTemp("", "birdnet-ingest-*"+ext)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(dst, io.LimitReader(src, ingestMaxUploadSize)); err != nil {
+		dst.Close()
+		os.Remove(dst.Name())
+		return "", err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(dst.Name())
+		return "", err
+	}
+	return dst.Name(), nil
+}
+
+// runIngestWorker analyzes queued recordings one at a time until ctx is cancelled
+func (c *Controller) runIngestWorker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case upload := <-c.ingestQueue:
+			c.logger.Printf("Analyzing recording from %s starting at %s", upload.source, upload.startTime.Format(time.RFC3339))
+			if err := c.Processor.ProcessRecording(ctx, upload.path, upload.source, upload.startTime); err != nil {
+				c.logger.Printf("Failed to analyze recording from %s: %v", upload.source, err)
+			}
+			if err := os.Remove(upload.path); err != nil {
+				c.logger.Printf("Failed to remove uploaded recording %s: %v", upload.path, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// ingest_test.go: Package api provides tests for the recording upload endpoint.
+
+package api
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/analysis/processor"
+)
+
+// newIngestRequest builds a multipart upload request with the given form fields and file name
+func newIngestRequest(t *testing.T, fields map[string]string, filename string) *http.Request {
+	t.Helper()
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for name, value := range fields {
+		require.NoError(t, writer.WriteField(name, value))
+	}
+	if filename != "" {
+		part, err := writer.CreateFormFile("file", filename)
+		require.NoError(t, err)
+		_, err = part.Write([]byte("RIFF"))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/ingest", body)
+	req.Header.Set(echo.HeaderContentType, writer.FormDataContentType())
+	return req
+}
+
+// TestIngestRecordingValidation tests that invalid uploads are rejected before they are queued
+func TestIngestRecordingValidation(t *testing.T) {
+	e, _, controller := setupTestEnvironment(t)
+	controller.Processor = &processor.Processor{}
+
+	testCases := []struct {
+		name     string
+		fields   map[string]string
+		filename string
+	}{
+		{"invalid station", map[string]string{"station": "../site", "start": "2024-05-01T05:30:00Z"}, "rec.wav"},
+		{"missing start", map[string]string{"station": "site-1"}, "rec.wav"},
+		{"future start", map[string]string{"station": "site-1", "start": "2999-01-01T00:00:00Z"}, "rec.wav"},
+		{"missing file", map[string]string{"station": "site-1", "start": "2024-05-01T05:30:00Z"}, ""},
+		{"unsupported format", map[string]string{"station": "site-1", "start": "2024-05-01T05:30:00Z"}, "rec.mp3"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(newIngestRequest(t, tc.fields, tc.filename), rec)
+
+			require.NoError(t, controller.IngestRecording(ctx))
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Empty(t, controller.ingestQueue)
+		})
+	}
+}
+
+// TestIngestAuthMiddleware tests that the shared ingest token is accepted as bearer token
+func TestIngestAuthMiddleware(t *testing.T) {
+	e, _, controller := setupTestEnvironment(t)
+	controller.Settings.Realtime.Ingest.Token = "recorder-secret"
+
+	handler := controller.ingestAuthMiddleware(func(ctx echo.Context) error {
+		return ctx.NoContent(http.StatusAccepted)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/ingest", http.NoBody)
+	req.Header.Set("Authorization", "Bearer recorder-secret")
+	rec := httptest.NewRecorder()
+	require.NoError(t, handler(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+
+	// Other tokens are validated by the regular authentication
+	req = httptest.NewRequest(http.MethodPost, "/api/v2/ingest", http.NoBody)
+	req.Header.Set("Authorization", "Bearer wrong")
+	rec = httptest.NewRecorder()
+	require.NoError(t, handler(e.NewContext(req, rec)))
+	assert.NotEqual(t, http.StatusAccepted, rec.Code)
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 
 	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/analysis/processor"
 	"github.com/tphakala/birdnet-go/internal/conf"
 	"github.com/tphakala/birdnet-go/internal/datastore"
 	"github.com/tphakala/birdnet-go/internal/imageprovider"
@@ -21,11 +22,13 @@ func InitializeAPI(
 	birdImageCache *imageprovider.BirdImageCache,
 	sunCalc *suncalc.SunCalc,
 	controlChan chan string,
+	proc *processor.Processor,
 	logger *log.Logger,
 ) *Controller {
 
 	// Create new API controller
 	apiController := New(e, ds, settings, birdImageCache, sunCalc, controlChan, logger)
+	apiController.Processor = proc
 
 	if logger != nil {
 		logger.Printf("JSON API v2 initialized at /api/v2")
@@ -12,6 +12,7 @@ import (
 	"strings"
 
 	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/spectrogram"
 )
 
 // safeFilenamePattern defines the acceptable characters for filenames
@@ -266,7 +267,7 @@ func (c *Controller) ServeSpectrogram(ctx echo.Context) error {
 	baseFilename := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
 
 	// Generate spectrogram filename with width
-	spectrogramFilename := fmt.Sprintf("%s_%d.png", baseFilename, width)
+	spectrogramFilename := c.spectrogramFilename(baseFilename, width)
 
 	// Validate the spectrogram path
 	spectrogramPath, err := c.validateMediaPath(exportPath, spectrogramFilename)
@@ -291,6 +292,11 @@ func (c *Controller) ServeSpectrogram(ctx echo.Context) error {
 	return ctx.File(spectrogramPath)
 }
 
+// spectrogramFilename returns the file name of the spectrogram of an audio clip for the given width
+func (c *Controller) spectrogramFilename(baseFilename string, width int) string {
+	return spectrogram.FileName(baseFilename, width, c.Settings.Realtime.Dashboard.Spectrogram.Format)
+}
+
 // generateSpectrogram creates a spectrogram image for the given audio file
 func (c *Controller) generateSpectrogram(audioPath string, width int) (string, error) {
 	// Extract base filename without extension
@@ -298,7 +304,7 @@ func (c *Controller) generateSpectrogram(audioPath string, width int) (string, e
 
 	// Generate spectrogram filename with width
 	exportPath := c.Settings.Realtime.Audio.Export.Path
-	spectrogramFilename := fmt.Sprintf("%s_%d.png", baseFilename, width)
+	spectrogramFilename := c.spectrogramFilename(baseFilename, width)
 
 	// Validate the spectrogram path
 	spectrogramPath, err := c.validateMediaPath(exportPath, spectrogramFilename)
@@ -306,9 +312,11 @@ func (c *Controller) generateSpectrogram(audioPath string, width int) (string, e
 		return "", fmt.Errorf("invalid spectrogram path: %w", err)
 	}
 
-	// TODO: Implement the spectrogram generation logic
-	// This will depend on the specific libraries you're using for spectrogram generation
+	// Render the spectrogram, a render of the same file already in progress is shared
+	opts := spectrogram.OptionsFromSettings(c.Settings, width)
+	if err := spectrogram.Generate(audioPath, spectrogramPath, c.Settings.Realtime.Dashboard.Spectrogram.Format, opts); err != nil {
+		return "", err
+	}
 
-	// For now, we'll just return an error indicating this isn't implemented yet
-	return spectrogramPath, fmt.Errorf("spectrogram generation not implemented yet")
+	return spectrogramPath, nil
 }
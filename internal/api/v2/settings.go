@@ -12,6 +12,7 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/myaudio"
 )
 
 // UpdateRequest represents a request to update settings
@@ -591,6 +592,20 @@ func updateSettingsSectionWithTracking(settings *conf.Settings, section string,
 		settings.Realtime.RTSP = rtspSettings
 		return nil
 
+	case "sources":
+		// The audio source list is replaced as a whole
+		var sources []conf.AudioSourceConfig
+		if err := json.Unmarshal(data, &sources); err != nil {
+			return err
+		}
+
+		if err := conf.ValidateAudioSources(sources); err != nil {
+			return err
+		}
+
+		settings.Realtime.Sources = sources
+		return nil
+
 	case "species":
 		// Create a temporary copy
 		speciesSettings := settings.Realtime.Species
@@ -689,6 +704,10 @@ func getSettingsSection(settings *conf.Settings, section string) (interface{}, e
 		return settings.Realtime.Weather, nil
 	case "mqtt":
 		return settings.Realtime.MQTT, nil
+	case "rtsp":
+		return settings.Realtime.RTSP, nil
+	case "sources":
+		return settings.Realtime.Sources, nil
 	case "birdweather":
 		return settings.Realtime.Birdweather, nil
 	case "species":
@@ -882,10 +901,13 @@ func getAllowedFieldMap() map[string]interface{} {
 					"Type":    true,
 					"Bitrate": true,
 				},
-				"Equalizer": true,
+				"Equalizer":      true,
+				"NoiseReduction": true,
+				"AGC":            true,
 			},
-			"MQTT": true, // Allow complete update of MQTT settings
-			"RTSP": true, // Allow complete update of RTSP settings
+			"MQTT":    true, // Allow complete update of MQTT settings
+			"RTSP":    true, // Allow complete update of RTSP settings
+			"Sources": true, // Allow complete update of the audio source list
 			"Species": map[string]interface{}{
 				"Include": true,
 				"Exclude": true,
@@ -918,16 +940,20 @@ func (c *Controller) handleSettingsChanges(oldSettings, currentSettings *conf.Se
 		reconfigActions = append(reconfigActions, "reconfigure_mqtt")
 	}
 
-	// Check RTSP settings
-	if rtspSettingsChanged(oldSettings, currentSettings) {
-		c.Debug("RTSP settings changed, triggering reconfiguration")
-		reconfigActions = append(reconfigActions, "reconfigure_rtsp_sources")
+	// Check audio source settings, sources are restarted individually without a full restart
+	if rtspSettingsChanged(oldSettings, currentSettings) ||
+		audioDeviceSettingChanged(oldSettings, currentSettings) ||
+		audioSourcesChanged(oldSettings, currentSettings) {
+		c.Debug("Audio source settings changed, triggering reconfiguration")
+		reconfigActions = append(reconfigActions, "reconfigure_audio_sources")
 	}
 
-	// Check audio device settings
-	if audioDeviceSettingChanged(oldSettings, currentSettings) {
-		c.Debug("Audio device changed. A restart will be required.")
-		// No action here as restart is manual
+	// Audio filter settings are applied directly to the filter chains of running sources
+	if audioFiltersChanged(oldSettings, currentSettings) {
+		c.Debug("Audio filter settings changed, updating filter chains")
+		if err := myaudio.UpdateFilterChains(&currentSettings.Realtime); err != nil {
+			return fmt.Errorf("failed to update audio filters: %w", err)
+		}
 	}
 
 	// Trigger reconfigurations asynchronously
@@ -957,6 +983,16 @@ func birdnetSettingsChanged(oldSettings, currentSettings *conf.Settings) bool {
 		return true
 	}
 
+	// Check for changes in the number of BirdNET interpreters
+	if oldSettings.BirdNET.Interpreters != currentSettings.BirdNET.Interpreters {
+		return true
+	}
+
+	// Check for changes in the threads of each BirdNET interpreter
+	if !reflect.DeepEqual(oldSettings.BirdNET.InterpreterThreads, currentSettings.BirdNET.InterpreterThreads) {
+		return true
+	}
+
 	// Check for changes in BirdNET model path
 	if oldSettings.BirdNET.ModelPath != currentSettings.BirdNET.ModelPath {
 		return true
@@ -1031,3 +1067,17 @@ func rtspSettingsChanged(oldSettings, currentSettings *conf.Settings) bool {
 func audioDeviceSettingChanged(oldSettings, currentSettings *conf.Settings) bool {
 	return oldSettings.Realtime.Audio.Source != currentSettings.Realtime.Audio.Source
 }
+
+// audioSourcesChanged checks if the unified audio source list has changed
+func audioSourcesChanged(oldSettings, currentSettings *conf.Settings) bool {
+	return !reflect.DeepEqual(oldSettings.Realtime.Sources, currentSettings.Realtime.Sources)
+}
+
+// audioFiltersChanged checks if the global equalizer, noise reduction or AGC settings, or the
+// source list with its per-source filter settings have changed
+func audioFiltersChanged(oldSettings, currentSettings *conf.Settings) bool {
+	return !reflect.DeepEqual(oldSettings.Realtime.Audio.Equalizer, currentSettings.Realtime.Audio.Equalizer) ||
+		oldSettings.Realtime.Audio.NoiseReduction != currentSettings.Realtime.Audio.NoiseReduction ||
+		oldSettings.Realtime.Audio.AGC != currentSettings.Realtime.Audio.AGC ||
+		audioSourcesChanged(oldSettings, currentSettings)
+}
@@ -3,16 +3,20 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/myaudio"
 )
 
-// Constants for WebSocket connections
+// Constants for WebSocket and Server-Sent Event connections
 const (
 	// Time allowed to write a message to the client
 	writeWait = 10 * time.Second
@@ -25,6 +29,9 @@ const (
 
 	// Maximum message size allowed from client
 	maxMessageSize = 512
+
+	// Interval of heartbeat comments sent to Server-Sent Event clients
+	sseHeartbeatInterval = 10 * time.Second
 )
 
 var (
@@ -61,6 +68,8 @@ func (c *Controller) initStreamRoutes() {
 	// Routes for real-time data streams
 	streamsGroup.GET("/audio-level", c.HandleAudioLevelStream)
 	streamsGroup.GET("/notifications", c.HandleNotificationsStream)
+	streamsGroup.GET("/spectrum", c.HandleSpectrumStream)
+	streamsGroup.GET("/audio/:source", c.HandleAudioStream)
 }
 
 // HandleAudioLevelStream handles WebSocket connections for streaming audio level data
@@ -122,6 +131,117 @@ func (c *Controller) HandleNotificationsStream(ctx echo.Context) error {
 	return nil
 }
 
+// HandleSpectrumStream handles GET /api/v2/streams/spectrum
+// Streams the live spectrum of the audio sources as Server-Sent Events. The first event describes
+// the frequency bands, it is followed by one event per spectrum frame. The optional "source" query
+// parameter limits the stream to one source ID.
+func (c *Controller) HandleSpectrumStream(ctx echo.Context) error {
+	frames, unsubscribe, err := myaudio.SubscribeSpectrum(ctx.QueryParam("source"))
+	if err != nil {
+		return c.HandleError(ctx, err, "Live spectrum is disabled", http.StatusServiceUnavailable)
+	}
+	defer unsubscribe()
+	info, _ := myaudio.LiveSpectrumInfo()
+
+	c.Debug("Client %s connected to spectrum stream", ctx.RealIP())
+	defer c.Debug("Client %s disconnected from spectrum stream", ctx.RealIP())
+
+	ctx.Response().Header().Set(echo.HeaderContentType, "text/event-stream")
+	ctx.Response().Header().Set(echo.HeaderCacheControl, "no-cache")
+	ctx.Response().Header().Set(echo.HeaderConnection, "keep-alive")
+	ctx.Response().WriteHeader(http.StatusOK)
+
+	if err := writeSSEEvent(ctx, "spectrum-info", info); err != nil {
+		return err
+	}
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Request().Context().Done():
+			return nil
+
+		case frame := <-frames:
+			if err := writeSSEEvent(ctx, "spectrum", frame); err != nil {
+				return err
+			}
+
+		case <-heartbeat.C:
+			if _, err := fmt.Fprintf(ctx.Response(), ": heartbeat %d\n\n", time.Now().Unix()); err != nil {
+				return err
+			}
+			ctx.Response().Flush()
+		}
+	}
+}
+
+// HandleAudioStream handles GET /api/v2/streams/audio/:source
+// Streams the live audio of a source as MP3 until the client disconnects. The number of concurrent
+// listeners is limited, the audio encoder of a source stops when its last listener leaves.
+func (c *Controller) HandleAudioStream(ctx echo.Context) error {
+	// Echo does not unescape path parameters, stream sources are identified by their URL
+	sourceID, err := url.PathUnescape(ctx.Param("source"))
+	if err != nil {
+		return c.HandleError(ctx, err, "Invalid source ID", http.StatusBadRequest)
+	}
+
+	audio, stop, err := myaudio.Listen(sourceID)
+	switch {
+	case errors.Is(err, myaudio.ErrListenDisabled):
+		return c.HandleError(ctx, err, "Live listening is disabled", http.StatusServiceUnavailable)
+	case errors.Is(err, myaudio.ErrUnknownSource):
+		return c.HandleError(ctx, err, "Audio source not found", http.StatusNotFound)
+	case errors.Is(err, myaudio.ErrTooManyListeners):
+		return c.HandleError(ctx, err, "Too many listeners, try again later", http.StatusTooManyRequests)
+	case err != nil:
+		return c.HandleError(ctx, err, "Failed to start live audio stream", http.StatusInternalServerError)
+	}
+	defer stop()
+
+	c.Debug("Client %s started listening to source %s", ctx.RealIP(), sourceID)
+	defer c.Debug("Client %s stopped listening to source %s", ctx.RealIP(), sourceID)
+
+	ctx.Response().Header().Set(echo.HeaderContentType, myaudio.ListenContentType)
+	ctx.Response().Header().Set(echo.HeaderCacheControl, "no-cache, no-store")
+	ctx.Response().Header().Set(echo.HeaderConnection, "keep-alive")
+	ctx.Response().WriteHeader(http.StatusOK)
+	ctx.Response().Flush()
+
+	for {
+		select {
+		case <-ctx.Request().Context().Done():
+			return nil
+
+		case chunk, ok := <-audio:
+			if !ok {
+				// The encoder stopped
+				return nil
+			}
+			if _, err := ctx.Response().Write(chunk); err != nil {
+				return nil
+			}
+			ctx.Response().Flush()
+		}
+	}
+}
+
+// writeSSEEvent writes a JSON encoded Server-Sent Event of the given type and flushes it to the client
+func writeSSEEvent(ctx echo.Context, eventType string, data any) error {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("error marshaling JSON: %w", err)
+	}
+
+	if _, err := fmt.Fprintf(ctx.Response(), "event: %s\ndata: %s\n\n", eventType, jsonData); err != nil {
+		return fmt.Errorf("error writing to client: %w", err)
+	}
+
+	ctx.Response().Flush()
+	return nil
+}
+
 // registerClient registers a WebSocket client with the appropriate stream manager
 func (c *Controller) registerClient(client *Client) {
 	// TODO: Implement proper client registration with the stream manager
@@ -0,0 +1,79 @@
+// streams_test.go: Package api provides tests for the real-time stream endpoints.
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/myaudio"
+)
+
+// TestHandleSpectrumStream tests that the spectrum stream describes its bands and is unavailable when disabled
+func TestHandleSpectrumStream(t *testing.T) {
+	e, _, controller := setupTestEnvironment(t)
+	t.Cleanup(func() { myaudio.EnableLiveSpectrum(&conf.LiveSpectrumSettings{}) })
+
+	t.Run("disabled", func(t *testing.T) {
+		myaudio.EnableLiveSpectrum(&conf.LiveSpectrumSettings{})
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(httptest.NewRequest(http.MethodGet, "/api/v2/streams/spectrum", http.NoBody), rec)
+		require.NoError(t, controller.HandleSpectrumStream(ctx))
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		myaudio.EnableLiveSpectrum(&conf.LiveSpectrumSettings{Enabled: true, Rate: 10, Bands: 64, FFTSize: 1024, MaxFreq: 12000})
+
+		// The client disconnects right after the stream is established
+		reqCtx, cancel := context.WithCancel(context.Background())
+		cancel()
+		req := httptest.NewRequest(http.MethodGet, "/api/v2/streams/spectrum?source=mic", http.NoBody).WithContext(reqCtx)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		require.NoError(t, controller.HandleSpectrumStream(ctx))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+		assert.Equal(t, "event: spectrum-info\ndata: {\"bands\":64,\"minFreq\":0,\"maxFreq\":12000,\"rate\":10,\"floor\":-120}\n\n", rec.Body.String())
+	})
+}
+
+// TestHandleAudioStreamErrors tests that live audio requests are rejected when listening is disabled or the source is not active
+func TestHandleAudioStreamErrors(t *testing.T) {
+	e, _, controller := setupTestEnvironment(t)
+	t.Cleanup(func() { myaudio.EnableLiveListen(&conf.AudioSettings{}) })
+
+	request := func(source string) int {
+		// The client disconnects right after the stream is established
+		reqCtx, cancel := context.WithCancel(context.Background())
+		cancel()
+		req := httptest.NewRequest(http.MethodGet, "/api/v2/streams/audio/"+source, http.NoBody).WithContext(reqCtx)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("source")
+		ctx.SetParamValues(source)
+		require.NoError(t, controller.HandleAudioStream(ctx))
+		return rec.Code
+	}
+
+	myaudio.EnableLiveListen(&conf.AudioSettings{})
+	assert.Equal(t, http.StatusServiceUnavailable, request("mic"))
+
+	settings := &conf.AudioSettings{FfmpegPath: "ffmpeg"}
+	settings.Listen.Enabled = true
+	settings.Listen.MaxListeners = 1
+	myaudio.EnableLiveListen(settings)
+	assert.Equal(t, http.StatusNotFound, request("missing"))
+
+	// Stream sources are identified by their escaped URL
+	require.NoError(t, myaudio.AllocateCaptureBuffer(1, conf.SampleRate, 2, "rtsp://cam.local/stream"))
+	t.Cleanup(func() { _ = myaudio.RemoveCaptureBuffer("rtsp://cam.local/stream") })
+	assert.NotEqual(t, http.StatusNotFound, request("rtsp%3A%2F%2Fcam.local%2Fstream"))
+}
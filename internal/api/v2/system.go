@@ -18,6 +18,7 @@ import (
 	"github.com/shirou/gopsutil/v3/host"
 	"github.com/shirou/gopsutil/v3/mem"
 	"github.com/shirou/gopsutil/v3/process"
+	"github.com/tphakala/birdnet-go/internal/conf"
 	"github.com/tphakala/birdnet-go/internal/myaudio"
 )
 
@@ -94,6 +95,13 @@ type ActiveAudioDevice struct {
 	Channels   int    `json:"channels"`
 }
 
+// AudioHealthResponse holds the health of the audio sources
+type AudioHealthResponse struct {
+	Enabled bool                   `json:"enabled"`
+	Healthy bool                   `json:"healthy"`
+	Sources []myaudio.SourceHealth `json:"sources"`
+}
+
 // Use monotonic clock for start time
 var startTime = time.Now()
 var startMonotonicTime = time.Now() // This inherently includes monotonic clock reading
@@ -178,6 +186,7 @@ func (c *Controller) initSystemRoutes() {
 	audioGroup := protectedGroup.Group("/audio")
 	audioGroup.GET("/devices", c.GetAudioDevices)
 	audioGroup.GET("/active", c.GetActiveAudioDevice)
+	audioGroup.GET("/health", c.GetAudioHealth)
 }
 
 // GetSystemInfo handles GET /api/v2/system/info
@@ -568,6 +577,25 @@ func (c *Controller) GetActiveAudioDevice(ctx echo.Context) error {
 	})
 }
 
+// GetAudioHealth handles GET /api/v2/system/audio/health
+// Returns the silence, clipping, DC offset, dropout and FFmpeg restart state of each audio source
+func (c *Controller) GetAudioHealth(ctx echo.Context) error {
+	sources, enabled := myaudio.AudioHealth()
+	response := AudioHealthResponse{
+		Enabled: enabled,
+		Healthy: true,
+		Sources: []myaudio.SourceHealth{},
+	}
+	if enabled {
+		response.Sources = sources
+	}
+	for i := range response.Sources {
+		response.Sources[i].Source = conf.SanitizeStreamUrl(response.Sources[i].Source) // source ID with stream credentials removed
+		response.Healthy = response.Healthy && response.Sources[i].Healthy
+	}
+	return ctx.JSON(http.StatusOK, response)
+}
+
 // Helper functions
 
 // FileSystemCategory represents categories of filesystems that should be handled similarly
@@ -1,6 +1,7 @@
 package birdnet
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"sort"
@@ -17,50 +18,171 @@ type Filter struct {
 	Label string
 }
 
+// ErrBatchSize is returned when the model cannot analyze a batch of the requested size
+var ErrBatchSize = errors.New("batch size not supported by the model")
+
 // DetectionsMap maps species names to a list of their detection results.
 type DetectionsMap map[string][]datastore.Results
 
 // Predict performs inference on a given sample using the TensorFlow Lite interpreter.
 // It processes the sample to predict species and their confidence levels.
 func (bn *BirdNET) Predict(sample [][]float32) ([]datastore.Results, error) {
-	// implement locking to prevent concurrent access to the interpreter, not
-	// necessarily best way to manage multiple audio sources but works for now
-	bn.mu.Lock()
-	defer bn.mu.Unlock()
+	results, err := bn.PredictBatch(sample[:1])
+	if err != nil {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+// PredictWithEmbedding performs inference on a given sample like Predict and also returns the
+// embedding of the sample, or nil if embeddings are disabled or the model has no embedding output.
+func (bn *BirdNET) PredictWithEmbedding(sample [][]float32) ([]datastore.Results, []float32, error) {
+	results, embeddings, err := bn.predictBatch(sample[:1], bn.Settings.BirdNET.Embeddings)
+	if err != nil {
+		return nil, nil, err
+	}
+	if embeddings == nil {
+		return results[0], nil, nil
+	}
+	return results[0], embeddings[0], nil
+}
+
+// PredictBatch performs inference on a batch of samples with a single invoke of the interpreter,
+// the input tensor is resized to the number of samples. It returns the results of each sample.
+func (bn *BirdNET) PredictBatch(samples [][]float32) ([][]datastore.Results, error) {
+	results, _, err := bn.predictBatch(samples, false)
+	return results, err
+}
+
+// predictBatch performs inference on a batch of samples and returns the results of each sample,
+// and their embeddings if withEmbeddings is set and the model has an embedding output.
+func (bn *BirdNET) predictBatch(samples [][]float32, withEmbeddings bool) ([][]datastore.Results, [][]float32, error) {
+	if len(samples) == 0 {
+		return nil, nil, nil
+	}
+
+	// Predictions hold the read lock so the model is not reloaded while they run
+	bn.mu.RLock()
+	defer bn.mu.RUnlock()
+
+	// Run the inference on an idle interpreter of the pool, waiting for one if all are busy
+	waitStart := time.Now()
+	interpreter := bn.pool.acquire()
+	inferenceStart := time.Now()
+	bn.reportPoolState()
+	defer func() {
+		bn.pool.release(interpreter)
+		bn.reportPoolUsage(inferenceStart.Sub(waitStart), time.Since(inferenceStart))
+		bn.reportPoolState()
+	}()
+
+	if err := resizeBatch(interpreter, len(samples)); err != nil {
+		return nil, nil, err
+	}
 
 	// Get the input tensor from the interpreter
-	inputTensor := bn.AnalysisInterpreter.GetInputTensor(0)
+	inputTensor := interpreter.GetInputTensor(0)
 	if inputTensor == nil {
-		return nil, fmt.Errorf("cannot get input tensor")
+		return nil, nil, fmt.Errorf("cannot get input tensor")
 	}
 
-	// Preparing input tensor with the sample data
-	copy(inputTensor.Float32s(), sample[0])
+	// Preparing input tensor with the sample data, short samples are padded with silence
+	input := inputTensor.Float32s()
+	sampleSize := len(input) / len(samples)
+	for i, sample := range samples {
+		window := input[i*sampleSize : (i+1)*sampleSize]
+		n := copy(window, sample)
+		clear(window[n:])
+	}
 
 	// DEBUG: Log the length of the sample data
 	//log.Printf("Invoking tensor with sample length: %d", len(sample[0]))
 
 	// Invoke the interpreter to perform inference
-	if status := bn.AnalysisInterpreter.Invoke(); status != tflite.OK {
-		return nil, fmt.Errorf("tensor invoke failed: %v", status)
+	if status := interpreter.Invoke(); status != tflite.OK {
+		return nil, nil, fmt.Errorf("tensor invoke failed: %v", status)
 	}
 
 	// Read the results from the output tensor
-	outputTensor := bn.AnalysisInterpreter.GetOutputTensor(0)
-	predictions := extractPredictions(outputTensor)
+	outputTensor := interpreter.GetOutputTensor(0)
+	batchPredictions, err := splitPredictions(extractPredictions(outputTensor), len(samples))
+	if err != nil {
+		return nil, nil, err
+	}
 
-	confidence := applySigmoidToPredictions(predictions, bn.Settings.BirdNET.Sensitivity)
+	batchResults := make([][]datastore.Results, len(samples))
+	for i, predictions := range batchPredictions {
+		confidence := applySigmoidToPredictions(predictions, bn.Settings.BirdNET.Sensitivity)
+
+		results, err := pairLabelsAndConfidence(bn.Settings.BirdNET.Labels, confidence)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Sorting results by confidence in descending order.
+		sortResults(results)
+
+		// Keep the top 10 results
+		batchResults[i] = trimResultsToMax(results, 10)
+	}
 
-	results, err := pairLabelsAndConfidence(bn.Settings.BirdNET.Labels, confidence)
+	// Read the embeddings from their output tensor, if the model has one
+	if !withEmbeddings || ActiveModel().EmbeddingOutput == 0 {
+		return batchResults, nil, nil
+	}
+	embeddingTensor := interpreter.GetOutputTensor(ActiveModel().EmbeddingOutput)
+	if embeddingTensor == nil {
+		return nil, nil, fmt.Errorf("cannot get embedding output tensor")
+	}
+	batchEmbeddings, err := splitPredictions(extractPredictions(embeddingTensor), len(samples))
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	return batchResults, batchEmbeddings, nil
+}
 
-	// Sorting results by confidence in descending order.
-	sortResults(results)
+// resizeBatch resizes the input tensor of the interpreter to a batch of batchSize samples and
+// reallocates the tensors if the batch size changes
+func resizeBatch(interpreter *tflite.Interpreter, batchSize int) error {
+	inputTensor := interpreter.GetInputTensor(0)
+	if inputTensor == nil {
+		return fmt.Errorf("cannot get input tensor")
+	}
+	if inputTensor.NumDims() < 2 {
+		if batchSize > 1 {
+			return fmt.Errorf("%w: model input has no batch dimension, set the batch size to 1", ErrBatchSize)
+		}
+		return nil
+	}
+	if inputTensor.Dim(0) == batchSize {
+		return nil
+	}
 
-	// Return the top 10 results
-	return trimResultsToMax(results, 10), nil
+	dims := make([]int32, inputTensor.NumDims())
+	for i := range dims {
+		dims[i] = int32(inputTensor.Dim(i))
+	}
+	dims[0] = int32(batchSize)
+	if status := interpreter.ResizeInputTensor(0, dims); status != tflite.OK {
+		return fmt.Errorf("%w: failed to resize input tensor to a batch of %d: %v", ErrBatchSize, batchSize, status)
+	}
+	if status := interpreter.AllocateTensors(); status != tflite.OK {
+		return fmt.Errorf("%w: tensor allocation failed for a batch of %d", ErrBatchSize, batchSize)
+	}
+	return nil
+}
+
+// splitPredictions splits the predictions or embeddings of a batch into those of each sample
+func splitPredictions(predictions []float32, batchSize int) ([][]float32, error) {
+	if batchSize <= 0 || len(predictions)%batchSize != 0 {
+		return nil, fmt.Errorf("cannot split %d predictions into a batch of %d", len(predictions), batchSize)
+	}
+	size := len(predictions) / batchSize
+	batch := make([][]float32, batchSize)
+	for i := range batch {
+		batch[i] = predictions[i*size : (i+1)*size]
+	}
+	return batch, nil
 }
 
 // AnalyzeAudio processes audio data in chunks and predicts species using the BirdNET model.
@@ -88,23 +210,40 @@ func (bn *BirdNET) Predict(sample [][]float32) ([]datastore.Results, error) {
 
 // processChunk handles the prediction for a single chunk of audio data.
 func (bn *BirdNET) ProcessChunk(chunk []float32, predStart time.Time) ([]datastore.Note, error) {
-	results, err := bn.Predict([][]float32{chunk})
+	notes, err := bn.ProcessChunks([][]float32{chunk}, []time.Time{predStart})
+	if err != nil {
+		return nil, err
+	}
+	return notes[0], nil
+}
+
+// ProcessChunks handles the prediction for a batch of audio chunks starting at predStarts with
+// a single inference, it returns the notes of each chunk.
+func (bn *BirdNET) ProcessChunks(chunks [][]float32, predStarts []time.Time) ([][]datastore.Note, error) {
+	if len(chunks) != len(predStarts) {
+		return nil, fmt.Errorf("mismatched chunks and start times: %d vs %d", len(chunks), len(predStarts))
+	}
+
+	batchResults, err := bn.PredictBatch(chunks)
 	if err != nil {
 		return nil, fmt.Errorf("prediction failed: %w", err)
 	}
 
-	// calculate predEnd time based on settings.BirdNET.Overlap
-	predEnd := predStart.Add(time.Duration((3.0 - bn.Settings.BirdNET.Overlap) * float64(time.Second)))
+	// calculate predEnd time based on the model window and settings.BirdNET.Overlap
+	step := time.Duration(ActiveModel().Step(bn.Settings.BirdNET.Overlap) * float64(time.Second))
 
 	var source = ""
 	var clipName = ""
 
-	var notes []datastore.Note
-	for _, result := range results {
-		note := observation.New(bn.Settings, predStart, predEnd, result.Species, float64(result.Confidence), source, clipName, 0)
-		notes = append(notes, note)
+	batchNotes := make([][]datastore.Note, len(chunks))
+	for i, results := range batchResults {
+		predEnd := predStarts[i].Add(step)
+		for _, result := range results {
+			note := observation.New(bn.Settings, predStarts[i], predEnd, result.Species, float64(result.Confidence), source, clipName, 0)
+			batchNotes[i] = append(batchNotes[i], note)
+		}
 	}
-	return notes, nil
+	return batchNotes, nil
 }
 
 // customSigmoid applies a sigmoid function with sensitivity adjustment to a value.
@@ -162,9 +301,12 @@ func EstimateTimeRemaining(start time.Time, current, total int) string {
 	return fmt.Sprintf("(Estimated time remaining: %s)", FormatDuration(remaining))
 }
 
-// extractPredictions extracts prediction results from a TensorFlow Lite tensor.
+// extractPredictions extracts prediction results of all samples of a batch from a TensorFlow Lite tensor.
 func extractPredictions(tensor *tflite.Tensor) []float32 {
-	predSize := tensor.Dim(tensor.NumDims() - 1)
+	predSize := 1
+	for i := 0; i < tensor.NumDims(); i++ {
+		predSize *= tensor.Dim(i)
+	}
 	predictions := make([]float32, predSize)
 	copy(predictions, tensor.Float32s())
 	return predictions
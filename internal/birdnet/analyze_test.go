@@ -0,0 +1,24 @@
+package birdnet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitPredictions(t *testing.T) {
+	// The output of a batch holds the predictions of each sample one after another
+	batch, err := splitPredictions([]float32{1, 2, 3, 4, 5, 6}, 3)
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{1, 2}, {3, 4}, {5, 6}}, batch)
+
+	batch, err = splitPredictions([]float32{1, 2, 3}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{1, 2, 3}}, batch)
+
+	_, err = splitPredictions([]float32{1, 2, 3}, 2)
+	assert.Error(t, err)
+	_, err = splitPredictions([]float32{1, 2, 3}, 0)
+	assert.Error(t, err)
+}
@@ -12,9 +12,11 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/tphakala/birdnet-go/internal/conf"
 	"github.com/tphakala/birdnet-go/internal/cpuspec"
+	"github.com/tphakala/birdnet-go/internal/telemetry/metrics"
 	tflite "github.com/tphakala/go-tflite"
 	"github.com/tphakala/go-tflite/delegates/xnnpack"
 )
@@ -33,7 +35,12 @@ var metaModelDataV1 []byte
 var metaModelDataV2 []byte
 
 // Model version string, default is the embedded model version
-var modelVersion = "BirdNET GLOBAL 6K V2.4 FP32"
+var modelVersion = embeddedModel.Name
+
+// ModelVersion returns the version of the loaded model, or the name or path of a custom model
+func ModelVersion() string {
+	return modelVersion
+}
 
 // Embedded labels in zip format.
 //
@@ -42,10 +49,12 @@ var labelsZip []byte
 
 // BirdNET struct represents the BirdNET model with interpreters and configuration.
 type BirdNET struct {
-	AnalysisInterpreter *tflite.Interpreter
+	AnalysisInterpreter *tflite.Interpreter // first interpreter of the analysis pool
 	RangeInterpreter    *tflite.Interpreter
 	Settings            *conf.Settings
-	mu                  sync.Mutex
+	mu                  sync.RWMutex                           // held for reading by predictions and for writing by model reloads
+	pool                *interpreterPool                       // analysis interpreters used for parallel inference
+	metrics             atomic.Pointer[metrics.BirdNETMetrics] // metrics of the interpreter pool, nil if not reported
 }
 
 // NewBirdNET initializes a new BirdNET instance with given settings.
@@ -89,9 +98,97 @@ func (bn *BirdNET) initializeModel() error {
 		return fmt.Errorf("cannot load model")
 	}
 
-	// Determine the number of threads for the interpreter based on settings and system capacity.
-	threads := bn.determineThreadCount(bn.Settings.BirdNET.Threads)
+	// Determine the number of threads for each interpreter based on settings and system capacity
+	numInterpreters := max(1, bn.Settings.BirdNET.Interpreters)
+	threadCounts := bn.interpreterThreadCounts(numInterpreters)
+	threads := threadCounts[0]
+
+	// Create the interpreters of the pool, they share the model
+	interpreters := make([]*tflite.Interpreter, 0, numInterpreters)
+	deleteInterpreters := func() {
+		for _, interpreter := range interpreters {
+			interpreter.Delete()
+		}
+	}
+	for i := 0; i < numInterpreters; i++ {
+		interpreter, err := bn.createInterpreter(model, threadCounts[i])
+		if err != nil {
+			deleteInterpreters()
+			return err
+		}
+		interpreters = append(interpreters, interpreter)
+	}
+
+	// Describe the model input from the metadata sidecar of a custom model
+	inputTensor := interpreters[0].GetInputTensor(0)
+	if inputTensor == nil {
+		deleteInterpreters()
+		return fmt.Errorf("cannot get input tensor")
+	}
+	info, err := loadModelInfo(bn.Settings.BirdNET.ModelPath, inputTensor.Dim(inputTensor.NumDims()-1))
+	if err != nil {
+		deleteInterpreters()
+		return err
+	}
+	if outputs := interpreters[0].GetOutputTensorCount(); info.EmbeddingOutput >= outputs {
+		deleteInterpreters()
+		return fmt.Errorf("model has no embedding output %d, it has %d outputs", info.EmbeddingOutput, outputs)
+	}
+	if bn.Settings.BirdNET.Embeddings && info.EmbeddingOutput == 0 {
+		deleteInterpreters()
+		return fmt.Errorf("embeddings are enabled but %s has no embedding output, declare it in the model sidecar or disable embeddings", info.Name)
+	}
+	bn.AnalysisInterpreter = interpreters[0]
+	bn.pool = newInterpreterPool(interpreters)
+	activeModel.Store(&info)
+	modelVersion = info.Name
+	bn.reportPoolState()
+
+	// Get CPU information for detailed message
+	var initMessage string
+	if bn.Settings.BirdNET.Threads == 0 {
+		spec := cpuspec.GetCPUSpec()
+		if spec.PerformanceCores > 0 {
+			initMessage = fmt.Sprintf("%s model initialized, optimized to use %v threads on %v P-cores (system has %v total CPUs)",
+				modelVersion, threads, spec.PerformanceCores, runtime.NumCPU())
+		} else {
+			initMessage = fmt.Sprintf("%s model initialized, using %v threads of available %v CPUs",
+				modelVersion, threads, runtime.NumCPU())
+		}
+	} else {
+		initMessage = fmt.Sprintf("%s model initialized, using configured %v threads of available %v CPUs",
+			modelVersion, threads, runtime.NumCPU())
+	}
+	if len(bn.Settings.BirdNET.InterpreterThreads) > 0 {
+		initMessage = fmt.Sprintf("%s model initialized, using %v threads for its interpreters of available %v CPUs",
+			modelVersion, threadCounts, runtime.NumCPU())
+	} else if numInterpreters > 1 {
+		initMessage += fmt.Sprintf(" for each of %v interpreters", numInterpreters)
+	}
+	fmt.Println(initMessage)
+	return nil
+}
+
+// interpreterThreadCounts returns the number of threads of each interpreter of the pool. The
+// threads of each interpreter can be configured separately, otherwise all use the same count.
+// Automatic thread counts are shared between the interpreters of the pool.
+func (bn *BirdNET) interpreterThreadCounts(numInterpreters int) []int {
+	counts := make([]int, numInterpreters)
+	for i := range counts {
+		configured := bn.Settings.BirdNET.Threads
+		if i < len(bn.Settings.BirdNET.InterpreterThreads) {
+			configured = bn.Settings.BirdNET.InterpreterThreads[i]
+		}
+		counts[i] = bn.determineThreadCount(configured)
+		if configured == 0 {
+			counts[i] = max(1, counts[i]/numInterpreters)
+		}
+	}
+	return counts
+}
 
+// createInterpreter creates and allocates an analysis interpreter for the model
+func (bn *BirdNET) createInterpreter(model *tflite.Model, threads int) (*tflite.Interpreter, error) {
 	// Configure interpreter options.
 	options := tflite.NewInterpreterOptions()
 
@@ -117,36 +214,15 @@ func (bn *BirdNET) initializeModel() error {
 	}, nil)
 
 	// Create and allocate the TensorFlow Lite interpreter.
-	bn.AnalysisInterpreter = tflite.NewInterpreter(model, options)
-	if bn.AnalysisInterpreter == nil {
-		return fmt.Errorf("cannot create interpreter")
-	}
-	if status := bn.AnalysisInterpreter.AllocateTensors(); status != tflite.OK {
-		return fmt.Errorf("tensor allocation failed")
+	interpreter := tflite.NewInterpreter(model, options)
+	if interpreter == nil {
+		return nil, fmt.Errorf("cannot create interpreter")
 	}
-
-	// Replace model version if custom model is used
-	if bn.Settings.BirdNET.ModelPath != "" {
-		modelVersion = bn.Settings.BirdNET.ModelPath
-	}
-
-	// Get CPU information for detailed message
-	var initMessage string
-	if bn.Settings.BirdNET.Threads == 0 {
-		spec := cpuspec.GetCPUSpec()
-		if spec.PerformanceCores > 0 {
-			initMessage = fmt.Sprintf("%s model initialized, optimized to use %v threads on %v P-cores (system has %v total CPUs)",
-				modelVersion, threads, spec.PerformanceCores, runtime.NumCPU())
-		} else {
-			initMessage = fmt.Sprintf("%s model initialized, using %v threads of available %v CPUs",
-				modelVersion, threads, runtime.NumCPU())
-		}
-	} else {
-		initMessage = fmt.Sprintf("%s model initialized, using configured %v threads of available %v CPUs",
-			modelVersion, threads, runtime.NumCPU())
+	if status := interpreter.AllocateTensors(); status != tflite.OK {
+		interpreter.Delete()
+		return nil, fmt.Errorf("tensor allocation failed")
 	}
-	fmt.Println(initMessage)
-	return nil
+	return interpreter, nil
 }
 
 // getMetaModelData returns the appropriate meta model data based on the settings.
@@ -214,13 +290,19 @@ func (bn *BirdNET) determineThreadCount(configuredThreads int) int {
 func (bn *BirdNET) loadLabels() error {
 	bn.Settings.BirdNET.Labels = []string{} // Reset labels.
 
+	// The label path setting overrides the label file declared by the model
+	labelPath := bn.Settings.BirdNET.LabelPath
+	if labelPath == "" {
+		labelPath = ActiveModel().LabelFile
+	}
+
 	// Use embedded labels if no external label path is set
-	if bn.Settings.BirdNET.LabelPath == "" {
+	if labelPath == "" {
 		return bn.loadEmbeddedLabels()
 	}
 
 	// Otherwise use external labels
-	return bn.loadExternalLabels()
+	return bn.loadExternalLabels(labelPath)
 }
 
 func (bn *BirdNET) loadEmbeddedLabels() error {
@@ -245,8 +327,8 @@ func (bn *BirdNET) loadEmbeddedLabels() error {
 	return fmt.Errorf("label file '%s' not found in the zip archive", labelFileName)
 }
 
-func (bn *BirdNET) loadExternalLabels() error {
-	file, err := os.Open(bn.Settings.BirdNET.LabelPath)
+func (bn *BirdNET) loadExternalLabels(labelPath string) error {
+	file, err := os.Open(labelPath)
 	if err != nil {
 		return fmt.Errorf("failed to open external label file: %w", err)
 	}
@@ -316,8 +398,8 @@ func (bn *BirdNET) readLabelFile(file *zip.File) error {
 
 // Delete releases resources used by the TensorFlow Lite interpreters.
 func (bn *BirdNET) Delete() {
-	if bn.AnalysisInterpreter != nil {
-		bn.AnalysisInterpreter.Delete()
+	if bn.pool != nil {
+		bn.pool.delete()
 	}
 	if bn.RangeInterpreter != nil {
 		bn.RangeInterpreter.Delete()
@@ -368,7 +450,10 @@ func (bn *BirdNET) ReloadModel() error {
 
 	// Store old interpreters to clean up after successful reload
 	oldAnalysisInterpreter := bn.AnalysisInterpreter
+	oldPool := bn.pool
 	oldRangeInterpreter := bn.RangeInterpreter
+	oldModel := activeModel.Load()
+	oldModelVersion := modelVersion
 
 	// Initialize new model
 	if err := bn.initializeModel(); err != nil {
@@ -379,12 +464,14 @@ func (bn *BirdNET) ReloadModel() error {
 	// Initialize new meta model
 	if err := bn.initializeMetaModel(); err != nil {
 		// Clean up the newly created analysis interpreter if meta model fails
-		if bn.AnalysisInterpreter != nil {
-			bn.AnalysisInterpreter.Delete()
-		}
+		bn.pool.delete()
 		// Restore the old interpreters
 		bn.AnalysisInterpreter = oldAnalysisInterpreter
+		bn.pool = oldPool
 		bn.RangeInterpreter = oldRangeInterpreter
+		activeModel.Store(oldModel)
+		modelVersion = oldModelVersion
+		bn.reportPoolState()
 		return fmt.Errorf("\033[31m❌ failed to reload meta model: %w\033[0m", err)
 	}
 	bn.Debug("\033[32m✅ Meta model initialized successfully\033[0m")
@@ -392,15 +479,17 @@ func (bn *BirdNET) ReloadModel() error {
 	// Reload labels
 	if err := bn.loadLabels(); err != nil {
 		// Clean up the newly created interpreters if label loading fails
-		if bn.AnalysisInterpreter != nil {
-			bn.AnalysisInterpreter.Delete()
-		}
+		bn.pool.delete()
 		if bn.RangeInterpreter != nil {
 			bn.RangeInterpreter.Delete()
 		}
 		// Restore the old interpreters
 		bn.AnalysisInterpreter = oldAnalysisInterpreter
+		bn.pool = oldPool
 		bn.RangeInterpreter = oldRangeInterpreter
+		activeModel.Store(oldModel)
+		modelVersion = oldModelVersion
+		bn.reportPoolState()
 		return fmt.Errorf("\033[31m❌ failed to reload labels: %w\033[0m", err)
 	}
 	bn.Debug("\033[32m✅ Labels loaded successfully\033[0m")
@@ -408,21 +497,23 @@ func (bn *BirdNET) ReloadModel() error {
 	// Validate that the model and labels match
 	if err := bn.validateModelAndLabels(); err != nil {
 		// Clean up the newly created interpreters if validation fails
-		if bn.AnalysisInterpreter != nil {
-			bn.AnalysisInterpreter.Delete()
-		}
+		bn.pool.delete()
 		if bn.RangeInterpreter != nil {
 			bn.RangeInterpreter.Delete()
 		}
 		// Restore the old interpreters
 		bn.AnalysisInterpreter = oldAnalysisInterpreter
+		bn.pool = oldPool
 		bn.RangeInterpreter = oldRangeInterpreter
+		activeModel.Store(oldModel)
+		modelVersion = oldModelVersion
+		bn.reportPoolState()
 		return fmt.Errorf("\033[31m❌ model validation failed: %w\033[0m", err)
 	}
 
 	// Clean up old interpreters after successful reload
-	if oldAnalysisInterpreter != nil {
-		oldAnalysisInterpreter.Delete()
+	if oldPool != nil {
+		oldPool.delete()
 	}
 	if oldRangeInterpreter != nil {
 		oldRangeInterpreter.Delete()
@@ -0,0 +1,81 @@
+// interpreter_pool.go manages the analysis interpreters used for parallel inference
+package birdnet
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/telemetry/metrics"
+	tflite "github.com/tphakala/go-tflite"
+)
+
+// interpreterPool holds the analysis interpreters of the model. Each interpreter runs one
+// inference at a time, so audio sources are analyzed in parallel up to the size of the pool.
+type interpreterPool struct {
+	interpreters []*tflite.Interpreter
+	idle         chan *tflite.Interpreter
+	busy         atomic.Int32
+}
+
+// newInterpreterPool creates a pool of the interpreters, all of them are idle
+func newInterpreterPool(interpreters []*tflite.Interpreter) *interpreterPool {
+	p := &interpreterPool{
+		interpreters: interpreters,
+		idle:         make(chan *tflite.Interpreter, len(interpreters)),
+	}
+	for _, interpreter := range interpreters {
+		p.idle <- interpreter
+	}
+	return p
+}
+
+// acquire waits until an interpreter is idle and returns it
+func (p *interpreterPool) acquire() *tflite.Interpreter {
+	interpreter := <-p.idle
+	p.busy.Add(1)
+	return interpreter
+}
+
+// release returns an interpreter taken with acquire to the pool
+func (p *interpreterPool) release(interpreter *tflite.Interpreter) {
+	p.busy.Add(-1)
+	p.idle <- interpreter
+}
+
+// size returns the number of interpreters in the pool
+func (p *interpreterPool) size() int {
+	return len(p.interpreters)
+}
+
+// inUse returns the number of interpreters running inference
+func (p *interpreterPool) inUse() int {
+	return int(p.busy.Load())
+}
+
+// delete releases the resources of all interpreters, the pool must not be used afterwards
+func (p *interpreterPool) delete() {
+	for _, interpreter := range p.interpreters {
+		interpreter.Delete()
+	}
+}
+
+// SetMetrics sets the metrics to which the utilization of the interpreter pool is reported
+func (bn *BirdNET) SetMetrics(m *metrics.BirdNETMetrics) {
+	bn.metrics.Store(m)
+	bn.reportPoolState()
+}
+
+// reportPoolState reports the size of the interpreter pool and the number of busy interpreters
+func (bn *BirdNET) reportPoolState() {
+	if m := bn.metrics.Load(); m != nil && bn.pool != nil {
+		m.SetInterpreterPool(bn.pool.size(), bn.pool.inUse())
+	}
+}
+
+// reportPoolUsage reports the time a prediction waited for an interpreter and the time the
+// interpreter was busy with it
+func (bn *BirdNET) reportPoolUsage(wait, busy time.Duration) {
+	if m := bn.metrics.Load(); m != nil {
+		m.AddInterpreterUsage(wait.Seconds(), busy.Seconds())
+	}
+}
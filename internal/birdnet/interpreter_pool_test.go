@@ -0,0 +1,86 @@
+package birdnet
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/telemetry/metrics"
+	tflite "github.com/tphakala/go-tflite"
+)
+
+func TestInterpreterPool(t *testing.T) {
+	first, second := &tflite.Interpreter{}, &tflite.Interpreter{}
+	pool := newInterpreterPool([]*tflite.Interpreter{first, second})
+	assert.Equal(t, 2, pool.size())
+
+	// Each interpreter is used by one prediction at a time
+	a := pool.acquire()
+	b := pool.acquire()
+	assert.ElementsMatch(t, []*tflite.Interpreter{first, second}, []*tflite.Interpreter{a, b})
+	assert.Equal(t, 2, pool.inUse())
+
+	// Further predictions wait until an interpreter is released
+	acquired := make(chan *tflite.Interpreter)
+	go func() { acquired <- pool.acquire() }()
+	select {
+	case <-acquired:
+		t.Fatal("interpreter acquired while all interpreters are busy")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	pool.release(b)
+	select {
+	case interpreter := <-acquired:
+		assert.Same(t, b, interpreter)
+	case <-time.After(time.Second):
+		t.Fatal("interpreter not acquired after release")
+	}
+	pool.release(a)
+	assert.Equal(t, 1, pool.inUse())
+}
+
+func TestInterpreterPoolMetrics(t *testing.T) {
+	m, err := metrics.NewBirdNETMetrics(prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	bn := &BirdNET{pool: newInterpreterPool([]*tflite.Interpreter{{}, {}, {}})}
+	bn.SetMetrics(m)
+	assert.InDelta(t, 3, testutil.ToFloat64(m.InterpreterPoolSize), 0)
+
+	// Busy interpreters and the time spent waiting for and using them are reported
+	interpreter := bn.pool.acquire()
+	bn.reportPoolState()
+	assert.InDelta(t, 1, testutil.ToFloat64(m.InterpreterPoolBusy), 0)
+
+	bn.pool.release(interpreter)
+	bn.reportPoolUsage(100*time.Millisecond, 2*time.Second)
+	bn.reportPoolState()
+	assert.InDelta(t, 0, testutil.ToFloat64(m.InterpreterPoolBusy), 0)
+	assert.InDelta(t, 0.1, testutil.ToFloat64(m.InterpreterWaitSeconds), 1e-9)
+	assert.InDelta(t, 2, testutil.ToFloat64(m.InterpreterBusySeconds), 1e-9)
+}
+
+func TestInterpreterThreadCounts(t *testing.T) {
+	cpus := runtime.NumCPU()
+	bn := &BirdNET{Settings: &conf.Settings{}}
+
+	// One thread count applies to every interpreter
+	bn.Settings.BirdNET.Threads = 1
+	assert.Equal(t, []int{1, 1, 1}, bn.interpreterThreadCounts(3))
+
+	// Each interpreter has its own thread count, limited to the available CPUs
+	bn.Settings.BirdNET.InterpreterThreads = []int{cpus + 1, 1}
+	assert.Equal(t, []int{cpus, 1}, bn.interpreterThreadCounts(2))
+
+	// Automatic thread counts are shared between the interpreters
+	bn.Settings.BirdNET.InterpreterThreads = []int{0, 1}
+	counts := bn.interpreterThreadCounts(2)
+	assert.Equal(t, max(1, bn.determineThreadCount(0)/2), counts[0])
+	assert.Equal(t, 1, counts[1])
+}
@@ -0,0 +1,107 @@
+// model_info.go describes the audio input of the analysis model
+package birdnet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// ModelInfo describes the audio a classification model analyzes. Custom models declare it in a
+// metadata sidecar, a JSON file next to the model with the same name and a .json extension.
+type ModelInfo struct {
+	Name          string  `json:"name"`          // name of the model shown in logs and the UI
+	SampleRate    int     `json:"sampleRate"`    // sample rate of the model input in Hz
+	WindowSeconds float64 `json:"windowSeconds"` // length of audio analyzed by one inference in seconds
+	LabelFile     string  `json:"labelFile"`     // label file of the model, relative to the sidecar
+	// EmbeddingOutput is the index of the output tensor holding the embeddings of the analyzed
+	// audio, the activations of the layer before the classifier. 0 if the model has no such output.
+	EmbeddingOutput int `json:"embeddingOutput"`
+}
+
+// embeddedModel describes the embedded BirdNET model
+var embeddedModel = ModelInfo{
+	Name:          "BirdNET GLOBAL 6K V2.4 FP32",
+	SampleRate:    48000,
+	WindowSeconds: 3,
+}
+
+// activeModel holds the description of the loaded analysis model
+var activeModel atomic.Pointer[ModelInfo]
+
+func init() {
+	model := embeddedModel
+	activeModel.Store(&model)
+}
+
+// ActiveModel returns the description of the loaded analysis model
+func ActiveModel() ModelInfo {
+	return *activeModel.Load()
+}
+
+// Samples returns the number of samples in an analysis window at the model sample rate
+func (m ModelInfo) Samples() int {
+	return int(math.Round(m.WindowSeconds * float64(m.SampleRate)))
+}
+
+// Step returns the time in seconds between the starts of consecutive analysis windows for the
+// overlap in seconds, the overlap is limited so windows always advance
+func (m ModelInfo) Step(overlap float64) float64 {
+	return m.WindowSeconds - max(0, min(overlap, m.WindowSeconds-0.01))
+}
+
+// MetadataPath returns the path of the metadata sidecar of a model file
+func MetadataPath(modelPath string) string {
+	return strings.TrimSuffix(modelPath, filepath.Ext(modelPath)) + ".json"
+}
+
+// loadModelInfo returns the description of the model at modelPath, or of the embedded model if
+// the path is empty. Values missing from the sidecar, or all of them if the model has no sidecar,
+// default to the capture sample rate and a window matching inputSize, the number of input
+// samples of the model.
+func loadModelInfo(modelPath string, inputSize int) (ModelInfo, error) {
+	if modelPath == "" {
+		return embeddedModel, nil
+	}
+
+	info := ModelInfo{Name: modelPath, SampleRate: conf.SampleRate}
+	metadataPath := MetadataPath(modelPath)
+	data, err := os.ReadFile(metadataPath)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &info); err != nil {
+			return ModelInfo{}, fmt.Errorf("invalid model metadata in %s: %w", metadataPath, err)
+		}
+		if info.LabelFile != "" && !filepath.IsAbs(info.LabelFile) {
+			info.LabelFile = filepath.Join(filepath.Dir(metadataPath), info.LabelFile)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return ModelInfo{}, fmt.Errorf("failed to read model metadata: %w", err)
+	}
+
+	if info.SampleRate <= 0 {
+		return ModelInfo{}, fmt.Errorf("invalid model sample rate: %d Hz", info.SampleRate)
+	}
+	if info.EmbeddingOutput < 0 {
+		return ModelInfo{}, fmt.Errorf("invalid model embedding output: %d", info.EmbeddingOutput)
+	}
+	if info.WindowSeconds < 0 {
+		return ModelInfo{}, fmt.Errorf("invalid model window length: %v seconds", info.WindowSeconds)
+	}
+	if info.WindowSeconds == 0 {
+		info.WindowSeconds = float64(inputSize) / float64(info.SampleRate)
+	}
+	if info.Samples() != inputSize {
+		return ModelInfo{}, fmt.Errorf("model input has %d samples but %v seconds at %d Hz is %d samples",
+			inputSize, info.WindowSeconds, info.SampleRate, info.Samples())
+	}
+
+	return info, nil
+}
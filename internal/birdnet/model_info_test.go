@@ -0,0 +1,72 @@
+package birdnet
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadModelInfo(t *testing.T) {
+	dir := t.TempDir()
+	modelPath := filepath.Join(dir, "perch.tflite")
+	require.NoError(t, os.WriteFile(MetadataPath(modelPath),
+		[]byte(`{"name": "Perch", "sampleRate": 32000, "windowSeconds": 5, "labelFile": "labels/perch.txt"}`), 0o644))
+
+	// The sidecar describes the model and its label file relative to the sidecar
+	info, err := loadModelInfo(modelPath, 160000)
+	require.NoError(t, err)
+	assert.Equal(t, ModelInfo{
+		Name:          "Perch",
+		SampleRate:    32000,
+		WindowSeconds: 5,
+		LabelFile:     filepath.Join(dir, "labels", "perch.txt"),
+	}, info)
+
+	// The input of the model must match the window described by the sidecar
+	_, err = loadModelInfo(modelPath, 144000)
+	assert.Error(t, err)
+}
+
+func TestLoadModelInfoDefaults(t *testing.T) {
+	// The embedded model is used without a model path
+	info, err := loadModelInfo("", 0)
+	require.NoError(t, err)
+	assert.Equal(t, embeddedModel, info)
+
+	// A custom classifier without a sidecar analyzes its input at the capture sample rate
+	modelPath := filepath.Join(t.TempDir(), "custom_classifier.tflite")
+	info, err = loadModelInfo(modelPath, 144000)
+	require.NoError(t, err)
+	assert.Equal(t, ModelInfo{Name: modelPath, SampleRate: 48000, WindowSeconds: 3}, info)
+
+	// Missing values of the sidecar are derived from the model input
+	require.NoError(t, os.WriteFile(MetadataPath(modelPath), []byte(`{"sampleRate": 16000}`), 0o644))
+	info, err = loadModelInfo(modelPath, 15600)
+	require.NoError(t, err)
+	assert.Equal(t, 16000, info.SampleRate)
+	assert.InDelta(t, 0.975, info.WindowSeconds, 1e-9)
+	assert.Equal(t, 15600, info.Samples())
+}
+
+func TestLoadModelInfoErrors(t *testing.T) {
+	modelPath := filepath.Join(t.TempDir(), "model.tflite")
+
+	for _, metadata := range []string{`{"sampleRate": 0}`, `{"windowSeconds": -1}`, `{"embeddingOutput": -1}`, `not json`} {
+		require.NoError(t, os.WriteFile(MetadataPath(modelPath), []byte(metadata), 0o644))
+		_, err := loadModelInfo(modelPath, 144000)
+		assert.Error(t, err, metadata)
+	}
+}
+
+func TestModelInfoStep(t *testing.T) {
+	model := ModelInfo{SampleRate: 48000, WindowSeconds: 3}
+	assert.InDelta(t, 1.5, model.Step(1.5), 1e-9)
+	assert.InDelta(t, 3, model.Step(0), 1e-9)
+
+	// Overlap is limited to the window of short models so windows always advance
+	short := ModelInfo{SampleRate: 16000, WindowSeconds: 1}
+	assert.InDelta(t, 0.01, short.Step(2.5), 1e-9)
+}
@@ -0,0 +1,312 @@
+package conf
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Audio source types supported in the realtime source list
+const (
+	SourceTypeSoundCard = "soundcard" // local capture device through miniaudio
+	SourceTypeRTSP      = "rtsp"      // RTSP stream captured with FFmpeg
+	SourceTypeStream    = "stream"    // generic network stream captured with FFmpeg
+	SourceTypeIngest    = "ingest"    // raw PCM pushed to the ingest server, registered while connected
+	SourceTypeReplay    = "replay"    // audio file played back at realtime or accelerated pace
+)
+
+// MaxReplaySpeed is the highest playback speed of replay sources, faster playback would let
+// the capture buffer wrap before audio clips of detections are saved
+const MaxReplaySpeed = 20
+
+// Network stream protocols supported by stream sources
+const (
+	StreamProtocolRTSP = "rtsp"
+	StreamProtocolHTTP = "http" // HTTP(S) streams such as Icecast and Shoutcast
+	StreamProtocolRTMP = "rtmp"
+	StreamProtocolSRT  = "srt"
+	StreamProtocolUDP  = "udp" // UDP unicast or multicast, e.g. MPEG-TS
+)
+
+// streamProtocolSchemes maps URL schemes to stream protocols
+var streamProtocolSchemes = map[string]string{
+	"rtsp":  StreamProtocolRTSP,
+	"rtsps": StreamProtocolRTSP,
+	"http":  StreamProtocolHTTP,
+	"https": StreamProtocolHTTP,
+	"icy":   StreamProtocolHTTP,
+	"rtmp":  StreamProtocolRTMP,
+	"rtmps": StreamProtocolRTMP,
+	"srt":   StreamProtocolSRT,
+	"udp":   StreamProtocolUDP,
+	"rtp":   StreamProtocolUDP,
+}
+
+// Default reconnect policy of stream sources
+const (
+	DefaultStreamMaxAttempts  = 5   // failed start attempts before giving up
+	DefaultStreamInitialDelay = 5   // seconds before the first retry
+	DefaultStreamMaxDelay     = 120 // maximum seconds between retries
+	DefaultStreamTimeout      = 60  // seconds without data before the stream is restarted
+)
+
+// StreamReconnectSettings defines how a stream source is restarted when it fails,
+// zero values use the defaults
+type StreamReconnectSettings struct {
+	MaxAttempts  *int // number of failed start attempts before giving up, 0 retries forever, nil uses the default
+	InitialDelay int  // delay in seconds before the first retry, doubled on every attempt
+	MaxDelay     int  // maximum delay in seconds between retries
+	Timeout      int  // seconds without audio data before the stream is restarted
+}
+
+// ReplaySettings defines the file and pace of a replay source
+type ReplaySettings struct {
+	Path      string  // WAV or FLAC file to play
+	Speed     float64 // playback speed relative to realtime, defaults to 1
+	StartTime string  // RFC 3339 time of the start of the file, defaults to the time playback starts
+	Loop      bool    // true to restart playback at the end of the file
+}
+
+// ParsedStartTime returns the configured start time, or the zero time if it is not set
+func (r *ReplaySettings) ParsedStartTime() (time.Time, error) {
+	if r.StartTime == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, r.StartTime)
+}
+
+// LegacySoundCardSourceID is the source ID of the sound card configured with realtime.audio.source
+const LegacySoundCardSourceID = "malgo"
+
+// AudioSourceConfig defines a single entry of the unified realtime audio source list
+type AudioSourceConfig struct {
+	ID           string                  // unique source identifier, used as buffer key and detection source
+	Name         string                  // friendly display name
+	Type         string                  // source type: soundcard, rtsp, stream or replay
+	Device       string                  // capture device name or ID for soundcard sources
+	URL          string                  // stream URL for rtsp and stream sources
+	Protocol     string                  // stream protocol: rtsp, http, rtmp, srt or udp, detected from the URL if empty
+	Transport    string                  // RTSP transport protocol, defaults to realtime.rtsp.transport
+	InputOptions []string                // additional FFmpeg input options, e.g. ["-analyzeduration", "1000000"]
+	Reconnect    StreamReconnectSettings // reconnect policy for stream sources
+	Replay       ReplaySettings          // file and pace of replay sources
+	Filters      *AudioFilterSettings    // filter chain of the source, nil to use the global equalizer, noise reduction and AGC
+	Disabled     bool                    // true to keep the source configured but not capture from it
+}
+
+// MaxSourceGain is the highest gain in dB which can be applied to a source by its filter chain
+const MaxSourceGain = 40
+
+// AudioFilterSettings defines the filter chain of a single audio source
+type AudioFilterSettings struct {
+	Gain           float64                // gain in dB applied before the equalizer
+	Equalizer      EqualizerSettings      // equalizer filters of the source
+	NoiseReduction NoiseReductionSettings // noise reduction of analyzed audio and exported clips
+	AGC            AGCSettings            // automatic gain control applied after the equalizer
+}
+
+// IsStream reports whether the source is a network stream captured with FFmpeg
+func (s *AudioSourceConfig) IsStream() bool {
+	return s.Type == SourceTypeRTSP || s.Type == SourceTypeStream
+}
+
+// StreamProtocol returns the configured stream protocol or the protocol matching the URL scheme
+func (s *AudioSourceConfig) StreamProtocol() string {
+	if s.Type == SourceTypeRTSP {
+		return StreamProtocolRTSP
+	}
+	if s.Protocol != "" {
+		return strings.ToLower(s.Protocol)
+	}
+	scheme, _, found := strings.Cut(s.URL, "://")
+	if !found {
+		return ""
+	}
+	return streamProtocolSchemes[strings.ToLower(scheme)]
+}
+
+// DisplayName returns the friendly name of the source, or the device name or sanitized URL
+func (s *AudioSourceConfig) DisplayName() string {
+	switch {
+	case s.Name != "":
+		return s.Name
+	case s.Type == SourceTypeSoundCard:
+		return s.Device
+	case s.Type == SourceTypeReplay:
+		return filepath.Base(s.Replay.Path)
+	default:
+		return SanitizeStreamUrl(s.URL)
+	}
+}
+
+// SourceID returns the configured ID of the source, or an ID derived from the device or URL
+func (s *AudioSourceConfig) SourceID() string {
+	if s.ID != "" {
+		return s.ID
+	}
+	switch s.Type {
+	case SourceTypeSoundCard:
+		return SourceTypeSoundCard + ":" + s.Device
+	case SourceTypeReplay:
+		return SourceTypeReplay + ":" + s.Replay.Path
+	default:
+		return s.URL
+	}
+}
+
+// Attempts returns the number of failed start attempts before the stream is given up, 0 if
+// the stream is retried forever
+func (r StreamReconnectSettings) Attempts() int {
+	if r.MaxAttempts == nil {
+		return DefaultStreamMaxAttempts
+	}
+	return *r.MaxAttempts
+}
+
+// withDefaults returns the reconnect settings with zero values replaced by defaults. MaxAttempts
+// is left as is because zero retries forever, see Attempts.
+func (r StreamReconnectSettings) withDefaults() StreamReconnectSettings {
+	if r.InitialDelay == 0 {
+		r.InitialDelay = DefaultStreamInitialDelay
+	}
+	if r.MaxDelay == 0 {
+		r.MaxDelay = DefaultStreamMaxDelay
+	}
+	if r.Timeout == 0 {
+		r.Timeout = DefaultStreamTimeout
+	}
+	return r
+}
+
+// AudioSources returns the unified list of enabled audio sources. Entries of the source list
+// are combined with the sound card configured in realtime.audio.source and the streams in
+// realtime.rtsp.urls, which keep their historical IDs "malgo" and the stream URL.
+func (s *RealtimeSettings) AudioSources() []AudioSourceConfig {
+	var sources []AudioSourceConfig
+	seen := make(map[string]bool)
+	seenURLs := make(map[string]bool)
+
+	add := func(source AudioSourceConfig) {
+		source.ID = source.SourceID()
+		if source.Disabled || source.ID == "" || seen[source.ID] {
+			return
+		}
+		if source.IsStream() {
+			// A stream can only be captured once, the first entry for a URL wins
+			if seenURLs[source.URL] {
+				return
+			}
+			seenURLs[source.URL] = true
+			if source.Transport == "" && source.StreamProtocol() == StreamProtocolRTSP {
+				source.Transport = s.RTSP.Transport
+			}
+			source.Reconnect = source.Reconnect.withDefaults()
+		}
+		seen[source.ID] = true
+		sources = append(sources, source)
+	}
+
+	if s.Audio.Source != "" {
+		add(AudioSourceConfig{ID: LegacySoundCardSourceID, Type: SourceTypeSoundCard, Device: s.Audio.Source})
+	}
+	for _, url := range s.RTSP.URLs {
+		add(AudioSourceConfig{ID: url, Type: SourceTypeRTSP, URL: url})
+	}
+	for i := range s.Sources {
+		add(s.Sources[i])
+	}
+
+	return sources
+}
+
+// SourceFilters returns the filter chain settings of the audio source with the given ID. Sources
+// without their own filter settings, including the legacy sources and live ingest sources, use
+// the global equalizer, noise reduction and automatic gain control settings.
+func (s *RealtimeSettings) SourceFilters(sourceID string) AudioFilterSettings {
+	for i := range s.Sources {
+		if s.Sources[i].SourceID() == sourceID && !s.Sources[i].Disabled && s.Sources[i].Filters != nil {
+			return *s.Sources[i].Filters
+		}
+	}
+	return AudioFilterSettings{Equalizer: s.Audio.Equalizer, NoiseReduction: s.Audio.NoiseReduction, AGC: s.Audio.AGC}
+}
+
+// ValidateAudioSources validates the entries of the unified audio source list
+func ValidateAudioSources(sources []AudioSourceConfig) error {
+	ids := make(map[string]bool)
+	for i := range sources {
+		source := &sources[i]
+		switch source.Type {
+		case SourceTypeSoundCard:
+			if source.Device == "" {
+				return fmt.Errorf("audio source %d: device is required for soundcard sources", i+1)
+			}
+		case SourceTypeRTSP, SourceTypeStream:
+			if source.URL == "" {
+				return fmt.Errorf("audio source %d: url is required for %s sources", i+1, source.Type)
+			}
+			switch source.StreamProtocol() {
+			case StreamProtocolRTSP, StreamProtocolHTTP, StreamProtocolRTMP, StreamProtocolSRT, StreamProtocolUDP:
+			case "":
+				return fmt.Errorf("audio source %d: cannot detect stream protocol of %s, set protocol explicitly", i+1, SanitizeStreamUrl(source.URL))
+			default:
+				return fmt.Errorf("audio source %d: unsupported stream protocol %q", i+1, source.Protocol)
+			}
+			if source.Transport != "" && source.Transport != "tcp" && source.Transport != "udp" {
+				return fmt.Errorf("audio source %d: invalid RTSP transport %q, must be tcp or udp", i+1, source.Transport)
+			}
+			if r := source.Reconnect; r.Attempts() < 0 || r.InitialDelay < 0 || r.MaxDelay < 0 || r.Timeout < 0 {
+				return fmt.Errorf("audio source %d: reconnect settings must not be negative", i+1)
+			}
+		case SourceTypeReplay:
+			if source.Replay.Path == "" {
+				return fmt.Errorf("audio source %d: replay path is required for replay sources", i+1)
+			}
+			if source.Replay.Speed < 0 || source.Replay.Speed > MaxReplaySpeed {
+				return fmt.Errorf("audio source %d: replay speed must be between 0 and %d", i+1, MaxReplaySpeed)
+			}
+			if _, err := source.Replay.ParsedStartTime(); err != nil {
+				return fmt.Errorf("audio source %d: invalid replay start time, use RFC 3339 format: %w", i+1, err)
+			}
+		default:
+			return fmt.Errorf("audio source %d: unsupported source type %q", i+1, source.Type)
+		}
+
+		if source.Filters != nil {
+			if err := validateAudioFilterSettings(source.Filters); err != nil {
+				return fmt.Errorf("audio source %d: %w", i+1, err)
+			}
+		}
+
+		id := source.SourceID()
+		if ids[id] {
+			return fmt.Errorf("audio source %d: duplicate source ID %q", i+1, id)
+		}
+		ids[id] = true
+	}
+	return nil
+}
+
+// validateAudioFilterSettings validates the filter chain settings of a source
+func validateAudioFilterSettings(filters *AudioFilterSettings) error {
+	if filters.Gain < -MaxSourceGain || filters.Gain > MaxSourceGain {
+		return fmt.Errorf("filter gain must be between -%d and %d dB", MaxSourceGain, MaxSourceGain)
+	}
+	if filters.Equalizer.Enabled {
+		for i, filter := range filters.Equalizer.Filters {
+			switch filter.Type {
+			case "LowPass", "HighPass", "AllPass", "BandPass", "BandReject", "LowShelf", "HighShelf", "Peaking":
+			default:
+				return fmt.Errorf("equalizer filter %d: unknown filter type %q", i+1, filter.Type)
+			}
+			if filter.Frequency <= 0 || filter.Frequency >= float64(SampleRate)/2 {
+				return fmt.Errorf("equalizer filter %d: frequency must be between 0 and %d Hz", i+1, SampleRate/2)
+			}
+		}
+	}
+	if err := validateNoiseReductionSettings(&filters.NoiseReduction); err != nil {
+		return err
+	}
+	return validateAGCSettings(&filters.AGC)
+}
@@ -37,6 +37,27 @@ type EqualizerSettings struct {
 	Filters []EqualizerFilter // equalizer filter configuration
 }
 
+// NoiseReductionSettings contains settings for the adaptive spectral noise reduction filter
+type NoiseReductionSettings struct {
+	Analysis   bool    // apply noise reduction to audio before analysis
+	Export     bool    // apply noise reduction to exported audio clips
+	Method     string  // "spectral" for spectral subtraction, "gate" for a spectral noise gate
+	Reduction  float64 // maximum noise attenuation in dB
+	Threshold  float64 // level above the noise profile in dB up to which audio is treated as noise
+	Adaptation float64 // time in seconds in which the noise profile adapts to changing background noise
+}
+
+// AGCSettings contains settings for the automatic gain control of captured audio
+type AGCSettings struct {
+	Enabled        bool    // true to normalize the level of captured audio before analysis
+	TargetMin      float64 // lower bound of the target RMS level range in dBFS
+	TargetMax      float64 // upper bound of the target RMS level range in dBFS
+	MaxGain        float64 // maximum amplification in dB
+	MaxAttenuation float64 // maximum attenuation in dB
+	Attack         float64 // time constant in seconds with which gain is reduced when audio is too loud
+	Release        float64 // time constant in seconds with which gain is raised when audio is too quiet
+}
+
 // AudioSettings contains settings for audio processing and export.
 type AudioSettings struct {
 	Source        string   // audio source to use for analysis
@@ -44,11 +65,12 @@ type AudioSettings struct {
 	SoxPath       string   // path to sox, runtime value
 	SoxAudioTypes []string `yaml:"-"` // supported audio types of sox, runtime value
 	Export        struct {
-		Debug     bool   // true to enable audio export debug
-		Enabled   bool   // export audio clips containing indentified bird calls
-		Path      string // path to audio clip export directory
-		Type      string // audio file type, wav, mp3 or flac
-		Bitrate   string // bitrate for audio export
+		Debug     bool         // true to enable audio export debug
+		Enabled   bool         // export audio clips containing indentified bird calls
+		Path      string       // path to audio clip export directory
+		Type      string       // audio file type, wav, mp3 or flac
+		Bitrate   string       // bitrate for audio export
+		Clip      ClipSettings // audio clip length settings
 		Retention struct {
 			Debug    bool   // true to enable retention debug
 			Policy   string // retention policy, "none", "age" or "usage"
@@ -57,18 +79,100 @@ type AudioSettings struct {
 			MinClips int    // minimum number of clips per species to keep
 		}
 	}
-	Equalizer EqualizerSettings // equalizer settings
+	Archive   ArchiveSettings     // continuous archive recording settings
+	Listen    ListenSettings      // live listen settings
+	Health    AudioHealthSettings // audio source health monitoring settings
+	Equalizer EqualizerSettings   // equalizer settings
+
+	NoiseReduction NoiseReductionSettings // noise reduction settings
+	AGC            AGCSettings            // automatic gain control settings
+}
+
+// ClipSettings contains settings for the length of exported audio clips
+type ClipSettings struct {
+	Mode      string // "fixed" for clips of fixed length, "event" for clips covering the whole detection event
+	Length    int    // clip length in seconds in fixed mode
+	PreRoll   int    // seconds of audio before the first detection
+	PostRoll  int    // seconds of audio after the last detection in event mode
+	MaxLength int    // maximum clip length in seconds in event mode
+}
+
+// MaxClipLength returns the longest clip in seconds which can be exported with the clip settings
+func (c *ClipSettings) MaxClipLength() int {
+	if c.Mode == "event" {
+		return c.MaxLength
+	}
+	return c.Length
+}
+
+// ArchiveSettings contains settings for continuous recording of all audio sources into
+// fixed-length segment files
+type ArchiveSettings struct {
+	Enabled       bool   // true to record all audio sources continuously
+	Path          string // path to the archive directory
+	Type          string // segment file type, wav, flac or opus
+	Bitrate       string // bitrate of opus segments
+	SegmentLength int    // length of each segment in seconds
+	Retention     struct {
+		Policy   string // retention policy, "none", "age" or "usage"
+		MaxAge   string // maximum age of segments to keep
+		MaxUsage string // maximum disk usage percentage before cleanup
+	}
+}
+
+// ListenSettings contains settings for listening to the live audio of sources in the browser
+type ListenSettings struct {
+	Enabled      bool   // true to enable the live audio stream endpoint
+	MaxListeners int    // maximum number of concurrent listeners of all sources
+	Bitrate      string // MP3 bitrate of the live audio stream
+}
+
+// AudioHealthSettings contains the thresholds of the audio source health monitoring
+type AudioHealthSettings struct {
+	Enabled          bool    // true to monitor the health of audio sources
+	SilenceThreshold float64 // level in dBFS below which a source is considered silent
+	SilenceDuration  int     // seconds of continuous silence before a source is reported silent
+	ClippingRatio    float64 // ratio of clipped samples above which a source is reported clipping
+	DCOffset         float64 // DC offset relative to full scale above which a source is reported
+	MinSampleRate    float64 // minimum ratio of received to expected samples before dropouts are reported
+	MaxRestarts      int     // FFmpeg restarts per hour above which a stream is reported unstable
 }
+
 type Thumbnails struct {
 	Debug   bool // true to enable debug mode
 	Summary bool // show thumbnails on summary table
 	Recent  bool // show thumbnails on recent table
 }
 
+// SpectrogramSettings contains settings for rendering spectrograms of audio clips.
+type SpectrogramSettings struct {
+	Format       string  // image format, png or webp
+	MinFreq      float64 // lowest frequency shown in Hz
+	MaxFreq      float64 // highest frequency shown in Hz
+	ColorMap     string  // color map, inferno, viridis or grayscale
+	DynamicRange float64 // range of levels shown in dB
+	Normalize    bool    // true to scale levels to the loudest point of the clip, false to scale to 0 dBFS
+	Detection    bool    // true to mark the detection window of the clip
+	TimeAxis     bool    // true to draw a time axis
+	PreRender    bool    // true to render spectrograms in the background when clips are saved
+	Widths       []int   // image widths in pixels rendered in the background
+}
+
+// LiveSpectrumSettings contains settings for the live spectrum stream of the audio sources.
+type LiveSpectrumSettings struct {
+	Enabled bool    // true to enable the live spectrum stream
+	Rate    int     // spectrum frames per second and source
+	Bands   int     // number of frequency bands per frame
+	FFTSize int     // FFT window size in samples, must be a power of two
+	MaxFreq float64 // highest frequency of the bands in Hz
+}
+
 // Dashboard contains settings for the web dashboard.
 type Dashboard struct {
-	Thumbnails   Thumbnails // thumbnails settings
-	SummaryLimit int        // limit for the number of species shown in the summary table
+	Thumbnails   Thumbnails           // thumbnails settings
+	SummaryLimit int                  // limit for the number of species shown in the summary table
+	Spectrogram  SpectrogramSettings  // spectrogram rendering settings
+	LiveSpectrum LiveSpectrumSettings // live spectrum stream settings
 }
 
 // DynamicThresholdSettings contains settings for dynamic threshold adjustment.
@@ -143,6 +247,16 @@ type TelemetrySettings struct {
 	Listen  string // IP address and port to listen on
 }
 
+// IngestSettings contains settings for the raw PCM network ingest server, which accepts
+// audio pushed by network microphones over TCP or WebSocket
+type IngestSettings struct {
+	Enabled         bool   // true to enable the ingest server
+	Listen          string // IP address and port for raw TCP streams, empty to disable
+	WebSocketListen string // IP address and port for WebSocket streams, empty to disable
+	Token           string // shared secret clients must send in the stream header, also accepted as bearer token by the upload API
+	MaxConnections  int    // maximum number of concurrent ingest streams
+}
+
 // RealtimeSettings contains all settings related to realtime processing.
 type RealtimeSettings struct {
 	Interval         int                      // minimum interval between log messages in seconds
@@ -159,6 +273,8 @@ type RealtimeSettings struct {
 	PrivacyFilter PrivacyFilterSettings // Privacy filter settings
 	DogBarkFilter DogBarkFilterSettings // Dog bark filter settings
 	RTSP          RTSPSettings          // RTSP settings
+	Sources       []AudioSourceConfig   // unified list of audio capture sources
+	Ingest        IngestSettings        // Raw PCM network ingest settings
 	MQTT          MQTTSettings          // MQTT settings
 	Telemetry     TelemetrySettings     // Telemetry settings
 	Species       SpeciesSettings       // Custom thresholds and actions for species
@@ -193,25 +309,30 @@ type ActionConfig struct {
 
 // InputConfig holds settings for file or directory analysis
 type InputConfig struct {
-	Path      string `yaml:"-"` // path to input file or directory
-	Recursive bool   `yaml:"-"` // true for recursive directory analysis
-	Watch     bool   `yaml:"-"` // true to watch directory for new files
+	Path          string `yaml:"-"` // path to input file or directory
+	Recursive     bool   `yaml:"-"` // true for recursive directory analysis
+	Watch         bool   `yaml:"-"` // true to watch directory for new files
+	SplitChannels bool   `yaml:"-"` // true to analyze each channel of multi-channel files separately
 }
 
 type BirdNETConfig struct {
-	Debug       bool                // true to enable debug mode
-	Sensitivity float64             // birdnet analysis sigmoid sensitivity
-	Threshold   float64             // threshold for prediction confidence to report
-	Overlap     float64             // birdnet analysis overlap between chunks
-	Longitude   float64             // longitude of recording location for prediction filtering
-	Latitude    float64             // latitude of recording location for prediction filtering
-	Threads     int                 // number of CPU threads to use for analysis
-	Locale      string              // language to use for labels
-	RangeFilter RangeFilterSettings // range filter settings
-	ModelPath   string              // path to external model file (empty for embedded)
-	LabelPath   string              // path to external label file (empty for embedded)
-	Labels      []string            `yaml:"-"` // list of available species labels, runtime value
-	UseXNNPACK  bool                // true to use XNNPACK delegate for inference acceleration
+	Debug              bool                // true to enable debug mode
+	Sensitivity        float64             // birdnet analysis sigmoid sensitivity
+	Threshold          float64             // threshold for prediction confidence to report
+	Overlap            float64             // birdnet analysis overlap between chunks
+	Longitude          float64             // longitude of recording location for prediction filtering
+	Latitude           float64             // latitude of recording location for prediction filtering
+	Threads            int                 // number of CPU threads to use for each analysis interpreter
+	Interpreters       int                 // number of analysis interpreters for parallel inference
+	InterpreterThreads []int               // CPU threads of each analysis interpreter, overrides Threads when set
+	BatchSize          int                 // number of chunks analyzed per inference in file and directory analysis
+	Locale             string              // language to use for labels
+	RangeFilter        RangeFilterSettings // range filter settings
+	ModelPath          string              // path to external model file (empty for embedded)
+	LabelPath          string              // path to external label file (empty for embedded)
+	Labels             []string            `yaml:"-"` // list of available species labels, runtime value
+	UseXNNPACK         bool                // true to use XNNPACK delegate for inference acceleration
+	Embeddings         bool                // true to store embeddings of detections for similarity search
 }
 
 // RangeFilterSettings contains settings for the range filter
@@ -26,12 +26,15 @@ func setDefaultConfig() {
 	viper.SetDefault("birdnet.threshold", 0.8)
 	viper.SetDefault("birdnet.overlap", 0.0)
 	viper.SetDefault("birdnet.threads", 0)
+	viper.SetDefault("birdnet.interpreters", 1)
+	viper.SetDefault("birdnet.batchsize", 8)
 	viper.SetDefault("birdnet.locale", "en")
 	viper.SetDefault("birdnet.latitude", 0.000)
 	viper.SetDefault("birdnet.longitude", 0.000)
 	viper.SetDefault("birdnet.modelpath", "")
 	viper.SetDefault("birdnet.labelpath", "")
 	viper.SetDefault("birdnet.usexnnpack", true)
+	viper.SetDefault("birdnet.embeddings", false)
 
 	// Range filter configuration
 	viper.SetDefault("birdnet.rangefilter.debug", false)
@@ -51,6 +54,52 @@ func setDefaultConfig() {
 	viper.SetDefault("realtime.audio.export.path", "clips/")
 	viper.SetDefault("realtime.audio.export.type", "wav")
 	viper.SetDefault("realtime.audio.export.bitrate", "128k")
+	viper.SetDefault("realtime.audio.export.clip.mode", "fixed")
+	viper.SetDefault("realtime.audio.export.clip.length", 15)
+	viper.SetDefault("realtime.audio.export.clip.preroll", 0)
+	viper.SetDefault("realtime.audio.export.clip.postroll", 3)
+	viper.SetDefault("realtime.audio.export.clip.maxlength", 60)
+
+	// Set default values for continuous archive recording
+	viper.SetDefault("realtime.audio.archive.enabled", false)
+	viper.SetDefault("realtime.audio.archive.path", "archive/")
+	viper.SetDefault("realtime.audio.archive.type", "flac")
+	viper.SetDefault("realtime.audio.archive.bitrate", "64k")
+	viper.SetDefault("realtime.audio.archive.segmentlength", 300)
+	viper.SetDefault("realtime.audio.archive.retention.policy", "age")
+	viper.SetDefault("realtime.audio.archive.retention.maxage", "7d")
+	viper.SetDefault("realtime.audio.archive.retention.maxusage", "80%")
+
+	// Set default values for live listening
+	viper.SetDefault("realtime.audio.listen.enabled", false)
+	viper.SetDefault("realtime.audio.listen.maxlisteners", 3)
+	viper.SetDefault("realtime.audio.listen.bitrate", "96k")
+
+	// Set default values for audio source health monitoring
+	viper.SetDefault("realtime.audio.health.enabled", true)
+	viper.SetDefault("realtime.audio.health.silencethreshold", -80.0)
+	viper.SetDefault("realtime.audio.health.silenceduration", 300)
+	viper.SetDefault("realtime.audio.health.clippingratio", 0.01)
+	viper.SetDefault("realtime.audio.health.dcoffset", 0.1)
+	viper.SetDefault("realtime.audio.health.minsamplerate", 0.95)
+	viper.SetDefault("realtime.audio.health.maxrestarts", 5)
+
+	// Noise reduction configuration
+	viper.SetDefault("realtime.audio.noisereduction.analysis", false)
+	viper.SetDefault("realtime.audio.noisereduction.export", false)
+	viper.SetDefault("realtime.audio.noisereduction.method", "spectral")
+	viper.SetDefault("realtime.audio.noisereduction.reduction", 12.0)
+	viper.SetDefault("realtime.audio.noisereduction.threshold", 6.0)
+	viper.SetDefault("realtime.audio.noisereduction.adaptation", 5.0)
+
+	// Automatic gain control configuration
+	viper.SetDefault("realtime.audio.agc.enabled", false)
+	viper.SetDefault("realtime.audio.agc.targetmin", -35.0)
+	viper.SetDefault("realtime.audio.agc.targetmax", -25.0)
+	viper.SetDefault("realtime.audio.agc.maxgain", 30.0)
+	viper.SetDefault("realtime.audio.agc.maxattenuation", 20.0)
+	viper.SetDefault("realtime.audio.agc.attack", 2.0)
+	viper.SetDefault("realtime.audio.agc.release", 10.0)
 
 	// Audio equalizer configuration
 	viper.SetDefault("realtime.audio.equalizer.enabled", false)
@@ -74,6 +123,21 @@ func setDefaultConfig() {
 	viper.SetDefault("realtime.dashboard.thumbnails.summary", false)
 	viper.SetDefault("realtime.dashboard.thumbnails.recent", true)
 	viper.SetDefault("realtime.dashboard.summarylimit", 30)
+	viper.SetDefault("realtime.dashboard.spectrogram.format", "png")
+	viper.SetDefault("realtime.dashboard.spectrogram.minfreq", 0)
+	viper.SetDefault("realtime.dashboard.spectrogram.maxfreq", 12000)
+	viper.SetDefault("realtime.dashboard.spectrogram.colormap", "inferno")
+	viper.SetDefault("realtime.dashboard.spectrogram.dynamicrange", 100)
+	viper.SetDefault("realtime.dashboard.spectrogram.normalize", true)
+	viper.SetDefault("realtime.dashboard.spectrogram.detection", true)
+	viper.SetDefault("realtime.dashboard.spectrogram.timeaxis", true)
+	viper.SetDefault("realtime.dashboard.spectrogram.prerender", true)
+	viper.SetDefault("realtime.dashboard.spectrogram.widths", []int{400})
+	viper.SetDefault("realtime.dashboard.livespectrum.enabled", true)
+	viper.SetDefault("realtime.dashboard.livespectrum.rate", 10)
+	viper.SetDefault("realtime.dashboard.livespectrum.bands", 128)
+	viper.SetDefault("realtime.dashboard.livespectrum.fftsize", 1024)
+	viper.SetDefault("realtime.dashboard.livespectrum.maxfreq", 12000)
 
 	// Retention policy configuration
 	viper.SetDefault("realtime.audio.export.retention.enabled", true)
@@ -127,6 +191,16 @@ func setDefaultConfig() {
 	viper.SetDefault("realtime.rtsp.urls", []string{})
 	viper.SetDefault("realtime.rtsp.transport", "tcp")
 
+	// Unified audio source list
+	viper.SetDefault("realtime.sources", []map[string]interface{}{})
+
+	// Raw PCM ingest configuration
+	viper.SetDefault("realtime.ingest.enabled", false)
+	viper.SetDefault("realtime.ingest.listen", "0.0.0.0:8091")
+	viper.SetDefault("realtime.ingest.websocketlisten", "0.0.0.0:8092")
+	viper.SetDefault("realtime.ingest.token", "")
+	viper.SetDefault("realtime.ingest.maxconnections", 10)
+
 	// MQTT configuration
 	viper.SetDefault("realtime.mqtt.enabled", false)
 	viper.SetDefault("realtime.mqtt.broker", "tcp://localhost:1883")
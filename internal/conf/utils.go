@@ -437,3 +437,24 @@ func SanitizeRTSPUrl(url string) string {
 
 	return url
 }
+
+// SanitizeStreamUrl removes credentials, path and query from a stream URL of any protocol
+// and returns a display-friendly version
+func SanitizeStreamUrl(url string) string {
+	scheme, rest, found := strings.Cut(url, "://")
+	if !found {
+		return url
+	}
+
+	// Drop path and query after the host:port
+	if i := strings.IndexAny(rest, "/?"); i > -1 {
+		rest = rest[:i]
+	}
+
+	// Drop credentials before the host
+	if i := strings.LastIndex(rest, "@"); i > -1 {
+		rest = rest[i+1:]
+	}
+
+	return scheme + "://" + rest
+}
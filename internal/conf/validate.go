@@ -107,6 +107,34 @@ func validateBirdNETSettings(settings *BirdNETConfig) error {
 		errs = append(errs, "BirdNET threads must be at least 0")
 	}
 
+	// Check if the number of interpreters is within a sensible range
+	if settings.Interpreters < 1 || settings.Interpreters > 32 {
+		errs = append(errs, "BirdNET interpreters must be between 1 and 32")
+	}
+
+	// Per-interpreter thread counts must match the interpreters, 0 shares the available threads
+	if len(settings.InterpreterThreads) > 0 {
+		if len(settings.InterpreterThreads) != settings.Interpreters {
+			errs = append(errs, fmt.Sprintf("BirdNET interpreterthreads must list the threads of each of the %d interpreters", settings.Interpreters))
+		}
+		for _, threads := range settings.InterpreterThreads {
+			if threads < 0 {
+				errs = append(errs, "BirdNET interpreterthreads must be at least 0")
+				break
+			}
+		}
+	}
+
+	// Check if the batch size is within a sensible range
+	if settings.BatchSize < 1 || settings.BatchSize > 64 {
+		errs = append(errs, "BirdNET batch size must be between 1 and 64")
+	}
+
+	// The built-in model has no embedding output, custom models declare it in their sidecar
+	if settings.Embeddings && settings.ModelPath == "" {
+		errs = append(errs, "BirdNET embeddings require a custom model with an embedding output, the built-in model has none")
+	}
+
 	// Validate RangeFilter settings
 	if settings.RangeFilter.Model == "" {
 		errs = append(errs, "RangeFilter model must not be empty")
@@ -170,6 +198,19 @@ func validateRealtimeSettings(settings *RealtimeSettings) error {
 	if settings.Interval < 0 {
 		return errors.New("Realtime interval must be non-negative")
 	}
+	// Validate the unified audio source list
+	if err := ValidateAudioSources(settings.Sources); err != nil {
+		return err
+	}
+	// Require a token for the ingest server so unauthenticated clients cannot inject audio
+	if settings.Ingest.Enabled {
+		if settings.Ingest.Token == "" {
+			return errors.New("Ingest token is required when the ingest server is enabled")
+		}
+		if settings.Ingest.MaxConnections < 1 {
+			return errors.New("Ingest max connections must be at least 1")
+		}
+	}
 	// Add more realtime settings validation as needed
 	return nil
 }
@@ -227,22 +268,16 @@ func validateAudioSettings(settings *AudioSettings) error {
 
 	// Validate audio export settings
 	if settings.Export.Enabled {
-		if settings.FfmpegPath == "" {
+		// WAV and FLAC are encoded natively, other formats require FFmpeg
+		if settings.FfmpegPath == "" && settings.Export.Type != "wav" && settings.Export.Type != "flac" {
 			settings.Export.Type = "wav"
 			log.Printf("FFmpeg not available, using WAV format for audio export")
 		} else {
 			// Validate audio type and bitrate
 			switch settings.Export.Type {
 			case "aac", "opus", "mp3":
-				if !strings.HasSuffix(settings.Export.Bitrate, "k") {
-					return fmt.Errorf("invalid bitrate format for %s: %s. Must end with 'k' (e.g., '64k')", settings.Export.Type, settings.Export.Bitrate)
-				}
-				bitrateValue, err := strconv.Atoi(strings.TrimSuffix(settings.Export.Bitrate, "k"))
-				if err != nil {
-					return fmt.Errorf("invalid bitrate value for %s: %s", settings.Export.Type, settings.Export.Bitrate)
-				}
-				if bitrateValue < 32 || bitrateValue > 320 {
-					return fmt.Errorf("bitrate for %s must be between 32k and 320k", settings.Export.Type)
+				if err := validateBitrate(settings.Export.Type, settings.Export.Bitrate); err != nil {
+					return err
 				}
 			case "wav", "flac":
 				// These formats don't use bitrate, so we'll ignore the bitrate setting
@@ -252,6 +287,137 @@ func validateAudioSettings(settings *AudioSettings) error {
 		}
 	}
 
+	// Validate audio clip length settings
+	if settings.Export.Enabled {
+		clip := &settings.Export.Clip
+		switch clip.Mode {
+		case "fixed":
+			if clip.Length < 3 || clip.Length > 300 {
+				return fmt.Errorf("clip length must be between 3 and 300 seconds")
+			}
+			if clip.PreRoll >= clip.Length {
+				return fmt.Errorf("clip pre-roll must be shorter than the clip length")
+			}
+		case "event":
+			if clip.MaxLength < 3 || clip.MaxLength > 300 {
+				return fmt.Errorf("maximum clip length must be between 3 and 300 seconds")
+			}
+		default:
+			return fmt.Errorf("invalid clip mode: %s, must be fixed or event", clip.Mode)
+		}
+		if clip.PreRoll < 0 || clip.PreRoll > 30 || clip.PostRoll < 0 || clip.PostRoll > 30 {
+			return fmt.Errorf("clip pre-roll and post-roll must be between 0 and 30 seconds")
+		}
+	}
+
+	// Validate archive recording settings
+	if settings.Archive.Enabled {
+		switch settings.Archive.Type {
+		case "wav", "flac":
+		case "opus":
+			// Opus segments are encoded with FFmpeg
+			if settings.FfmpegPath == "" {
+				settings.Archive.Type = "flac"
+				log.Printf("FFmpeg not available, using FLAC format for audio archive")
+			} else if err := validateBitrate(settings.Archive.Type, settings.Archive.Bitrate); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported audio archive type: %s, must be wav, flac or opus", settings.Archive.Type)
+		}
+		if settings.Archive.SegmentLength < 10 || settings.Archive.SegmentLength > 3600 {
+			return fmt.Errorf("archive segment length must be between 10 and 3600 seconds")
+		}
+		switch settings.Archive.Retention.Policy {
+		case "none", "age", "usage":
+		default:
+			return fmt.Errorf("invalid archive retention policy: %s, must be none, age or usage", settings.Archive.Retention.Policy)
+		}
+	}
+
+	// Validate live listen settings
+	if settings.Listen.Enabled {
+		if settings.Listen.MaxListeners < 1 || settings.Listen.MaxListeners > 20 {
+			return fmt.Errorf("maximum live listeners must be between 1 and 20")
+		}
+		if settings.Listen.Bitrate == "" {
+			return fmt.Errorf("live listen bitrate must be set")
+		}
+	}
+
+	// Validate audio health monitoring settings
+	if settings.Health.Enabled {
+		health := &settings.Health
+		if health.SilenceThreshold < -120 || health.SilenceThreshold > -20 {
+			return fmt.Errorf("silence threshold must be between -120 and -20 dBFS")
+		}
+		if health.SilenceDuration < 10 {
+			return fmt.Errorf("silence duration must be at least 10 seconds")
+		}
+		if health.ClippingRatio <= 0 || health.ClippingRatio > 1 {
+			return fmt.Errorf("clipping ratio must be between 0 and 1")
+		}
+		if health.DCOffset <= 0 || health.DCOffset > 1 {
+			return fmt.Errorf("DC offset threshold must be between 0 and 1")
+		}
+		if health.MinSampleRate <= 0 || health.MinSampleRate > 1 {
+			return fmt.Errorf("minimum sample rate ratio must be between 0 and 1")
+		}
+		if health.MaxRestarts < 1 {
+			return fmt.Errorf("maximum FFmpeg restarts must be at least 1")
+		}
+	}
+
+	// Validate noise reduction settings
+	if err := validateNoiseReductionSettings(&settings.NoiseReduction); err != nil {
+		return err
+	}
+
+	// Validate automatic gain control settings
+	return validateAGCSettings(&settings.AGC)
+}
+
+// validateNoiseReductionSettings validates noise reduction settings, they are only checked when
+// noise reduction is enabled
+func validateNoiseReductionSettings(nr *NoiseReductionSettings) error {
+	if !nr.Analysis && !nr.Export {
+		return nil
+	}
+	switch nr.Method {
+	case "spectral", "gate":
+	default:
+		return fmt.Errorf("invalid noise reduction method: %s, must be spectral or gate", nr.Method)
+	}
+	if nr.Reduction <= 0 || nr.Reduction > 60 {
+		return fmt.Errorf("noise reduction must be between 0 and 60 dB")
+	}
+	if nr.Threshold < 0 || nr.Threshold > 30 {
+		return fmt.Errorf("noise reduction threshold must be between 0 and 30 dB")
+	}
+	if nr.Adaptation < 0.5 || nr.Adaptation > 60 {
+		return fmt.Errorf("noise profile adaptation time must be between 0.5 and 60 seconds")
+	}
+	return nil
+}
+
+// validateAGCSettings validates automatic gain control settings, they are only checked when
+// automatic gain control is enabled
+func validateAGCSettings(agc *AGCSettings) error {
+	if !agc.Enabled {
+		return nil
+	}
+	if agc.TargetMin < -80 || agc.TargetMax > 0 || agc.TargetMin > agc.TargetMax {
+		return fmt.Errorf("AGC target range must be between -80 and 0 dBFS with the minimum below the maximum")
+	}
+	if agc.MaxGain < 0 || agc.MaxGain > 60 {
+		return fmt.Errorf("AGC maximum gain must be between 0 and 60 dB")
+	}
+	if agc.MaxAttenuation < 0 || agc.MaxAttenuation > 60 {
+		return fmt.Errorf("AGC maximum attenuation must be between 0 and 60 dB")
+	}
+	if agc.Attack < 0.01 || agc.Attack > 60 || agc.Release < 0.01 || agc.Release > 60 {
+		return fmt.Errorf("AGC attack and release times must be between 0.01 and 60 seconds")
+	}
 	return nil
 }
 
@@ -262,6 +428,47 @@ func validateDashboardSettings(settings *Dashboard) error {
 		return fmt.Errorf("Dashboard SummaryLimit must be between 10 and 1000")
 	}
 
+	// Validate spectrogram settings
+	spectrogram := &settings.Spectrogram
+	switch spectrogram.Format {
+	case "png", "webp":
+	default:
+		return fmt.Errorf("unsupported spectrogram format: %s, must be png or webp", spectrogram.Format)
+	}
+	switch spectrogram.ColorMap {
+	case "inferno", "viridis", "grayscale":
+	default:
+		return fmt.Errorf("unsupported spectrogram color map: %s, must be inferno, viridis or grayscale", spectrogram.ColorMap)
+	}
+	if spectrogram.MinFreq < 0 || spectrogram.MaxFreq <= spectrogram.MinFreq || spectrogram.MaxFreq > SampleRate/2 {
+		return fmt.Errorf("spectrogram frequency range must be within 0 and %d Hz", SampleRate/2)
+	}
+	if spectrogram.DynamicRange < 10 || spectrogram.DynamicRange > 200 {
+		return fmt.Errorf("spectrogram dynamic range must be between 10 and 200 dB")
+	}
+	for _, width := range spectrogram.Widths {
+		if width < 100 || width > 2000 {
+			return fmt.Errorf("spectrogram widths must be between 100 and 2000 pixels, got %d", width)
+		}
+	}
+
+	// Validate live spectrum settings
+	liveSpectrum := &settings.LiveSpectrum
+	if liveSpectrum.Enabled {
+		if liveSpectrum.Rate < 1 || liveSpectrum.Rate > 50 {
+			return fmt.Errorf("live spectrum rate must be between 1 and 50 frames per second, got %d", liveSpectrum.Rate)
+		}
+		if liveSpectrum.FFTSize < 256 || liveSpectrum.FFTSize > 8192 || liveSpectrum.FFTSize&(liveSpectrum.FFTSize-1) != 0 {
+			return fmt.Errorf("live spectrum FFT size must be a power of two between 256 and 8192, got %d", liveSpectrum.FFTSize)
+		}
+		if liveSpectrum.Bands < 16 || liveSpectrum.Bands > liveSpectrum.FFTSize/2 {
+			return fmt.Errorf("live spectrum bands must be between 16 and %d, got %d", liveSpectrum.FFTSize/2, liveSpectrum.Bands)
+		}
+		if liveSpectrum.MaxFreq < 1000 || liveSpectrum.MaxFreq > SampleRate/2 {
+			return fmt.Errorf("live spectrum maximum frequency must be between 1000 and %d Hz", SampleRate/2)
+		}
+	}
+
 	return nil
 }
 
@@ -273,3 +480,18 @@ func validateWeatherSettings(settings *WeatherSettings) error {
 	}
 	return nil
 }
+
+// validateBitrate checks the bitrate of a lossy audio format
+func validateBitrate(format, bitrate string) error {
+	if !strings.HasSuffix(bitrate, "k") {
+		return fmt.Errorf("invalid bitrate format for %s: %s. Must end with 'k' (e.g., '64k')", format, bitrate)
+	}
+	bitrateValue, err := strconv.Atoi(strings.TrimSuffix(bitrate, "k"))
+	if err != nil {
+		return fmt.Errorf("invalid bitrate value for %s: %s", format, bitrate)
+	}
+	if bitrateValue < 32 || bitrateValue > 320 {
+		return fmt.Errorf("bitrate for %s must be between 32k and 320k", format)
+	}
+	return nil
+}
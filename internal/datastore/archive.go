@@ -0,0 +1,63 @@
+// internal/datastore/archive.go
+package datastore
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SaveAudioSegment adds a segment of the continuous audio archive to the index
+func (ds *DataStore) SaveAudioSegment(segment *AudioSegment) error {
+	if segment == nil {
+		return errors.New("segment cannot be nil")
+	}
+
+	// Times are stored in UTC so they compare correctly in all databases
+	segment.StartTime = segment.StartTime.UTC()
+	segment.EndTime = segment.EndTime.UTC()
+
+	if err := ds.DB.Create(segment).Error; err != nil {
+		return fmt.Errorf("failed to save audio segment: %w", err)
+	}
+	return nil
+}
+
+// GetAudioSegments retrieves the archive segments of a source which overlap the given time range,
+// ordered by start time
+func (ds *DataStore) GetAudioSegments(source string, start, end time.Time) ([]AudioSegment, error) {
+	var segments []AudioSegment
+
+	err := ds.DB.Where("source = ? AND start_time < ? AND end_time > ?", source, end.UTC(), start.UTC()).
+		Order("start_time ASC").
+		Find(&segments).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audio segments for source %s: %w", source, err)
+	}
+
+	return segments, nil
+}
+
+// GetAudioSegmentsBefore retrieves up to limit archive segments of all sources which ended before
+// the given time, oldest first
+func (ds *DataStore) GetAudioSegmentsBefore(before time.Time, limit int) ([]AudioSegment, error) {
+	var segments []AudioSegment
+
+	err := ds.DB.Where("end_time < ?", before.UTC()).
+		Order("start_time ASC").
+		Limit(limit).
+		Find(&segments).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audio segments: %w", err)
+	}
+
+	return segments, nil
+}
+
+// DeleteAudioSegment removes a segment from the archive index
+func (ds *DataStore) DeleteAudioSegment(id uint) error {
+	if err := ds.DB.Delete(&AudioSegment{}, id).Error; err != nil {
+		return fmt.Errorf("failed to delete audio segment %d: %w", id, err)
+	}
+	return nil
+}
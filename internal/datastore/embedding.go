@@ -0,0 +1,176 @@
+// internal/datastore/embedding.go
+package datastore
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// ErrNoEmbedding is returned when similar notes are requested for a note without an embedding
+var ErrNoEmbedding = errors.New("note has no embedding")
+
+// SimilarNote is a note with the cosine similarity of its embedding to the embedding of another note
+type SimilarNote struct {
+	Note       Note
+	Similarity float64 // Cosine similarity of the embeddings, from -1 to 1
+}
+
+// EncodeEmbedding encodes an embedding for storing it as little-endian float32 values
+func EncodeEmbedding(embedding []float32) []byte {
+	data := make([]byte, len(embedding)*4)
+	for i, value := range embedding {
+		binary.LittleEndian.PutUint32(data[i*4:], math.Float32bits(value))
+	}
+	return data
+}
+
+// DecodeEmbedding decodes an embedding stored with EncodeEmbedding
+func DecodeEmbedding(data []byte) ([]float32, error) {
+	if len(data)%4 != 0 {
+		return nil, fmt.Errorf("invalid embedding length: %d bytes", len(data))
+	}
+	embedding := make([]float32, len(data)/4)
+	for i := range embedding {
+		embedding[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[i*4:]))
+	}
+	return embedding, nil
+}
+
+// CosineSimilarity returns the cosine similarity of two embeddings, 0 if their lengths differ or
+// either of them is all zeros
+func CosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// GetNoteEmbedding retrieves the embedding of a note, nil if the note has no embedding
+func (ds *DataStore) GetNoteEmbedding(noteID string) ([]float32, error) {
+	id, err := strconv.ParseUint(noteID, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid note ID: %w", err)
+	}
+
+	var embedding NoteEmbedding
+	err = ds.DB.Session(&gorm.Session{
+		Logger: ds.DB.Logger.LogMode(logger.Silent),
+	}).Where("note_id = ?", id).First(&embedding).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Return nil if no embedding exists
+		}
+		return nil, fmt.Errorf("error getting note embedding: %w", err)
+	}
+
+	return DecodeEmbedding(embedding.Vector)
+}
+
+// GetSimilarNotes retrieves up to limit notes whose embeddings are most similar to the embedding of
+// the given note by cosine similarity, most similar first. Candidates are restricted to notes from
+// startDate to endDate and of the given species, empty values are not applied. Embeddings of a
+// different length, from another model, are not comparable and are skipped. Returns ErrNoEmbedding
+// if the note has no embedding.
+func (ds *DataStore) GetSimilarNotes(noteID, startDate, endDate, species string, limit int) ([]SimilarNote, error) {
+	target, err := ds.GetNoteEmbedding(noteID)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		return nil, ErrNoEmbedding
+	}
+	if limit <= 0 {
+		return []SimilarNote{}, nil
+	}
+
+	query := ds.DB.Model(&NoteEmbedding{}).
+		Select("note_embeddings.*").
+		Joins("JOIN notes ON notes.id = note_embeddings.note_id").
+		Where("note_embeddings.note_id <> ?", noteID)
+
+	// Apply date range filter
+	if startDate != "" {
+		query = query.Where("notes.date >= ?", startDate)
+	}
+	if endDate != "" {
+		query = query.Where("notes.date <= ?", endDate)
+	}
+
+	// Apply species filter
+	if species != "" {
+		query = query.Where("(notes.scientific_name = ? OR notes.common_name = ?)", species, species)
+	}
+
+	// Score the embeddings of the candidates in batches to limit memory use
+	type score struct {
+		noteID     uint
+		similarity float64
+	}
+	var scores []score
+	var batch []NoteEmbedding
+	err = query.FindInBatches(&batch, 1000, func(tx *gorm.DB, _ int) error {
+		for _, embedding := range batch {
+			vector, err := DecodeEmbedding(embedding.Vector)
+			if err != nil || len(vector) != len(target) {
+				continue
+			}
+			scores = append(scores, score{embedding.NoteID, CosineSimilarity(target, vector)})
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return nil, fmt.Errorf("error getting note embeddings: %w", err)
+	}
+
+	sort.Slice(scores, func(i, j int) bool {
+		return scores[i].similarity > scores[j].similarity
+	})
+	if len(scores) > limit {
+		scores = scores[:limit]
+	}
+	if len(scores) == 0 {
+		return []SimilarNote{}, nil
+	}
+
+	// Retrieve the notes of the best matches with their review and lock status
+	ids := make([]uint, len(scores))
+	for i, s := range scores {
+		ids[i] = s.noteID
+	}
+	var notes []Note
+	if err := ds.DB.Preload("Review").Preload("Lock").Where("id IN ?", ids).Find(&notes).Error; err != nil {
+		return nil, fmt.Errorf("error getting similar notes: %w", err)
+	}
+	notesByID := make(map[uint]Note, len(notes))
+	for i := range notes {
+		if notes[i].Review != nil {
+			notes[i].Verified = notes[i].Review.Verified
+		}
+		notes[i].Locked = notes[i].Lock != nil
+		notesByID[notes[i].ID] = notes[i]
+	}
+
+	similar := make([]SimilarNote, 0, len(scores))
+	for _, s := range scores {
+		if note, ok := notesByID[s.noteID]; ok {
+			similar = append(similar, SimilarNote{Note: note, Similarity: s.similarity})
+		}
+	}
+	return similar, nil
+}
@@ -0,0 +1,98 @@
+package datastore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+func TestEncodeEmbedding(t *testing.T) {
+	embedding := []float32{0.5, -1.25, 0, 3e-7}
+	decoded, err := DecodeEmbedding(EncodeEmbedding(embedding))
+	require.NoError(t, err)
+	assert.Equal(t, embedding, decoded)
+
+	_, err = DecodeEmbedding([]byte{1, 2, 3})
+	assert.Error(t, err)
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	assert.InDelta(t, 1, CosineSimilarity([]float32{1, 2, 3}, []float32{2, 4, 6}), 1e-9)
+	assert.InDelta(t, 0, CosineSimilarity([]float32{1, 0}, []float32{0, 1}), 1e-9)
+	assert.InDelta(t, -1, CosineSimilarity([]float32{1, 1}, []float32{-1, -1}), 1e-9)
+
+	// Embeddings which cannot be compared are not similar
+	assert.InDelta(t, 0, CosineSimilarity([]float32{1, 2}, []float32{1, 2, 3}), 0)
+	assert.InDelta(t, 0, CosineSimilarity([]float32{0, 0}, []float32{1, 2}), 0)
+}
+
+// TestGetSimilarNotes tests that notes are ranked by the similarity of their embeddings
+func TestGetSimilarNotes(t *testing.T) {
+	ds := createDatabase(t, &conf.Settings{})
+
+	embeddings := [][]float32{
+		{1, 0, 0},
+		{0.9, 0.1, 0},
+		{0, 1, 0},
+		{0.7, 0.7, 0},
+		nil,          // detected without embeddings
+		{1, 0, 0, 0}, // embedding of another model
+		{1, 0.01, 0}, // detected earlier
+		{1, 0.02, 0}, // another species
+	}
+	for i, embedding := range embeddings {
+		note := Note{Date: "2024-05-01", CommonName: "American Robin", ScientificName: "Turdus migratorius"}
+		switch i {
+		case 6:
+			note.Date = "2024-03-01"
+		case 7:
+			note.CommonName, note.ScientificName = "Blue Jay", "Cyanocitta cristata"
+		}
+		if embedding != nil {
+			note.Embedding = &NoteEmbedding{Vector: EncodeEmbedding(embedding)}
+		}
+		require.NoError(t, ds.Save(&note, nil))
+	}
+
+	embedding, err := ds.GetNoteEmbedding("2")
+	require.NoError(t, err)
+	assert.Equal(t, embeddings[1], embedding)
+
+	noteIDs := func(similar []SimilarNote) []uint {
+		var ids []uint
+		for i := range similar {
+			ids = append(ids, similar[i].Note.ID)
+		}
+		return ids
+	}
+
+	// Candidates are restricted to the date range and species
+	similar, err := ds.GetSimilarNotes("1", "2024-04-01", "2024-05-31", "Turdus migratorius", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{2, 4, 3}, noteIDs(similar))
+	assert.InDelta(t, 0.9939, similar[0].Similarity, 1e-4)
+
+	similar, err = ds.GetSimilarNotes("1", "", "", "", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{7, 8, 2, 4, 3}, noteIDs(similar))
+
+	similar, err = ds.GetSimilarNotes("1", "2024-05-01", "", "Blue Jay", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{8}, noteIDs(similar))
+
+	similar, err = ds.GetSimilarNotes("1", "", "", "", 1)
+	require.NoError(t, err)
+	require.Len(t, similar, 1)
+	assert.Equal(t, uint(7), similar[0].Note.ID)
+
+	_, err = ds.GetSimilarNotes("5", "", "", "", 10)
+	assert.ErrorIs(t, err, ErrNoEmbedding)
+
+	// Embeddings are deleted with their notes
+	require.NoError(t, ds.Delete("2"))
+	embedding, err = ds.GetNoteEmbedding("2")
+	require.NoError(t, err)
+	assert.Nil(t, embedding)
+}
@@ -45,6 +45,8 @@ type Interface interface {
 	GetHourlyDetections(date, hour string, duration, limit, offset int) ([]Note, error)
 	CountSpeciesDetections(species, date, hour string, duration int) (int64, error)
 	CountSearchResults(query string) (int64, error)
+	SearchNotesBySignal(query string, filter *SignalFilter, sortAscending bool, limit, offset int) ([]Note, error)
+	CountSignalSearchResults(query string, filter *SignalFilter) (int64, error)
 	Transaction(fc func(tx *gorm.DB) error) error
 	// Lock management methods
 	LockNote(noteID string) error
@@ -62,6 +64,14 @@ type Interface interface {
 	GetHourlyAnalyticsData(date string, species string) ([]HourlyAnalyticsData, error)
 	GetDailyAnalyticsData(startDate, endDate string, species string) ([]DailyAnalyticsData, error)
 	GetDetectionTrends(period string, limit int) ([]DailyAnalyticsData, error)
+	// Audio archive methods
+	SaveAudioSegment(segment *AudioSegment) error
+	GetAudioSegments(source string, start, end time.Time) ([]AudioSegment, error)
+	GetAudioSegmentsBefore(before time.Time, limit int) ([]AudioSegment, error)
+	DeleteAudioSegment(id uint) error
+	// Embedding methods
+	GetNoteEmbedding(noteID string) ([]float32, error)
+	GetSimilarNotes(noteID, startDate, endDate, species string, limit int) ([]SimilarNote, error)
 }
 
 // DataStore implements StoreInterface using a GORM database.
@@ -217,6 +227,10 @@ func (ds *DataStore) Delete(id string) error {
 		if err := tx.Where("note_id = ?", noteID).Delete(&Results{}).Error; err != nil {
 			return fmt.Errorf("deleting results for note ID %d: %w", noteID, err)
 		}
+		// Delete the embedding of the note
+		if err := tx.Where("note_id = ?", noteID).Delete(&NoteEmbedding{}).Error; err != nil {
+			return fmt.Errorf("deleting embedding for note ID %d: %w", noteID, err)
+		}
 		// Delete the note itself
 		if err := tx.Delete(&Note{}, noteID).Error; err != nil {
 			return fmt.Errorf("deleting note with ID %d: %w", noteID, err)
@@ -22,11 +22,16 @@ type Note struct {
 	Threshold      float64
 	Sensitivity    float64
 	ClipName       string
+	ClipDuration   time.Duration // Length of the exported audio clip
 	ProcessingTime time.Duration
-	Results        []Results     `gorm:"foreignKey:NoteID;constraint:OnDelete:CASCADE"`
-	Review         *NoteReview   `gorm:"foreignKey:NoteID;constraint:OnDelete:CASCADE"` // One-to-one relationship with cascade delete
-	Comments       []NoteComment `gorm:"foreignKey:NoteID;constraint:OnDelete:CASCADE"` // One-to-many relationship with cascade delete
-	Lock           *NoteLock     `gorm:"foreignKey:NoteID;constraint:OnDelete:CASCADE"` // One-to-one relationship with cascade delete
+	RMSLevel       *float64       // RMS level of the detection window in dBFS, nil if not measured
+	PeakLevel      *float64       // Peak level of the detection window in dBFS, nil if not measured
+	SNR            *float64       `gorm:"index:idx_notes_snr"` // Estimated signal to noise ratio in dB, nil if not measured
+	Results        []Results      `gorm:"foreignKey:NoteID;constraint:OnDelete:CASCADE"`
+	Review         *NoteReview    `gorm:"foreignKey:NoteID;constraint:OnDelete:CASCADE"`          // One-to-one relationship with cascade delete
+	Comments       []NoteComment  `gorm:"foreignKey:NoteID;constraint:OnDelete:CASCADE"`          // One-to-many relationship with cascade delete
+	Lock           *NoteLock      `gorm:"foreignKey:NoteID;constraint:OnDelete:CASCADE"`          // One-to-one relationship with cascade delete
+	Embedding      *NoteEmbedding `gorm:"foreignKey:NoteID;constraint:OnDelete:CASCADE" json:"-"` // One-to-one relationship with cascade delete
 
 	// Virtual fields to maintain compatibility with templates
 	Verified string `gorm:"-"` // This will be populated from Review.Verified
@@ -79,6 +84,15 @@ type NoteLock struct {
 	LockedAt time.Time `gorm:"index;not null"`                                                                                    // When the note was locked
 }
 
+// NoteEmbedding holds the embedding of a Note, the activations of the layer before the classifier
+// of the model, used to find similar detections
+// GORM will automatically create table name as 'note_embeddings'
+type NoteEmbedding struct {
+	ID     uint   `gorm:"primaryKey"`
+	NoteID uint   `gorm:"uniqueIndex;not null;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;foreignKey:NoteID;references:ID"` // Foreign key to associate with Note
+	Vector []byte `gorm:"not null"`                                                                                          // Little-endian float32 values, see EncodeEmbedding
+}
+
 // DailyEvents represents the daily weather data that doesn't change throughout the day
 type DailyEvents struct {
 	ID       uint   `gorm:"primaryKey"`
@@ -110,6 +124,16 @@ type HourlyWeather struct {
 	WeatherIcon   string
 }
 
+// AudioSegment represents a file of the continuous audio archive
+type AudioSegment struct {
+	ID        uint      `gorm:"primaryKey"`
+	Source    string    `gorm:"index:idx_audiosegment_source_start;not null"` // Audio source the segment was recorded from
+	StartTime time.Time `gorm:"index:idx_audiosegment_source_start;not null"` // Time of the first sample, in UTC
+	EndTime   time.Time `gorm:"index;not null"`                               // Time after the last sample, in UTC
+	Path      string    `gorm:"uniqueIndex;not null"`                         // Path of the segment file
+	Size      int64     // Size of the segment file in bytes
+}
+
 // ImageCache represents cached image metadata for species
 type ImageCache struct {
 	ID             uint      `gorm:"primaryKey"`
@@ -0,0 +1,100 @@
+// internal/datastore/signal.go
+package datastore
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// SignalFilter restricts detections by their measured signal levels, nil bounds are not applied.
+// Detections without measurements never match a filter with bounds.
+type SignalFilter struct {
+	MinSNR  *float64 // minimum signal to noise ratio in dB
+	MaxSNR  *float64 // maximum signal to noise ratio in dB
+	MinRMS  *float64 // minimum RMS level in dBFS
+	MaxRMS  *float64 // maximum RMS level in dBFS
+	MinPeak *float64 // minimum peak level in dBFS
+	MaxPeak *float64 // maximum peak level in dBFS
+}
+
+// IsEmpty reports whether the filter has no bounds
+func (f *SignalFilter) IsEmpty() bool {
+	return f.MinSNR == nil && f.MaxSNR == nil &&
+		f.MinRMS == nil && f.MaxRMS == nil &&
+		f.MinPeak == nil && f.MaxPeak == nil
+}
+
+// String returns a representation of the filter bounds, used in cache keys
+func (f *SignalFilter) String() string {
+	format := func(v *float64) string {
+		if v == nil {
+			return "-"
+		}
+		return fmt.Sprintf("%g", *v)
+	}
+	return fmt.Sprintf("snr:%s-%s,rms:%s-%s,peak:%s-%s",
+		format(f.MinSNR), format(f.MaxSNR), format(f.MinRMS), format(f.MaxRMS), format(f.MinPeak), format(f.MaxPeak))
+}
+
+// apply adds the conditions of the filter to a query on notes
+func (f *SignalFilter) apply(db *gorm.DB) *gorm.DB {
+	bounds := []struct {
+		condition string
+		value     *float64
+	}{
+		{"snr >= ?", f.MinSNR},
+		{"snr <= ?", f.MaxSNR},
+		{"rms_level >= ?", f.MinRMS},
+		{"rms_level <= ?", f.MaxRMS},
+		{"peak_level >= ?", f.MinPeak},
+		{"peak_level <= ?", f.MaxPeak},
+	}
+	for _, b := range bounds {
+		if b.value != nil {
+			db = db.Where(b.condition, *b.value)
+		}
+	}
+	return db
+}
+
+// SearchNotesBySignal searches notes by common or scientific name like SearchNotes and returns
+// only those whose signal levels are within the bounds of the filter
+func (ds *DataStore) SearchNotesBySignal(query string, filter *SignalFilter, sortAscending bool, limit, offset int) ([]Note, error) {
+	var notes []Note
+	sortOrder := sortAscendingString(sortAscending)
+
+	err := filter.apply(ds.DB.Preload("Review").Preload("Lock").Preload("Comments", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at DESC") // Order comments by creation time, newest first
+	}).Where("(common_name LIKE ? OR scientific_name LIKE ?)", "%"+query+"%", "%"+query+"%")).
+		Order("id " + sortOrder).
+		Limit(limit).
+		Offset(offset).
+		Find(&notes).Error
+	if err != nil {
+		return nil, fmt.Errorf("error searching notes by signal levels: %w", err)
+	}
+
+	// Populate virtual fields
+	for i := range notes {
+		if notes[i].Review != nil {
+			notes[i].Verified = notes[i].Review.Verified
+		}
+		notes[i].Locked = notes[i].Lock != nil
+	}
+
+	return notes, nil
+}
+
+// CountSignalSearchResults counts the notes matching a search query and signal level filter
+func (ds *DataStore) CountSignalSearchResults(query string, filter *SignalFilter) (int64, error) {
+	var count int64
+	err := filter.apply(ds.DB.Model(&Note{}).
+		Where("(common_name LIKE ? OR scientific_name LIKE ?)", "%"+query+"%", "%"+query+"%")).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("error counting search results by signal levels: %w", err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,54 @@
+package datastore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// TestSearchNotesBySignal tests that notes are filtered by their signal levels
+func TestSearchNotesBySignal(t *testing.T) {
+	ds := createDatabase(t, &conf.Settings{})
+
+	level := func(v float64) *float64 { return &v }
+	notes := []Note{
+		{CommonName: "American Robin", ScientificName: "Turdus migratorius", SNR: level(25), RMSLevel: level(-30), PeakLevel: level(-10)},
+		{CommonName: "American Robin", ScientificName: "Turdus migratorius", SNR: level(6), RMSLevel: level(-55), PeakLevel: level(-35)},
+		{CommonName: "Blue Jay", ScientificName: "Cyanocitta cristata", SNR: level(30), RMSLevel: level(-25), PeakLevel: level(-3)},
+		{CommonName: "Blue Jay", ScientificName: "Cyanocitta cristata"}, // detected before levels were measured
+	}
+	for i := range notes {
+		require.NoError(t, ds.Save(&notes[i], nil))
+	}
+
+	tests := []struct {
+		name   string
+		query  string
+		filter SignalFilter
+		want   []uint
+	}{
+		{"no bounds", "", SignalFilter{}, []uint{4, 3, 2, 1}},
+		{"minimum SNR", "", SignalFilter{MinSNR: level(20)}, []uint{3, 1}},
+		{"SNR range with search", "robin", SignalFilter{MinSNR: level(5), MaxSNR: level(10)}, []uint{2}},
+		{"RMS and peak", "", SignalFilter{MaxRMS: level(-20), MinPeak: level(-5)}, []uint{3}},
+		{"faint calls", "jay", SignalFilter{MaxSNR: level(10)}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, err := ds.SearchNotesBySignal(tt.query, &tt.filter, false, 10, 0)
+			require.NoError(t, err)
+			var ids []uint
+			for i := range found {
+				ids = append(ids, found[i].ID)
+			}
+			assert.Equal(t, tt.want, ids)
+
+			count, err := ds.CountSignalSearchResults(tt.query, &tt.filter)
+			require.NoError(t, err)
+			assert.Equal(t, int64(len(tt.want)), count)
+		})
+	}
+}
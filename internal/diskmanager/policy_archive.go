@@ -0,0 +1,103 @@
+// policy_archive.go - code for audio archive retention policy
+package diskmanager
+
+import (
+	"errors"
+	"log"
+	"os"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// archiveCleanupBatchSize is the number of segments fetched from the index at a time
+const archiveCleanupBatchSize = 100
+
+// ArchiveInterface represents the minimal database interface needed for archive cleanup
+type ArchiveInterface interface {
+	GetAudioSegmentsBefore(before time.Time, limit int) ([]datastore.AudioSegment, error)
+	DeleteAudioSegment(id uint) error
+}
+
+// ArchiveCleanup removes archive segments from the filesystem and the index, either when they are
+// older than the maximum age or, oldest first, while disk usage is above the threshold
+func ArchiveCleanup(quit <-chan struct{}, db ArchiveInterface) error {
+	settings := conf.Setting()
+	retention := settings.Realtime.Audio.Archive.Retention
+	baseDir := settings.Realtime.Audio.Archive.Path
+
+	var done func() (bool, error)
+	before := time.Now()
+
+	switch retention.Policy {
+	case "age":
+		retentionPeriodInHours, err := conf.ParseRetentionPeriod(retention.MaxAge)
+		if err != nil {
+			log.Printf("Invalid archive retention period: %s\n", err)
+			return err
+		}
+		before = before.Add(-time.Duration(retentionPeriodInHours) * time.Hour)
+		done = func() (bool, error) { return false, nil }
+
+	case "usage":
+		threshold, err := conf.ParsePercentage(retention.MaxUsage)
+		if err != nil {
+			return err
+		}
+		done = func() (bool, error) {
+			diskUsage, err := GetDiskUsage(baseDir)
+			if err != nil {
+				return true, err
+			}
+			return diskUsage <= threshold, nil
+		}
+
+	default:
+		return nil
+	}
+
+	maxDeletions := 1000 // Maximum number of segments to delete in one run
+	deletedSegments := 0 // Counter for the number of deleted segments
+
+	for deletedSegments < maxDeletions {
+		segments, err := db.GetAudioSegmentsBefore(before, archiveCleanupBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(segments) == 0 {
+			break
+		}
+
+		for i := range segments {
+			select {
+			case <-quit:
+				log.Printf("Archive cleanup interrupted by quit signal\n")
+				return nil
+			default:
+			}
+
+			finished, err := done()
+			if err != nil {
+				return err
+			}
+			if finished || deletedSegments >= maxDeletions {
+				return nil
+			}
+
+			if err := os.Remove(segments[i].Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				log.Printf("Failed to remove archive segment %s: %s\n", segments[i].Path, err)
+				return err
+			}
+			if err := db.DeleteAudioSegment(segments[i].ID); err != nil {
+				return err
+			}
+			deletedSegments++
+		}
+	}
+
+	if deletedSegments > 0 {
+		log.Printf("Archive cleanup removed %d segments", deletedSegments)
+	}
+	return nil
+}
@@ -33,41 +33,53 @@ func (h *Handlers) initializeLevelsData(isAuthenticated bool) (levels map[string
 	lastUpdate = make(map[string]time.Time)
 	lastNonZero = make(map[string]time.Time)
 
-	// Add configured audio device if set
-	if h.Settings.Realtime.Audio.Source != "" {
-		sourceName := h.Settings.Realtime.Audio.Source
-		if !isAuthenticated {
-			sourceName = "audio-source-1"
-		}
-		levels["malgo"] = myaudio.AudioLevelData{
+	// Add all configured audio sources
+	for _, source := range h.Settings.Realtime.AudioSources() {
+		levels[source.ID] = myaudio.AudioLevelData{
 			Level:  0,
-			Name:   sourceName,
-			Source: "malgo",
+			Name:   h.audioSourceDisplayName(source.ID, isAuthenticated),
+			Source: source.ID,
 		}
 		now := time.Now()
-		lastUpdate["malgo"] = now
-		lastNonZero["malgo"] = now
+		lastUpdate[source.ID] = now
+		lastNonZero[source.ID] = now
 	}
 
-	// Add all configured RTSP sources
-	for i, url := range h.Settings.Realtime.RTSP.URLs {
-		var displayName string
-		if isAuthenticated {
-			displayName = conf.SanitizeRTSPUrl(url)
-		} else {
-			displayName = fmt.Sprintf("camera-%d", i+1)
+	return levels, lastUpdate, lastNonZero
+}
+
+// audioSourceDisplayName returns the name shown for an audio source. Configured display names are
+// shown to everyone, otherwise authenticated clients see the device name or sanitized stream URL
+// and others see a generic numbered name.
+func (h *Handlers) audioSourceDisplayName(sourceID string, isAuthenticated bool) string {
+	soundCards, streams, replays := 0, 0, 0
+	for _, source := range h.Settings.Realtime.AudioSources() {
+		switch source.Type {
+		case conf.SourceTypeSoundCard:
+			soundCards++
+		case conf.SourceTypeReplay:
+			replays++
+		default:
+			streams++
 		}
-		levels[url] = myaudio.AudioLevelData{
-			Level:  0,
-			Name:   displayName,
-			Source: url,
+		if source.ID != sourceID {
+			continue
+		}
+
+		switch {
+		case isAuthenticated || source.Name != "":
+			return source.DisplayName()
+		case source.Type == conf.SourceTypeSoundCard:
+			return fmt.Sprintf("audio-source-%d", soundCards)
+		case source.Type == conf.SourceTypeReplay:
+			return fmt.Sprintf("replay-%d", replays)
+		default:
+			return fmt.Sprintf("camera-%d", streams)
 		}
-		now := time.Now()
-		lastUpdate[url] = now
-		lastNonZero[url] = now
 	}
 
-	return levels, lastUpdate, lastNonZero
+	// Source is not configured, such as a live ingest stream or a removed source
+	return ""
 }
 
 // isSourceInactive checks if a source should be considered inactive based on its update times
@@ -91,23 +103,9 @@ func (h *Handlers) updateAudioLevels(audioData myaudio.AudioLevelData, levels ma
 
 	now := time.Now()
 
-	if audioData.Source == "malgo" {
-		if isAuthenticated {
-			audioData.Name = h.Settings.Realtime.Audio.Source
-		} else {
-			audioData.Name = "audio-source-1"
-		}
-	} else {
-		if isAuthenticated {
-			audioData.Name = conf.SanitizeRTSPUrl(audioData.Source)
-		} else {
-			for i, url := range h.Settings.Realtime.RTSP.URLs {
-				if url == audioData.Source {
-					audioData.Name = fmt.Sprintf("camera-%d", i+1)
-					break
-				}
-			}
-		}
+	// Live sources which are not in the configuration report their own name
+	if name := h.audioSourceDisplayName(audioData.Source, isAuthenticated); name != "" {
+		audioData.Name = name
 	}
 
 	// Update activity times
@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
 	"html"
@@ -10,15 +9,14 @@ import (
 	"net/http"
 	"net/url"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
 	"runtime"
-	"strconv"
 	"strings"
 
 	"github.com/labstack/echo/v4"
 	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/spectrogram"
 )
 
 // MaxClipNameLength is the maximum allowed length for a clip name
@@ -274,7 +272,9 @@ func (h *Handlers) ServeSpectrogram(c echo.Context) error {
 	if !exists {
 		h.Debug("ServeSpectrogram: Spectrogram file not found, attempting to create it")
 		// Try to create the spectrogram
-		if err := createSpectrogramWithSoX(fullPath, spectrogramPath, 400); err != nil {
+		settings := conf.Setting()
+		opts := spectrogram.OptionsFromSettings(settings, 400)
+		if err := spectrogram.Generate(fullPath, spectrogramPath, settings.Realtime.Dashboard.Spectrogram.Format, opts); err != nil {
 			h.Debug("ServeSpectrogram: Failed to create spectrogram: %v", err)
 			c.Response().Header().Set(echo.HeaderContentType, "image/svg+xml")
 			return c.File("assets/images/spectrogram-placeholder.svg")
@@ -291,8 +291,8 @@ func (h *Handlers) ServeSpectrogram(c echo.Context) error {
 	}
 
 	h.Debug("ServeSpectrogram: Serving spectrogram file: %s", spectrogramPath)
-	// Set the correct Content-Type header for PNG images
-	c.Response().Header().Set(echo.HeaderContentType, "image/png")
+	// Set the Content-Type header of the configured image format
+	c.Response().Header().Set(echo.HeaderContentType, spectrogram.ContentType(conf.Setting().Realtime.Dashboard.Spectrogram.Format))
 	c.Response().Header().Set("Cache-Control", "public, max-age=2592000, immutable") // Cache spectrograms for 30 days
 	return c.File(spectrogramPath)
 }
@@ -325,7 +325,8 @@ func (h *Handlers) getSpectrogramPath(audioFileName string, width int) (string,
 	baseNameWithoutExt := strings.TrimSuffix(filepath.Base(audioFileName), filepath.Ext(audioFileName))
 	h.Debug("getSpectrogramPath: Base name without extension: %s", baseNameWithoutExt)
 
-	spectrogramFileName := fmt.Sprintf("%s_%dpx.png", baseNameWithoutExt, width)
+	format := conf.Setting().Realtime.Dashboard.Spectrogram.Format
+	spectrogramFileName := spectrogram.FileName(baseNameWithoutExt, width, format)
 	h.Debug("getSpectrogramPath: Spectrogram filename: %s", spectrogramFileName)
 
 	// Join paths using OS-specific separators and clean the result
@@ -371,206 +372,6 @@ func fileExists(filename string) (bool, error) {
 	return !info.IsDir(), nil
 }
 
-// createSpectrogramWithSoX generates a spectrogram for an audio file using ffmpeg and SoX.
-// It supports various audio formats by using ffmpeg to pipe the audio to SoX when necessary.
-func createSpectrogramWithSoX(audioClipPath, spectrogramPath string, width int) error {
-	// Get ffmpeg and sox paths from settings
-	ffmpegBinary := conf.Setting().Realtime.Audio.FfmpegPath
-	soxBinary := conf.Setting().Realtime.Audio.SoxPath
-
-	// Verify ffmpeg and SoX paths
-	if ffmpegBinary == "" {
-		return fmt.Errorf("ffmpeg path not set in settings")
-	}
-	if soxBinary == "" {
-		return fmt.Errorf("SoX path not set in settings")
-	}
-
-	// Set height based on width
-	heightStr := strconv.Itoa(width / 2)
-	widthStr := strconv.Itoa(width)
-
-	// Determine if we need to use ffmpeg based on file extension
-	ext := strings.ToLower(filepath.Ext(audioClipPath))
-	// remove prefix dot
-	ext = strings.TrimPrefix(ext, ".")
-	useFFmpeg := true
-	for _, soxType := range conf.Setting().Realtime.Audio.SoxAudioTypes {
-		if strings.EqualFold(ext, soxType) {
-			useFFmpeg = false
-			break
-		}
-	}
-
-	var cmd *exec.Cmd
-	var soxCmd *exec.Cmd
-
-	// Decode audio using ffmpeg and pipe to sox for spectrogram creation
-	if useFFmpeg {
-		// Build ffmpeg command arguments
-		ffmpegArgs := []string{"-hide_banner", "-i", audioClipPath, "-f", "sox", "-"}
-
-		// Build SoX command arguments
-		soxArgs := append([]string{"-t", "sox", "-"}, getSoxSpectrogramArgs(widthStr, heightStr, spectrogramPath)...)
-
-		// Set up commands
-		if runtime.GOOS == "windows" {
-			cmd = exec.Command(ffmpegBinary, ffmpegArgs...)
-			soxCmd = exec.Command(soxBinary, soxArgs...)
-		} else {
-			cmd = exec.Command("nice", append([]string{"-n", "19", ffmpegBinary}, ffmpegArgs...)...)
-			soxCmd = exec.Command("nice", append([]string{"-n", "19", soxBinary}, soxArgs...)...)
-		}
-
-		// Set up pipe between ffmpeg and sox
-		var err error
-		soxCmd.Stdin, err = cmd.StdoutPipe()
-		if err != nil {
-			return fmt.Errorf("error creating pipe: %w", err)
-		}
-
-		// Capture combined output
-		var ffmpegOutput, soxOutput bytes.Buffer
-		cmd.Stderr = &ffmpegOutput
-		soxCmd.Stderr = &soxOutput
-
-		// Allow other goroutines to run before starting SoX
-		runtime.Gosched()
-
-		// Start sox command
-		if err := soxCmd.Start(); err != nil {
-			log.Printf("SoX cmd: %s", soxCmd.String())
-			return fmt.Errorf("error starting SoX command: %w", err)
-		}
-
-		// Define error message template
-		const errFFmpegSoxFailed = "ffmpeg command failed: %v\nffmpeg output: %s\nsox output: %s\n%s"
-
-		// Run ffmpeg command
-		if err := cmd.Run(); err != nil {
-			// Stop the SoX command to clean up resources
-			if killErr := soxCmd.Process.Kill(); killErr != nil {
-				log.Printf("Failed to kill SoX process: %v", killErr)
-			}
-
-			// Wait for SoX to finish and collect its error, if any
-			waitErr := soxCmd.Wait()
-
-			// Prepare additional error information
-			var additionalInfo string
-			if waitErr != nil && !errors.Is(waitErr, os.ErrProcessDone) {
-				additionalInfo = fmt.Sprintf("sox wait error: %v", waitErr)
-			}
-
-			// Use fmt.Errorf with the constant format string
-			return fmt.Errorf(errFFmpegSoxFailed, err, ffmpegOutput.String(), soxOutput.String(), additionalInfo)
-		}
-
-		// Allow other goroutines to run before waiting for SoX to finish
-		runtime.Gosched()
-
-		// Wait for sox command to finish
-		if err := soxCmd.Wait(); err != nil {
-			return fmt.Errorf("SoX command failed: %w\nffmpeg output: %s\nsox output: %s", err, ffmpegOutput.String(), soxOutput.String())
-		}
-
-		// Allow other goroutines to run after SoX finishes
-		runtime.Gosched()
-	} else {
-		// Use SoX directly for supported formats
-		soxArgs := append([]string{audioClipPath}, getSoxSpectrogramArgs(widthStr, heightStr, spectrogramPath)...)
-
-		if runtime.GOOS == "windows" {
-			soxCmd = exec.Command(soxBinary, soxArgs...)
-		} else {
-			soxCmd = exec.Command("nice", append([]string{"-n", "19", soxBinary}, soxArgs...)...)
-		}
-
-		// Capture output
-		var soxOutput bytes.Buffer
-		soxCmd.Stderr = &soxOutput
-		soxCmd.Stdout = &soxOutput
-
-		// Allow other goroutines to run before running SoX
-		runtime.Gosched()
-
-		// Run SoX command
-		if err := soxCmd.Run(); err != nil {
-			return fmt.Errorf("SoX command failed: %w\nOutput: %s", err, soxOutput.String())
-		}
-
-		// Allow other goroutines to run after SoX finishes
-		runtime.Gosched()
-	}
-
-	return nil
-}
-
-// getSoxSpectrogramArgs returns the common SoX arguments for generating a spectrogram
-func getSoxSpectrogramArgs(widthStr, heightStr, spectrogramPath string) []string {
-	// TODO: make these dynamic based on audio length and gain
-	const audioLength = "15"
-	const dynamicRange = "100"
-
-	args := []string{"-n", "rate", "24k", "spectrogram", "-x", widthStr, "-y", heightStr, "-d", audioLength, "-z", dynamicRange, "-o", spectrogramPath}
-	width, _ := strconv.Atoi(widthStr)
-	if width < 800 {
-		args = append(args, "-r")
-	}
-	return args
-}
-
-// createSpectrogramWithFFmpeg generates a spectrogram for an audio file using only ffmpeg.
-// It supports various audio formats and applies the same practices as createSpectrogramWithSoX.
-func createSpectrogramWithFFmpeg(audioClipPath, spectrogramPath string, width int) error {
-	// Get ffmpeg path from settings
-	ffmpegBinary := conf.Setting().Realtime.Audio.FfmpegPath
-
-	// Verify ffmpeg path
-	if ffmpegBinary == "" {
-		return fmt.Errorf("ffmpeg path not set in settings")
-	}
-
-	// Set height based on width
-	height := width / 2
-	heightStr := strconv.Itoa(height)
-	widthStr := strconv.Itoa(width)
-
-	// Build ffmpeg command arguments
-	ffmpegArgs := []string{
-		"-hide_banner",
-		"-y", // answer yes to overwriting the output file if it already exists
-		"-i", audioClipPath,
-		"-lavfi", fmt.Sprintf("showspectrumpic=s=%sx%s:legend=0:gain=3:drange=100", widthStr, heightStr),
-		"-frames:v", "1", // Generate only one frame instead of animation
-		spectrogramPath,
-	}
-
-	// Determine the command based on the OS
-	var cmd *exec.Cmd
-	if runtime.GOOS == "windows" {
-		// Directly use ffmpeg command on Windows
-		cmd = exec.Command(ffmpegBinary, ffmpegArgs...)
-	} else {
-		// Prepend 'nice' to the command on Unix-like systems
-		cmd = exec.Command("nice", append([]string{"-n", "19", ffmpegBinary}, ffmpegArgs...)...)
-	}
-
-	log.Printf("ffmpeg command: %s", cmd.String())
-
-	// Capture combined output
-	var output bytes.Buffer
-	cmd.Stderr = &output
-	cmd.Stdout = &output
-
-	// Run ffmpeg command
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("ffmpeg command failed: %w\nOutput: %s", err, output.String())
-	}
-
-	return nil
-}
-
 // sanitizeContentDispositionFilename sanitizes a filename for use in Content-Disposition header
 func sanitizeContentDispositionFilename(filename string) string {
 	// Remove any characters that could cause issues in headers
@@ -104,34 +104,32 @@ func (h *Handlers) SaveSettings(c echo.Context) error {
 		h.controlChan <- "reconfigure_birdweather"
 	}
 
-	// Check if RTSP settings were included in the form and have changed
-	if hasRTSPSettings(formParams) && rtspSettingsChanged(&oldSettings, settings) {
+	// Check if RTSP settings were included in the form and have changed, or if the audio
+	// device or source list changed. Audio sources are restarted without a full restart.
+	if (hasRTSPSettings(formParams) && rtspSettingsChanged(&oldSettings, settings)) ||
+		audioDeviceSettingChanged(&oldSettings, settings) || audioSourcesChanged(&oldSettings, settings) {
 		h.SSE.SendNotification(Notification{
-			Message: "Reconfiguring RTSP sources...",
+			Message: "Reconfiguring audio sources...",
 			Type:    "info",
 		})
-		h.controlChan <- "reconfigure_rtsp_sources"
-	}
-
-	// Check if audio device settings have changed
-	if audioDeviceSettingChanged(&oldSettings, settings) {
-		h.SSE.SendNotification(Notification{
-			Message: "Audio device changed. Please restart the application for the changes to take effect.",
-			Type:    "warning",
-		})
+		h.controlChan <- "reconfigure_audio_sources"
 	}
 
 	// Check the authentication settings and update if needed
 	h.updateAuthenticationSettings(settings)
 
-	// Check if audio equalizer settings have changed
-	if equalizerSettingsChanged(oldSettings.Realtime.Audio.Equalizer, settings.Realtime.Audio.Equalizer) {
-		if err := myaudio.UpdateFilterChain(settings); err != nil {
+	// Check if audio equalizer, noise reduction, AGC or per-source filter settings have changed,
+	// the filter chains of running sources are updated without restarting them
+	if equalizerSettingsChanged(oldSettings.Realtime.Audio.Equalizer, settings.Realtime.Audio.Equalizer) ||
+		settings.Realtime.Audio.NoiseReduction != oldSettings.Realtime.Audio.NoiseReduction ||
+		settings.Realtime.Audio.AGC != oldSettings.Realtime.Audio.AGC ||
+		audioSourcesChanged(&oldSettings, settings) {
+		if err := myaudio.UpdateFilterChains(&settings.Realtime); err != nil {
 			h.SSE.SendNotification(Notification{
-				Message: fmt.Sprintf("Error updating audio EQ filters: %v", err),
+				Message: fmt.Sprintf("Error updating audio filters: %v", err),
 				Type:    "error",
 			})
-			return h.NewHandlerError(err, "Failed to update audio EQ filters", http.StatusInternalServerError)
+			return h.NewHandlerError(err, "Failed to update audio filters", http.StatusInternalServerError)
 		}
 	}
 
@@ -646,6 +644,16 @@ func birdnetSettingsChanged(oldSettings, currentSettings *conf.Settings) bool {
 		return true
 	}
 
+	// Check for changes in the number of BirdNET interpreters
+	if oldSettings.BirdNET.Interpreters != currentSettings.BirdNET.Interpreters {
+		return true
+	}
+
+	// Check for changes in the threads of each BirdNET interpreter
+	if !reflect.DeepEqual(oldSettings.BirdNET.InterpreterThreads, currentSettings.BirdNET.InterpreterThreads) {
+		return true
+	}
+
 	// Check for changes in BirdNET model path
 	if oldSettings.BirdNET.ModelPath != currentSettings.BirdNET.ModelPath {
 		return true
@@ -669,6 +677,11 @@ func audioDeviceSettingChanged(oldSettings, currentSettings *conf.Settings) bool
 	return oldSettings.Realtime.Audio.Source != currentSettings.Realtime.Audio.Source
 }
 
+// audioSourcesChanged checks if the unified audio source list has been modified
+func audioSourcesChanged(oldSettings, currentSettings *conf.Settings) bool {
+	return !reflect.DeepEqual(oldSettings.Realtime.Sources, currentSettings.Realtime.Sources)
+}
+
 // rtspSettingsChanged checks if RTSP settings have been modified
 func rtspSettingsChanged(oldSettings, currentSettings *conf.Settings) bool {
 	// Check for changes in RTSP transport protocol
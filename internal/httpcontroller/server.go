@@ -155,6 +155,7 @@ func (s *Server) initializeServer() {
 		s.BirdImageCache,
 		s.SunCalc,
 		s.controlChan,
+		s.Processor,
 		log.Default(),
 	)
 
@@ -160,6 +160,12 @@ func (m *mockStore) GetHourlyDetections(date, hour string, duration, limit, offs
 func (m *mockStore) CountSpeciesDetections(species, date, hour string, duration int) (int64, error) {
 	return 0, nil
 }
+func (m *mockStore) SearchNotesBySignal(query string, filter *datastore.SignalFilter, sortAscending bool, limit, offset int) ([]datastore.Note, error) {
+	return nil, nil
+}
+func (m *mockStore) CountSignalSearchResults(query string, filter *datastore.SignalFilter) (int64, error) {
+	return 0, nil
+}
 func (m *mockStore) CountSearchResults(query string) (int64, error)         { return 0, nil }
 func (m *mockStore) Transaction(fc func(tx *gorm.DB) error) error           { return nil }
 func (m *mockStore) LockNote(noteID string) error                           { return nil }
@@ -182,6 +188,22 @@ func (m *mockStore) GetHourlyAnalyticsData(date, species string) ([]datastore.Ho
 func (m *mockStore) GetSpeciesSummaryData() ([]datastore.SpeciesSummaryData, error) {
 	return []datastore.SpeciesSummaryData{}, nil
 }
+func (m *mockStore) SaveAudioSegment(segment *datastore.AudioSegment) error {
+	return nil
+}
+func (m *mockStore) GetAudioSegments(source string, start, end time.Time) ([]datastore.AudioSegment, error) {
+	return []datastore.AudioSegment{}, nil
+}
+func (m *mockStore) GetAudioSegmentsBefore(before time.Time, limit int) ([]datastore.AudioSegment, error) {
+	return []datastore.AudioSegment{}, nil
+}
+func (m *mockStore) DeleteAudioSegment(id uint) error {
+	return nil
+}
+func (m *mockStore) GetNoteEmbedding(noteID string) ([]float32, error) { return nil, nil }
+func (m *mockStore) GetSimilarNotes(noteID, startDate, endDate, species string, limit int) ([]datastore.SimilarNote, error) {
+	return nil, nil
+}
 
 // mockFailingStore is a mock implementation that simulates database failures
 type mockFailingStore struct {
@@ -0,0 +1,111 @@
+package myaudio
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+const (
+	// agcBlockSize is the number of samples between gain updates, 10 ms at 48 kHz
+	agcBlockSize = conf.SampleRate / 100
+
+	// agcLevelWindow is the time constant in seconds of the level measured by the AGC
+	agcLevelWindow = 0.5
+
+	// agcGateLevel is the level in dBFS below which the gain is held, it keeps the AGC from
+	// amplifying digital silence and disconnected inputs up to the maximum gain
+	agcGateLevel = -80
+)
+
+// automaticGain normalizes the level of an audio stream into a target range. The RMS level is
+// followed with a smoothed envelope and the gain is changed only while the amplified level is
+// outside of the target range, so the dynamics of calls within the range are kept.
+type automaticGain struct {
+	targetMin, targetMax float64 // target level range in dBFS
+	minGain, maxGain     float64 // gain limits in dB
+	attackRate           float64 // per block rate with which gain falls
+	releaseRate          float64 // per block rate with which gain rises
+	levelRate            float64 // per block rate of the level envelope
+
+	envelope float64 // smoothed mean square of the input
+	measured bool    // true when envelope holds a level
+	gain     float64 // current gain in dB
+	linear   float64 // linear gain applied to the next sample
+	step     float64 // change of the linear gain per sample
+	sum      float64 // sum of squares of the current block
+	count    int     // number of samples in the current block
+}
+
+// newAutomaticGain creates an automatic gain control with the settings, the gain starts at 0 dB
+func newAutomaticGain(settings *conf.AGCSettings, sampleRate float64) (*automaticGain, error) {
+	if settings.TargetMin > settings.TargetMax {
+		return nil, fmt.Errorf("AGC target minimum %v dBFS is above the maximum %v dBFS", settings.TargetMin, settings.TargetMax)
+	}
+	if settings.MaxGain < 0 || settings.MaxAttenuation < 0 {
+		return nil, fmt.Errorf("AGC gain limits must not be negative")
+	}
+	if settings.Attack <= 0 || settings.Release <= 0 {
+		return nil, fmt.Errorf("AGC attack and release times must be positive")
+	}
+
+	// Rates of one pole smoothing for the given time constants at the block rate
+	block := agcBlockSize / sampleRate
+	return &automaticGain{
+		targetMin:   settings.TargetMin,
+		targetMax:   settings.TargetMax,
+		minGain:     -settings.MaxAttenuation,
+		maxGain:     settings.MaxGain,
+		attackRate:  1 - math.Exp(-block/settings.Attack),
+		releaseRate: 1 - math.Exp(-block/settings.Release),
+		levelRate:   1 - math.Exp(-block/agcLevelWindow),
+		linear:      1,
+	}, nil
+}
+
+// process applies the gain to samples in place and adapts the gain to their level
+func (a *automaticGain) process(samples []float64) {
+	for i, x := range samples {
+		a.sum += x * x
+		a.count++
+		samples[i] = x * a.linear
+		a.linear += a.step
+		if a.count == agcBlockSize {
+			a.update()
+		}
+	}
+}
+
+// update adapts the gain at the end of a block, the new gain is ramped in over the next block
+func (a *automaticGain) update() {
+	meanSquare := a.sum / float64(a.count)
+	a.sum, a.count = 0, 0
+
+	if a.measured {
+		a.envelope += a.levelRate * (meanSquare - a.envelope)
+	} else {
+		a.envelope = meanSquare
+		a.measured = true
+	}
+
+	// Full scale is 1, the level of a full scale sine is -3 dBFS
+	level := 10 * math.Log10(a.envelope+1e-20)
+	target := a.gain
+	if level > agcGateLevel {
+		switch {
+		case level+a.gain > a.targetMax:
+			target = a.targetMax - level
+		case level+a.gain < a.targetMin:
+			target = a.targetMin - level
+		}
+		target = max(min(target, a.maxGain), a.minGain)
+	}
+
+	rate := a.releaseRate
+	if target < a.gain {
+		rate = a.attackRate
+	}
+	a.gain += rate * (target - a.gain)
+	a.step = (math.Pow(10, a.gain/20) - a.linear) / agcBlockSize
+}
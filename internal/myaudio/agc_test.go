@@ -0,0 +1,116 @@
+package myaudio
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// testAGCSettings returns automatic gain control settings used in tests
+func testAGCSettings() *conf.AGCSettings {
+	return &conf.AGCSettings{Enabled: true, TargetMin: -35, TargetMax: -25, MaxGain: 30, MaxAttenuation: 20, Attack: 0.5, Release: 1}
+}
+
+// sine returns seconds of a 1 kHz sine with the amplitude
+func sine(seconds int, amplitude float64) []float64 {
+	samples := make([]float64, seconds*conf.SampleRate)
+	for i := range samples {
+		samples[i] = amplitude * math.Sin(2*math.Pi*1000*float64(i)/conf.SampleRate)
+	}
+	return samples
+}
+
+// rmsLevel returns the RMS level of samples in dBFS
+func rmsLevel(samples []float64) float64 {
+	var sum float64
+	for _, s := range samples {
+		sum += s * s
+	}
+	return 10 * math.Log10(sum/float64(len(samples)))
+}
+
+func TestAutomaticGainNormalizesLevel(t *testing.T) {
+	tests := []struct {
+		name      string
+		amplitude float64
+	}{
+		{"quiet source is amplified", 0.002},  // -57 dBFS
+		{"loud source is attenuated", 0.5},    // -9 dBFS
+		{"level in range is unchanged", 0.03}, // -33.5 dBFS
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agc, err := newAutomaticGain(testAGCSettings(), conf.SampleRate)
+			require.NoError(t, err)
+
+			samples := sine(20, tt.amplitude)
+			agc.process(samples)
+
+			// After settling the level is within the target range
+			level := rmsLevel(samples[15*conf.SampleRate:])
+			assert.GreaterOrEqual(t, level, -35.5)
+			assert.LessOrEqual(t, level, -24.5)
+			assert.InDelta(t, level-rmsLevel(sine(1, tt.amplitude)), agc.gain, 0.5)
+		})
+	}
+}
+
+func TestAutomaticGainLimits(t *testing.T) {
+	agc, err := newAutomaticGain(testAGCSettings(), conf.SampleRate)
+	require.NoError(t, err)
+
+	// Very quiet audio is amplified up to the maximum gain
+	agc.process(sine(20, 0.0002))
+	assert.InDelta(t, 30, agc.gain, 0.5)
+
+	// Silence holds the gain instead of raising it further
+	agc.process(make([]float64, 5*conf.SampleRate))
+	assert.InDelta(t, 30, agc.gain, 0.5)
+}
+
+func TestAutomaticGainAttackRelease(t *testing.T) {
+	settings := testAGCSettings()
+	settings.Attack, settings.Release = 0.2, 5
+	agc, err := newAutomaticGain(settings, conf.SampleRate)
+	require.NoError(t, err)
+
+	// A loud onset is turned down quickly
+	agc.process(sine(2, 0.5))
+	assert.Less(t, agc.gain, -14.0)
+
+	// The gain rises slowly when the audio gets quiet again
+	agc.process(sine(2, 0.002))
+	assert.Less(t, agc.gain, 0.0)
+}
+
+func TestNewAutomaticGainErrors(t *testing.T) {
+	settings := testAGCSettings()
+	settings.TargetMin = -20
+	_, err := newAutomaticGain(settings, conf.SampleRate)
+	assert.Error(t, err)
+
+	settings = testAGCSettings()
+	settings.Attack = 0
+	_, err = newAutomaticGain(settings, conf.SampleRate)
+	assert.Error(t, err)
+}
+
+func TestAppliedGain(t *testing.T) {
+	t.Cleanup(func() { removeFilterChain("agc-mic") })
+
+	assert.Nil(t, appliedGain("agc-mic"))
+	require.NoError(t, UpdateFilterChain("agc-mic", &conf.AudioFilterSettings{AGC: *testAGCSettings()}))
+
+	// The gain applied to a quiet source is reported with its audio level
+	for i := 0; i < 10; i++ {
+		audio := sinePCM(1000, 0.002)
+		require.NoError(t, ApplyFilters("agc-mic", audio))
+	}
+	level := calculateAudioLevel(sinePCM(1000, 0.002), "agc-mic", "Garden")
+	require.NotNil(t, level.Gain)
+	assert.Greater(t, *level.Gain, 20.0)
+}
@@ -24,10 +24,9 @@ const (
 )
 
 var (
-	overlapSize     int                               // overlapSize is the number of bytes to overlap between chunks
-	readSize        int                               // readSize is the number of bytes to read from the ring buffer
 	analysisBuffers map[string]*ringbuffer.RingBuffer // analysisBuffers is a map to store ring buffers for each audio source
 	prevData        map[string][]byte                 // prevData is a map to store the previous data for each audio source
+	readOffsets     map[string]int64                  // readOffsets holds the number of bytes read from the buffer of each audio source
 	abMutex         sync.RWMutex                      // Mutex to protect access to the analysisBuffers and prevData maps
 	warningCounter  map[string]int
 )
@@ -37,9 +36,23 @@ func init() {
 	warningCounter = make(map[string]int)
 }
 
-// SecondsToBytes converts overlap in seconds to bytes
+// SecondsToBytes converts a duration in seconds to bytes of captured audio, rounded down to whole samples
 func SecondsToBytes(seconds float64) int {
-	return int(seconds * float64(conf.SampleRate) * float64(conf.BitDepth/8))
+	return int(seconds*float64(conf.SampleRate)) * (conf.BitDepth / 8)
+}
+
+// analysisWindow returns the size in bytes of the captured audio analyzed by the active model and
+// the number of bytes between the starts of consecutive windows with the configured overlap
+func analysisWindow() (size, step int) {
+	model := birdnet.ActiveModel()
+	return SecondsToBytes(model.WindowSeconds), SecondsToBytes(model.Step(conf.Setting().BirdNET.Overlap))
+}
+
+// AnalysisBufferCapacity returns the capacity of an analysis buffer, which holds three analysis
+// windows of the active model
+func AnalysisBufferCapacity() int {
+	size, _ := analysisWindow()
+	return 3 * size
 }
 
 // AllocateAnalysisBuffer initializes a ring buffer for a single audio source.
@@ -53,14 +66,6 @@ func AllocateAnalysisBuffer(capacity int, source string) error {
 		return fmt.Errorf("empty source name provided")
 	}
 
-	settings := conf.Setting()
-
-	// Set overlapSize based on user setting in seconds if not already set
-	if overlapSize == 0 {
-		overlapSize = SecondsToBytes(settings.BirdNET.Overlap)
-		readSize = conf.BufferSize - overlapSize
-	}
-
 	// Initialize the analysis ring buffer
 	ab := ringbuffer.New(capacity)
 	if ab == nil {
@@ -83,12 +88,16 @@ func AllocateAnalysisBuffer(capacity int, source string) error {
 	if prevData == nil {
 		prevData = make(map[string][]byte)
 	}
+	if readOffsets == nil {
+		readOffsets = make(map[string]int64)
+	}
 	if warningCounter == nil {
 		warningCounter = make(map[string]int)
 	}
 
 	analysisBuffers[source] = ab
 	prevData[source] = nil
+	readOffsets[source] = 0
 	warningCounter[source] = 0
 
 	// Log the buffer creation for debugging
@@ -113,11 +122,61 @@ func RemoveAnalysisBuffer(source string) error {
 	// Remove from all maps
 	delete(analysisBuffers, source)
 	delete(prevData, source)
+	delete(readOffsets, source)
 	delete(warningCounter, source)
 
 	return nil
 }
 
+// resetAnalysisBuffer discards the unread audio of a source and restarts the count of bytes read,
+// used when a source starts its audio over on a new clock
+func resetAnalysisBuffer(source string) error {
+	abMutex.Lock()
+	defer abMutex.Unlock()
+
+	ab, exists := analysisBuffers[source]
+	if !exists {
+		return fmt.Errorf("no ring buffer found for source: %s", source)
+	}
+	ab.Reset()
+	prevData[source] = nil
+	readOffsets[source] = 0
+	return nil
+}
+
+// ResizeAnalysisBuffers reallocates the analysis buffers of all sources when the analysis window of
+// the active model has changed, such as after a model reload. Unread audio is kept, audio held for
+// an incomplete window of the previous model is discarded.
+func ResizeAnalysisBuffers() {
+	capacity := AnalysisBufferCapacity()
+
+	abMutex.Lock()
+	defer abMutex.Unlock()
+
+	for source, ab := range analysisBuffers {
+		if ab.Capacity() == capacity {
+			continue
+		}
+
+		unread := ab.Bytes()
+		if len(unread) > capacity {
+			// Skip the oldest audio which does not fit, it is counted as read
+			readOffsets[source] += int64(len(unread) - capacity)
+			unread = unread[len(unread)-capacity:]
+		}
+		resized := ringbuffer.New(capacity)
+		if _, err := resized.Write(unread); err != nil {
+			log.Printf("⚠️ Failed to keep unread audio of source %s when resizing its analysis buffer: %v", source, err)
+			readOffsets[source] += int64(len(unread))
+			resized.Reset()
+		}
+
+		analysisBuffers[source] = resized
+		prevData[source] = nil
+		log.Printf("🔄 Resized analysis buffer of source %s from %d to %d bytes", source, ab.Capacity(), capacity)
+	}
+}
+
 // InitAnalysisBuffers initializes the ring buffers for each audio source with a given capacity.
 // It returns an error if memory allocation fails or if the inputs are invalid.
 func InitAnalysisBuffers(capacity int, sources []string) error {
@@ -155,12 +214,14 @@ func CleanupAnalysisBuffers() {
 		ab.Reset()
 		delete(analysisBuffers, source)
 		delete(prevData, source)
+		delete(readOffsets, source)
 		delete(warningCounter, source)
 	}
 
 	// Reset the maps
 	analysisBuffers = make(map[string]*ringbuffer.RingBuffer)
 	prevData = make(map[string][]byte)
+	readOffsets = make(map[string]int64)
 	warningCounter = make(map[string]int)
 }
 
@@ -174,6 +235,9 @@ func WriteToAnalysisBuffer(stream string, data []byte) error {
 		return fmt.Errorf("no analysis buffer found for stream: %s", stream)
 	}
 
+	// Reduce background noise before analysis if enabled
+	data = reduceAnalysisNoise(stream, data)
+
 	// Get buffer capacity information
 	capacity := ab.Capacity()
 	if capacity == 0 {
@@ -192,7 +256,10 @@ func WriteToAnalysisBuffer(stream string, data []byte) error {
 
 	// Write data to the ring buffer
 	for retry := 0; retry < maxRetries; retry++ {
-		abMutex.Lock()           // Lock the mutex to prevent other goroutines from reading or writing to the buffer
+		abMutex.Lock() // Lock the mutex to prevent other goroutines from reading or writing to the buffer
+		if current, exists := analysisBuffers[stream]; exists {
+			ab = current // The buffer may have been reallocated for another model
+		}
 		n, err := ab.Write(data) // Write data to the ring buffer
 		abMutex.Unlock()         // Unlock the mutex
 
@@ -230,21 +297,45 @@ func WriteToAnalysisBuffer(stream string, data []byte) error {
 	return fmt.Errorf("failed to write to analysis buffer for stream %s after %d attempts", stream, maxRetries)
 }
 
+// analysisBufferFree returns the number of bytes which can be written to the analysis buffer
+// of a stream without overwriting unread data
+func analysisBufferFree(stream string) (int, error) {
+	abMutex.RLock()
+	defer abMutex.RUnlock()
+
+	ab, exists := analysisBuffers[stream]
+	if !exists {
+		return 0, fmt.Errorf("no analysis buffer found for stream: %s", stream)
+	}
+	return ab.Free(), nil
+}
+
 // ReadFromAnalysisBuffer reads a sliding chunk of audio data from the ring buffer for a given stream.
 func ReadFromAnalysisBuffer(stream string) ([]byte, error) {
+	data, _, err := readAnalysisWindow(stream)
+	return data, err
+}
+
+// readAnalysisWindow reads a sliding chunk of audio data from the ring buffer for a given stream
+// like ReadFromAnalysisBuffer, and returns the offset in bytes of the start of the chunk in the
+// audio written to the buffer
+func readAnalysisWindow(stream string) ([]byte, int64, error) {
 	abMutex.Lock()
 	defer abMutex.Unlock()
 
 	// Get the ring buffer for the given stream
 	ab, exists := analysisBuffers[stream]
 	if !exists {
-		return nil, fmt.Errorf("no analysis buffer found for stream: %s", stream)
+		return nil, 0, fmt.Errorf("no analysis buffer found for stream: %s", stream)
 	}
 
+	// The window and overlap follow the active model and settings
+	windowSize, readSize := analysisWindow()
+
 	// Calculate the number of bytes written to the buffer
 	bytesWritten := ab.Length() - ab.Free()
 	if bytesWritten < readSize {
-		return nil, nil
+		return nil, 0, nil
 	}
 
 	// Create a slice to hold the data we're going to read
@@ -252,26 +343,40 @@ func ReadFromAnalysisBuffer(stream string) ([]byte, error) {
 	// Read data from the ring buffer
 	bytesRead, err := ab.Read(data)
 	if err != nil {
-		return nil, fmt.Errorf("error reading %d bytes from analysis buffer for stream: %s", bytesRead, stream)
+		return nil, 0, fmt.Errorf("error reading %d bytes from analysis buffer for stream: %s", bytesRead, stream)
 	}
+	readOffsets[stream] += int64(bytesRead)
 
-	// Join with previous data to ensure we're processing chunkSize bytes
+	// Join with previous data to ensure we're processing windowSize bytes
 	var fullData []byte
 	prevData[stream] = append(prevData[stream], data...)
 	fullData = prevData[stream]
-	if len(fullData) >= conf.BufferSize {
+	offset := readOffsets[stream] - int64(len(fullData))
+	if len(fullData) >= windowSize {
 		// Update prevData for the next iteration
 		prevData[stream] = fullData[readSize:]
-		fullData = fullData[:conf.BufferSize]
+		fullData = fullData[:windowSize]
 	} else {
 		// If there isn't enough data even after appending, update prevData and return nil
 		prevData[stream] = fullData
-		return nil, nil
+		return nil, 0, nil
 
 	}
 
 	//log.Printf("✅ Read %d bytes from analysis buffer for stream %s", len(fullData), stream)
-	return fullData, nil
+	return fullData, offset, nil
+}
+
+// analysisStartTime returns the start time of an analyzed chunk of a source at the given byte
+// offset in the audio written to its analysis buffer. Sources with a synthetic clock may be
+// written ahead of the analysis, so their chunks are timed by their position in the audio.
+// Chunks of live sources are timed by the wall clock minus the pre-recording time.
+func analysisStartTime(source string, offset int64, preRecordingTime time.Duration) time.Time {
+	if origin, ok := SourceClockOrigin(source); ok {
+		samples := offset / int64(conf.BitDepth/8)
+		return origin.Add(time.Duration(samples) * time.Second / time.Duration(conf.SampleRate))
+	}
+	return time.Now().Add(preRecordingTime)
 }
 
 // AnalysisBufferMonitor monitors the buffer and processes audio data when enough data is present.
@@ -288,6 +393,9 @@ func AnalysisBufferMonitor(wg *sync.WaitGroup, bn *birdnet.BirdNET, quitChan cha
 	ticker := time.NewTicker(pollInterval)
 	defer ticker.Stop()
 
+	// endSent is set once the end of the audio of the source has been sent to the processor
+	endSent := false
+
 	for {
 		select {
 		case <-quitChan:
@@ -295,28 +403,36 @@ func AnalysisBufferMonitor(wg *sync.WaitGroup, bn *birdnet.BirdNET, quitChan cha
 			return
 
 		case <-ticker.C: // Wait for the next tick
-			data, err := ReadFromAnalysisBuffer(source)
+			// The audio of a finished replay has been analyzed in full when no window is left
+			// to read after its end, checked before reading as audio is written until the end
+			ended := SourceEnded(source)
+			data, offset, err := readAnalysisWindow(source)
 			if err != nil {
 				log.Printf("❌ Buffer read error: %v", err)
 				time.Sleep(1 * time.Second) // Wait for 1 second before trying again
 				continue
 			}
-			// if buffer has 3 seconds of data, process it
-			if len(data) == conf.BufferSize {
+			// if buffer has a full analysis window of data, process it
+			if len(data) > 0 {
 
 				/*if err := validatePCMData(data); err != nil {
 					log.Printf("Invalid PCM data for source %s: %v", source, err)
 					continue
 				}*/
 
-				startTime := time.Now().Add(preRecordingTime)
+				// Sources replaying files are timed by the position of the chunk in the file
+				startTime := analysisStartTime(source, offset, preRecordingTime)
 				// DEBUG
 				//log.Printf("Processing data for source %s", source)
 				err := ProcessData(bn, data, startTime, source)
 				if err != nil {
 					log.Printf("❌ Error processing data for source %s: %v", source, err)
 				}
+			} else if ended && !endSent {
+				endSent = sendEndOfSource(source, quitChan)
 			}
+			// A restarted replay ends again
+			endSent = endSent && ended
 		}
 	}
 }
@@ -327,8 +443,8 @@ func AnalysisBufferMonitor(wg *sync.WaitGroup, bn *birdnet.BirdNET, quitChan cha
 		return fmt.Errorf("invalid PCM data size: %d", len(data))
 	}
 
-	// Expected length for 3 seconds of audio data
-	expectedLength := 48000 * 2 * 3 // 48000 samples/sec * 2 bytes/sample * 3 seconds
+	// Expected length of one analysis window of the active model
+	expectedLength := SecondsToBytes(birdnet.ActiveModel().WindowSeconds)
 	if len(data) != expectedLength {
 		return fmt.Errorf("unexpected PCM data length: %d (expected %d)", len(data), expectedLength)
 	}
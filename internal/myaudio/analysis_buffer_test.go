@@ -0,0 +1,36 @@
+package myaudio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestResizeAnalysisBuffers tests that analysis buffers allocated for the window of another model
+// are reallocated for the active model without losing unread audio
+func TestResizeAnalysisBuffers(t *testing.T) {
+	const id = "resize_test"
+	windowSize, step := analysisWindow()
+	require.NoError(t, AllocateAnalysisBuffer(2*windowSize, id))
+	defer func() { assert.NoError(t, RemoveAnalysisBuffer(id)) }()
+
+	// Audio of an incomplete window is held back, the rest of the written audio is unread
+	unread := make([]byte, windowSize)
+	for i := range unread {
+		unread[i] = byte(i)
+	}
+	require.NoError(t, WriteToAnalysisBuffer(id, unread))
+	abMutex.Lock()
+	prevData[id] = make([]byte, step)
+	abMutex.Unlock()
+
+	ResizeAnalysisBuffers()
+
+	abMutex.RLock()
+	defer abMutex.RUnlock()
+	assert.Equal(t, AnalysisBufferCapacity(), analysisBuffers[id].Capacity())
+	assert.Equal(t, unread, analysisBuffers[id].Bytes())
+	assert.Nil(t, prevData[id])
+	assert.Zero(t, readOffsets[id])
+}
@@ -0,0 +1,531 @@
+package myaudio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-audio/audio"
+	"github.com/go-audio/wav"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+const (
+	// archiveQueueSize is the number of audio blocks queued per source while a segment is written
+	archiveQueueSize = 512
+
+	// archiveMaxDrift is the largest gap or overlap between consecutive audio blocks which is
+	// tolerated within a segment, larger discontinuities start a new segment
+	archiveMaxDrift = time.Second
+)
+
+// errArchiveRangeRead is returned by the decoder callback when the needed part of a segment has been read
+var errArchiveRangeRead = errors.New("archive range read")
+
+// archiveDirPattern matches characters which are replaced in archive directory names
+var archiveDirPattern = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// archiveRecorders holds the active archive recorders by source ID, audio written to the
+// capture buffer of a source is also passed to its recorder
+var archiveRecorders sync.Map // source ID -> *archiveRecorder
+
+// ArchiveIndex is the datastore interface needed to index archive segments
+type ArchiveIndex interface {
+	SaveAudioSegment(segment *datastore.AudioSegment) error
+}
+
+// Archiver records the audio of sources continuously into fixed-length segment files and indexes
+// the segments in the datastore
+type Archiver struct {
+	settings *conf.AudioSettings
+	index    ArchiveIndex
+
+	mu        sync.Mutex
+	recorders map[string]*archiveRecorder
+}
+
+// NewArchiver creates a new archiver using the archive settings of the audio settings
+func NewArchiver(settings *conf.AudioSettings, index ArchiveIndex) *Archiver {
+	return &Archiver{
+		settings:  settings,
+		index:     index,
+		recorders: make(map[string]*archiveRecorder),
+	}
+}
+
+// Start starts recording the audio of a source, starting a source which is already recorded is a no-op
+func (a *Archiver) Start(sourceID string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, exists := a.recorders[sourceID]; exists {
+		return
+	}
+
+	r := &archiveRecorder{
+		sourceID: sourceID,
+		archiver: a,
+		blocks:   make(chan archiveBlock, archiveQueueSize),
+		done:     make(chan struct{}),
+	}
+	a.recorders[sourceID] = r
+	archiveRecorders.Store(sourceID, r)
+
+	go r.run()
+	log.Printf("🗄️ Archive recording started for source %s", sourceID)
+}
+
+// Stop stops recording a source and finalizes its current segment
+func (a *Archiver) Stop(sourceID string) {
+	a.mu.Lock()
+	r, exists := a.recorders[sourceID]
+	delete(a.recorders, sourceID)
+	a.mu.Unlock()
+
+	if !exists {
+		return
+	}
+
+	archiveRecorders.Delete(sourceID)
+	r.close()
+	<-r.done
+	log.Printf("🗄️ Archive recording stopped for source %s", sourceID)
+}
+
+// StopAll stops recording all sources
+func (a *Archiver) StopAll() {
+	a.mu.Lock()
+	ids := make([]string, 0, len(a.recorders))
+	for id := range a.recorders {
+		ids = append(ids, id)
+	}
+	a.mu.Unlock()
+
+	for _, id := range ids {
+		a.Stop(id)
+	}
+}
+
+// archiveAudio passes audio written to the capture buffer of a source to its archive recorder
+func archiveAudio(sourceID string, data []byte) {
+	if r, exists := archiveRecorders.Load(sourceID); exists {
+		r.(*archiveRecorder).write(data, SourceNow(sourceID))
+	}
+}
+
+// archiveBlock is a block of audio queued for archiving
+type archiveBlock struct {
+	pcm []byte
+	end time.Time // time at the end of the block
+}
+
+// archiveRecorder writes the audio of one source into segment files
+type archiveRecorder struct {
+	sourceID string
+	archiver *Archiver
+	blocks   chan archiveBlock
+	done     chan struct{}
+
+	mu      sync.RWMutex // protects closed and sending to blocks
+	closed  bool
+	dropped atomic.Int64
+
+	segment *archiveSegment // segment being written, owned by run
+}
+
+// write queues a block of audio without blocking the capture
+func (r *archiveRecorder) write(data []byte, end time.Time) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.closed {
+		return
+	}
+
+	select {
+	case r.blocks <- archiveBlock{pcm: append([]byte(nil), data...), end: end}:
+	default:
+		if r.dropped.Add(1)%100 == 1 {
+			log.Printf("⚠️ Archive queue for source %s is full, dropping audio", r.sourceID)
+		}
+	}
+}
+
+// close stops accepting audio, run finalizes the current segment after the queue is drained
+func (r *archiveRecorder) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.closed {
+		r.closed = true
+		close(r.blocks)
+	}
+}
+
+// run writes queued blocks until the recorder is closed
+func (r *archiveRecorder) run() {
+	defer close(r.done)
+
+	for block := range r.blocks {
+		if err := r.writeBlock(block); err != nil {
+			log.Printf("❌ Error writing audio archive of source %s: %v", r.sourceID, err)
+			r.abortSegment()
+		}
+	}
+	if err := r.finishSegment(); err != nil {
+		log.Printf("❌ Error finalizing audio archive segment of source %s: %v", r.sourceID, err)
+	}
+}
+
+// writeBlock writes a block of audio, splitting it at segment boundaries
+func (r *archiveRecorder) writeBlock(block archiveBlock) error {
+	const bytesPerSample = conf.BitDepth / 8
+
+	pcm := block.pcm[:len(block.pcm)/bytesPerSample*bytesPerSample]
+	start := block.end.Add(-samplesDuration(len(pcm) / bytesPerSample))
+
+	// Start a new segment if the audio does not continue the current segment
+	if r.segment != nil {
+		drift := start.Sub(r.segment.position())
+		if drift > archiveMaxDrift || drift < -archiveMaxDrift {
+			if err := r.finishSegment(); err != nil {
+				return err
+			}
+		}
+	}
+
+	for len(pcm) > 0 {
+		if r.segment == nil {
+			segment, err := r.archiver.openSegment(r.sourceID, start)
+			if err != nil {
+				return err
+			}
+			r.segment = segment
+		}
+
+		// Write up to the segment boundary
+		remaining := int(r.segment.boundary.Sub(r.segment.position()) * time.Duration(conf.SampleRate) / time.Second)
+		n := min(len(pcm), max(remaining, 0)*bytesPerSample)
+		if n > 0 {
+			if err := r.segment.encoder.Write(pcm[:n]); err != nil {
+				return err
+			}
+			r.segment.samples += n / bytesPerSample
+			pcm = pcm[n:]
+			start = r.segment.position()
+		}
+
+		if !r.segment.position().Before(r.segment.boundary) {
+			if err := r.finishSegment(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// finishSegment finalizes the current segment file and adds it to the index
+func (r *archiveRecorder) finishSegment() error {
+	segment := r.segment
+	if segment == nil {
+		return nil
+	}
+	r.segment = nil
+
+	if err := segment.encoder.Close(); err != nil {
+		os.Remove(segment.tempPath())
+		return fmt.Errorf("failed to finalize segment %s: %w", segment.path, err)
+	}
+	if segment.samples == 0 {
+		return os.Remove(segment.tempPath())
+	}
+	if err := finalizeOutput(segment.tempPath()); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(segment.path)
+	if err != nil {
+		return err
+	}
+	return r.archiver.index.SaveAudioSegment(&datastore.AudioSegment{
+		Source:    r.sourceID,
+		StartTime: segment.start,
+		EndTime:   segment.position(),
+		Path:      segment.path,
+		Size:      info.Size(),
+	})
+}
+
+// abortSegment discards the current segment after a write error
+func (r *archiveRecorder) abortSegment() {
+	if r.segment == nil {
+		return
+	}
+	r.segment.encoder.Close()
+	os.Remove(r.segment.tempPath())
+	r.segment = nil
+}
+
+// archiveSegment is a segment file being written
+type archiveSegment struct {
+	path     string
+	start    time.Time // time of the first sample
+	boundary time.Time // time at which the segment ends
+	samples  int
+	encoder  segmentEncoder
+}
+
+// position returns the time after the last written sample
+func (s *archiveSegment) position() time.Time {
+	return s.start.Add(samplesDuration(s.samples))
+}
+
+// tempPath returns the path the segment is written to until it is finalized
+func (s *archiveSegment) tempPath() string {
+	return s.path + tempExt
+}
+
+// openSegment creates a new segment file for a source starting at the given time. Segments end
+// at multiples of the segment length so segments of all sources are aligned.
+func (a *Archiver) openSegment(sourceID string, start time.Time) (*archiveSegment, error) {
+	length := time.Duration(a.settings.Archive.SegmentLength) * time.Second
+	start = start.UTC()
+
+	segment := &archiveSegment{
+		path:     ArchiveSegmentPath(a.settings.Archive.Path, sourceID, start, a.settings.Archive.Type),
+		start:    start,
+		boundary: start.Truncate(length).Add(length),
+	}
+	if err := os.MkdirAll(filepath.Dir(segment.path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	var err error
+	switch a.settings.Archive.Type {
+	case "flac":
+		segment.encoder, err = newFLACSegmentEncoder(segment.tempPath())
+	case "opus":
+		segment.encoder, err = newFFmpegSegmentEncoder(a.settings.FfmpegPath, segment.tempPath(), "opus", a.settings.Archive.Bitrate)
+	default:
+		segment.encoder, err = newWAVSegmentEncoder(segment.tempPath())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return segment, nil
+}
+
+// ArchiveSegmentPath returns the path of the segment of a source starting at the given time
+func ArchiveSegmentPath(basePath, sourceID string, start time.Time, fileType string) string {
+	start = start.UTC()
+	return filepath.Join(basePath, archiveDirName(sourceID), start.Format("2006"), start.Format("01"), start.Format("02"),
+		start.Format("20060102T150405Z")+"."+fileType)
+}
+
+// archiveDirName returns a directory name for a source ID with credentials and unsafe characters removed
+func archiveDirName(sourceID string) string {
+	if strings.Contains(sourceID, "://") {
+		sourceID = conf.SanitizeStreamUrl(sourceID)
+	}
+	return strings.Trim(archiveDirPattern.ReplaceAllString(sourceID, "_"), "_.")
+}
+
+// samplesDuration returns the duration of the given number of samples at the analysis sample rate
+func samplesDuration(samples int) time.Duration {
+	return time.Duration(samples) * time.Second / time.Duration(conf.SampleRate)
+}
+
+// segmentEncoder writes PCM data to a segment file
+type segmentEncoder interface {
+	Write(pcm []byte) error
+	Close() error
+}
+
+// wavSegmentEncoder writes segments as WAV files
+type wavSegmentEncoder struct {
+	file    *os.File
+	encoder *wav.Encoder
+}
+
+// newWAVSegmentEncoder creates a WAV file for a segment
+func newWAVSegmentEncoder(path string) (*wavSegmentEncoder, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create segment file: %w", err)
+	}
+	return &wavSegmentEncoder{
+		file:    file,
+		encoder: wav.NewEncoder(file, conf.SampleRate, conf.BitDepth, conf.NumChannels, 1),
+	}, nil
+}
+
+// Write appends PCM data to the WAV file
+func (e *wavSegmentEncoder) Write(pcm []byte) error {
+	return e.encoder.Write(&audio.IntBuffer{
+		Data:   byteSliceToInts(pcm),
+		Format: &audio.Format{SampleRate: conf.SampleRate, NumChannels: conf.NumChannels},
+	})
+}
+
+// Close finalizes the WAV header and closes the file
+func (e *wavSegmentEncoder) Close() error {
+	err := e.encoder.Close()
+	return errors.Join(err, e.file.Close())
+}
+
+// flacSegmentEncoder writes segments as FLAC files with the native encoder
+type flacSegmentEncoder struct {
+	file    *os.File
+	encoder *FLACEncoder
+}
+
+// newFLACSegmentEncoder creates a FLAC file for a segment
+func newFLACSegmentEncoder(path string) (*flacSegmentEncoder, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create segment file: %w", err)
+	}
+	encoder, err := NewFLACEncoder(file, conf.SampleRate, conf.NumChannels, nil)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &flacSegmentEncoder{file: file, encoder: encoder}, nil
+}
+
+// Write encodes PCM data into the FLAC file
+func (e *flacSegmentEncoder) Write(pcm []byte) error {
+	return e.encoder.Write(pcm)
+}
+
+// Close writes the last frame, updates the stream header and closes the file
+func (e *flacSegmentEncoder) Close() error {
+	err := e.encoder.Close()
+	return errors.Join(err, e.file.Close())
+}
+
+// ffmpegSegmentEncoder writes segments in lossy formats, such as Opus, by streaming the audio to FFmpeg
+type ffmpegSegmentEncoder struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stderr *BoundedBuffer
+}
+
+// newFFmpegSegmentEncoder starts FFmpeg encoding a segment of the given format and bitrate
+func newFFmpegSegmentEncoder(ffmpegPath, path, format, bitrate string) (*ffmpegSegmentEncoder, error) {
+	if err := validateFFmpegPath(ffmpegPath); err != nil {
+		return nil, err
+	}
+
+	settings := &conf.AudioSettings{}
+	settings.Export.Type = format
+	settings.Export.Bitrate = bitrate
+	cmd := exec.Command(ffmpegPath, append([]string{"-hide_banner", "-nostats"}, buildFFmpegArgs(path, settings, nil)...)...)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+	stderr := NewBoundedBuffer(4096)
+	cmd.Stderr = stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start FFmpeg: %w", err)
+	}
+	return &ffmpegSegmentEncoder{cmd: cmd, stdin: stdin, stderr: stderr}, nil
+}
+
+// Write passes PCM data to FFmpeg
+func (e *ffmpegSegmentEncoder) Write(pcm []byte) error {
+	if _, err := e.stdin.Write(pcm); err != nil {
+		return fmt.Errorf("failed to write PCM data to FFmpeg: %w", err)
+	}
+	return nil
+}
+
+// Close ends the input and waits for FFmpeg to finish the file
+func (e *ffmpegSegmentEncoder) Close() error {
+	e.stdin.Close()
+	if err := e.cmd.Wait(); err != nil {
+		return fmt.Errorf("FFmpeg failed: %w: %s", err, strings.TrimSpace(e.stderr.String()))
+	}
+	return nil
+}
+
+// WriteArchiveRange writes the archived audio between start and end as 16-bit PCM, decoded from
+// the given segments ordered by start time. Gaps not covered by segments are filled with silence,
+// so the output always has the exact length of the requested range.
+func WriteArchiveRange(w io.Writer, segments []datastore.AudioSegment, start, end time.Time) error {
+	total := int(end.Sub(start) * time.Duration(conf.SampleRate) / time.Second)
+	written := 0
+
+	// Decode segments without overlap to get continuous audio
+	settings := *conf.Setting()
+	settings.BirdNET.Overlap = 0
+
+	for i := range segments {
+		segment := &segments[i]
+		offset := int(segment.StartTime.Sub(start) * time.Duration(conf.SampleRate) / time.Second)
+		length := int(segment.EndTime.Sub(segment.StartTime) * time.Duration(conf.SampleRate) / time.Second)
+
+		if err := writeSilence(w, min(offset, total)-written); err != nil {
+			return err
+		}
+		written = max(written, min(offset, total))
+		if written >= total {
+			break
+		}
+
+		settings.Input.Path = segment.Path
+		index := 0
+		err := ReadAudioFileBuffered(&settings, func(chunk []float32) error {
+			// Skip samples before the output position and after the end of the segment or range
+			from := max(written-(offset+index), 0)
+			to := min(len(chunk), length-index, total-(offset+index))
+			index += len(chunk)
+			if from < to {
+				if _, err := w.Write(ConvertFloat32ToPCM16(chunk[from:to])); err != nil {
+					return err
+				}
+				written += to - from
+			}
+			if written >= total || index >= length {
+				return errArchiveRangeRead
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errArchiveRangeRead) {
+			if errors.Is(err, os.ErrNotExist) {
+				log.Printf("⚠️ Archive segment %s is missing", segment.Path)
+				continue
+			}
+			return fmt.Errorf("error reading archive segment %s: %w", segment.Path, err)
+		}
+	}
+
+	return writeSilence(w, total-written)
+}
+
+// writeSilence writes the given number of silent samples
+func writeSilence(w io.Writer, samples int) error {
+	const blockSamples = 4096
+	if samples <= 0 {
+		return nil
+	}
+	block := make([]byte, min(samples, blockSamples)*conf.BitDepth/8)
+	for samples > 0 {
+		n := min(samples, blockSamples)
+		if _, err := w.Write(block[:n*conf.BitDepth/8]); err != nil {
+			return err
+		}
+		samples -= n
+	}
+	return nil
+}
@@ -2,85 +2,150 @@ package myaudio
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"math"
+	"reflect"
+	"slices"
 	"sync"
 
 	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/myaudio/denoise"
 	"github.com/tphakala/birdnet-go/internal/myaudio/equalizer"
 )
 
-// Global variables for filter chain and mutex
+// sourceFilterChain holds the filters applied to the audio of a single source
+type sourceFilterChain struct {
+	mu        sync.Mutex               // filters keep state between blocks and process one block at a time
+	settings  conf.AudioFilterSettings // settings the chain was created from
+	gain      float64                  // linear gain applied before the equalizer
+	equalizer *equalizer.FilterChain   // equalizer filters of the source
+	agc       *automaticGain           // automatic gain control, nil if disabled
+	denoiser  *denoise.Denoiser        // noise reduction of analyzed audio, nil if disabled
+}
+
+// Filter chains of the audio sources by source ID, sources without a chain are not filtered
 var (
-	filterChain *equalizer.FilterChain
-	filterMutex sync.RWMutex
+	filterChains = make(map[string]*sourceFilterChain)
+	filterMutex  sync.RWMutex
 )
 
-// InitializeFilterChain sets up the initial filter chain based on settings
-func InitializeFilterChain(settings *conf.Settings) error {
-	filterMutex.Lock()
-	defer filterMutex.Unlock()
+// newSourceFilterChain creates a filter chain from the filter settings of a source
+func newSourceFilterChain(settings *conf.AudioFilterSettings) (*sourceFilterChain, error) {
+	if settings.Gain < -conf.MaxSourceGain || settings.Gain > conf.MaxSourceGain {
+		return nil, fmt.Errorf("filter gain must be between -%d and %d dB", conf.MaxSourceGain, conf.MaxSourceGain)
+	}
 
-	// Create a new filter chain
-	filterChain = equalizer.NewFilterChain()
+	chain := &sourceFilterChain{
+		settings:  *settings,
+		gain:      math.Pow(10, settings.Gain/20),
+		equalizer: equalizer.NewFilterChain(),
+	}
+	chain.settings.Equalizer.Filters = slices.Clone(settings.Equalizer.Filters)
 
 	// If equalizer is enabled in settings, add filters
-	if settings.Realtime.Audio.Equalizer.Enabled {
-		for _, filterConfig := range settings.Realtime.Audio.Equalizer.Filters {
-			// Create and add each filter
+	if settings.Equalizer.Enabled {
+		for _, filterConfig := range settings.Equalizer.Filters {
 			filter, err := createFilter(filterConfig, float64(conf.SampleRate))
 			if err != nil {
-				return err
+				return nil, fmt.Errorf("failed to create audio EQ filter: %w", err)
 			}
 			if filter != nil {
-				if err := filterChain.AddFilter(filter); err != nil {
-					return fmt.Errorf("failed to add audio EQ filter: %w", err)
+				if err := chain.equalizer.AddFilter(filter); err != nil {
+					return nil, fmt.Errorf("failed to add audio EQ filter: %w", err)
 				}
 			}
 		}
 	}
 
-	return nil
+	if settings.AGC.Enabled {
+		agc, err := newAutomaticGain(&settings.AGC, float64(conf.SampleRate))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create automatic gain control: %w", err)
+		}
+		chain.agc = agc
+	}
+
+	// Noise reduction of exported clips only needs to be checked, clips are processed on export
+	if settings.NoiseReduction.Analysis || settings.NoiseReduction.Export {
+		denoiser, err := newDenoiser(&settings.NoiseReduction)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create noise reduction filter: %w", err)
+		}
+		if settings.NoiseReduction.Analysis {
+			chain.denoiser = denoiser
+		}
+	}
+
+	return chain, nil
 }
 
-// UpdateFilterChain updates the filter chain based on new settings
-func UpdateFilterChain(settings *conf.Settings) error {
-	// Lock the mutex to ensure thread-safety
+// UpdateFilterChain replaces the filter chain of an audio source, the new filters are applied
+// from the next block of audio on. The chain is kept if the settings did not change, so the
+// state of its filters and the learned noise profile are not lost. If the settings are invalid
+// the current chain of the source stays in use.
+func UpdateFilterChain(sourceID string, settings *conf.AudioFilterSettings) error {
 	filterMutex.Lock()
 	defer filterMutex.Unlock()
 
-	// Create a new filter chain
-	newChain := equalizer.NewFilterChain()
-
-	// If equalizer is enabled in settings, add filters
-	if settings.Realtime.Audio.Equalizer.Enabled {
-		// Iterate through each filter configuration
-		for _, filterConfig := range settings.Realtime.Audio.Equalizer.Filters {
-			// Create a new filter based on the configuration
-			filter, err := createFilter(filterConfig, float64(conf.SampleRate))
-			if err != nil {
-				return fmt.Errorf("failed to create audio EQ filter: %w", err)
-			}
-			// If filter was successfully created, add it to the new chain
-			if filter != nil {
-				if err := newChain.AddFilter(filter); err != nil {
-					return fmt.Errorf("failed to add audio EQ filter: %w", err)
-				}
-			}
-		}
+	if current, exists := filterChains[sourceID]; exists && reflect.DeepEqual(current.settings, *settings) {
+		return nil
 	}
 
-	// Replace the old filter chain with the new one
-	filterChain = newChain
+	chain, err := newSourceFilterChain(settings)
+	if err != nil {
+		return fmt.Errorf("invalid filter settings for audio source %s: %w", sourceID, err)
+	}
+	filterChains[sourceID] = chain
 	return nil
 }
 
-// ResetFilters resets the state of all filters in the current filter chain
-func ResetFilters() {
+// UpdateFilterChains updates the filter chains of all sources which have one to the current
+// settings, it is called when filter settings are changed through the settings interface
+func UpdateFilterChains(settings *conf.RealtimeSettings) error {
+	filterMutex.RLock()
+	ids := make([]string, 0, len(filterChains))
+	for id := range filterChains {
+		ids = append(ids, id)
+	}
+	filterMutex.RUnlock()
+
+	var errs []error
+	for _, id := range ids {
+		filters := settings.SourceFilters(id)
+		if err := UpdateFilterChain(id, &filters); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// removeFilterChain removes the filter chain of a source when it is released
+func removeFilterChain(sourceID string) {
 	filterMutex.Lock()
 	defer filterMutex.Unlock()
+	delete(filterChains, sourceID)
+}
+
+// getFilterChain returns the filter chain of a source, or nil if it has none
+func getFilterChain(sourceID string) *sourceFilterChain {
+	filterMutex.RLock()
+	defer filterMutex.RUnlock()
+	return filterChains[sourceID]
+}
 
-	if filterChain != nil {
-		filterChain.Reset()
+// ResetFilters resets the state of the filters of all sources
+func ResetFilters() {
+	filterMutex.RLock()
+	defer filterMutex.RUnlock()
+
+	for _, chain := range filterChains {
+		chain.mu.Lock()
+		chain.equalizer.Reset()
+		if chain.denoiser != nil {
+			chain.denoiser.Reset()
+		}
+		chain.mu.Unlock()
 	}
 }
 
@@ -114,34 +179,53 @@ func createFilter(config conf.EqualizerFilter, sampleRate float64) (*equalizer.F
 	}
 }
 
-// ApplyFilters applies the current filter chain to a byte slice of audio samples
-func ApplyFilters(samples []byte) error {
+// ApplyFilters applies the gain, equalizer and automatic gain control of the filter chain of a
+// source in place to a byte slice of audio samples, samples exceeding full scale after filtering
+// are clipped
+func ApplyFilters(sourceID string, samples []byte) error {
 	if len(samples)%2 != 0 {
 		return fmt.Errorf("invalid sample length: must be even")
 	}
 
-	filterMutex.RLock()
-	defer filterMutex.RUnlock()
-
 	// If no filters, return early
-	if filterChain.Length() == 0 {
-		return nil // No filters to apply
+	chain := getFilterChain(sourceID)
+	if chain == nil || (chain.gain == 1 && chain.equalizer.Length() == 0 && chain.agc == nil) {
+		return nil
 	}
 
 	// Convert byte slice to float64 slice
 	floatSamples := make([]float64, len(samples)/2)
 	for i := 0; i < len(samples); i += 2 {
-		floatSamples[i/2] = float64(int16(binary.LittleEndian.Uint16(samples[i:]))) / 32768.0
+		floatSamples[i/2] = float64(int16(binary.LittleEndian.Uint16(samples[i:]))) / 32768.0 * chain.gain
 	}
 
 	// Apply filters to the float samples in batch
-	filterChain.ApplyBatch(floatSamples)
+	chain.mu.Lock()
+	chain.equalizer.ApplyBatch(floatSamples)
+	if chain.agc != nil {
+		chain.agc.process(floatSamples)
+	}
+	chain.mu.Unlock()
 
 	// Convert back to byte slice
 	for i, sample := range floatSamples {
-		intSample := int16(sample * 32767.0)
+		intSample := int16(max(min(sample*32767.0, math.MaxInt16), math.MinInt16))
 		binary.LittleEndian.PutUint16(samples[i*2:], uint16(intSample))
 	}
 
 	return nil
 }
+
+// appliedGain returns the gain in dB currently applied by the automatic gain control of a
+// source, or nil if the source has no automatic gain control
+func appliedGain(sourceID string) *float64 {
+	chain := getFilterChain(sourceID)
+	if chain == nil || chain.agc == nil {
+		return nil
+	}
+
+	chain.mu.Lock()
+	gain := math.Round(chain.agc.gain*10) / 10
+	chain.mu.Unlock()
+	return &gain
+}
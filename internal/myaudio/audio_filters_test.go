@@ -0,0 +1,111 @@
+package myaudio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// highPassFilters returns filter settings with a steep high-pass filter at 1 kHz
+func highPassFilters() *conf.AudioFilterSettings {
+	return &conf.AudioFilterSettings{
+		Equalizer: conf.EqualizerSettings{
+			Enabled: true,
+			Filters: []conf.EqualizerFilter{{Type: "HighPass", Frequency: 1000, Q: 0.707, Passes: 2}},
+		},
+	}
+}
+
+func TestApplyFiltersPerSource(t *testing.T) {
+	t.Cleanup(func() {
+		removeFilterChain("filter-camera")
+		removeFilterChain("filter-garden")
+	})
+
+	require.NoError(t, UpdateFilterChain("filter-camera", highPassFilters()))
+	require.NoError(t, UpdateFilterChain("filter-garden", &conf.AudioFilterSettings{}))
+
+	// Low frequency road noise is removed from the camera but kept for the garden mic
+	camera := sinePCM(100, 0.5)
+	garden := sinePCM(100, 0.5)
+	require.NoError(t, ApplyFilters("filter-camera", camera))
+	require.NoError(t, ApplyFilters("filter-garden", garden))
+	assert.Less(t, levelOf(camera[len(camera)/2:]), levelOf(garden[len(garden)/2:])-30)
+	assert.Equal(t, sinePCM(100, 0.5), garden)
+
+	// Sources without a chain are not filtered
+	unknown := sinePCM(100, 0.5)
+	require.NoError(t, ApplyFilters("filter-unknown", unknown))
+	assert.Equal(t, sinePCM(100, 0.5), unknown)
+
+	assert.Error(t, ApplyFilters("filter-camera", make([]byte, 3)))
+}
+
+func TestApplyFiltersGain(t *testing.T) {
+	t.Cleanup(func() { removeFilterChain("filter-gain") })
+
+	require.NoError(t, UpdateFilterChain("filter-gain", &conf.AudioFilterSettings{Gain: 12}))
+	audio := sinePCM(1000, 0.1)
+	require.NoError(t, ApplyFilters("filter-gain", audio))
+	assert.InDelta(t, levelOf(sinePCM(1000, 0.1))+12, levelOf(audio), 0.1)
+
+	// Samples beyond full scale are clipped instead of wrapping around
+	require.NoError(t, UpdateFilterChain("filter-gain", &conf.AudioFilterSettings{Gain: 20}))
+	loud := sinePCM(1000, 0.5)
+	require.NoError(t, ApplyFilters("filter-gain", loud))
+	levels := MeasureSignalLevels("filter-gain", loud)
+	assert.InDelta(t, 0, levels.Peak, 0.01)
+	assert.Greater(t, levels.RMS, -3.0)
+}
+
+func TestUpdateFilterChain(t *testing.T) {
+	t.Cleanup(func() { removeFilterChain("filter-reload") })
+
+	require.NoError(t, UpdateFilterChain("filter-reload", highPassFilters()))
+	chain := getFilterChain("filter-reload")
+
+	// Unchanged settings keep the chain and the state of its filters
+	require.NoError(t, UpdateFilterChain("filter-reload", highPassFilters()))
+	assert.Same(t, chain, getFilterChain("filter-reload"))
+
+	// Invalid settings are rejected and the current chain stays in use
+	invalid := highPassFilters()
+	invalid.Equalizer.Filters[0].Type = "Notch"
+	assert.Error(t, UpdateFilterChain("filter-reload", invalid))
+	assert.Error(t, UpdateFilterChain("filter-reload", &conf.AudioFilterSettings{Gain: 60}))
+	assert.Same(t, chain, getFilterChain("filter-reload"))
+
+	// Changed settings replace the chain
+	require.NoError(t, UpdateFilterChain("filter-reload", &conf.AudioFilterSettings{Gain: 6}))
+	assert.NotSame(t, chain, getFilterChain("filter-reload"))
+	assert.Zero(t, getFilterChain("filter-reload").equalizer.Length())
+}
+
+func TestUpdateFilterChains(t *testing.T) {
+	t.Cleanup(func() {
+		removeFilterChain("filter-own")
+		removeFilterChain("filter-global")
+	})
+
+	settings := &conf.RealtimeSettings{
+		Sources: []conf.AudioSourceConfig{
+			{ID: "filter-own", Type: conf.SourceTypeRTSP, URL: "rtsp://example.com/road", Filters: highPassFilters()},
+		},
+	}
+	require.NoError(t, UpdateFilterChain("filter-own", &conf.AudioFilterSettings{}))
+	require.NoError(t, UpdateFilterChain("filter-global", &conf.AudioFilterSettings{}))
+
+	// Sources get their own filters, other sources the global equalizer
+	settings.Audio.Equalizer = conf.EqualizerSettings{
+		Enabled: true,
+		Filters: []conf.EqualizerFilter{{Type: "LowPass", Frequency: 10000, Q: 0.707, Passes: 1}},
+	}
+	require.NoError(t, UpdateFilterChains(settings))
+	assert.Equal(t, "HighPass", getFilterChain("filter-own").settings.Equalizer.Filters[0].Type)
+	assert.Equal(t, "LowPass", getFilterChain("filter-global").settings.Equalizer.Filters[0].Type)
+
+	// Only sources which are running have a chain
+	assert.Nil(t, getFilterChain("filter-stopped"))
+}
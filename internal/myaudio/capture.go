@@ -9,7 +9,6 @@ import (
 	"os"
 	"runtime"
 	"strings"
-	"sync"
 	"time"
 	"unsafe"
 
@@ -34,15 +33,13 @@ type AudioDeviceInfo struct {
 
 // AudioLevelData holds audio level data
 type AudioLevelData struct {
-	Level    int    `json:"level"`    // 0-100
-	Clipping bool   `json:"clipping"` // true if clipping is detected
-	Source   string `json:"source"`   // Source identifier (e.g., "malgo" for device, or RTSP URL)
-	Name     string `json:"name"`     // Human-readable name of the source
+	Level    int      `json:"level"`          // 0-100
+	Clipping bool     `json:"clipping"`       // true if clipping is detected
+	Source   string   `json:"source"`         // Source identifier from the audio source list (e.g., "malgo" or RTSP URL)
+	Name     string   `json:"name"`           // Human-readable name of the source
+	Gain     *float64 `json:"gain,omitempty"` // Gain in dB applied by automatic gain control, nil if disabled
 }
 
-// activeStreams keeps track of currently active RTSP streams
-var activeStreams sync.Map
-
 // ffmpegMonitor is the global FFmpeg process monitor
 var ffmpegMonitor *FFmpegMonitor
 
@@ -157,113 +154,6 @@ func SetAudioDevice(deviceName string) (string, error) {
 	return infos[index].Name(), nil
 }
 
-// ReconfigureRTSPStreams handles dynamic reconfiguration of RTSP streams
-func ReconfigureRTSPStreams(settings *conf.Settings, wg *sync.WaitGroup, quitChan, restartChan chan struct{}, audioLevelChan chan AudioLevelData) {
-	// If there are no RTSP URLs configured and FFmpeg monitor is running, stop it
-	if len(settings.Realtime.RTSP.URLs) == 0 {
-		if ffmpegMonitor != nil {
-			ffmpegMonitor.Stop()
-			ffmpegMonitor = nil
-		}
-		return
-	}
-
-	// Initialize FFmpeg monitor if not already running
-	if ffmpegMonitor == nil {
-		ffmpegMonitor = NewFFmpegMonitor()
-		ffmpegMonitor.Start()
-	}
-
-	// Get current active streams
-	currentStreams := make(map[string]bool)
-	activeStreams.Range(func(key, value interface{}) bool {
-		currentStreams[key.(string)] = true
-		return true
-	})
-
-	// Stop streams that are no longer in settings
-	for url := range currentStreams {
-		found := false
-		for _, newURL := range settings.Realtime.RTSP.URLs {
-			if url == newURL {
-				found = true
-				break
-			}
-		}
-		if !found {
-			// Stream is no longer in settings, stop it
-			if process, exists := ffmpegProcesses.Load(url); exists {
-				if p, ok := process.(*FFmpegProcess); ok {
-					// Stop the FFmpeg process first
-					p.Cleanup(url)
-					// Wait a short time for the process to fully stop
-					time.Sleep(100 * time.Millisecond)
-				}
-			}
-
-			// Mark stream as inactive before removing buffers
-			activeStreams.Delete(url)
-			log.Printf("⬇️ Stream %s removed", url)
-			// Wait a short time for any in-flight writes to complete
-			time.Sleep(100 * time.Millisecond)
-
-			// Now it's safe to remove the buffers
-			if err := RemoveAnalysisBuffer(url); err != nil {
-				log.Printf("❌ Warning: failed to remove analysis buffer for %s: %v", url, err)
-			}
-			if err := RemoveCaptureBuffer(url); err != nil {
-				log.Printf("❌ Warning: failed to remove capture buffer for %s: %v", url, err)
-			}
-		}
-	}
-
-	// Start new streams
-	for _, url := range settings.Realtime.RTSP.URLs {
-		// Check if stream is already active
-		if _, exists := activeStreams.Load(url); exists {
-			continue
-		}
-
-		abExists, cbExists := false, false //nolint:wastedassign // Need to initialize variables
-		// Check if analysis buffer exists
-		abMutex.RLock()
-		_, abExists = analysisBuffers[url]
-		abMutex.RUnlock()
-
-		// Check if capture buffer exists
-		cbMutex.RLock()
-		_, cbExists = captureBuffers[url]
-		cbMutex.RUnlock()
-
-		// Initialize analysis buffer if it doesn't exist
-		if !abExists {
-			if err := AllocateAnalysisBuffer(conf.BufferSize*3, url); err != nil {
-				log.Printf("❌ Failed to initialize analysis buffer for %s: %v", url, err)
-				continue
-			}
-		}
-
-		// Initialize capture buffer if it doesn't exist
-		if !cbExists {
-			if err := AllocateCaptureBuffer(60, conf.SampleRate, conf.BitDepth/8, url); err != nil {
-				// Clean up the ring buffer if audio buffer init fails and we just created it
-				if !abExists {
-					err := RemoveCaptureBuffer(url)
-					if err != nil {
-						log.Printf("❌ Failed to remove capture buffer for %s: %v", url, err)
-					}
-				}
-				log.Printf("❌ Failed to initialize capture buffer for %s: %v", url, err)
-				continue
-			}
-		}
-
-		// New stream, start it
-		activeStreams.Store(url, true)
-		go CaptureAudioRTSP(url, settings.Realtime.RTSP.Transport, wg, quitChan, restartChan, audioLevelChan)
-	}
-}
-
 // initializeBuffersForSource handles the initialization of analysis and capture buffers for a given source
 func initializeBuffersForSource(sourceID string) error {
 	var abExists, cbExists bool
@@ -280,14 +170,14 @@ func initializeBuffersForSource(sourceID string) error {
 
 	// Initialize analysis buffer if it doesn't exist
 	if !abExists {
-		if err := AllocateAnalysisBuffer(conf.BufferSize*3, sourceID); err != nil {
+		if err := AllocateAnalysisBuffer(AnalysisBufferCapacity(), sourceID); err != nil {
 			return fmt.Errorf("failed to initialize analysis buffer: %w", err)
 		}
 	}
 
 	// Initialize capture buffer if it doesn't exist
 	if !cbExists {
-		if err := AllocateCaptureBuffer(60, conf.SampleRate, conf.BitDepth/8, sourceID); err != nil {
+		if err := AllocateCaptureBuffer(CaptureBufferSeconds(), conf.SampleRate, conf.BitDepth/8, sourceID); err != nil {
 			// Clean up the analysis buffer if we just created it and capture buffer init fails
 			if !abExists {
 				if cleanupErr := RemoveAnalysisBuffer(sourceID); cleanupErr != nil {
@@ -301,50 +191,6 @@ func initializeBuffersForSource(sourceID string) error {
 	return nil
 }
 
-func CaptureAudio(settings *conf.Settings, wg *sync.WaitGroup, quitChan, restartChan chan struct{}, audioLevelChan chan AudioLevelData) {
-	// If no RTSP URLs and no audio device configured, return early
-	if len(settings.Realtime.RTSP.URLs) == 0 && settings.Realtime.Audio.Source == "" {
-		return
-	}
-
-	// Initialize buffers for RTSP sources
-	if len(settings.Realtime.RTSP.URLs) > 0 {
-		for _, url := range settings.Realtime.RTSP.URLs {
-			if err := initializeBuffersForSource(url); err != nil {
-				log.Printf("❌ Failed to initialize buffers for RTSP source %s: %v", url, err)
-				continue
-			}
-
-			activeStreams.Store(url, true)
-			go CaptureAudioRTSP(url, settings.Realtime.RTSP.Transport, wg, quitChan, restartChan, audioLevelChan)
-		}
-	}
-
-	// Handle sound card source if configured
-	if settings.Realtime.Audio.Source != "" {
-		// Validate audio device
-		if err := ValidateAudioDevice(settings); err != nil {
-			log.Printf("⚠️ Audio device validation failed: %v", err)
-			return
-		}
-
-		selectedSource, err := selectCaptureSource(settings)
-		if err != nil {
-			log.Printf("❌ Audio device selection failed: %v", err)
-			return
-		}
-
-		// Initialize buffers for local audio device
-		if err := initializeBuffersForSource("malgo"); err != nil {
-			log.Printf("❌ Failed to initialize buffers for device capture: %v", err)
-			return
-		}
-
-		// Device audio capture
-		go captureAudioMalgo(settings, selectedSource, wg, quitChan, restartChan, audioLevelChan)
-	}
-}
-
 // isHardwareDevice checks if the device ID indicates a hardware device
 func isHardwareDevice(decodedID string) bool {
 	// On Linux, hardware devices have IDs in the format ":X,Y"
@@ -398,11 +244,10 @@ func TestCaptureDevice(ctx *malgo.AllocatedContext, info *malgo.DeviceInfo) bool
 	return true
 }
 
-// ValidateAudioDevice checks if the configured audio source is available and working.
+// ValidateAudioDevice checks if the given audio capture device is available and working.
 // Returns an error if the device is not available or not working.
-// This function also updates the settings if the device is not valid.
-func ValidateAudioDevice(settings *conf.Settings) error {
-	if settings.Realtime.Audio.Source == "" {
+func ValidateAudioDevice(device string) error {
+	if device == "" {
 		return nil
 	}
 
@@ -419,7 +264,6 @@ func ValidateAudioDevice(settings *conf.Settings) error {
 	// Initialize malgo context
 	malgoCtx, err := malgo.InitContext([]malgo.Backend{backend}, malgo.ContextConfig{}, nil)
 	if err != nil {
-		settings.Realtime.Audio.Source = ""
 		return fmt.Errorf("failed to initialize audio context: %w", err)
 	}
 	defer malgoCtx.Uninit() //nolint:errcheck // We handle errors in the caller
@@ -427,14 +271,12 @@ func ValidateAudioDevice(settings *conf.Settings) error {
 	// Get list of capture devices
 	infos, err := malgoCtx.Devices(malgo.Capture)
 	if err != nil {
-		settings.Realtime.Audio.Source = ""
 		return fmt.Errorf("failed to get capture devices: %w", err)
 	}
 
 	// Filter to get only hardware devices to check if any are available
 	hardwareDevices := getHardwareDevices(infos)
 	if len(hardwareDevices) == 0 {
-		settings.Realtime.Audio.Source = ""
 		return fmt.Errorf("no hardware audio capture devices found")
 	}
 
@@ -445,21 +287,19 @@ func ValidateAudioDevice(settings *conf.Settings) error {
 			continue
 		}
 
-		if matchesDeviceSettings(decodedID, &infos[i], settings.Realtime.Audio.Source) {
+		if matchesDeviceSettings(decodedID, &infos[i], device) {
 			if TestCaptureDevice(malgoCtx, &infos[i]) {
 				return nil
 			}
-			settings.Realtime.Audio.Source = ""
-			return fmt.Errorf("configured audio device '%s' failed hardware test", settings.Realtime.Audio.Source)
+			return fmt.Errorf("configured audio device '%s' failed hardware test", device)
 		}
 	}
 
-	//settings.Realtime.Audio.Source = ""
-	return fmt.Errorf("configured audio device '%s' not found", settings.Realtime.Audio.Source)
+	return fmt.Errorf("configured audio device '%s' not found", device)
 }
 
-// selectCaptureSource selects and tests an appropriate capture device based on the provided settings.
-func selectCaptureSource(settings *conf.Settings) (captureSource, error) {
+// selectCaptureSource selects and tests the capture device matching the given device name or ID.
+func selectCaptureSource(settings *conf.Settings, device string) (captureSource, error) {
 	var backend malgo.Backend
 	switch runtime.GOOS {
 	case "linux":
@@ -499,7 +339,7 @@ func selectCaptureSource(settings *conf.Settings) (captureSource, error) {
 			output = fmt.Sprintf("%s, %s", output, decodedID)
 		}
 
-		if matchesDeviceSettings(decodedID, &infos[i], settings.Realtime.Audio.Source) {
+		if matchesDeviceSettings(decodedID, &infos[i], device) {
 			if TestCaptureDevice(malgoCtx, &infos[i]) {
 				fmt.Printf("%s (✅ selected)\n", output)
 				return captureSource{
@@ -514,7 +354,7 @@ func selectCaptureSource(settings *conf.Settings) (captureSource, error) {
 		fmt.Println(output)
 	}
 
-	return captureSource{}, fmt.Errorf("no working capture device found matching '%s'", settings.Realtime.Audio.Source)
+	return captureSource{}, fmt.Errorf("no working capture device found matching '%s'", device)
 }
 
 // matchesDeviceSettings checks if the device matches the settings specified by the user.
@@ -536,12 +376,9 @@ func hexToASCII(hexStr string) (string, error) {
 	return string(bytes), nil
 }
 
-func captureAudioMalgo(settings *conf.Settings, source captureSource, wg *sync.WaitGroup, quitChan, restartChan chan struct{}, audioLevelChan chan AudioLevelData) {
-	wg.Add(1)
-	defer func() {
-		wg.Done()
-	}()
-
+// captureAudioMalgo captures audio from a local capture device and writes it to the buffers of the
+// given source ID. It returns when quitChan is closed or the device needs a full restart.
+func captureAudioMalgo(settings *conf.Settings, sourceID string, source captureSource, quitChan, restartChan chan struct{}, audioLevelChan chan AudioLevelData) {
 	if settings.Debug {
 		fmt.Println("Initializing context")
 	}
@@ -575,33 +412,25 @@ func captureAudioMalgo(settings *conf.Settings, source captureSource, wg *sync.W
 	deviceConfig.Alsa.NoMMap = 1
 	deviceConfig.Capture.DeviceID = source.Pointer
 
-	// Initialize the filter chain
-	if err := InitializeFilterChain(settings); err != nil {
-		log.Printf("❌ Error initializing filter chain: %v", err)
-	}
-
 	var captureDevice *malgo.Device
 
 	onReceiveFrames := func(pSample2, pSamples []byte, framecount uint32) {
-		// Apply audio EQ filters if enabled
-		if settings.Realtime.Audio.Equalizer.Enabled {
-			err := ApplyFilters(pSamples)
-			if err != nil {
-				log.Printf("❌ Error applying audio EQ filters: %v", err)
-			}
+		// Apply the filter chain of the source
+		if err := ApplyFilters(sourceID, pSamples); err != nil {
+			log.Printf("❌ Error applying audio filters: %v", err)
 		}
 
-		err = WriteToAnalysisBuffer("malgo", pSamples)
+		err = WriteToAnalysisBuffer(sourceID, pSamples)
 		if err != nil {
 			log.Printf("❌ Error writing to analysis buffer: %v", err)
 		}
-		err = WriteToCaptureBuffer("malgo", pSamples)
+		err = WriteToCaptureBuffer(sourceID, pSamples)
 		if err != nil {
 			log.Printf("❌ Error writing to capture buffer: %v", err)
 		}
 
 		// Calculate audio level
-		audioLevelData := calculateAudioLevel(pSamples, "malgo", source.Name)
+		audioLevelData := calculateAudioLevel(pSamples, sourceID, source.Name)
 
 		// Send level to channel (non-blocking)
 		select {
@@ -778,5 +607,6 @@ func calculateAudioLevel(samples []byte, source, name string) AudioLevelData {
 		Clipping: isClipping,
 		Source:   source,
 		Name:     name,
+		Gain:     appliedGain(source),
 	}
 }
@@ -22,6 +22,8 @@ type CaptureBuffer struct {
 	bufferDuration time.Duration
 	startTime      time.Time
 	initialized    bool
+	clock          func() time.Time // returns the time at the end of the written data
+	recorded       bool             // true if the clock has been replaced for recorded audio
 	lock           sync.Mutex
 }
 
@@ -94,6 +96,15 @@ func RemoveCaptureBuffer(source string) error {
 	return nil
 }
 
+// CaptureBufferSeconds returns the length of the capture buffers, which must hold the longest
+// exported clip from the first detection until the clip is read after the detection is flushed
+func CaptureBufferSeconds() int {
+	const minSeconds = 60    // default buffer length
+	const marginSeconds = 30 // flush delay and processing lag
+	clip := &conf.Setting().Realtime.Audio.Export.Clip
+	return max(minSeconds, clip.MaxClipLength()+clip.PreRoll+marginSeconds)
+}
+
 // InitCaptureBuffers initializes the capture buffers for each capture source.
 // It returns an error if initialization fails for any source.
 func InitCaptureBuffers(durationSeconds, sampleRate, bytesPerSample int, sources []string) error {
@@ -133,9 +144,55 @@ func WriteToCaptureBuffer(source string, data []byte) error {
 	}
 
 	cb.Write(data)
+	archiveAudio(source, data)
+	analyzeSpectrum(source, data)
+	listenAudio(source, data)
+	monitorHealth(source, data)
+	return nil
+}
+
+// HasCaptureBuffer reports whether a capture buffer is allocated for a source, which is the case
+// while the source is active
+func HasCaptureBuffer(source string) bool {
+	cbMutex.RLock()
+	defer cbMutex.RUnlock()
+	_, exists := captureBuffers[source]
+	return exists
+}
+
+// SetCaptureBufferClock replaces the wall clock of the capture buffer of a source. This is used
+// for recorded audio, where the clock must return the recording time at the end of the written data.
+func SetCaptureBufferClock(source string, clock func() time.Time) error {
+	cbMutex.RLock()
+	cb, exists := captureBuffers[source]
+	cbMutex.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("no capture buffer found for source: %s", source)
+	}
+
+	cb.lock.Lock()
+	cb.clock = clock
+	cb.recorded = true
+	cb.lock.Unlock()
 	return nil
 }
 
+// isRecordedCaptureBuffer reports whether the capture buffer of a source holds recorded audio
+// running on its own clock, see SetCaptureBufferClock
+func isRecordedCaptureBuffer(source string) bool {
+	cbMutex.RLock()
+	cb, exists := captureBuffers[source]
+	cbMutex.RUnlock()
+	if !exists {
+		return false
+	}
+
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+	return cb.recorded
+}
+
 // ReadSegmentFromCaptureBuffer extracts a segment of audio data from the buffer for a given source.
 func ReadSegmentFromCaptureBuffer(source string, requestedStartTime time.Time, duration int) ([]byte, error) {
 	cbMutex.RLock()
@@ -160,6 +217,7 @@ func NewCaptureBuffer(durationSeconds, sampleRate, bytesPerSample int) *CaptureB
 		bufferSize:     alignedBufferSize,
 		bufferDuration: time.Second * time.Duration(durationSeconds),
 		initialized:    false,
+		clock:          time.Now,
 	}
 
 	return cb
@@ -171,9 +229,12 @@ func (cb *CaptureBuffer) Write(data []byte) {
 	cb.lock.Lock()
 	defer cb.lock.Unlock()
 
+	now := cb.clock()
+
 	if !cb.initialized {
-		// Initialize the buffer's start time based on the current time.
-		cb.startTime = time.Now()
+		// Initialize the buffer's start time based on the current time and the length of the data.
+		dataDuration := time.Duration(len(data)/cb.bytesPerSample) * time.Second / time.Duration(cb.sampleRate)
+		cb.startTime = now.Add(-dataDuration)
 		cb.initialized = true
 	}
 
@@ -189,7 +250,7 @@ func (cb *CaptureBuffer) Write(data []byte) {
 	// Determine if the write operation has overwritten old data.
 	if cb.writeIndex <= prevWriteIndex {
 		// If old data has been overwritten, adjust startTime to maintain accurate timekeeping.
-		cb.startTime = time.Now().Add(-cb.bufferDuration)
+		cb.startTime = now.Add(-cb.bufferDuration)
 		if conf.Setting().Realtime.Audio.Export.Debug {
 			log.Printf("Buffer wrapped during write, adjusting start time to %v", cb.startTime)
 		}
@@ -227,7 +288,7 @@ func (cb *CaptureBuffer) ReadSegment(requestedStartTime time.Time, duration int)
 		}
 
 		// Wait until the current time is past the requested end time
-		if time.Now().After(requestedEndTime) {
+		if cb.clock().After(requestedEndTime) {
 			var segment []byte
 			if startIndex < endIndex {
 				if conf.Setting().Realtime.Audio.Export.Debug {
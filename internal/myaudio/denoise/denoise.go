@@ -0,0 +1,227 @@
+// Package denoise provides an adaptive spectral noise reduction filter for streaming audio.
+//
+// Audio is processed in overlapping short-time Fourier transform frames. A noise profile is
+// estimated for each frequency bin from the frames in which the bin stays close to the
+// profile, so the profile follows changes of the background noise while louder sounds such as
+// bird calls barely affect it. Noise is then removed from each frame either by spectral
+// subtraction or by a spectral noise gate.
+package denoise
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/tphakala/birdnet-go/internal/myaudio/dsp"
+)
+
+// Noise reduction methods
+const (
+	MethodSpectral = "spectral" // spectral subtraction of the noise profile
+	MethodGate     = "gate"     // attenuation of bins which do not rise above the noise profile
+)
+
+const (
+	// frameSize is the length of the analysis frames in samples, frames overlap by half
+	frameSize = 1024
+	hopSize   = frameSize / 2
+	bins      = frameSize/2 + 1
+
+	// loudAdaptation is the factor by which the noise profile adapts slower to bins above the threshold
+	loudAdaptation = 0.1
+
+	// levelFloor is the lowest bin level in dB, it is below the quantization noise of 16-bit
+	// audio and keeps digital silence from pulling the noise profile down without bound
+	levelFloor = -100
+
+	// powerSmoothing is the per frame smoothing of the power of each bin, it reduces the variance
+	// of noise power which would otherwise let noise peaks through
+	powerSmoothing = 0.6
+
+	// gainRelease is the per frame smoothing of falling bin gains, it reduces musical noise
+	gainRelease = 0.5
+)
+
+// Denoiser removes stationary background noise from a stream of audio samples
+type Denoiser struct {
+	method    string
+	minGain   float64 // gain applied to noise, the maximum attenuation
+	threshold float64 // level above the noise profile in dB up to which bins are noise
+	alpha     float64 // per frame adaptation rate of the noise profile
+
+	window   []float64 // square root of a Hann window, used for analysis and synthesis
+	input    []float64 // samples of the frame being collected
+	overlap  []float64 // overlap-add accumulator of processed frames
+	output   []float64 // processed samples of the previous hop
+	fill     int       // number of samples in input
+	power    []float64 // smoothed power of each bin
+	profile  []float64 // noise level of each bin in dB
+	gains    []float64 // gain applied to each bin in the previous frame
+	spectrum []complex128
+	smoothed bool // true when power holds the power of previous frames
+	profiled bool // true when profile holds a noise estimate
+}
+
+// New creates a denoiser. Method is MethodSpectral or MethodGate, reduction is the maximum
+// noise attenuation in dB, threshold the level above the noise profile in dB up to which audio
+// is treated as noise and adaptation the time constant of the noise profile in seconds.
+func New(sampleRate float64, method string, reduction, threshold, adaptation float64) (*Denoiser, error) {
+	if method != MethodSpectral && method != MethodGate {
+		return nil, fmt.Errorf("unknown noise reduction method: %s", method)
+	}
+	if reduction <= 0 {
+		return nil, fmt.Errorf("noise reduction must be positive: %v", reduction)
+	}
+	if threshold < 0 {
+		return nil, fmt.Errorf("noise threshold must not be negative: %v", threshold)
+	}
+	if sampleRate <= 0 || adaptation <= 0 {
+		return nil, fmt.Errorf("invalid noise profile adaptation time %v at sample rate %v", adaptation, sampleRate)
+	}
+
+	window := dsp.HannWindow(frameSize)
+	for i := range window {
+		window[i] = math.Sqrt(window[i])
+	}
+
+	d := &Denoiser{
+		method:    method,
+		minGain:   math.Pow(10, -reduction/20),
+		threshold: threshold,
+		alpha:     min(float64(hopSize)/(adaptation*sampleRate), 1),
+		window:    window,
+		input:     make([]float64, frameSize),
+		overlap:   make([]float64, frameSize),
+		output:    make([]float64, hopSize),
+		power:     make([]float64, bins),
+		profile:   make([]float64, bins),
+		gains:     make([]float64, bins),
+		spectrum:  make([]complex128, frameSize),
+	}
+	d.Reset()
+	return d, nil
+}
+
+// Latency returns the delay of the processed audio in samples
+func (d *Denoiser) Latency() int {
+	return frameSize
+}
+
+// Process reduces the noise of the samples in place. The output is delayed by Latency samples.
+func (d *Denoiser) Process(samples []float64) {
+	for i, x := range samples {
+		d.input[d.fill] = x
+		samples[i] = d.output[d.fill-hopSize]
+		d.fill++
+		if d.fill == frameSize {
+			d.processFrame()
+			copy(d.input, d.input[hopSize:])
+			d.fill = hopSize
+		}
+	}
+}
+
+// Learn adapts the noise profile to the samples without processing them, it is used to
+// estimate the noise of a recording before it is processed
+func (d *Denoiser) Learn(samples []float64) {
+	for start := 0; start+frameSize <= len(samples); start += hopSize {
+		for i := range d.spectrum {
+			d.spectrum[i] = complex(samples[start+i]*d.window[i], 0)
+		}
+		dsp.FFT(d.spectrum)
+		for k := 0; k < bins; k++ {
+			d.adaptProfile(k, d.smoothLevel(k, d.spectrum[k]))
+		}
+		d.smoothed, d.profiled = true, true
+	}
+}
+
+// Reset clears the audio held by the denoiser, the noise profile is kept
+func (d *Denoiser) Reset() {
+	clear(d.input)
+	clear(d.overlap)
+	clear(d.output)
+	d.fill = hopSize
+	d.smoothed = false
+	for k := range d.gains {
+		d.gains[k] = 1
+	}
+}
+
+// processFrame removes the noise from the frame in the input buffer and adds it to the output
+func (d *Denoiser) processFrame() {
+	for i := range d.spectrum {
+		d.spectrum[i] = complex(d.input[i]*d.window[i], 0)
+	}
+	dsp.FFT(d.spectrum)
+
+	for k := 0; k < bins; k++ {
+		level := d.smoothLevel(k, d.spectrum[k])
+		d.adaptProfile(k, level)
+
+		var gain float64
+		switch d.method {
+		case MethodGate:
+			gain = d.minGain
+			if level > d.profile[k]+d.threshold {
+				gain = 1
+			}
+		default:
+			// Power up to the threshold above the noise profile relative to the power of the bin
+			noise := math.Pow(10, (d.profile[k]+d.threshold-level)/10)
+			gain = math.Sqrt(max(1-noise, d.minGain*d.minGain))
+		}
+
+		// Gains rise immediately to keep the onset of calls and fall smoothly
+		if gain < d.gains[k] {
+			gain = d.gains[k] + gainRelease*(gain-d.gains[k])
+		}
+		d.gains[k] = gain
+
+		d.spectrum[k] *= complex(gain, 0)
+		if k > 0 && k < frameSize/2 {
+			d.spectrum[frameSize-k] *= complex(gain, 0)
+		}
+	}
+	d.smoothed, d.profiled = true, true
+
+	// Inverse transform by conjugation
+	for i := range d.spectrum {
+		d.spectrum[i] = complex(real(d.spectrum[i]), -imag(d.spectrum[i]))
+	}
+	dsp.FFT(d.spectrum)
+
+	for i := range d.overlap {
+		d.overlap[i] += real(d.spectrum[i]) / frameSize * d.window[i]
+	}
+
+	// The first hop has received both of its overlapping frames and is complete
+	copy(d.output, d.overlap[:hopSize])
+	copy(d.overlap, d.overlap[hopSize:])
+	clear(d.overlap[hopSize:])
+}
+
+// adaptProfile updates the noise level of a bin, levels well above the profile are likely
+// not noise and adapt the profile slowly. The first frame initializes the profile.
+func (d *Denoiser) adaptProfile(k int, level float64) {
+	if !d.profiled {
+		d.profile[k] = level
+		return
+	}
+
+	alpha := d.alpha
+	if level > d.profile[k]+d.threshold {
+		alpha *= loudAdaptation
+	}
+	d.profile[k] += alpha * (level - d.profile[k])
+}
+
+// smoothLevel adds the power of a frequency bin to its smoothed power and returns the smoothed level in dB
+func (d *Denoiser) smoothLevel(k int, c complex128) float64 {
+	power := real(c)*real(c) + imag(c)*imag(c)
+	if d.smoothed {
+		d.power[k] += (1 - powerSmoothing) * (power - d.power[k])
+	} else {
+		d.power[k] = power
+	}
+	return max(10*math.Log10(d.power[k]+1e-20), levelFloor)
+}
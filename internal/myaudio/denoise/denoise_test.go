@@ -0,0 +1,106 @@
+package denoise
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSampleRate = 48000
+
+// noisyTone returns white noise with a tone added from the given second on
+func noisyTone(seconds, toneStart int, noise, tone float64) []float64 {
+	rng := rand.New(rand.NewSource(1))
+	samples := make([]float64, seconds*testSampleRate)
+	for i := range samples {
+		samples[i] = noise * rng.NormFloat64()
+		if i >= toneStart*testSampleRate {
+			samples[i] += tone * math.Sin(2*math.Pi*3000*float64(i)/testSampleRate)
+		}
+	}
+	return samples
+}
+
+// rms returns the RMS level of samples in dB
+func rms(samples []float64) float64 {
+	var sum float64
+	for _, s := range samples {
+		sum += s * s
+	}
+	return 10 * math.Log10(sum/float64(len(samples)))
+}
+
+// toneLevel returns the level in dB of the 3 kHz component of samples
+func toneLevel(samples []float64, offset int) float64 {
+	var re, im float64
+	for i, s := range samples {
+		phase := 2 * math.Pi * 3000 * float64(i+offset) / testSampleRate
+		re += s * math.Cos(phase)
+		im += s * math.Sin(phase)
+	}
+	amplitude := 2 * math.Hypot(re, im) / float64(len(samples))
+	return 20 * math.Log10(amplitude)
+}
+
+func TestDenoiserReducesNoise(t *testing.T) {
+	for _, method := range []string{MethodSpectral, MethodGate} {
+		t.Run(method, func(t *testing.T) {
+			d, err := New(testSampleRate, method, 20, 10, 1)
+			require.NoError(t, err)
+
+			input := noisyTone(8, 5, 0.01, 0.2)
+			output := append([]float64(nil), input...)
+			d.Process(output)
+
+			// The output is delayed by the latency of the denoiser
+			latency := d.Latency()
+			noiseIn := input[3*testSampleRate : 5*testSampleRate-latency]
+			noiseOut := output[3*testSampleRate+latency : 5*testSampleRate]
+			assert.Less(t, rms(noiseOut), rms(noiseIn)-12, "noise should be reduced by more than 12 dB")
+
+			// The tone is kept
+			toneIn := input[6*testSampleRate : 8*testSampleRate-latency]
+			toneOut := output[6*testSampleRate+latency : 8*testSampleRate]
+			assert.InDelta(t, toneLevel(toneIn, 6*testSampleRate), toneLevel(toneOut, 6*testSampleRate), 1)
+		})
+	}
+}
+
+func TestDenoiserLearn(t *testing.T) {
+	d, err := New(testSampleRate, MethodSpectral, 20, 10, 1)
+	require.NoError(t, err)
+
+	// With the noise profile learned beforehand the noise is reduced from the start
+	input := noisyTone(2, 2, 0.01, 0)
+	d.Learn(input)
+	output := append([]float64(nil), input...)
+	d.Process(output)
+	assert.Less(t, rms(output[d.Latency():testSampleRate/2]), rms(input[:testSampleRate/2])-10)
+}
+
+func TestDenoiserReset(t *testing.T) {
+	d, err := New(testSampleRate, MethodGate, 20, 6, 1)
+	require.NoError(t, err)
+
+	d.Process(noisyTone(1, 1, 0.01, 0))
+	d.Reset()
+
+	// Audio held before the reset is not output
+	output := make([]float64, d.Latency())
+	d.Process(output)
+	assert.Equal(t, make([]float64, d.Latency()), output)
+}
+
+func TestNewErrors(t *testing.T) {
+	_, err := New(testSampleRate, "wiener", 12, 6, 5)
+	assert.Error(t, err)
+	_, err = New(testSampleRate, MethodSpectral, 0, 6, 5)
+	assert.Error(t, err)
+	_, err = New(testSampleRate, MethodSpectral, 12, -1, 5)
+	assert.Error(t, err)
+	_, err = New(testSampleRate, MethodGate, 12, 6, 0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,51 @@
+package dsp
+
+import (
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// dft computes the discrete Fourier transform directly
+func dft(x []complex128) []complex128 {
+	n := len(x)
+	out := make([]complex128, n)
+	for k := range out {
+		for i, v := range x {
+			out[k] += v * cmplx.Exp(complex(0, -2*math.Pi*float64(k*i)/float64(n)))
+		}
+	}
+	return out
+}
+
+func TestFFT(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, n := range []int{1, 2, 8, 64, 512} {
+		x := make([]complex128, n)
+		for i := range x {
+			x[i] = complex(rng.Float64()*2-1, rng.Float64()*2-1)
+		}
+		want := dft(x)
+		FFT(x)
+		for k := range x {
+			assert.InDelta(t, real(want[k]), real(x[k]), 1e-9, "n=%d bin %d", n, k)
+			assert.InDelta(t, imag(want[k]), imag(x[k]), 1e-9, "n=%d bin %d", n, k)
+		}
+	}
+}
+
+func TestHannWindow(t *testing.T) {
+	w := HannWindow(8)
+	assert.InDelta(t, 0, w[0], 1e-12)
+	assert.InDelta(t, 1, w[4], 1e-12)
+	assert.InDelta(t, w[1], w[7], 1e-12)
+}
+
+func TestIsPowerOfTwo(t *testing.T) {
+	for n, want := range map[int]bool{0: false, 1: true, 2: true, 3: false, 1024: true, 1000: false, -4: false} {
+		assert.Equal(t, want, IsPowerOfTwo(n), "n=%d", n)
+	}
+}
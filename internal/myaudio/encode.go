@@ -71,8 +71,34 @@ func ConvertPCMToWAV(pcmData []byte, sampleRate, bitDepth, numChannels int) ([]b
 	return buf.Bytes(), nil
 }
 
+// WriteWAVHeader writes the header of a WAV file holding dataSize bytes of PCM data in the
+// analysis format, used when streaming WAV data whose length is known in advance.
+func WriteWAVHeader(w io.Writer, dataSize int) error {
+	const headerSize = 44
+	blockAlign := conf.NumChannels * conf.BitDepth / 8
+
+	header := make([]byte, headerSize)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], uint32(headerSize-8+dataSize))
+	copy(header[8:], "WAVE")
+	copy(header[12:], "fmt ")
+	binary.LittleEndian.PutUint32(header[16:], 16) // fmt chunk size
+	binary.LittleEndian.PutUint16(header[20:], 1)  // PCM
+	binary.LittleEndian.PutUint16(header[22:], uint16(conf.NumChannels))
+	binary.LittleEndian.PutUint32(header[24:], uint32(conf.SampleRate))
+	binary.LittleEndian.PutUint32(header[28:], uint32(conf.SampleRate*blockAlign))
+	binary.LittleEndian.PutUint16(header[32:], uint16(blockAlign))
+	binary.LittleEndian.PutUint16(header[34:], uint16(conf.BitDepth))
+	copy(header[36:], "data")
+	binary.LittleEndian.PutUint32(header[40:], uint32(dataSize))
+
+	_, err := w.Write(header)
+	return err
+}
+
 // SavePCMDataToWAV saves the given PCM data as a WAV file at the specified filePath.
-func SavePCMDataToWAV(filePath string, pcmData []byte) error {
+// If meta is set, the detection metadata is added as LIST/INFO and GUANO chunks.
+func SavePCMDataToWAV(filePath string, pcmData []byte, meta *ClipMetadata) error {
 	// Create the directory structure if it doesn't exist
 	if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
 		return fmt.Errorf("failed to create directories: %w", err)
@@ -96,6 +122,14 @@ func SavePCMDataToWAV(filePath string, pcmData []byte) error {
 		return fmt.Errorf("failed to write to WAV encoder: %w", err)
 	}
 
+	// Append the metadata chunks after the audio data
+	if meta != nil {
+		duration := float64(len(intSamples)) / float64(conf.SampleRate*conf.NumChannels)
+		if err := enc.AddBE(meta.wavChunks(duration)); err != nil {
+			return fmt.Errorf("failed to write WAV metadata: %w", err)
+		}
+	}
+
 	// Close the WAV encoder, which finalizes the file format.
 	return enc.Close()
 }
@@ -5,6 +5,8 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/tphakala/birdnet-go/internal/conf"
 )
@@ -15,7 +17,8 @@ const tempExt = ".temp"
 // ExportAudioWithFFmpeg exports PCM data to the specified format using FFmpeg
 // outputPath is full path with audio file name and extension based on format
 // pcmData is the PCM data to export
-func ExportAudioWithFFmpeg(pcmData []byte, outputPath string, settings *conf.AudioSettings) error {
+// meta is the detection metadata written as tags, it may be nil
+func ExportAudioWithFFmpeg(pcmData []byte, outputPath string, settings *conf.AudioSettings, meta *ClipMetadata) error {
 	// Validate the FFmpeg path
 	if err := validateFFmpegPath(settings.FfmpegPath); err != nil {
 		return err
@@ -29,7 +32,7 @@ func ExportAudioWithFFmpeg(pcmData []byte, outputPath string, settings *conf.Aud
 	}
 
 	// Run the FFmpeg command to process the audio
-	if err := runFFmpegCommand(settings.FfmpegPath, pcmData, tempFilePath, settings); err != nil {
+	if err := runFFmpegCommand(settings.FfmpegPath, pcmData, tempFilePath, settings, meta); err != nil {
 		return err
 	}
 
@@ -60,9 +63,9 @@ func finalizeOutput(tempFilePath string) error {
 }
 
 // runFFmpegCommand executes the FFmpeg command to process the audio
-func runFFmpegCommand(ffmpegPath string, pcmData []byte, tempFilePath string, settings *conf.AudioSettings) error {
+func runFFmpegCommand(ffmpegPath string, pcmData []byte, tempFilePath string, settings *conf.AudioSettings, meta *ClipMetadata) error {
 	// Build the FFmpeg command arguments
-	args := buildFFmpegArgs(tempFilePath, settings)
+	args := buildFFmpegArgs(tempFilePath, settings, meta)
 
 	// Create the FFmpeg command
 	cmd := exec.Command(ffmpegPath, args...)
@@ -95,24 +98,32 @@ func runFFmpegCommand(ffmpegPath string, pcmData []byte, tempFilePath string, se
 }
 
 // buildFFmpegArgs constructs the arguments for the FFmpeg command
-func buildFFmpegArgs(tempFilePath string, settings *conf.AudioSettings) []string {
+func buildFFmpegArgs(tempFilePath string, settings *conf.AudioSettings, meta *ClipMetadata) []string {
 	ffmpegSampleRate, ffmpegNumChannels, ffmpegFormat := getFFmpegFormat(conf.SampleRate, conf.NumChannels, conf.BitDepth)
 
 	outputEncoder := getEncoder(settings.Export.Type)
 	outputFormat := getOutputFormat(settings.Export.Type)
 	outputBitrate := getMaxBitrate(settings.Export.Type, settings.Export.Bitrate)
 
-	return []string{
+	args := []string{
 		"-f", ffmpegFormat, // Input format based on bit depth
 		"-ar", ffmpegSampleRate, // Sample rate
 		"-ac", ffmpegNumChannels, // Number of channels
 		"-i", "-", // Read from stdin
 		"-c:a", outputEncoder,
 		"-b:a", outputBitrate,
+	}
+
+	// Detection metadata as tags of the output file
+	if meta != nil {
+		args = append(args, meta.ffmpegArgs(settings.Export.Type)...)
+	}
+
+	return append(args,
 		"-f", outputFormat, // Specify the output format
 		"-y",         // Overwrite output file if it exists
 		tempFilePath, // Write to the temporary file
-	}
+	)
 }
 
 // getCodec returns the appropriate codec to use with FFmpeg based on the format
@@ -153,13 +164,18 @@ func getOutputFormat(exportType string) string {
 
 // getMaxBitrate limits the bitrate to the maximum allowed by the format
 func getMaxBitrate(format, requestedBitrate string) string {
+	// Bitrates are compared as numbers of kbit/s, "64k" is lower than "256k"
+	kbps, err := strconv.Atoi(strings.TrimSuffix(requestedBitrate, "k"))
+	if err != nil {
+		return requestedBitrate
+	}
 	switch format {
 	case "opus":
-		if requestedBitrate > "256k" {
+		if kbps > 256 {
 			return "256k"
 		}
 	case "mp3":
-		if requestedBitrate > "320k" {
+		if kbps > 320 {
 			return "320k"
 		}
 	}
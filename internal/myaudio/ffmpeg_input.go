@@ -16,13 +16,17 @@ import (
 )
 
 // ffmpegProcesses keeps track of running FFmpeg processes for each URL
-// This is used to ensure that only one FFmpeg process is running per RTSP source
+// This is used to ensure that only one FFmpeg process is running per stream source
 var ffmpegProcesses sync.Map
 
 // FFmpegConfig holds the configuration for the FFmpeg command
 type FFmpegConfig struct {
-	URL       string
-	Transport string
+	SourceID     string // ID of the audio source the stream is captured for
+	URL          string
+	Protocol     string   // stream protocol, one of the conf.StreamProtocol constants
+	Transport    string   // RTSP transport protocol
+	InputOptions []string // additional FFmpeg input options
+	Reconnect    conf.StreamReconnectSettings
 }
 
 // FFmpegProcess represents a running FFmpeg process
@@ -34,19 +38,19 @@ type FFmpegProcess struct {
 	restartTracker *FFmpegRestartTracker
 }
 
-// audioWatchdog is a struct that keeps track of the last time data was received from the RTSP source
+// audioWatchdog is a struct that keeps track of the last time data was received from the stream source
 type audioWatchdog struct {
 	lastDataTime time.Time
 	mu           sync.Mutex
 }
 
-// FFmpegRestartTracker keeps track of restart information for each RTSP source
+// FFmpegRestartTracker keeps track of restart information for each stream source
 type FFmpegRestartTracker struct {
 	restartCount  int
 	lastRestartAt time.Time
 }
 
-// restartTrackers keeps track of restart information for each RTSP source URL
+// restartTrackers keeps track of restart information for each stream source URL
 var restartTrackers sync.Map
 
 // backoffStrategy implements an exponential backoff for retries
@@ -104,12 +108,17 @@ func newBackoffStrategy(maxAttempts int, initialDelay, maxDelay time.Duration) *
 	}
 }
 
-// nextDelay returns the next delay and a boolean indicating if the maximum number of attempts has been reached
+// nextDelay returns the next delay and a boolean indicating if the maximum number of attempts has been reached.
+// A maximum of zero attempts retries forever.
 func (b *backoffStrategy) nextDelay() (time.Duration, bool) {
-	if b.attempt >= b.maxAttempts {
+	if b.maxAttempts > 0 && b.attempt >= b.maxAttempts {
 		return 0, false
 	}
-	delay := b.initialDelay * time.Duration(1<<uint(b.attempt))
+	// Double the delay on every attempt, stopping at the maximum so it cannot overflow
+	delay := b.initialDelay
+	for i := 0; i < b.attempt && delay < b.maxDelay; i++ {
+		delay *= 2
+	}
 	if delay > b.maxDelay {
 		delay = b.maxDelay
 	}
@@ -180,7 +189,7 @@ func (p *FFmpegProcess) Cleanup(url string) {
 
 	select {
 	case <-done:
-		log.Printf("🛑 FFmpeg process for RTSP source %s stopped normally", url)
+		log.Printf("🛑 FFmpeg process for stream source %s stopped normally", url)
 		// Process finished normally
 	case <-time.After(10 * time.Second):
 		// Timeout occurred, forcefully kill the process
@@ -199,7 +208,7 @@ func (p *FFmpegProcess) Cleanup(url string) {
 }
 
 // startWatchdog starts a goroutine that monitors the audio stream for inactivity
-func (p *FFmpegProcess) startWatchdog(ctx context.Context, url string, watchdog *audioWatchdog) <-chan struct{} {
+func (p *FFmpegProcess) startWatchdog(ctx context.Context, url string, timeout time.Duration, watchdog *audioWatchdog) <-chan struct{} {
 	watchdogDone := make(chan struct{})
 	go func() {
 		defer close(watchdogDone)
@@ -212,18 +221,11 @@ func (p *FFmpegProcess) startWatchdog(ctx context.Context, url string, watchdog
 				return
 			case <-ticker.C:
 				// Check if the stream is still configured
-				settings := conf.Setting()
-				streamConfigured := false
-				for _, configuredURL := range settings.Realtime.RTSP.URLs {
-					if configuredURL == url {
-						streamConfigured = true
-						break
-					}
-				}
+				streamConfigured := isStreamConfigured(url)
 
 				// Only check watchdog timeout if the stream is still configured
-				if streamConfigured && watchdog.timeSinceLastData() > 60*time.Second {
-					log.Printf("⚠️ No data received from RTSP source %s for 60 seconds, triggering restart", url)
+				if streamConfigured && watchdog.timeSinceLastData() > timeout {
+					log.Printf("⚠️ No data received from stream source %s for %v, triggering restart", url, timeout)
 					return
 				} else if !streamConfigured {
 					return
@@ -234,42 +236,39 @@ func (p *FFmpegProcess) startWatchdog(ctx context.Context, url string, watchdog
 	return watchdogDone
 }
 
-// processAudio reads audio data from FFmpeg's stdout and writes it to buffers
-func (p *FFmpegProcess) processAudio(ctx context.Context, url string, restartChan chan struct{}, audioLevelChan chan AudioLevelData) error {
+// processAudio reads audio data from FFmpeg's stdout and writes it to the buffers of the audio source
+func (p *FFmpegProcess) processAudio(ctx context.Context, config FFmpegConfig, restartChan chan struct{}, audioLevelChan chan AudioLevelData) error {
+	url := config.URL
+
 	// Create a buffer to store audio data
 	buf := make([]byte, 32768)
 	watchdog := &audioWatchdog{lastDataTime: time.Now()}
 
 	// Start watchdog goroutine
-	watchdogDone := p.startWatchdog(ctx, url, watchdog)
+	timeout := time.Duration(config.Reconnect.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = conf.DefaultStreamTimeout * time.Second
+	}
+	watchdogDone := p.startWatchdog(ctx, url, timeout, watchdog)
 
 	// Continuously process audio data
 	for {
 		select {
 		case <-ctx.Done():
-			log.Printf("Stopping audio processing for RTSP source: %s", url)
+			log.Printf("Stopping audio processing for stream source: %s", url)
 			<-watchdogDone // Wait for watchdog to finish
 			return nil     // Return nil on normal shutdown
 		case <-watchdogDone:
 			// Check if the stream is still configured before triggering restart
-			settings := conf.Setting()
-			streamConfigured := false
-			for _, configuredURL := range settings.Realtime.RTSP.URLs {
-				if configuredURL == url {
-					streamConfigured = true
-					break
-				}
-			}
-
-			if streamConfigured {
+			if isStreamConfigured(url) {
 				// Trigger restart by sending signal to restartChan
 				select {
 				case restartChan <- struct{}{}:
-					log.Printf("🔄 Watchdog triggered restart for RTSP source %s", url)
+					log.Printf("🔄 Watchdog triggered restart for stream source %s", url)
 				default:
 					log.Printf("❌ Restart channel full, dropping restart request for %s", url)
 				}
-				return fmt.Errorf("watchdog detected no data for RTSP source %s", url)
+				return fmt.Errorf("watchdog detected no data for stream source %s", url)
 			} else {
 				return nil
 			}
@@ -289,24 +288,28 @@ func (p *FFmpegProcess) processAudio(ctx context.Context, url string, restartCha
 			// Ensure we don't process more data than we've read
 			if n > 0 {
 				watchdog.update() // Update the watchdog timestamp
+				// Apply the filter chain of the source
+				if err := ApplyFilters(config.SourceID, buf[:n]); err != nil {
+					log.Printf("❌ Error applying audio filters for stream source %s: %v", url, err)
+				}
 				// Write the audio data to the analysis buffer
-				err = WriteToAnalysisBuffer(url, buf[:n])
+				err = WriteToAnalysisBuffer(config.SourceID, buf[:n])
 				if err != nil {
-					log.Printf("❌ Error writing to analysis buffer for RTSP source %s: %v", url, err)
+					log.Printf("❌ Error writing to analysis buffer for stream source %s: %v", url, err)
 					time.Sleep(1 * time.Second)
 					continue
 				}
 
 				// Write the audio data to the capture buffer
-				err = WriteToCaptureBuffer(url, buf[:n])
+				err = WriteToCaptureBuffer(config.SourceID, buf[:n])
 				if err != nil {
-					log.Printf("❌ Error writing to capture buffer for RTSP source %s: %v", url, err)
+					log.Printf("❌ Error writing to capture buffer for stream source %s: %v", url, err)
 					time.Sleep(1 * time.Second)
 					continue
 				}
 
 				// Calculate audio level with source information
-				audioLevelData := calculateAudioLevel(buf[:n], url, "")
+				audioLevelData := calculateAudioLevel(buf[:n], config.SourceID, "")
 
 				// Send level to channel (non-blocking)
 				select {
@@ -324,6 +327,29 @@ func (p *FFmpegProcess) processAudio(ctx context.Context, url string, restartCha
 	}
 }
 
+// ffmpegInputArgs returns the FFmpeg input arguments for the stream protocol, followed by the
+// user configured input options and the input URL
+func ffmpegInputArgs(config FFmpegConfig) []string {
+	var args []string
+
+	switch config.Protocol {
+	case conf.StreamProtocolRTSP:
+		if config.Transport != "" {
+			args = append(args, "-rtsp_transport", config.Transport) // Set RTSP transport protocol
+		}
+	case conf.StreamProtocolHTTP:
+		// Let FFmpeg reconnect dropped HTTP connections, e.g. Icecast streams, before the watchdog restarts it
+		args = append(args,
+			"-reconnect", "1",
+			"-reconnect_streamed", "1",
+			"-reconnect_delay_max", "10",
+		)
+	}
+
+	args = append(args, config.InputOptions...)
+	return append(args, "-i", config.URL)
+}
+
 // startFFmpeg starts an FFmpeg process with the given configuration
 func startFFmpeg(ctx context.Context, config FFmpegConfig) (*FFmpegProcess, error) {
 	settings := conf.Setting().Realtime.Audio
@@ -337,10 +363,9 @@ func startFFmpeg(ctx context.Context, config FFmpegConfig) (*FFmpegProcess, erro
 	// Get the FFmpeg-compatible values for sample rate, channels, and bit depth
 	ffmpegSampleRate, ffmpegNumChannels, ffmpegFormat := getFFmpegFormat(conf.SampleRate, conf.NumChannels, conf.BitDepth)
 
-	// Prepare the FFmpeg command with appropriate arguments
-	cmd := exec.CommandContext(ctx, settings.FfmpegPath,
-		"-rtsp_transport", config.Transport, // Set RTSP transport protocol
-		"-i", config.URL, // Input URL
+	// Prepare the FFmpeg command with protocol specific input options
+	args := ffmpegInputArgs(config)
+	args = append(args,
 		"-loglevel", "error", // Set log level to error
 		"-vn",              // Disable video
 		"-f", ffmpegFormat, // Set output format to signed 16-bit little-endian
@@ -349,6 +374,7 @@ func startFFmpeg(ctx context.Context, config FFmpegConfig) (*FFmpegProcess, erro
 		"-hide_banner", // Hide the banner
 		"pipe:1",       // Output to stdout
 	)
+	cmd := exec.CommandContext(ctx, settings.FfmpegPath, args...)
 
 	// Set up platform-specific process group
 	setupProcessGroup(cmd)
@@ -372,7 +398,7 @@ func startFFmpeg(ctx context.Context, config FFmpegConfig) (*FFmpegProcess, erro
 		cancel() // Cancel the context if process start fails
 		return nil, fmt.Errorf("error starting FFmpeg: %w", err)
 	} else {
-		log.Printf("✅ FFmpeg started successfully for RTSP source %s", config.URL)
+		log.Printf("✅ FFmpeg started successfully for stream source %s", config.URL)
 	}
 
 	// Create a channel to receive the exit status of the FFmpeg process
@@ -383,7 +409,7 @@ func startFFmpeg(ctx context.Context, config FFmpegConfig) (*FFmpegProcess, erro
 		if err != nil {
 			// Don't log if process was killed (normal shutdown) or context was cancelled
 			if !strings.Contains(err.Error(), "signal: killed") && !errors.Is(err, context.Canceled) {
-				log.Printf("⚠️ FFmpeg process for RTSP source %s exited with error: %v", config.URL, err)
+				log.Printf("⚠️ FFmpeg process for stream source %s exited with error: %v", config.URL, err)
 				// Include stderr in the error if available
 				if stderrBuf.String() != "" {
 					log.Printf("⚠️ FFmpeg process stderr:\n%v", stderrBuf.String())
@@ -408,21 +434,13 @@ func startFFmpeg(ctx context.Context, config FFmpegConfig) (*FFmpegProcess, erro
 }
 
 func manageFfmpegLifecycle(ctx context.Context, config FFmpegConfig, restartChan chan struct{}, audioLevelChan chan AudioLevelData) error {
-	// Create a new backoff strategy with 5 attempts, 5 seconds initial delay, and 2 minutes maximum delay
-	backoff := newBackoffStrategy(5, 5*time.Second, 2*time.Minute)
+	// Create a new backoff strategy from the reconnect policy of the stream
+	reconnect := config.Reconnect
+	backoff := newBackoffStrategy(reconnect.Attempts(), time.Duration(reconnect.InitialDelay)*time.Second, time.Duration(reconnect.MaxDelay)*time.Second)
 
 	for {
 		// Check if the stream is still configured before starting/restarting
-		settings := conf.Setting()
-		streamConfigured := false
-		for _, url := range settings.Realtime.RTSP.URLs {
-			if url == config.URL {
-				streamConfigured = true
-				break
-			}
-		}
-
-		if !streamConfigured {
+		if !isStreamConfigured(config.URL) {
 			// Remove the process from the map if it exists
 			if process, exists := ffmpegProcesses.Load(config.URL); exists {
 				if p, ok := process.(*FFmpegProcess); ok {
@@ -447,7 +465,7 @@ func manageFfmpegLifecycle(ctx context.Context, config FFmpegConfig, restartChan
 			}
 
 			// Log the failure and the next retry attempt
-			log.Printf("⚠️ Failed to start FFmpeg for RTSP source %s: %v. Retrying in %v...", config.URL, err, delay)
+			log.Printf("⚠️ Failed to start FFmpeg for stream source %s: %v. Retrying in %v...", config.URL, err, delay)
 
 			// Wait for either the context to be cancelled or the delay to pass
 			select {
@@ -469,7 +487,7 @@ func manageFfmpegLifecycle(ctx context.Context, config FFmpegConfig, restartChan
 		// Start processing audio and wait for it to finish or for a restart signal
 		processDone := make(chan error, 1)
 		go func() {
-			processDone <- process.processAudio(ctx, config.URL, restartChan, audioLevelChan)
+			processDone <- process.processAudio(ctx, config, restartChan, audioLevelChan)
 		}()
 
 		select {
@@ -483,42 +501,24 @@ func manageFfmpegLifecycle(ctx context.Context, config FFmpegConfig, restartChan
 			process.Cleanup(config.URL)
 
 			// Check if the stream is still configured before handling the error
-			settings := conf.Setting()
-			streamConfigured := false
-			for _, url := range settings.Realtime.RTSP.URLs {
-				if url == config.URL {
-					streamConfigured = true
-					break
-				}
-			}
-
-			if !streamConfigured {
+			if !isStreamConfigured(config.URL) {
 				ffmpegProcesses.Delete(config.URL)
 				return nil
 			}
 
 			if err != nil && !errors.Is(err, context.Canceled) {
-				log.Printf("⚠️ FFmpeg process for RTSP source %s ended unexpectedly: %v", config.URL, err)
+				log.Printf("⚠️ FFmpeg process for stream source %s ended unexpectedly: %v", config.URL, err)
 			}
 
 		case <-restartChan:
 			// Restart signal received
-			log.Printf("🔄 Restart signal received, restarting FFmpeg for RTSP source %s.", config.URL)
+			log.Printf("🔄 Restart signal received, restarting FFmpeg for stream source %s.", config.URL)
 			process.Cleanup(config.URL)
 			backoff.reset()
 		}
 
 		// Check configuration again before waiting for restart
-		settings = conf.Setting()
-		streamConfigured = false
-		for _, url := range settings.Realtime.RTSP.URLs {
-			if url == config.URL {
-				streamConfigured = true
-				break
-			}
-		}
-
-		if !streamConfigured {
+		if !isStreamConfigured(config.URL) {
 			log.Printf("🛑 Stream %s is no longer configured, stopping lifecycle manager", config.URL)
 			ffmpegProcesses.Delete(config.URL)
 			return nil
@@ -526,6 +526,7 @@ func manageFfmpegLifecycle(ctx context.Context, config FFmpegConfig, restartChan
 
 		// Update restart information and wait before attempting to restart
 		process.updateRestartInfo()
+		recordFFmpegRestart(config.SourceID)
 		delay := process.getRestartDelay()
 
 		// wait for either the context to be cancelled (user requested quit) or the delay to pass
@@ -535,7 +536,7 @@ func manageFfmpegLifecycle(ctx context.Context, config FFmpegConfig, restartChan
 		case <-time.After(delay):
 			// Continue to next iteration after delay
 		case <-restartChan:
-			log.Printf("🔄 Restart signal received during restart delay, restarting FFmpeg for RTSP source %s immediately.", config.URL)
+			log.Printf("🔄 Restart signal received during restart delay, restarting FFmpeg for stream source %s immediately.", config.URL)
 			continue
 		}
 	}
@@ -553,19 +554,35 @@ func getExitCode(err error) int {
 	return -1
 }
 
-// CaptureAudioRTSP is the main function for capturing audio from an RTSP stream
-func CaptureAudioRTSP(url, transport string, wg *sync.WaitGroup, quitChan <-chan struct{}, restartChan chan struct{}, audioLevelChan chan AudioLevelData) {
+// isStreamConfigured reports whether the stream URL belongs to an enabled source in the audio source list
+func isStreamConfigured(url string) bool {
+	for _, source := range conf.Setting().Realtime.AudioSources() {
+		if source.IsStream() && source.URL == url {
+			return true
+		}
+	}
+	return false
+}
+
+// CaptureAudioStream is the main function for capturing audio from a network stream into the buffers of an audio source
+func CaptureAudioStream(source conf.AudioSourceConfig, quitChan <-chan struct{}, restartChan chan struct{}, audioLevelChan chan AudioLevelData) {
+	url := source.URL
+
 	// Return with error if FFmpeg path is not set
 	if conf.GetFfmpegBinaryName() == "" {
-		log.Printf("❌ FFmpeg is not available, cannot capture audio from RTSP source %s.", url)
+		log.Printf("❌ FFmpeg is not available, cannot capture audio from stream source %s.", url)
 		log.Printf("⚠️ Please make sure FFmpeg is installed and included in system PATH.")
 		return
 	}
 
 	// Create a configuration for FFmpeg
 	config := FFmpegConfig{
-		URL:       url,
-		Transport: transport,
+		SourceID:     source.ID,
+		URL:          url,
+		Protocol:     source.StreamProtocol(),
+		Transport:    source.Transport,
+		InputOptions: source.InputOptions,
+		Reconnect:    source.Reconnect,
 	}
 
 	// Create a new context with cancellation
@@ -576,29 +593,33 @@ func CaptureAudioRTSP(url, transport string, wg *sync.WaitGroup, quitChan <-chan
 	// Start a goroutine to handle the quit signal
 	go func() {
 		// Wait for a signal on the quit channel
-		<-quitChan
-		// Log that a quit signal was received
-		log.Printf("🔴 Quit signal received, stopping FFmpeg for RTSP source %s.", url)
-		// Cancel the context to stop all operations
-		cancel()
+		select {
+		case <-quitChan:
+			// Log that a quit signal was received
+			log.Printf("🔴 Quit signal received, stopping FFmpeg for stream source %s.", url)
+			// Cancel the context to stop all operations
+			cancel()
+		case <-ctx.Done():
+			// Lifecycle manager exited on its own
+		}
 	}()
 
 	// Manage the FFmpeg lifecycle
 	err := manageFfmpegLifecycle(ctx, config, restartChan, audioLevelChan)
 	// If an error occurred and it's not due to context cancellation, log it and report to user
 	if err != nil && !errors.Is(err, context.Canceled) {
-		log.Printf("⚠️ FFmpeg lifecycle manager for RTSP source %s exited with error: %v", url, err)
+		log.Printf("⚠️ FFmpeg lifecycle manager for stream source %s exited with error: %v", url, err)
 	}
 }
 
-// audioWatchdog is a struct that keeps track of the last time data was received from the RTSP source
+// update records that data was received from the stream source
 func (w *audioWatchdog) update() {
 	w.mu.Lock()
 	w.lastDataTime = time.Now()
 	w.mu.Unlock()
 }
 
-// timeSinceLastData returns the time since the last data was received from the RTSP source
+// timeSinceLastData returns the time since the last data was received from the stream source
 func (w *audioWatchdog) timeSinceLastData() time.Duration {
 	w.mu.Lock()
 	defer w.mu.Unlock()
@@ -0,0 +1,64 @@
+package myaudio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// TestFFmpegInputArgs tests protocol specific FFmpeg input arguments
+func TestFFmpegInputArgs(t *testing.T) {
+	testCases := []struct {
+		name     string
+		config   FFmpegConfig
+		expected []string
+	}{
+		{
+			name:     "RTSP with transport",
+			config:   FFmpegConfig{URL: "rtsp://cam/stream", Protocol: conf.StreamProtocolRTSP, Transport: "tcp"},
+			expected: []string{"-rtsp_transport", "tcp", "-i", "rtsp://cam/stream"},
+		},
+		{
+			name:   "HTTP with input options",
+			config: FFmpegConfig{URL: "https://icecast/mic.mp3", Protocol: conf.StreamProtocolHTTP, InputOptions: []string{"-icy", "0"}},
+			expected: []string{
+				"-reconnect", "1", "-reconnect_streamed", "1", "-reconnect_delay_max", "10",
+				"-icy", "0", "-i", "https://icecast/mic.mp3",
+			},
+		},
+		{
+			name:     "UDP multicast",
+			config:   FFmpegConfig{URL: "udp://239.0.0.1:5000", Protocol: conf.StreamProtocolUDP},
+			expected: []string{"-i", "udp://239.0.0.1:5000"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ffmpegInputArgs(tc.config))
+		})
+	}
+}
+
+// TestBackoffStrategy tests the retry delays and the attempt limit of the backoff strategy
+func TestBackoffStrategy(t *testing.T) {
+	backoff := newBackoffStrategy(3, 5*time.Second, 12*time.Second)
+	for _, expected := range []time.Duration{5 * time.Second, 10 * time.Second, 12 * time.Second} {
+		delay, retry := backoff.nextDelay()
+		assert.True(t, retry)
+		assert.Equal(t, expected, delay)
+	}
+	_, retry := backoff.nextDelay()
+	assert.False(t, retry, "attempts are used up")
+
+	// Zero attempts retries forever at the maximum delay
+	backoff = newBackoffStrategy(0, 5*time.Second, 2*time.Minute)
+	var delay time.Duration
+	for i := 0; i < 100; i++ {
+		delay, retry = backoff.nextDelay()
+		assert.True(t, retry)
+	}
+	assert.Equal(t, 2*time.Minute, delay)
+}
@@ -63,20 +63,13 @@ func (m *FFmpegMonitor) monitorLoop() {
 
 // checkProcesses verifies running FFmpeg processes against configuration
 func (m *FFmpegMonitor) checkProcesses() {
-	// Get configured URLs
-	settings := conf.Setting()
-	configuredURLs := make(map[string]bool)
-	for _, url := range settings.Realtime.RTSP.URLs {
-		configuredURLs[url] = true
-	}
-
 	// Check running processes against configuration
 	ffmpegProcesses.Range(func(key, value interface{}) bool {
 		url := key.(string)
 		process := value.(*FFmpegProcess)
 
 		// If URL is not in configuration, clean up the process
-		if !configuredURLs[url] {
+		if !isStreamConfigured(url) {
 			log.Printf("🧹 Found orphaned FFmpeg process for URL %s, cleaning up", url)
 			process.Cleanup(url)
 		}
@@ -0,0 +1,536 @@
+package myaudio
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"math/bits"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// flacBlockSize is the number of samples per channel in each FLAC frame
+	flacBlockSize = 4096
+
+	// flacStreamInfoSize is the size of the STREAMINFO metadata block without its header
+	flacStreamInfoSize = 34
+
+	// flacMaxFixedOrder is the highest order of the fixed linear predictors
+	flacMaxFixedOrder = 4
+
+	// flacMaxPartitionOrder is the highest Rice partition order tried for the residual
+	flacMaxPartitionOrder = 6
+
+	// flacMaxRiceParameter is the highest Rice parameter of the 4-bit residual coding method
+	flacMaxRiceParameter = 14
+)
+
+// flacSampleRateCodes maps sample rates to their codes in the frame header, other rates are
+// read from STREAMINFO
+var flacSampleRateCodes = map[int]uint64{
+	88200: 1, 176400: 2, 192000: 3, 8000: 4, 16000: 5, 22050: 6,
+	24000: 7, 32000: 8, 44100: 9, 48000: 10, 96000: 11,
+}
+
+// FLACEncoder encodes 16-bit PCM data into a FLAC stream using fixed linear prediction and
+// Rice coded residuals. It is a native alternative to FFmpeg for lossless audio export.
+type FLACEncoder struct {
+	w           io.Writer
+	sampleRate  int
+	numChannels int
+
+	pending      []int32 // interleaved samples not yet encoded into a frame
+	frameNumber  uint64
+	totalSamples uint64 // samples per channel written so far
+	minFrameSize int
+	maxFrameSize int
+	md5          hash.Hash
+	comments     []string // Vorbis comments written after STREAMINFO
+	closed       bool
+}
+
+// NewFLACEncoder creates a FLAC encoder writing to w and writes the stream header with the given
+// Vorbis comments, which may be empty. If w is also an io.WriteSeeker, Close updates the header
+// with the stream length and checksum.
+func NewFLACEncoder(w io.Writer, sampleRate, numChannels int, comments []string) (*FLACEncoder, error) {
+	if numChannels < 1 || numChannels > 8 {
+		return nil, fmt.Errorf("unsupported number of channels for FLAC: %d", numChannels)
+	}
+	if sampleRate <= 0 || sampleRate >= 1<<20 {
+		return nil, fmt.Errorf("unsupported sample rate for FLAC: %d", sampleRate)
+	}
+
+	e := &FLACEncoder{
+		w:           w,
+		sampleRate:  sampleRate,
+		numChannels: numChannels,
+		md5:         md5.New(),
+		comments:    comments,
+	}
+
+	if _, err := io.WriteString(w, "fLaC"); err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(e.streamInfo()); err != nil {
+		return nil, err
+	}
+	if len(comments) > 0 {
+		if _, err := w.Write(flacVorbisComment(comments)); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// Write encodes interleaved 16-bit little-endian PCM data, complete frames are written immediately
+func (e *FLACEncoder) Write(pcm []byte) error {
+	if e.closed {
+		return errors.New("FLAC encoder is closed")
+	}
+	if len(pcm)%(2*e.numChannels) != 0 {
+		return fmt.Errorf("PCM data length %d is not a multiple of the frame size", len(pcm))
+	}
+
+	e.md5.Write(pcm)
+	for i := 0; i < len(pcm); i += 2 {
+		e.pending = append(e.pending, int32(int16(binary.LittleEndian.Uint16(pcm[i:]))))
+	}
+
+	frameSamples := flacBlockSize * e.numChannels
+	written := 0
+	for len(e.pending)-written >= frameSamples {
+		if err := e.writeFrame(e.pending[written : written+frameSamples]); err != nil {
+			return err
+		}
+		written += frameSamples
+	}
+	e.pending = append(e.pending[:0], e.pending[written:]...)
+	return nil
+}
+
+// Close encodes the remaining samples and updates the stream header if the writer is seekable.
+// The underlying writer is not closed.
+func (e *FLACEncoder) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+
+	if len(e.pending) > 0 {
+		if err := e.writeFrame(e.pending); err != nil {
+			return err
+		}
+		e.pending = nil
+	}
+
+	ws, ok := e.w.(io.WriteSeeker)
+	if !ok {
+		return nil
+	}
+	if _, err := ws.Seek(4, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := ws.Write(e.streamInfo()); err != nil {
+		return err
+	}
+	_, err := ws.Seek(0, io.SeekEnd)
+	return err
+}
+
+// streamInfo returns the STREAMINFO metadata block with its header
+func (e *FLACEncoder) streamInfo() []byte {
+	bw := &flacBitWriter{}
+	if len(e.comments) == 0 {
+		bw.writeBits(1, 1) // last metadata block
+	} else {
+		bw.writeBits(0, 1)
+	}
+	bw.writeBits(0, 7) // STREAMINFO
+	bw.writeBits(flacStreamInfoSize, 24)
+
+	bw.writeBits(flacBlockSize, 16)
+	bw.writeBits(flacBlockSize, 16)
+	bw.writeBits(uint64(e.minFrameSize), 24)
+	bw.writeBits(uint64(e.maxFrameSize), 24)
+	bw.writeBits(uint64(e.sampleRate), 20)
+	bw.writeBits(uint64(e.numChannels-1), 3)
+	bw.writeBits(16-1, 5)
+	bw.writeBits(e.totalSamples, 36)
+
+	// The checksum is only known after all samples are written, it is zero while unknown
+	sum := make([]byte, md5.Size)
+	if e.closed {
+		sum = e.md5.Sum(nil)
+	}
+	for _, b := range sum {
+		bw.writeBits(uint64(b), 8)
+	}
+	return bw.bytes()
+}
+
+// flacVorbisComment returns the VORBIS_COMMENT metadata block with its header as last metadata block
+func flacVorbisComment(comments []string) []byte {
+	const vendor = "BirdNET-Go"
+
+	var body []byte
+	body = binary.LittleEndian.AppendUint32(body, uint32(len(vendor)))
+	body = append(body, vendor...)
+	body = binary.LittleEndian.AppendUint32(body, uint32(len(comments)))
+	for _, comment := range comments {
+		body = binary.LittleEndian.AppendUint32(body, uint32(len(comment)))
+		body = append(body, comment...)
+	}
+
+	header := binary.BigEndian.AppendUint32(nil, uint32(len(body)))
+	header[0] = 0x80 | 4 // last metadata block, VORBIS_COMMENT
+	return append(header, body...)
+}
+
+// writeFrame encodes interleaved samples as one frame
+func (e *FLACEncoder) writeFrame(samples []int32) error {
+	blockSize := len(samples) / e.numChannels
+	bw := &flacBitWriter{}
+
+	// Frame header
+	bw.writeBits(0x3FFE, 14) // sync code
+	bw.writeBits(0, 1)       // reserved
+	bw.writeBits(0, 1)       // fixed block size stream
+	if blockSize == flacBlockSize {
+		bw.writeBits(12, 4) // 4096 samples
+	} else {
+		bw.writeBits(7, 4) // block size in 16 bits at the end of the header
+	}
+	bw.writeBits(flacSampleRateCodes[e.sampleRate], 4)
+	bw.writeBits(uint64(e.numChannels-1), 4) // independent channels
+	bw.writeBits(4, 3)                       // 16 bits per sample
+	bw.writeBits(0, 1)                       // reserved
+	bw.writeUTF8(e.frameNumber)
+	if blockSize != flacBlockSize {
+		bw.writeBits(uint64(blockSize-1), 16)
+	}
+	bw.writeBits(uint64(flacCRC8(bw.bytes())), 8)
+
+	// One subframe per channel
+	channel := make([]int32, blockSize)
+	for ch := 0; ch < e.numChannels; ch++ {
+		for i := range channel {
+			channel[i] = samples[i*e.numChannels+ch]
+		}
+		writeFLACSubframe(bw, channel, 16)
+	}
+
+	// Frame footer
+	bw.align()
+	frame := bw.bytes()
+	frame = binary.BigEndian.AppendUint16(frame, flacCRC16(frame))
+
+	if _, err := e.w.Write(frame); err != nil {
+		return err
+	}
+
+	if e.minFrameSize == 0 || len(frame) < e.minFrameSize {
+		e.minFrameSize = len(frame)
+	}
+	e.maxFrameSize = max(e.maxFrameSize, len(frame))
+	e.frameNumber++
+	e.totalSamples += uint64(blockSize)
+	return nil
+}
+
+// writeFLACSubframe encodes the samples of one channel with the smallest of the constant,
+// fixed prediction and verbatim subframe types
+func writeFLACSubframe(bw *flacBitWriter, samples []int32, bitsPerSample uint) {
+	constant := true
+	for _, s := range samples[1:] {
+		if s != samples[0] {
+			constant = false
+			break
+		}
+	}
+	if constant {
+		bw.writeBits(0, 8) // zero padding bit, CONSTANT type, no wasted bits
+		bw.writeSigned(samples[0], bitsPerSample)
+		return
+	}
+
+	// Find the fixed predictor order with the cheapest residual
+	bestOrder, bestCost := -1, len(samples)*int(bitsPerSample)
+	var bestResidual []int32
+	var bestPartitions riceCoding
+	for order := 0; order <= min(flacMaxFixedOrder, len(samples)-1); order++ {
+		residual := fixedResidual(samples, order)
+		coding := chooseRiceCoding(residual, len(samples), order)
+		if cost := order*int(bitsPerSample) + coding.bits; cost < bestCost {
+			bestOrder, bestCost = order, cost
+			bestResidual, bestPartitions = residual, coding
+		}
+	}
+
+	if bestOrder < 0 {
+		bw.writeBits(1<<1, 8) // VERBATIM
+		for _, s := range samples {
+			bw.writeSigned(s, bitsPerSample)
+		}
+		return
+	}
+
+	bw.writeBits(uint64(0x08|bestOrder)<<1, 8) // FIXED with predictor order
+	for _, s := range samples[:bestOrder] {
+		bw.writeSigned(s, bitsPerSample)
+	}
+	bestPartitions.write(bw, bestResidual)
+}
+
+// fixedResidual returns the residual of the fixed linear predictor of the given order
+func fixedResidual(samples []int32, order int) []int32 {
+	residual := make([]int32, len(samples)-order)
+	for i := order; i < len(samples); i++ {
+		s := samples
+		var r int32
+		switch order {
+		case 0:
+			r = s[i]
+		case 1:
+			r = s[i] - s[i-1]
+		case 2:
+			r = s[i] - 2*s[i-1] + s[i-2]
+		case 3:
+			r = s[i] - 3*s[i-1] + 3*s[i-2] - s[i-3]
+		case 4:
+			r = s[i] - 4*s[i-1] + 6*s[i-2] - 4*s[i-3] + s[i-4]
+		}
+		residual[i-order] = r
+	}
+	return residual
+}
+
+// riceCoding holds the partition order and Rice parameters chosen for a residual
+type riceCoding struct {
+	predictorOrder int
+	partitionOrder int
+	parameters     []int
+	bits           int // encoded size in bits
+}
+
+// chooseRiceCoding finds the partition order and parameters which encode the residual in the fewest bits
+func chooseRiceCoding(residual []int32, blockSize, order int) riceCoding {
+	best := riceCoding{bits: -1}
+	for partitionOrder := 0; partitionOrder <= flacMaxPartitionOrder; partitionOrder++ {
+		partitions := 1 << partitionOrder
+		if blockSize%partitions != 0 || blockSize/partitions <= order {
+			break
+		}
+
+		coding := riceCoding{predictorOrder: order, partitionOrder: partitionOrder, bits: 2 + 4}
+		start := 0
+		for p := 0; p < partitions; p++ {
+			n := blockSize / partitions
+			if p == 0 {
+				n -= order
+			}
+			param, cost := riceParameter(residual[start : start+n])
+			coding.parameters = append(coding.parameters, param)
+			coding.bits += 4 + cost
+			start += n
+		}
+
+		if best.bits < 0 || coding.bits < best.bits {
+			best = coding
+		}
+	}
+	return best
+}
+
+// riceParameter returns the Rice parameter with the smallest encoding of a partition and its size in bits
+func riceParameter(residual []int32) (param, cost int) {
+	var sum uint64
+	for _, r := range residual {
+		sum += uint64(zigzag(r))
+	}
+
+	// The optimal parameter is close to log2 of the mean, the neighbours are tried as well
+	estimate := 0
+	if len(residual) > 0 && sum > uint64(len(residual)) {
+		estimate = min(bits.Len64(sum/uint64(len(residual)))-1, flacMaxRiceParameter)
+	}
+
+	cost = -1
+	for k := max(estimate-1, 0); k <= min(estimate+1, flacMaxRiceParameter); k++ {
+		c := len(residual) * (k + 1)
+		for _, r := range residual {
+			c += int(zigzag(r) >> k)
+		}
+		if cost < 0 || c < cost {
+			param, cost = k, c
+		}
+	}
+	return param, cost
+}
+
+// write writes the residual with the chosen partitions and parameters
+func (c riceCoding) write(bw *flacBitWriter, residual []int32) {
+	bw.writeBits(0, 2) // Rice coding with 4-bit parameters
+	bw.writeBits(uint64(c.partitionOrder), 4)
+
+	start := 0
+	partitionSize := (len(residual) + c.predictorOrder) >> c.partitionOrder
+	for p, k := range c.parameters {
+		n := partitionSize
+		if p == 0 {
+			n -= c.predictorOrder
+		}
+		bw.writeBits(uint64(k), 4)
+		for _, r := range residual[start : start+n] {
+			u := zigzag(r)
+			bw.writeUnary(u >> k)
+			bw.writeBits(uint64(u)&(1<<k-1), uint(k))
+		}
+		start += n
+	}
+}
+
+// zigzag maps signed residuals to unsigned values for Rice coding
+func zigzag(r int32) uint32 {
+	return uint32(r<<1) ^ uint32(r>>31)
+}
+
+// flacBitWriter accumulates a bit stream in memory
+type flacBitWriter struct {
+	buf   []byte
+	acc   uint64 // pending bits, right aligned
+	nbits uint   // number of pending bits
+}
+
+// writeBits writes the n least significant bits of v, most significant first
+func (w *flacBitWriter) writeBits(v uint64, n uint) {
+	for n > 0 {
+		take := min(n, 32)
+		n -= take
+		w.acc = w.acc<<take | (v>>n)&(1<<take-1)
+		w.nbits += take
+		for w.nbits >= 8 {
+			w.nbits -= 8
+			w.buf = append(w.buf, byte(w.acc>>w.nbits))
+		}
+	}
+}
+
+// writeSigned writes a two's complement value with n bits
+func (w *flacBitWriter) writeSigned(v int32, n uint) {
+	w.writeBits(uint64(uint32(v))&(1<<n-1), n)
+}
+
+// writeUnary writes q zero bits followed by a one bit
+func (w *flacBitWriter) writeUnary(q uint32) {
+	for q >= 32 {
+		w.writeBits(0, 32)
+		q -= 32
+	}
+	w.writeBits(1, uint(q)+1)
+}
+
+// writeUTF8 writes a frame number with the UTF-8 like variable length coding of FLAC
+func (w *flacBitWriter) writeUTF8(v uint64) {
+	if v < 0x80 {
+		w.writeBits(v, 8)
+		return
+	}
+
+	// Number of continuation bytes needed for the value
+	n := 1
+	for v >= 1<<(6*n+6-n) {
+		n++
+	}
+	w.writeBits((0xFF00>>(n+1))&0xFF|v>>(6*n), 8)
+	for i := n - 1; i >= 0; i-- {
+		w.writeBits(0x80|(v>>(6*i))&0x3F, 8)
+	}
+}
+
+// align pads the stream with zero bits to the next byte boundary
+func (w *flacBitWriter) align() {
+	if w.nbits > 0 {
+		w.writeBits(0, 8-w.nbits)
+	}
+}
+
+// bytes returns the complete bytes written so far
+func (w *flacBitWriter) bytes() []byte {
+	return w.buf
+}
+
+// flacCRC8 computes the CRC-8 of a frame header with polynomial x^8 + x^2 + x + 1
+func flacCRC8(data []byte) uint8 {
+	var crc uint8
+	for _, b := range data {
+		crc ^= b
+		for i := 0; i < 8; i++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x07
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// flacCRC16 computes the CRC-16 of a frame with polynomial x^16 + x^15 + x^2 + 1
+func flacCRC16(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x8005
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// SavePCMDataToFLAC saves the given PCM data as a FLAC file at the specified filePath without FFmpeg.
+// If meta is set, the detection metadata is added as Vorbis comments.
+func SavePCMDataToFLAC(filePath string, pcmData []byte, sampleRate, numChannels int, meta *ClipMetadata) error {
+	// Create the directory structure if it doesn't exist
+	if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create directories: %w", err)
+	}
+
+	// Write to a temporary file first so the export is atomic
+	tempFilePath, err := createTempFile(filePath)
+	if err != nil {
+		return err
+	}
+	outFile, err := os.Create(tempFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+
+	var comments []string
+	if meta != nil {
+		comments = meta.vorbisComments()
+	}
+	enc, err := NewFLACEncoder(outFile, sampleRate, numChannels, comments)
+	if err == nil {
+		err = enc.Write(pcmData)
+	}
+	if err == nil {
+		err = enc.Close()
+	}
+	if closeErr := outFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tempFilePath)
+		return fmt.Errorf("failed to encode FLAC: %w", err)
+	}
+
+	return finalizeOutput(tempFilePath)
+}
@@ -0,0 +1,79 @@
+package myaudio
+
+import (
+	"encoding/binary"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/flac"
+)
+
+// TestFLACEncoderRoundTrip tests that encoded FLAC files decode to the original PCM data
+func TestFLACEncoderRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	testCases := []struct {
+		name        string
+		numChannels int
+		samples     int
+		sample      func(i int) int16
+	}{
+		{"tone with noise", 1, 3*flacBlockSize + 123, func(i int) int16 {
+			return int16(8000*math.Sin(2*math.Pi*1000*float64(i)/48000) + float64(rng.Intn(200)-100))
+		}},
+		{"silence", 1, 2 * flacBlockSize, func(int) int16 { return 0 }},
+		{"white noise", 1, flacBlockSize, func(int) int16 { return int16(rng.Intn(65536) - 32768) }},
+		{"full scale square", 1, flacBlockSize + 1, func(i int) int16 {
+			if i%7 < 3 {
+				return math.MaxInt16
+			}
+			return math.MinInt16
+		}},
+		{"stereo", 2, flacBlockSize + 17, func(i int) int16 { return int16(i%512 - 256) }},
+		{"single sample", 1, 1, func(int) int16 { return 1234 }},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pcm := make([]byte, tc.samples*tc.numChannels*2)
+			for i := 0; i < len(pcm)/2; i++ {
+				binary.LittleEndian.PutUint16(pcm[i*2:], uint16(tc.sample(i)))
+			}
+
+			path := filepath.Join(t.TempDir(), "clip.flac")
+			require.NoError(t, SavePCMDataToFLAC(path, pcm, 48000, tc.numChannels, nil))
+
+			file, err := os.Open(path)
+			require.NoError(t, err)
+			defer file.Close()
+
+			// Decode verifies the frame checksums and the MD5 of the stream
+			decoded, meta, err := flac.Decode(file)
+			require.NoError(t, err)
+			assert.Equal(t, 48000, meta.SampleRate)
+			assert.Equal(t, tc.numChannels, meta.NChannels)
+			assert.Equal(t, int64(tc.samples), meta.TotalSamples)
+			assert.Equal(t, pcm, decoded)
+		})
+	}
+}
+
+// TestFLACEncoderCompression tests that predictable audio is compressed
+func TestFLACEncoderCompression(t *testing.T) {
+	pcm := make([]byte, 48000*2)
+	for i := 0; i < len(pcm)/2; i++ {
+		binary.LittleEndian.PutUint16(pcm[i*2:], uint16(int16(8000*math.Sin(2*math.Pi*440*float64(i)/48000))))
+	}
+
+	path := filepath.Join(t.TempDir(), "tone.flac")
+	require.NoError(t, SavePCMDataToFLAC(path, pcm, 48000, 1, nil))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Less(t, info.Size(), int64(len(pcm)/2))
+}
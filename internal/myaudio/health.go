@@ -0,0 +1,431 @@
+package myaudio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/telemetry/metrics"
+)
+
+const (
+	// healthCheckInterval is the length of the windows in which the health of audio sources is measured
+	healthCheckInterval = 10 * time.Second
+
+	// healthLevelFloor is the lowest level reported in dBFS
+	healthLevelFloor = -120
+)
+
+// Audio source health issues
+const (
+	HealthIssueSilence  = "silence"
+	HealthIssueClipping = "clipping"
+	HealthIssueDCOffset = "dc_offset"
+	HealthIssueUnderrun = "underrun"
+	HealthIssueRestarts = "ffmpeg_restarts"
+	HealthIssueFailed   = "capture_failed"
+)
+
+// healthIssues lists the health issues in the order they are reported, with the description used
+// in alerts and the advice given when the issue starts
+var healthIssues = []struct {
+	issue, description, advice string
+}{
+	{HealthIssueSilence, "prolonged silence", "check that the microphone is connected and not muted"},
+	{HealthIssueClipping, "clipping", "reduce the input gain"},
+	{HealthIssueDCOffset, "DC offset", "check the microphone and audio interface"},
+	{HealthIssueUnderrun, "audio dropouts", "fewer samples are received than expected"},
+	{HealthIssueRestarts, "frequent FFmpeg restarts", "check the stream connection"},
+	{HealthIssueFailed, "capture failure", "reconnect attempts are used up, check the stream and restart the source"},
+}
+
+// healthMonitor is the running audio health monitor, nil if health monitoring is disabled
+var healthMonitor atomic.Pointer[HealthMonitor]
+
+// SourceHealth is the health of an audio source measured in the most recent check
+type SourceHealth struct {
+	Source          string    `json:"source"`
+	Healthy         bool      `json:"healthy"`
+	Issues          []string  `json:"issues"`
+	LevelDBFS       float64   `json:"level_dbfs"`        // RMS level
+	ClippingRatio   float64   `json:"clipping_ratio"`    // ratio of clipped samples
+	DCOffset        float64   `json:"dc_offset"`         // mean sample value relative to full scale
+	SampleRateRatio float64   `json:"sample_rate_ratio"` // ratio of received to expected samples
+	SilentSeconds   float64   `json:"silent_seconds"`    // duration of the current silence
+	Underruns       int64     `json:"underruns"`         // checks in which too few samples were received
+	FFmpegRestarts  int64     `json:"ffmpeg_restarts"`   // FFmpeg restarts since the monitor started
+	LastAudio       time.Time `json:"last_audio"`        // time audio was last received
+	CheckedAt       time.Time `json:"checked_at"`
+}
+
+// HealthAlert is raised when an audio source starts or stops having a health issue
+type HealthAlert struct {
+	Source  string
+	Issue   string
+	Active  bool   // true when the issue started, false when it cleared
+	Type    string // notification type, "warning" when the issue started and "success" when it cleared
+	Message string
+}
+
+// HealthMonitor analyzes the audio of all sources for prolonged silence, clipping, DC offset,
+// dropouts and FFmpeg restarts and raises alerts when thresholds are crossed
+type HealthMonitor struct {
+	settings *conf.AudioHealthSettings
+	metrics  *metrics.AudioHealthMetrics
+	notify   func(HealthAlert)
+
+	sources sync.Map // source ID -> *sourceHealth
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// sourceHealth accumulates the audio of one source between checks
+type sourceHealth struct {
+	mu          sync.Mutex
+	windowStart time.Time
+	samples     int64
+	clipped     int64
+	sum         float64
+	sumSquares  float64
+	lastAudio   time.Time
+
+	restarts      []time.Time // FFmpeg restarts within the last hour
+	restartsTotal int64
+	underruns     int64
+	failed        bool      // capture gave up and the source no longer delivers audio
+	silentSince   time.Time // start of the current silence, zero if not silent
+	issues        map[string]bool
+	status        SourceHealth
+}
+
+// NewHealthMonitor creates an audio health monitor, metrics and notify may be nil
+func NewHealthMonitor(settings *conf.AudioHealthSettings, m *metrics.AudioHealthMetrics, notify func(HealthAlert)) *HealthMonitor {
+	return &HealthMonitor{
+		settings: settings,
+		metrics:  m,
+		notify:   notify,
+		done:     make(chan struct{}),
+	}
+}
+
+// StartHealthMonitor starts monitoring the health of audio sources if it is enabled in settings.
+// Alerts are passed to notify. The returned function stops the monitor.
+func StartHealthMonitor(settings *conf.AudioHealthSettings, m *metrics.AudioHealthMetrics, notify func(HealthAlert)) (stop func()) {
+	if !settings.Enabled {
+		return func() {}
+	}
+
+	hm := NewHealthMonitor(settings, m, notify)
+	healthMonitor.Store(hm)
+	hm.wg.Add(1)
+	go hm.run()
+
+	return func() {
+		healthMonitor.CompareAndSwap(hm, nil)
+		close(hm.done)
+		hm.wg.Wait()
+	}
+}
+
+// AudioHealth returns the health of all monitored audio sources, false if health monitoring is disabled
+func AudioHealth() ([]SourceHealth, bool) {
+	hm := healthMonitor.Load()
+	if hm == nil {
+		return nil, false
+	}
+	return hm.Status(), true
+}
+
+// monitorHealth passes audio written to the capture buffer of a source to the health monitor
+func monitorHealth(sourceID string, data []byte) {
+	if hm := healthMonitor.Load(); hm != nil {
+		hm.write(sourceID, data, time.Now())
+	}
+}
+
+// registerHealthSource starts monitoring a source when it starts, so that a source which never
+// delivers audio is reported
+func registerHealthSource(sourceID string) {
+	if hm := healthMonitor.Load(); hm != nil {
+		hm.source(sourceID, time.Now())
+	}
+}
+
+// unregisterHealthSource stops monitoring a source which has been stopped
+func unregisterHealthSource(sourceID string) {
+	if hm := healthMonitor.Load(); hm != nil {
+		hm.remove(sourceID)
+	}
+}
+
+// recordFFmpegRestart records a restart of the FFmpeg process of a stream source
+func recordFFmpegRestart(sourceID string) {
+	if hm := healthMonitor.Load(); hm != nil {
+		hm.recordRestart(sourceID, time.Now())
+	}
+}
+
+// markSourceFailed reports a source whose capture has given up, it stays unhealthy until the
+// source is restarted
+func markSourceFailed(sourceID string) {
+	if hm := healthMonitor.Load(); hm != nil {
+		hm.markFailed(sourceID, time.Now())
+	}
+}
+
+// run checks the health of all sources periodically until the monitor is stopped
+func (hm *HealthMonitor) run() {
+	defer hm.wg.Done()
+
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-hm.done:
+			return
+		case now := <-ticker.C:
+			hm.Check(now)
+		}
+	}
+}
+
+// source returns the health state of a source, creating it on first use
+func (hm *HealthMonitor) source(sourceID string, now time.Time) *sourceHealth {
+	if s, exists := hm.sources.Load(sourceID); exists {
+		return s.(*sourceHealth)
+	}
+	s, _ := hm.sources.LoadOrStore(sourceID, &sourceHealth{
+		windowStart: now,
+		issues:      make(map[string]bool),
+	})
+	return s.(*sourceHealth)
+}
+
+// remove forgets the health state of a source
+func (hm *HealthMonitor) remove(sourceID string) {
+	hm.sources.Delete(sourceID)
+	if hm.metrics != nil {
+		hm.metrics.RemoveSource(sourceID)
+	}
+}
+
+// isRealtimeHealthSource reports whether the audio of a source is expected continuously at the
+// sample rate, which is not the case for recordings analyzed faster than realtime and replays
+// which have finished playing
+func isRealtimeHealthSource(sourceID string) bool {
+	return !isRecordedCaptureBuffer(sourceID) && !SourceEnded(sourceID)
+}
+
+// write accumulates 16-bit PCM audio of a source
+func (hm *HealthMonitor) write(sourceID string, data []byte, now time.Time) {
+	s := hm.source(sourceID, now)
+
+	var sum, sumSquares float64
+	var clipped int64
+	for i := 0; i+1 < len(data); i += 2 {
+		sample := int16(binary.LittleEndian.Uint16(data[i:]))
+		if sample == math.MaxInt16 || sample == math.MinInt16 {
+			clipped++
+		}
+		v := float64(sample)
+		sum += v
+		sumSquares += v * v
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.samples += int64(len(data) / 2)
+	s.clipped += clipped
+	s.sum += sum
+	s.sumSquares += sumSquares
+	s.lastAudio = now
+}
+
+// recordRestart records an FFmpeg restart of a source
+func (hm *HealthMonitor) recordRestart(sourceID string, now time.Time) {
+	s := hm.source(sourceID, now)
+	s.mu.Lock()
+	s.restarts = append(s.restarts, now)
+	s.restartsTotal++
+	s.mu.Unlock()
+
+	if hm.metrics != nil {
+		hm.metrics.IncrementFFmpegRestarts(sourceID)
+	}
+}
+
+// markFailed records that the capture of a source has given up
+func (hm *HealthMonitor) markFailed(sourceID string, now time.Time) {
+	s := hm.source(sourceID, now)
+	s.mu.Lock()
+	s.failed = true
+	s.mu.Unlock()
+}
+
+// Check evaluates the audio received from each source since the previous check, updates the
+// metrics and raises alerts for issues which started or cleared
+func (hm *HealthMonitor) Check(now time.Time) {
+	var alerts []HealthAlert
+
+	hm.sources.Range(func(key, value any) bool {
+		sourceID, s := key.(string), value.(*sourceHealth)
+
+		// Forget sources which have been stopped
+		if !HasCaptureBuffer(sourceID) {
+			hm.remove(sourceID)
+			return true
+		}
+
+		alerts = append(alerts, hm.checkSource(sourceID, s, now, isRealtimeHealthSource(sourceID))...)
+		return true
+	})
+
+	for _, alert := range alerts {
+		if alert.Active {
+			log.Printf("⚠️ %s", alert.Message)
+		} else {
+			log.Printf("✅ %s", alert.Message)
+		}
+		if hm.notify != nil {
+			hm.notify(alert)
+		}
+	}
+}
+
+// checkSource evaluates one source and returns the alerts for changed issues. Silence and
+// dropouts are only checked for sources whose audio arrives in realtime.
+func (hm *HealthMonitor) checkSource(sourceID string, s *sourceHealth, now time.Time, realtime bool) []HealthAlert {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elapsed := now.Sub(s.windowStart)
+	if elapsed <= 0 {
+		return nil
+	}
+
+	status := SourceHealth{
+		Source:          sourceID,
+		LevelDBFS:       healthLevelFloor,
+		SampleRateRatio: float64(s.samples) / (elapsed.Seconds() * conf.SampleRate),
+		LastAudio:       s.lastAudio,
+		CheckedAt:       now,
+	}
+	if s.samples > 0 {
+		n := float64(s.samples)
+		rms := math.Sqrt(s.sumSquares/n) / 32768
+		status.LevelDBFS = max(20*math.Log10(rms+1e-10), healthLevelFloor)
+		status.ClippingRatio = float64(s.clipped) / n
+		status.DCOffset = math.Abs(s.sum/n) / 32768
+	}
+
+	// Silence is measured from the start of the first silent window
+	if realtime && status.LevelDBFS < hm.settings.SilenceThreshold {
+		if s.silentSince.IsZero() {
+			s.silentSince = s.windowStart
+		}
+		status.SilentSeconds = now.Sub(s.silentSince).Seconds()
+	} else {
+		s.silentSince = time.Time{}
+	}
+
+	// Only restarts within the last hour count towards the limit
+	recent := s.restarts[:0]
+	for _, t := range s.restarts {
+		if now.Sub(t) < time.Hour {
+			recent = append(recent, t)
+		}
+	}
+	s.restarts = recent
+
+	issues := map[string]bool{
+		HealthIssueSilence:  status.SilentSeconds >= float64(hm.settings.SilenceDuration),
+		HealthIssueClipping: status.ClippingRatio > hm.settings.ClippingRatio,
+		HealthIssueDCOffset: status.DCOffset > hm.settings.DCOffset,
+		HealthIssueUnderrun: realtime && status.SampleRateRatio < hm.settings.MinSampleRate,
+		HealthIssueRestarts: len(s.restarts) > hm.settings.MaxRestarts,
+		HealthIssueFailed:   s.failed,
+	}
+	if issues[HealthIssueUnderrun] {
+		s.underruns++
+		if hm.metrics != nil {
+			hm.metrics.IncrementUnderruns(sourceID)
+		}
+	}
+
+	var alerts []HealthAlert
+	status.Issues = []string{}
+	for _, i := range healthIssues {
+		active := issues[i.issue]
+		if active {
+			status.Issues = append(status.Issues, i.issue)
+		}
+		if active != s.issues[i.issue] {
+			alerts = append(alerts, newHealthAlert(sourceID, i.issue, i.description, i.advice, active))
+		}
+		if hm.metrics != nil {
+			hm.metrics.SetIssue(sourceID, i.issue, active)
+		}
+	}
+	s.issues = issues
+
+	status.Healthy = len(status.Issues) == 0
+	status.Underruns = s.underruns
+	status.FFmpegRestarts = s.restartsTotal
+	s.status = status
+
+	if hm.metrics != nil {
+		hm.metrics.SetSourceStats(sourceID, status.LevelDBFS, status.ClippingRatio, status.DCOffset,
+			status.SampleRateRatio, status.SilentSeconds)
+	}
+
+	// Start the next window
+	s.windowStart = now
+	s.samples, s.clipped = 0, 0
+	s.sum, s.sumSquares = 0, 0
+
+	return alerts
+}
+
+// newHealthAlert creates the alert for an issue of a source which started or cleared. Messages are
+// logged and shown in the web interface, so stream credentials are removed from the source ID.
+func newHealthAlert(sourceID, issue, description, advice string, active bool) HealthAlert {
+	name := conf.SanitizeStreamUrl(sourceID)
+	if active {
+		return HealthAlert{
+			Source:  sourceID,
+			Issue:   issue,
+			Active:  true,
+			Type:    "warning",
+			Message: fmt.Sprintf("Audio source %s: %s, %s", name, description, advice),
+		}
+	}
+	return HealthAlert{
+		Source:  sourceID,
+		Issue:   issue,
+		Type:    "success",
+		Message: fmt.Sprintf("Audio source %s recovered from %s", name, description),
+	}
+}
+
+// Status returns the health of all monitored sources measured in the most recent check, sorted by source ID
+func (hm *HealthMonitor) Status() []SourceHealth {
+	status := []SourceHealth{}
+	hm.sources.Range(func(key, value any) bool {
+		s := value.(*sourceHealth)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if !s.status.CheckedAt.IsZero() {
+			status = append(status, s.status)
+		}
+		return true
+	})
+	sort.Slice(status, func(i, j int) bool { return status[i].Source < status[j].Source })
+	return status
+}
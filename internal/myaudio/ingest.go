@@ -0,0 +1,449 @@
+package myaudio
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// Ingest protocol limits and timeouts
+const (
+	ingestHeaderTimeout = 10 * time.Second // time allowed to send the stream header
+	ingestReadTimeout   = 30 * time.Second // connections without data for this long are closed
+	ingestMaxHeaderSize = 4096             // maximum size of the JSON stream header
+	ingestMinSampleRate = 8000
+	ingestMaxSampleRate = 192000
+	ingestMaxChannels   = 8
+)
+
+// ingestSourcePrefix is prepended to station names to form the source ID of ingest streams
+const ingestSourcePrefix = "ingest:"
+
+// stationNamePattern restricts station names to characters safe for source IDs and file names
+var stationNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// IsValidStationName reports whether name can be used as the station name of a remote recorder
+func IsValidStationName(name string) bool {
+	return stationNamePattern.MatchString(name)
+}
+
+// IngestHeader is sent by clients before the PCM data, as a single JSON line over TCP or as
+// the first text message over WebSocket. Audio must be interleaved signed 16-bit little-endian PCM.
+type IngestHeader struct {
+	Token      string `json:"token"`       // shared secret from realtime.ingest.token
+	Station    string `json:"station"`     // unique station name, used as source name
+	SampleRate int    `json:"sample_rate"` // sample rate of the PCM data in Hz
+	Channels   int    `json:"channels"`    // number of interleaved channels, downmixed to mono
+}
+
+// validate checks the header fields and authenticates the client
+func (h *IngestHeader) validate(token string) error {
+	if subtle.ConstantTimeCompare([]byte(h.Token), []byte(token)) != 1 {
+		return errors.New("invalid token")
+	}
+	if !IsValidStationName(h.Station) {
+		return errors.New("station name must be 1-64 characters of letters, digits, '.', '_' or '-'")
+	}
+	if h.SampleRate < ingestMinSampleRate || h.SampleRate > ingestMaxSampleRate {
+		return fmt.Errorf("sample rate must be between %d and %d Hz", ingestMinSampleRate, ingestMaxSampleRate)
+	}
+	if h.Channels < 1 || h.Channels > ingestMaxChannels {
+		return fmt.Errorf("channels must be between 1 and %d", ingestMaxChannels)
+	}
+	return nil
+}
+
+// sourceConfig returns the audio source configuration for the stream
+func (h *IngestHeader) sourceConfig() conf.AudioSourceConfig {
+	return conf.AudioSourceConfig{
+		ID:   ingestSourcePrefix + h.Station,
+		Name: h.Station,
+		Type: conf.SourceTypeIngest,
+	}
+}
+
+// IngestServer accepts raw PCM audio pushed by network microphones over TCP or WebSocket and
+// registers each connection as a live audio source for as long as it is connected
+type IngestServer struct {
+	settings       *conf.IngestSettings
+	sources        *SourceManager
+	audioLevelChan chan AudioLevelData
+
+	mu          sync.Mutex
+	listener    net.Listener
+	httpServer  *http.Server
+	connections int
+	wg          sync.WaitGroup
+}
+
+// NewIngestServer creates a new ingest server. The server is stopped when quitChan is closed.
+func NewIngestServer(settings *conf.IngestSettings, sources *SourceManager, quitChan chan struct{}, audioLevelChan chan AudioLevelData) *IngestServer {
+	s := &IngestServer{
+		settings:       settings,
+		sources:        sources,
+		audioLevelChan: audioLevelChan,
+	}
+
+	go func() {
+		<-quitChan
+		s.Stop()
+	}()
+
+	return s
+}
+
+// Start starts the TCP and WebSocket listeners
+func (s *IngestServer) Start() error {
+	if s.settings.Token == "" {
+		return errors.New("ingest token is not set")
+	}
+
+	if s.settings.Listen != "" {
+		listener, err := net.Listen("tcp", s.settings.Listen)
+		if err != nil {
+			return fmt.Errorf("failed to start ingest TCP listener: %w", err)
+		}
+		s.mu.Lock()
+		s.listener = listener
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.acceptTCP(listener)
+		}()
+		log.Printf("🎙️ Ingest server listening for raw PCM streams on %s", s.settings.Listen)
+	}
+
+	if s.settings.WebSocketListen != "" {
+		listener, err := net.Listen("tcp", s.settings.WebSocketListen)
+		if err != nil {
+			s.Stop()
+			return fmt.Errorf("failed to start ingest WebSocket listener: %w", err)
+		}
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/", s.handleWebSocket)
+		server := &http.Server{
+			Handler:           mux,
+			ReadHeaderTimeout: ingestHeaderTimeout,
+		}
+		s.mu.Lock()
+		s.httpServer = server
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("❌ Ingest WebSocket server error: %v", err)
+			}
+		}()
+		log.Printf("🎙️ Ingest server listening for WebSocket PCM streams on %s", s.settings.WebSocketListen)
+	}
+
+	return nil
+}
+
+// Stop closes the listeners. Active streams are disconnected when their sources are stopped.
+func (s *IngestServer) Stop() {
+	s.mu.Lock()
+	if s.listener != nil {
+		s.listener.Close()
+		s.listener = nil
+	}
+	if s.httpServer != nil {
+		s.httpServer.Close()
+		s.httpServer = nil
+	}
+	s.mu.Unlock()
+}
+
+// acquireConnection reserves a connection slot, returning false if the limit is reached
+func (s *IngestServer) acquireConnection() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.connections >= s.settings.MaxConnections {
+		return false
+	}
+	s.connections++
+	return true
+}
+
+// releaseConnection frees a connection slot
+func (s *IngestServer) releaseConnection() {
+	s.mu.Lock()
+	s.connections--
+	s.mu.Unlock()
+}
+
+// acceptTCP accepts raw TCP connections until the listener is closed
+func (s *IngestServer) acceptTCP(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Printf("❌ Ingest server accept error: %v", err)
+			}
+			return
+		}
+		go s.handleTCP(conn)
+	}
+}
+
+// handleTCP reads the JSON header line followed by raw PCM data from a TCP connection
+func (s *IngestServer) handleTCP(conn net.Conn) {
+	defer conn.Close()
+
+	if !s.acquireConnection() {
+		fmt.Fprintf(conn, "ERROR: too many connections\n")
+		return
+	}
+	defer s.releaseConnection()
+
+	// Read the header line within the header timeout
+	if err := conn.SetReadDeadline(time.Now().Add(ingestHeaderTimeout)); err != nil {
+		return
+	}
+	reader := bufio.NewReaderSize(conn, ingestMaxHeaderSize)
+	line, err := reader.ReadSlice('\n')
+	if err != nil {
+		fmt.Fprintf(conn, "ERROR: invalid header\n")
+		return
+	}
+
+	var header IngestHeader
+	if err := json.Unmarshal(line, &header); err != nil {
+		fmt.Fprintf(conn, "ERROR: invalid header\n")
+		return
+	}
+	if err := header.validate(s.settings.Token); err != nil {
+		log.Printf("⚠️ Rejected ingest stream from %s: %v", conn.RemoteAddr(), err)
+		fmt.Fprintf(conn, "ERROR: %v\n", err)
+		return
+	}
+
+	err = s.sources.RunLiveSource(header.sourceConfig(), func(quit <-chan struct{}) {
+		fmt.Fprintf(conn, "OK\n")
+
+		// Close the connection to unblock reads when the source is stopped
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			select {
+			case <-quit:
+				conn.Close()
+			case <-done:
+			}
+		}()
+
+		buf := make([]byte, 8192)
+		writer := newIngestWriter(header)
+		for {
+			if err := conn.SetReadDeadline(time.Now().Add(ingestReadTimeout)); err != nil {
+				return
+			}
+			n, err := reader.Read(buf)
+			if n > 0 {
+				if err := writer.write(buf[:n], s.audioLevelChan); err != nil {
+					log.Printf("❌ Error writing ingest stream %s: %v", header.Station, err)
+					return
+				}
+			}
+			if err != nil {
+				if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+					log.Printf("⚠️ Ingest stream %s closed: %v", header.Station, err)
+				}
+				return
+			}
+		}
+	})
+	if err != nil {
+		log.Printf("⚠️ Rejected ingest stream %s: %v", header.Station, err)
+		fmt.Fprintf(conn, "ERROR: %v\n", err)
+	}
+}
+
+// ingestUpgrader upgrades ingest WebSocket connections. Microcontroller clients do not send
+// an Origin header, authentication is done with the token in the stream header.
+var ingestUpgrader = websocket.Upgrader{
+	ReadBufferSize:  8192,
+	WriteBufferSize: 1024,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// handleWebSocket reads the JSON header message followed by binary PCM messages from a WebSocket
+func (s *IngestServer) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	if !s.acquireConnection() {
+		http.Error(w, "too many connections", http.StatusServiceUnavailable)
+		return
+	}
+	defer s.releaseConnection()
+
+	conn, err := ingestUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("❌ Error upgrading ingest connection to WebSocket: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	reject := func(reason string) {
+		msg := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, reason)
+		_ = conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+	}
+
+	// Read the header message within the header timeout
+	conn.SetReadLimit(ingestMaxHeaderSize)
+	if err := conn.SetReadDeadline(time.Now().Add(ingestHeaderTimeout)); err != nil {
+		return
+	}
+	var header IngestHeader
+	if err := conn.ReadJSON(&header); err != nil {
+		reject("invalid header")
+		return
+	}
+	if err := header.validate(s.settings.Token); err != nil {
+		log.Printf("⚠️ Rejected ingest stream from %s: %v", r.RemoteAddr, err)
+		reject(err.Error())
+		return
+	}
+
+	err = s.sources.RunLiveSource(header.sourceConfig(), func(quit <-chan struct{}) {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte("OK")); err != nil {
+			return
+		}
+
+		// Close the connection to unblock reads when the source is stopped
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			select {
+			case <-quit:
+				conn.Close()
+			case <-done:
+			}
+		}()
+
+		conn.SetReadLimit(1 << 20)
+		writer := newIngestWriter(header)
+		for {
+			if err := conn.SetReadDeadline(time.Now().Add(ingestReadTimeout)); err != nil {
+				return
+			}
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) && !errors.Is(err, net.ErrClosed) {
+					log.Printf("⚠️ Ingest stream %s closed: %v", header.Station, err)
+				}
+				return
+			}
+			if messageType != websocket.BinaryMessage {
+				continue
+			}
+			if err := writer.write(data, s.audioLevelChan); err != nil {
+				log.Printf("❌ Error writing ingest stream %s: %v", header.Station, err)
+				return
+			}
+		}
+	})
+	if err != nil {
+		log.Printf("⚠️ Rejected ingest stream %s: %v", header.Station, err)
+		reject(err.Error())
+	}
+}
+
+// ingestWriter converts received PCM data to the analysis format and writes it to the buffers
+// of the ingest source
+type ingestWriter struct {
+	sourceID   string
+	name       string
+	sampleRate int
+	channels   int
+	pending    []byte     // partial frame left over from the previous write
+	resampler  *Resampler // resampler of the stream, created on first use if the sample rate differs
+}
+
+// newIngestWriter creates a writer for the stream described by the header
+func newIngestWriter(header IngestHeader) *ingestWriter {
+	return &ingestWriter{
+		sourceID:   ingestSourcePrefix + header.Station,
+		name:       header.Station,
+		sampleRate: header.SampleRate,
+		channels:   header.Channels,
+	}
+}
+
+// write converts a block of received data and writes it to the analysis and capture buffers
+func (w *ingestWriter) write(data []byte, audioLevelChan chan AudioLevelData) error {
+	pcm, err := w.convert(data)
+	if err != nil || len(pcm) == 0 {
+		return err
+	}
+
+	if err := ApplyFilters(w.sourceID, pcm); err != nil {
+		return fmt.Errorf("error applying audio filters: %w", err)
+	}
+	if err := WriteToAnalysisBuffer(w.sourceID, pcm); err != nil {
+		return fmt.Errorf("error writing to analysis buffer: %w", err)
+	}
+	if err := WriteToCaptureBuffer(w.sourceID, pcm); err != nil {
+		return fmt.Errorf("error writing to capture buffer: %w", err)
+	}
+
+	// Send level to channel (non-blocking)
+	select {
+	case audioLevelChan <- calculateAudioLevel(pcm, w.sourceID, w.name):
+	default:
+	}
+	return nil
+}
+
+// convert downmixes interleaved 16-bit PCM to mono and resamples it to the analysis sample rate
+func (w *ingestWriter) convert(data []byte) ([]byte, error) {
+	frameSize := 2 * w.channels
+	if len(w.pending) > 0 {
+		data = append(w.pending, data...)
+	}
+	frames := len(data) / frameSize
+	w.pending = append([]byte(nil), data[frames*frameSize:]...)
+	data = data[:frames*frameSize]
+
+	if w.channels == 1 && w.sampleRate == conf.SampleRate {
+		return data, nil
+	}
+
+	samples := make([]float32, frames)
+	for i := range samples {
+		var sum float32
+		for ch := 0; ch < w.channels; ch++ {
+			offset := i*frameSize + ch*2
+			sum += float32(int16(binary.LittleEndian.Uint16(data[offset:]))) / 32768.0
+		}
+		samples[i] = sum / float32(w.channels)
+	}
+
+	if w.sampleRate != conf.SampleRate {
+		if w.resampler == nil {
+			var err error
+			if w.resampler, err = NewResampler(w.sampleRate, conf.SampleRate); err != nil {
+				return nil, fmt.Errorf("error resampling audio: %w", err)
+			}
+		}
+		samples = w.resampler.Process(samples)
+	}
+
+	return ConvertFloat32ToPCM16(samples), nil
+}
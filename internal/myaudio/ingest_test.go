@@ -0,0 +1,100 @@
+package myaudio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// TestIngestHeaderValidate tests authentication and validation of ingest stream headers
+func TestIngestHeaderValidate(t *testing.T) {
+	valid := IngestHeader{Token: "secret", Station: "garden-1", SampleRate: 16000, Channels: 1}
+	assert.NoError(t, valid.validate("secret"))
+
+	testCases := []struct {
+		name   string
+		modify func(h *IngestHeader)
+	}{
+		{"wrong token", func(h *IngestHeader) { h.Token = "wrong" }},
+		{"empty station", func(h *IngestHeader) { h.Station = "" }},
+		{"invalid station", func(h *IngestHeader) { h.Station = "../garden" }},
+		{"low sample rate", func(h *IngestHeader) { h.SampleRate = 100 }},
+		{"no channels", func(h *IngestHeader) { h.Channels = 0 }},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			header := valid
+			tc.modify(&header)
+			assert.Error(t, header.validate("secret"))
+		})
+	}
+}
+
+// TestIngestWriterConvert tests downmixing, resampling and partial frame handling
+func TestIngestWriterConvert(t *testing.T) {
+	writer := newIngestWriter(IngestHeader{Station: "test", SampleRate: conf.SampleRate / 2, Channels: 2})
+
+	// 1000 stereo frames with left at half scale and right silent, split mid-frame
+	data := make([]byte, 1000*4)
+	for i := 0; i < 1000; i++ {
+		binary.LittleEndian.PutUint16(data[i*4:], uint16(16384))
+	}
+
+	first, err := writer.convert(data[:1999])
+	require.NoError(t, err)
+	second, err := writer.convert(data[1999:])
+	require.NoError(t, err)
+
+	// 499 + 501 frames doubled in sample rate, the resampler holds back its latency
+	latency := writer.resampler.Latency()
+	assert.Len(t, first, (499-latency)*2*2)
+	assert.Len(t, second, 501*2*2)
+	assert.InDelta(t, 8192, int16(binary.LittleEndian.Uint16(second[40:])), 1)
+}
+
+// TestIngestServerTCP tests that a TCP stream is registered as a live source and removed on disconnect
+func TestIngestServerTCP(t *testing.T) {
+	var wg sync.WaitGroup
+	quit := make(chan struct{})
+	defer close(quit)
+
+	levels := make(chan AudioLevelData, 10)
+	sources := NewSourceManager(&conf.Settings{}, &wg, quit, levels)
+	server := NewIngestServer(&conf.IngestSettings{Listen: "127.0.0.1:0", Token: "secret", MaxConnections: 1}, sources, quit, levels)
+	require.NoError(t, server.Start())
+
+	conn, err := net.Dial("tcp", server.listener.Addr().String())
+	require.NoError(t, err)
+
+	header, err := json.Marshal(IngestHeader{Token: "secret", Station: "mic", SampleRate: conf.SampleRate, Channels: 1})
+	require.NoError(t, err)
+	_, err = conn.Write(append(header, '\n'))
+	require.NoError(t, err)
+
+	response, err := bufio.NewReader(conn).ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "OK\n", response)
+	assert.Equal(t, []string{"ingest:mic"}, sources.ActiveSources())
+
+	_, err = conn.Write(make([]byte, 4800))
+	require.NoError(t, err)
+	select {
+	case level := <-levels:
+		assert.Equal(t, "ingest:mic", level.Source)
+		assert.Equal(t, "mic", level.Name)
+	case <-time.After(2 * time.Second):
+		t.Fatal("no audio level received")
+	}
+
+	require.NoError(t, conn.Close())
+	assert.Eventually(t, func() bool { return len(sources.ActiveSources()) == 0 }, 2*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,103 @@
+package myaudio
+
+import (
+	"encoding/binary"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+const (
+	// levelFrameSize is the number of samples in the frames whose levels are compared to
+	// find the loudest part of a detection and the background noise
+	levelFrameSize = conf.SampleRate / 20 // 50 ms
+
+	// levelFloor is the lowest level reported in dBFS
+	levelFloor = -120
+
+	// noiseFloorPercentile is the percentile of frame levels taken as the noise floor of a window
+	noiseFloorPercentile = 0.1
+
+	// noiseFloorFall and noiseFloorRise are the smoothing factors applied when the noise floor
+	// of a source falls or rises, the floor follows quiet periods quickly and loud ones slowly
+	noiseFloorFall = 0.5
+	noiseFloorRise = 0.05
+)
+
+// noiseFloors holds the background noise floor estimate of each source in dBFS
+var noiseFloors sync.Map // source ID -> *noiseFloor
+
+// noiseFloor is the smoothed background noise level of a source
+type noiseFloor struct {
+	mu    sync.Mutex
+	level float64
+}
+
+// SignalLevels are the loudness measurements of an analyzed audio window
+type SignalLevels struct {
+	RMS  float64 // RMS level of the window in dBFS
+	Peak float64 // peak sample level of the window in dBFS
+	SNR  float64 // level of the loudest frame above the background noise floor in dB
+}
+
+// MeasureSignalLevels measures the RMS and peak level of 16-bit PCM audio of a source and
+// estimates its signal to noise ratio. The noise floor of the source is estimated from the
+// quietest frames of the audio analyzed so far and updated with the measured window.
+func MeasureSignalLevels(sourceID string, data []byte) SignalLevels {
+	levels := SignalLevels{RMS: levelFloor, Peak: levelFloor}
+
+	samples := len(data) / 2
+	if samples == 0 {
+		return levels
+	}
+
+	var sumSquares, frameSquares float64
+	var peak int
+	frames := make([]float64, 0, samples/levelFrameSize+1)
+	for i := 0; i < samples; i++ {
+		sample := int(int16(binary.LittleEndian.Uint16(data[i*2:])))
+		peak = max(peak, sample, -sample)
+
+		v := float64(sample)
+		sumSquares += v * v
+		frameSquares += v * v
+		if (i+1)%levelFrameSize == 0 || i == samples-1 {
+			frames = append(frames, toDBFS(math.Sqrt(frameSquares/float64(i%levelFrameSize+1))))
+			frameSquares = 0
+		}
+	}
+
+	levels.RMS = toDBFS(math.Sqrt(sumSquares / float64(samples)))
+	levels.Peak = toDBFS(float64(peak))
+
+	sort.Float64s(frames)
+	windowFloor := frames[int(float64(len(frames)-1)*noiseFloorPercentile)]
+	loudest := frames[len(frames)-1]
+
+	// The window is compared to the noise floor before it is included in the estimate
+	value, _ := noiseFloors.LoadOrStore(sourceID, &noiseFloor{level: windowFloor})
+	nf := value.(*noiseFloor)
+	nf.mu.Lock()
+	levels.SNR = loudest - nf.level
+	if windowFloor < nf.level {
+		nf.level += noiseFloorFall * (windowFloor - nf.level)
+	} else {
+		nf.level += noiseFloorRise * (windowFloor - nf.level)
+	}
+	nf.mu.Unlock()
+
+	return levels
+}
+
+// ResetSignalLevels forgets the noise floor estimate of a source which has been stopped or whose
+// recording has been analyzed
+func ResetSignalLevels(sourceID string) {
+	noiseFloors.Delete(sourceID)
+}
+
+// toDBFS converts a 16-bit sample amplitude to dBFS
+func toDBFS(amplitude float64) float64 {
+	return max(20*math.Log10(amplitude/32768+1e-10), levelFloor)
+}
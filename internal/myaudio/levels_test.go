@@ -0,0 +1,52 @@
+package myaudio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// detectionWindow returns 3 seconds of quiet background audio with a louder call in the last half second
+func detectionWindow(background, call float64) []byte {
+	window := append(append(sinePCM(200, background), sinePCM(200, background)...), sinePCM(200, background)...)
+	copy(window[len(window)/6*5:], sinePCM(3000, call))
+	return window
+}
+
+func TestMeasureSignalLevels(t *testing.T) {
+	levels := MeasureSignalLevels("levels-tone", sinePCM(1000, 0.5))
+	assert.InDelta(t, -9.03, levels.RMS, 0.05)
+	assert.InDelta(t, -6.02, levels.Peak, 0.05)
+
+	silent := MeasureSignalLevels("levels-empty", nil)
+	assert.Equal(t, SignalLevels{RMS: levelFloor, Peak: levelFloor}, silent)
+}
+
+func TestMeasureSignalLevelsSNR(t *testing.T) {
+	// Without history the quiet part of the window is the noise floor
+	levels := MeasureSignalLevels("levels-mic", detectionWindow(0.001, 0.1))
+	assert.InDelta(t, 40, levels.SNR, 0.5)
+
+	// The noise floor follows a louder background of the source within about a minute
+	for i := 0; i < 100; i++ {
+		MeasureSignalLevels("levels-mic", sinePCM(200, 0.01))
+	}
+	levels = MeasureSignalLevels("levels-mic", detectionWindow(0.01, 0.1))
+	assert.InDelta(t, 20, levels.SNR, 0.5)
+	assert.InDelta(t, -20, levels.Peak, 0.05)
+
+	// A call which lasts for the whole window is measured against the background of earlier windows
+	levels = MeasureSignalLevels("levels-mic", sinePCM(3000, 0.1))
+	assert.InDelta(t, 20, levels.SNR, 0.5)
+
+	// Noise floors are tracked per source
+	levels = MeasureSignalLevels("levels-rtsp", sinePCM(3000, 0.1))
+	assert.InDelta(t, 0, levels.SNR, 0.5)
+
+	// The noise floor of a stopped source is forgotten
+	ResetSignalLevels("levels-mic")
+	_, exists := noiseFloors.Load("levels-mic")
+	assert.False(t, exists)
+	levels = MeasureSignalLevels("levels-mic", detectionWindow(0.001, 0.1))
+	assert.InDelta(t, 40, levels.SNR, 0.5)
+}
@@ -0,0 +1,340 @@
+package myaudio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+const (
+	// listenQueueSize is the number of PCM blocks queued for the live audio encoder of a source
+	listenQueueSize = 64
+
+	// listenerQueueSize is the number of encoded chunks queued for each listener
+	listenerQueueSize = 64
+
+	// listenChunkSize is the size of encoded audio chunks read from the encoder
+	listenChunkSize = 4096
+
+	// ListenContentType is the content type of the live audio stream
+	ListenContentType = "audio/mpeg"
+)
+
+// Live listen errors
+var (
+	ErrListenDisabled   = errors.New("live listening is disabled")
+	ErrTooManyListeners = errors.New("too many live listeners")
+	ErrUnknownSource    = errors.New("audio source is not active")
+)
+
+// liveListen is the active live listen hub, nil if live listening is disabled
+var liveListen atomic.Pointer[listenHub]
+
+// listenStreams holds the running live audio encoders by source ID, audio written to the capture
+// buffer of a source is also passed to its encoder
+var listenStreams sync.Map // source ID -> *listenStream
+
+// listenEncoderCommand returns the command encoding 16-bit PCM from stdin to MP3 on stdout
+var listenEncoderCommand = func(ffmpegPath, bitrate string) *exec.Cmd {
+	sampleRate, numChannels, format := getFFmpegFormat(conf.SampleRate, conf.NumChannels, conf.BitDepth)
+	return exec.Command(ffmpegPath,
+		"-hide_banner", "-loglevel", "error",
+		"-f", format, "-ar", sampleRate, "-ac", numChannels, "-i", "-",
+		"-c:a", "libmp3lame", "-b:a", getMaxBitrate("mp3", bitrate),
+		"-flush_packets", "1", "-f", "mp3", "-")
+}
+
+// EnableLiveListen enables or disables listening to the live audio of sources. The audio of a
+// source is encoded to MP3 once and shared by all of its listeners, the encoder runs only while
+// the source has listeners.
+func EnableLiveListen(settings *conf.AudioSettings) {
+	var h *listenHub
+	if settings.Listen.Enabled {
+		h = &listenHub{
+			ffmpegPath:   settings.FfmpegPath,
+			bitrate:      settings.Listen.Bitrate,
+			maxListeners: settings.Listen.MaxListeners,
+			streams:      make(map[string]*listenStream),
+		}
+	}
+	if old := liveListen.Swap(h); old != nil {
+		old.stopAll()
+	}
+}
+
+// Listen starts listening to the live audio of a source. It returns a channel of MP3 encoded audio
+// chunks, which is closed if the encoder stops, and a function which stops listening.
+func Listen(sourceID string) (audio <-chan []byte, stop func(), err error) {
+	h := liveListen.Load()
+	if h == nil {
+		return nil, nil, ErrListenDisabled
+	}
+	if !HasCaptureBuffer(sourceID) {
+		return nil, nil, fmt.Errorf("%w: %s", ErrUnknownSource, sourceID)
+	}
+	return h.listen(sourceID)
+}
+
+// stopListen stops the live audio encoder of a source which has stopped, its listeners are disconnected
+func stopListen(sourceID string) {
+	if h := liveListen.Load(); h != nil {
+		h.stopSource(sourceID)
+	}
+}
+
+// listenAudio passes audio written to the capture buffer of a source to its live audio encoder
+func listenAudio(sourceID string, data []byte) {
+	if s, exists := listenStreams.Load(sourceID); exists {
+		s.(*listenStream).write(data)
+	}
+}
+
+// listenHub manages the live audio encoders and enforces the listener limit
+type listenHub struct {
+	ffmpegPath   string
+	bitrate      string
+	maxListeners int
+
+	mu        sync.Mutex
+	listeners int
+	streams   map[string]*listenStream
+}
+
+// listen adds a listener to the stream of a source, starting its encoder if needed
+func (h *listenHub) listen(sourceID string) (audio <-chan []byte, stop func(), err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.listeners >= h.maxListeners {
+		return nil, nil, ErrTooManyListeners
+	}
+
+	s, exists := h.streams[sourceID]
+	if !exists || s.ended() {
+		if exists {
+			h.removeStream(s)
+		}
+		if err := validateFFmpegPath(h.ffmpegPath); err != nil {
+			return nil, nil, err
+		}
+		s, err = startListenStream(sourceID, listenEncoderCommand(h.ffmpegPath, h.bitrate))
+		if err != nil {
+			return nil, nil, err
+		}
+		h.streams[sourceID] = s
+		listenStreams.Store(sourceID, s)
+		log.Printf("🎧 Live audio stream started for source %s", sourceID)
+	}
+
+	ch := s.addListener()
+	h.listeners++
+
+	var once sync.Once
+	return ch, func() { once.Do(func() { h.unlisten(s, ch) }) }, nil
+}
+
+// unlisten removes a listener and stops the encoder of the source with its last listener
+func (h *listenHub) unlisten(s *listenStream, ch chan []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.listeners--
+	if s.removeListener(ch) == 0 {
+		h.removeStream(s)
+	}
+}
+
+// removeStream stops the encoder of a stream, the caller must hold h.mu
+func (h *listenHub) removeStream(s *listenStream) {
+	if h.streams[s.sourceID] == s {
+		delete(h.streams, s.sourceID)
+		listenStreams.CompareAndDelete(s.sourceID, s)
+	}
+	if s.stop() {
+		log.Printf("🎧 Live audio stream stopped for source %s", s.sourceID)
+	}
+}
+
+// stopSource stops the encoder of a source, the channels of its listeners are closed
+func (h *listenHub) stopSource(sourceID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if s, exists := h.streams[sourceID]; exists {
+		h.removeStream(s)
+	}
+}
+
+// stopAll stops all encoders, the channels of their listeners are closed
+func (h *listenHub) stopAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, s := range h.streams {
+		h.removeStream(s)
+	}
+}
+
+// listenStream encodes the live audio of a source and distributes it to listeners
+type listenStream struct {
+	sourceID string
+	cmd      *exec.Cmd
+	pcm      chan []byte
+	done     chan struct{} // closed when the encoder has exited
+
+	mu        sync.RWMutex // protects stopped, listeners and sending to pcm
+	stopped   bool
+	listeners map[chan []byte]struct{}
+	dropped   atomic.Int64
+}
+
+// startListenStream starts the encoder of a source
+func startListenStream(sourceID string, cmd *exec.Cmd) (*listenStream, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start live audio encoder: %w", err)
+	}
+
+	s := &listenStream{
+		sourceID:  sourceID,
+		cmd:       cmd,
+		pcm:       make(chan []byte, listenQueueSize),
+		done:      make(chan struct{}),
+		listeners: make(map[chan []byte]struct{}),
+	}
+	go s.feed(stdin)
+	go s.distribute(stdout)
+	return s, nil
+}
+
+// write queues a block of PCM audio for the encoder without blocking the capture
+func (s *listenStream) write(data []byte) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.stopped {
+		return
+	}
+
+	select {
+	case s.pcm <- append([]byte(nil), data...):
+	default:
+		if s.dropped.Add(1)%100 == 1 {
+			log.Printf("⚠️ Live audio queue for source %s is full, dropping audio", s.sourceID)
+		}
+	}
+}
+
+// feed writes queued PCM audio to the encoder until the stream is stopped
+func (s *listenStream) feed(stdin io.WriteCloser) {
+	defer stdin.Close()
+	for data := range s.pcm {
+		if _, err := stdin.Write(data); err != nil {
+			// The encoder has exited, distribute reports it
+			for range s.pcm {
+			}
+			return
+		}
+	}
+}
+
+// distribute sends encoded audio to all listeners until the encoder exits. Chunks are dropped for
+// listeners which do not keep up.
+func (s *listenStream) distribute(stdout io.Reader) {
+	for {
+		buf := make([]byte, listenChunkSize)
+		n, err := stdout.Read(buf)
+		if n > 0 {
+			s.mu.RLock()
+			for ch := range s.listeners {
+				select {
+				case ch <- buf[:n]:
+				default:
+				}
+			}
+			s.mu.RUnlock()
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	if err := s.cmd.Wait(); err != nil && !s.isStopped() {
+		log.Printf("❌ Live audio encoder for source %s failed: %v", s.sourceID, err)
+	}
+
+	// Close the channels of remaining listeners so that they are disconnected
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopLocked()
+	for ch := range s.listeners {
+		close(ch)
+		delete(s.listeners, ch)
+	}
+	close(s.done)
+}
+
+// addListener adds a listener channel for encoded audio
+func (s *listenStream) addListener() chan []byte {
+	ch := make(chan []byte, listenerQueueSize)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended() {
+		close(ch)
+		return ch
+	}
+	s.listeners[ch] = struct{}{}
+	return ch
+}
+
+// removeListener removes a listener channel and returns the number of remaining listeners
+func (s *listenStream) removeListener(ch chan []byte) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.listeners, ch)
+	return len(s.listeners)
+}
+
+// stop stops feeding the encoder, which then exits. It returns false if the stream was already stopped.
+func (s *listenStream) stop() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stopLocked()
+}
+
+// stopLocked stops feeding the encoder, the caller must hold s.mu
+func (s *listenStream) stopLocked() bool {
+	if s.stopped {
+		return false
+	}
+	s.stopped = true
+	close(s.pcm)
+	return true
+}
+
+// isStopped reports whether the stream has been stopped
+func (s *listenStream) isStopped() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.stopped
+}
+
+// ended reports whether the encoder has exited and the listeners have been disconnected
+func (s *listenStream) ended() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
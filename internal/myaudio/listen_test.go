@@ -0,0 +1,164 @@
+package myaudio
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// testLiveListen enables live listening with an encoder which passes audio through unchanged and
+// allocates capture buffers for the given sources
+func testLiveListen(t *testing.T, maxListeners int, sources ...string) {
+	t.Helper()
+	if _, err := exec.LookPath("cat"); err != nil {
+		t.Skip("cat is not available")
+	}
+
+	encoderCommand := listenEncoderCommand
+	listenEncoderCommand = func(string, string) *exec.Cmd { return exec.Command("cat") }
+
+	settings := &conf.AudioSettings{FfmpegPath: "ffmpeg"}
+	settings.Listen.Enabled = true
+	settings.Listen.MaxListeners = maxListeners
+	EnableLiveListen(settings)
+
+	for _, source := range sources {
+		require.NoError(t, AllocateCaptureBuffer(1, conf.SampleRate, 2, source))
+	}
+
+	t.Cleanup(func() {
+		EnableLiveListen(&conf.AudioSettings{})
+		listenEncoderCommand = encoderCommand
+		for _, source := range sources {
+			_ = RemoveCaptureBuffer(source)
+		}
+	})
+}
+
+// receiveAudio reads encoded audio until n bytes have been received
+func receiveAudio(t *testing.T, audio <-chan []byte, n int) []byte {
+	t.Helper()
+	var received []byte
+	timeout := time.After(5 * time.Second)
+	for len(received) < n {
+		select {
+		case chunk, ok := <-audio:
+			require.True(t, ok, "audio stream ended")
+			received = append(received, chunk...)
+		case <-timeout:
+			require.FailNow(t, "timeout waiting for audio")
+		}
+	}
+	return received
+}
+
+// waitForStreamStop waits until the encoder of a source has been torn down
+func waitForStreamStop(t *testing.T, source string) {
+	t.Helper()
+	assert.Eventually(t, func() bool {
+		_, exists := listenStreams.Load(source)
+		return !exists
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestListenSharedEncoder(t *testing.T) {
+	testLiveListen(t, 3, "listen-mic")
+
+	first, stopFirst, err := Listen("listen-mic")
+	require.NoError(t, err)
+	second, stopSecond, err := Listen("listen-mic")
+	require.NoError(t, err)
+
+	// Both listeners receive the audio of the single encoder
+	listenAudio("listen-mic", []byte("live audio"))
+	assert.Equal(t, []byte("live audio"), receiveAudio(t, first, 10))
+	assert.Equal(t, []byte("live audio"), receiveAudio(t, second, 10))
+
+	// The encoder keeps running until the last listener leaves
+	stopFirst()
+	stopFirst()
+	_, exists := listenStreams.Load("listen-mic")
+	assert.True(t, exists)
+
+	stopSecond()
+	waitForStreamStop(t, "listen-mic")
+
+	// A new listener starts a new encoder
+	third, stopThird, err := Listen("listen-mic")
+	require.NoError(t, err)
+	defer stopThird()
+	listenAudio("listen-mic", []byte("again"))
+	assert.Equal(t, []byte("again"), receiveAudio(t, third, 5))
+}
+
+func TestListenLimits(t *testing.T) {
+	testLiveListen(t, 2, "listen-a", "listen-b")
+
+	// Unknown sources are rejected
+	_, _, err := Listen("listen-missing")
+	require.ErrorIs(t, err, ErrUnknownSource)
+
+	// The listener limit applies across sources
+	_, stopA, err := Listen("listen-a")
+	require.NoError(t, err)
+	_, stopB, err := Listen("listen-b")
+	require.NoError(t, err)
+	defer stopB()
+
+	_, _, err = Listen("listen-a")
+	require.ErrorIs(t, err, ErrTooManyListeners)
+
+	stopA()
+	_, stopA, err = Listen("listen-a")
+	require.NoError(t, err)
+	defer stopA()
+}
+
+func TestListenDisabled(t *testing.T) {
+	testLiveListen(t, 1, "listen-off")
+
+	// Disabling live listening disconnects listeners
+	audio, stop, err := Listen("listen-off")
+	require.NoError(t, err)
+	defer stop()
+
+	EnableLiveListen(&conf.AudioSettings{})
+	select {
+	case _, ok := <-audio:
+		assert.False(t, ok)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "listener was not disconnected")
+	}
+
+	_, _, err = Listen("listen-off")
+	assert.ErrorIs(t, err, ErrListenDisabled)
+}
+
+func TestListenSourceStopped(t *testing.T) {
+	testLiveListen(t, 2, "listen-stopped", "listen-other")
+
+	audio, stop, err := Listen("listen-stopped")
+	require.NoError(t, err)
+	defer stop()
+	other, stopOther, err := Listen("listen-other")
+	require.NoError(t, err)
+	defer stopOther()
+
+	// Stopping a source disconnects its listeners and removes its encoder
+	stopListen("listen-stopped")
+	select {
+	case _, ok := <-audio:
+		assert.False(t, ok)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "listener was not disconnected")
+	}
+	waitForStreamStop(t, "listen-stopped")
+
+	// Listeners of other sources are not affected
+	listenAudio("listen-other", []byte("still live"))
+	assert.Equal(t, []byte("still live"), receiveAudio(t, other, 10))
+}
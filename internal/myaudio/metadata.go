@@ -0,0 +1,284 @@
+package myaudio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tphakala/flac"
+)
+
+// ClipMetadata describes the detection an exported audio clip belongs to. It is embedded into the
+// clip as WAV LIST/INFO and GUANO chunks, FLAC Vorbis comments or, through FFmpeg, ID3 tags and
+// container metadata of lossy formats.
+type ClipMetadata struct {
+	CommonName     string
+	ScientificName string
+	Confidence     float64
+	Time           time.Time // time of the detection
+	ClipStart      time.Time // time of the start of the clip, the detection is at Time - ClipStart within it
+	Latitude       float64
+	Longitude      float64
+	NodeName       string // name of the BirdNET-Go node
+	Model          string // model version
+	Software       string // name and version of the software
+}
+
+// guanoTimeFormat is the format of GUANO timestamps
+const guanoTimeFormat = "2006-01-02T15:04:05-07:00"
+
+// title returns the species of the detection as title
+func (m *ClipMetadata) title() string {
+	if m.ScientificName == "" {
+		return m.CommonName
+	}
+	return fmt.Sprintf("%s (%s)", m.CommonName, m.ScientificName)
+}
+
+// comment returns a one line summary of the detection
+func (m *ClipMetadata) comment() string {
+	comment := fmt.Sprintf("%s detected with confidence %s", m.title(), m.confidence())
+	if m.Model != "" {
+		comment += " by " + m.Model
+	}
+	return comment
+}
+
+// confidence returns the confidence formatted with two decimals
+func (m *ClipMetadata) confidence() string {
+	return strconv.FormatFloat(m.Confidence, 'f', 2, 64)
+}
+
+// hasLocation reports whether the coordinates of the node are set
+func (m *ClipMetadata) hasLocation() bool {
+	return m.Latitude != 0 || m.Longitude != 0
+}
+
+// formatCoordinate formats a latitude or longitude in decimal degrees
+func formatCoordinate(v float64) string {
+	return strconv.FormatFloat(v, 'f', 6, 64)
+}
+
+// guano returns the GUANO metadata text of the clip, see https://guano-md.org
+func (m *ClipMetadata) guano(durationSeconds float64) string {
+	var b strings.Builder
+	field := func(key, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "%s: %s\n", key, value)
+		}
+	}
+
+	field("GUANO|Version", "1.0")
+	field("Make", "BirdNET-Go")
+	field("Firmware Version", m.Software)
+	if !m.Time.IsZero() {
+		field("Timestamp", m.Time.Format(guanoTimeFormat))
+	}
+	if !m.ClipStart.IsZero() {
+		field("BirdNET|Clip Start", m.ClipStart.Format(guanoTimeFormat))
+	}
+	field("Length", strconv.FormatFloat(durationSeconds, 'f', 2, 64))
+	if m.hasLocation() {
+		field("Loc Position", formatCoordinate(m.Latitude)+" "+formatCoordinate(m.Longitude))
+	}
+	field("Species Auto ID", m.ScientificName)
+	field("BirdNET|Common Name", m.CommonName)
+	field("BirdNET|Confidence", m.confidence())
+	field("BirdNET|Model", m.Model)
+	field("BirdNET|Node", m.NodeName)
+	return b.String()
+}
+
+// wavChunks returns the LIST/INFO and GUANO chunks which are appended to a WAV file
+func (m *ClipMetadata) wavChunks(durationSeconds float64) []byte {
+	var info bytes.Buffer
+	info.WriteString("INFO")
+	infoField := func(id, value string) {
+		if value != "" {
+			writeRIFFChunk(&info, id, append([]byte(value), 0))
+		}
+	}
+	infoField("INAM", m.title())
+	infoField("ISBJ", m.ScientificName)
+	infoField("IART", m.NodeName)
+	if !m.Time.IsZero() {
+		infoField("ICRD", m.Time.Format(time.RFC3339))
+	}
+	infoField("ICMT", m.comment())
+	infoField("ISFT", m.Software)
+
+	var chunks bytes.Buffer
+	writeRIFFChunk(&chunks, "LIST", info.Bytes())
+	writeRIFFChunk(&chunks, "guan", []byte(m.guano(durationSeconds)))
+	return chunks.Bytes()
+}
+
+// writeRIFFChunk writes a RIFF chunk, padding the data to an even length
+func writeRIFFChunk(b *bytes.Buffer, id string, data []byte) {
+	b.WriteString(id)
+	_ = binary.Write(b, binary.LittleEndian, uint32(len(data)))
+	b.Write(data)
+	if len(data)%2 == 1 {
+		b.WriteByte(0)
+	}
+}
+
+// vorbisComments returns the clip metadata as Vorbis comments for FLAC files
+func (m *ClipMetadata) vorbisComments() []string {
+	comments := []string{
+		"TITLE=" + m.title(),
+		"ARTIST=" + m.NodeName,
+		"COMMENT=" + m.comment(),
+		"SPECIES=" + m.CommonName,
+		"SCIENTIFIC_NAME=" + m.ScientificName,
+		"CONFIDENCE=" + m.confidence(),
+	}
+	if !m.Time.IsZero() {
+		comments = append(comments, "DATE="+m.Time.Format(time.RFC3339))
+	}
+	if !m.ClipStart.IsZero() {
+		comments = append(comments, "CLIP_START="+m.ClipStart.Format(time.RFC3339))
+	}
+	if m.hasLocation() {
+		comments = append(comments, "LATITUDE="+formatCoordinate(m.Latitude), "LONGITUDE="+formatCoordinate(m.Longitude))
+	}
+	if m.Model != "" {
+		comments = append(comments, "MODEL="+m.Model)
+	}
+	if m.Software != "" {
+		comments = append(comments, "ENCODER="+m.Software)
+	}
+	return comments
+}
+
+// ffmpegArgs returns the FFmpeg output options which write the clip metadata, for MP3 these
+// become ID3v2 tags with custom fields stored as TXXX frames
+func (m *ClipMetadata) ffmpegArgs(format string) []string {
+	var args []string
+	for _, comment := range m.vorbisComments() {
+		key, value, _ := strings.Cut(comment, "=")
+		if key == "ENCODER" {
+			// FFmpeg sets the encoder tag itself
+			continue
+		}
+		args = append(args, "-metadata", strings.ToLower(key)+"="+value)
+	}
+	if format == "mp3" {
+		args = append(args, "-id3v2_version", "3")
+	}
+	return args
+}
+
+// maxGUANOSize limits the size of a GUANO chunk which is read from a WAV file
+const maxGUANOSize = 64 * 1024
+
+// errNoClipTimes is returned for audio files which do not record the time of a detection in a clip
+var errNoClipTimes = errors.New("audio file has no recorded detection and clip start times")
+
+// ReadClipTimes returns the time of the detection and the start time of an exported audio clip
+// recorded in its metadata. WAV and FLAC clips are read directly, other formats require FFmpeg.
+func ReadClipTimes(filePath string) (detection, clipStart time.Time, err error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	defer file.Close()
+
+	format, err := detectAudioFormat(file, filePath)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	var tags map[string]string
+	layout := time.RFC3339
+	switch format {
+	case formatWAV:
+		tags, err = readGUANO(file)
+		tags = map[string]string{"date": tags["Timestamp"], "clip_start": tags["BirdNET|Clip Start"]}
+		layout = guanoTimeFormat
+	case formatFLAC:
+		tags, err = readFLACTags(file)
+	case formatMP3, formatOgg, formatM4A:
+		tags, err = readFFmpegTags(filePath)
+	default:
+		return time.Time{}, time.Time{}, errNoClipTimes
+	}
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	if tags["date"] == "" || tags["clip_start"] == "" {
+		return time.Time{}, time.Time{}, errNoClipTimes
+	}
+	if detection, err = time.Parse(layout, tags["date"]); err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid detection time: %w", err)
+	}
+	if clipStart, err = time.Parse(layout, tags["clip_start"]); err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid clip start time: %w", err)
+	}
+	return detection, clipStart, nil
+}
+
+// readGUANO returns the fields of the GUANO chunk of a WAV file, nil if the file has none
+func readGUANO(file *os.File) (map[string]string, error) {
+	if _, err := file.Seek(12, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(file, header); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		size := int64(binary.LittleEndian.Uint32(header[4:8]))
+		if string(header[0:4]) != "guan" {
+			// Chunks are padded to an even length
+			if _, err := file.Seek(size+size%2, io.SeekCurrent); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if size > maxGUANOSize {
+			return nil, fmt.Errorf("GUANO chunk of %d bytes is too large", size)
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(file, data); err != nil {
+			return nil, fmt.Errorf("error reading GUANO chunk: %w", err)
+		}
+		fields := make(map[string]string)
+		for _, line := range strings.Split(string(data), "\n") {
+			if key, value, ok := strings.Cut(line, ":"); ok {
+				fields[strings.TrimSpace(key)] = strings.TrimSpace(value)
+			}
+		}
+		return fields, nil
+	}
+}
+
+// readFLACTags returns the Vorbis comments of a FLAC file with lower case keys
+func readFLACTags(file *os.File) (map[string]string, error) {
+	decoder, err := flac.NewDecoder(file)
+	if err != nil {
+		return nil, fmt.Errorf("invalid FLAC file: %w", err)
+	}
+
+	tags := make(map[string]string)
+	if decoder.VorbisComment != nil {
+		for _, comment := range decoder.VorbisComment.Comments {
+			if key, value, ok := strings.Cut(comment, "="); ok {
+				tags[strings.ToLower(key)] = value
+			}
+		}
+	}
+	return tags, nil
+}
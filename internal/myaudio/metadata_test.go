@@ -0,0 +1,138 @@
+package myaudio
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-audio/wav"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/flac"
+)
+
+// testClipMetadata returns metadata of a sample detection
+func testClipMetadata() *ClipMetadata {
+	return &ClipMetadata{
+		CommonName:     "Eurasian Blackbird",
+		ScientificName: "Turdus merula",
+		Confidence:     0.8765,
+		Time:           time.Date(2024, 5, 1, 5, 30, 0, 0, time.UTC),
+		ClipStart:      time.Date(2024, 5, 1, 5, 29, 57, 0, time.UTC),
+		Latitude:       60.1699,
+		Longitude:      24.9384,
+		NodeName:       "garden",
+		Model:          "BirdNET GLOBAL 6K V2.4 FP32",
+		Software:       "BirdNET-Go 1.0",
+	}
+}
+
+// TestSavePCMDataToWAVMetadata tests that WAV clips carry INFO and GUANO metadata and remain readable
+func TestSavePCMDataToWAVMetadata(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clip.wav")
+	pcm := make([]byte, conf.SampleRate*2)
+	require.NoError(t, SavePCMDataToWAV(path, pcm, testClipMetadata()))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "INAM")
+	assert.Contains(t, string(data), "Eurasian Blackbird (Turdus merula)")
+	assert.Contains(t, string(data), "GUANO|Version: 1.0\n")
+	assert.Contains(t, string(data), "Loc Position: 60.169900 24.938400\n")
+	assert.Contains(t, string(data), "Species Auto ID: Turdus merula\n")
+	assert.Contains(t, string(data), "BirdNET|Confidence: 0.88\n")
+	assert.Equal(t, 0, len(data)%2, "chunks must be word aligned")
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	decoder := wav.NewDecoder(file)
+	buf, err := decoder.FullPCMBuffer()
+	require.NoError(t, err)
+	assert.Len(t, buf.Data, conf.SampleRate)
+	decoder.ReadMetadata()
+	require.NoError(t, decoder.Err())
+	require.NotNil(t, decoder.Metadata)
+	assert.Equal(t, "garden", decoder.Metadata.Artist)
+}
+
+// TestSavePCMDataToFLACMetadata tests that FLAC clips carry the metadata as Vorbis comments
+func TestSavePCMDataToFLACMetadata(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clip.flac")
+	pcm := make([]byte, conf.SampleRate*2)
+	require.NoError(t, SavePCMDataToFLAC(path, pcm, conf.SampleRate, 1, testClipMetadata()))
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	decoded, meta, err := flac.Decode(file)
+	require.NoError(t, err)
+	assert.Equal(t, pcm, decoded)
+	require.NotNil(t, meta.VorbisComment)
+	assert.Contains(t, meta.VorbisComment.Comments, "TITLE=Eurasian Blackbird (Turdus merula)")
+	assert.Contains(t, meta.VorbisComment.Comments, "CONFIDENCE=0.88")
+	assert.Contains(t, meta.VorbisComment.Comments, "LATITUDE=60.169900")
+	assert.Contains(t, meta.VorbisComment.Comments, "DATE=2024-05-01T05:30:00Z")
+}
+
+// TestBuildFFmpegArgsMetadata tests that metadata is passed to FFmpeg as output options
+func TestBuildFFmpegArgsMetadata(t *testing.T) {
+	settings := &conf.AudioSettings{}
+	settings.Export.Type = "mp3"
+	settings.Export.Bitrate = "96k"
+
+	args := buildFFmpegArgs("clip.mp3.temp", settings, testClipMetadata())
+	assert.Contains(t, args, "title=Eurasian Blackbird (Turdus merula)")
+	assert.Contains(t, args, "scientific_name=Turdus merula")
+	assert.Contains(t, args, "-id3v2_version")
+	assert.Equal(t, "clip.mp3.temp", args[len(args)-1])
+
+	args = buildFFmpegArgs("clip.opus.temp", settings, nil)
+	assert.NotContains(t, args, "-metadata")
+}
+
+// TestReadClipTimes tests that the detection and clip start times are read back from WAV and FLAC clips
+func TestReadClipTimes(t *testing.T) {
+	dir := t.TempDir()
+	pcm := make([]byte, conf.SampleRate*2)
+	meta := testClipMetadata()
+
+	wavPath := filepath.Join(dir, "clip.wav")
+	require.NoError(t, SavePCMDataToWAV(wavPath, pcm, meta))
+	flacPath := filepath.Join(dir, "clip.flac")
+	require.NoError(t, SavePCMDataToFLAC(flacPath, pcm, conf.SampleRate, 1, meta))
+
+	for _, path := range []string{wavPath, flacPath} {
+		detection, clipStart, err := ReadClipTimes(path)
+		require.NoError(t, err, path)
+		assert.True(t, meta.Time.Equal(detection), path)
+		assert.True(t, meta.ClipStart.Equal(clipStart), path)
+	}
+
+	// Clips without metadata have no recorded times
+	require.NoError(t, SavePCMDataToWAV(wavPath, pcm, nil))
+	_, _, err := ReadClipTimes(wavPath)
+	assert.ErrorIs(t, err, errNoClipTimes)
+}
+
+// TestParseFFmpegTags tests parsing of metadata tags from FFmpeg input information
+func TestParseFFmpegTags(t *testing.T) {
+	output := `Input #0, mp3, from 'clip.mp3':
+  Metadata:
+    title           : Eurasian Blackbird (Turdus merula)
+    date            : 2024-05-01T05:30:00Z
+    CLIP_START      : 2024-05-01T05:29:57Z
+  Duration: 00:00:15.02, start: 0.025057, bitrate: 97 kb/s
+  Stream #0:0: Audio: mp3, 48000 Hz, mono, fltp, 96 kb/s
+    Metadata:
+      title           : other
+`
+	tags := parseFFmpegTags(output)
+	assert.Equal(t, "Eurasian Blackbird (Turdus merula)", tags["title"])
+	assert.Equal(t, "2024-05-01T05:30:00Z", tags["date"])
+	assert.Equal(t, "2024-05-01T05:29:57Z", tags["clip_start"])
+}
@@ -0,0 +1,68 @@
+package myaudio
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/myaudio/denoise"
+)
+
+// newDenoiser creates a denoiser with the noise reduction settings
+func newDenoiser(settings *conf.NoiseReductionSettings) (*denoise.Denoiser, error) {
+	return denoise.New(float64(conf.SampleRate), settings.Method, settings.Reduction, settings.Threshold, settings.Adaptation)
+}
+
+// reduceAnalysisNoise returns the audio of a source with noise reduced if the filter chain of
+// the source reduces noise of analyzed audio, the returned slice is a copy and data is not modified
+func reduceAnalysisNoise(sourceID string, data []byte) []byte {
+	chain := getFilterChain(sourceID)
+	if chain == nil || chain.denoiser == nil || len(data) < 2 {
+		return data
+	}
+
+	samples := pcmToFloat64(data)
+	chain.mu.Lock()
+	chain.denoiser.Process(samples)
+	chain.mu.Unlock()
+
+	return float64ToPCM(samples)
+}
+
+// ReduceNoise returns a copy of a 16-bit PCM audio clip with background noise reduced. The noise
+// profile is learned from the clip itself before it is processed.
+func ReduceNoise(data []byte, settings *conf.NoiseReductionSettings) ([]byte, error) {
+	d, err := newDenoiser(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	samples := pcmToFloat64(data)
+	d.Learn(samples)
+
+	// The clip is padded so that the delay of the denoiser can be removed
+	latency := d.Latency()
+	samples = append(samples, make([]float64, latency)...)
+	d.Process(samples)
+
+	return float64ToPCM(samples[latency:]), nil
+}
+
+// pcmToFloat64 converts 16-bit PCM audio to samples in the range -1 to 1
+func pcmToFloat64(data []byte) []float64 {
+	samples := make([]float64, len(data)/2)
+	for i := range samples {
+		samples[i] = float64(int16(binary.LittleEndian.Uint16(data[i*2:]))) / 32768.0
+	}
+	return samples
+}
+
+// float64ToPCM converts samples in the range -1 to 1 to 16-bit PCM audio, samples out of range are clipped
+func float64ToPCM(samples []float64) []byte {
+	data := make([]byte, len(samples)*2)
+	for i, sample := range samples {
+		v := math.Round(sample * 32767.0)
+		binary.LittleEndian.PutUint16(data[i*2:], uint16(int16(max(min(v, math.MaxInt16), math.MinInt16))))
+	}
+	return data
+}
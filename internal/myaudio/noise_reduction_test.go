@@ -0,0 +1,80 @@
+package myaudio
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// testNoiseReductionSettings returns noise reduction settings used in tests
+func testNoiseReductionSettings() *conf.NoiseReductionSettings {
+	return &conf.NoiseReductionSettings{Method: "spectral", Reduction: 20, Threshold: 10, Adaptation: 1}
+}
+
+// levelOf returns the RMS level of 16-bit PCM audio in dBFS
+func levelOf(data []byte) float64 {
+	return MeasureSignalLevels("noise-reduction-level", data).RMS
+}
+
+func TestReduceNoise(t *testing.T) {
+	// Background hum with a call in the last half second
+	var clip []byte
+	for i := 0; i < 3; i++ {
+		clip = append(clip, sinePCM(200, 0.01)...)
+	}
+	background := len(clip) / 6 * 5
+	call := sinePCM(3000, 0.3)
+	for i := background; i < len(clip); i += 2 {
+		sample := int16(binary.LittleEndian.Uint16(clip[i:])) + int16(binary.LittleEndian.Uint16(call[i-background:]))
+		binary.LittleEndian.PutUint16(clip[i:], uint16(sample))
+	}
+
+	denoised, err := ReduceNoise(clip, testNoiseReductionSettings())
+	require.NoError(t, err)
+	require.Len(t, denoised, len(clip))
+
+	// The background is reduced and the call is kept in place
+	assert.Less(t, levelOf(denoised[:background]), levelOf(clip[:background])-10)
+	assert.InDelta(t, levelOf(clip[background+4096:]), levelOf(denoised[background+4096:]), 1)
+
+	_, err = ReduceNoise(clip, &conf.NoiseReductionSettings{Method: "wiener", Reduction: 20, Threshold: 10, Adaptation: 1})
+	assert.Error(t, err)
+}
+
+func TestAnalysisNoiseReduction(t *testing.T) {
+	t.Cleanup(func() {
+		removeFilterChain("nr-mic")
+		removeFilterChain("nr-camera")
+	})
+
+	audio := sinePCM(200, 0.01)
+
+	// Sources without noise reduction pass audio through
+	require.NoError(t, UpdateFilterChain("nr-camera", &conf.AudioFilterSettings{}))
+	assert.Equal(t, audio, reduceAnalysisNoise("nr-camera", audio))
+	assert.Equal(t, audio, reduceAnalysisNoise("nr-unknown", audio))
+
+	// Enabled noise reduction returns processed audio and keeps the input unchanged
+	filters := &conf.AudioFilterSettings{NoiseReduction: *testNoiseReductionSettings()}
+	filters.NoiseReduction.Analysis = true
+	require.NoError(t, UpdateFilterChain("nr-mic", filters))
+	input := append([]byte(nil), audio...)
+	output := reduceAnalysisNoise("nr-mic", input)
+	assert.Equal(t, audio, input)
+	assert.Len(t, output, len(audio))
+	assert.NotEqual(t, audio, output)
+
+	// Invalid settings are rejected and the current denoiser is kept
+	denoiser := getFilterChain("nr-mic").denoiser
+	filters.NoiseReduction.Reduction = 0
+	assert.Error(t, UpdateFilterChain("nr-mic", filters))
+	assert.Same(t, denoiser, getFilterChain("nr-mic").denoiser)
+
+	// Changed settings replace the denoiser and its learned noise profile
+	filters.NoiseReduction.Reduction = 12
+	require.NoError(t, UpdateFilterChain("nr-mic", filters))
+	assert.NotSame(t, denoiser, getFilterChain("nr-mic").denoiser)
+}
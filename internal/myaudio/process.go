@@ -2,9 +2,11 @@
 package myaudio
 
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"log"
+	"math"
 	"time"
 
 	"github.com/tphakala/birdnet-go/internal/analysis/queue"
@@ -24,8 +26,13 @@ func ProcessData(bn *birdnet.BirdNET, data []byte, startTime time.Time, source s
 		return fmt.Errorf("error converting %v bit PCM data to float32: %w", conf.BitDepth, err)
 	}
 
+	// resample audio data to the sample rate of the model
+	if sampleData[0], err = ResampleToModel(sampleData[0]); err != nil {
+		return fmt.Errorf("error resampling audio for the model: %w", err)
+	}
+
 	// run BirdNET inference
-	results, err := bn.Predict(sampleData)
+	results, embedding, err := bn.PredictWithEmbedding(sampleData)
 	if err != nil {
 		return fmt.Errorf("error predicting species: %w", err)
 	}
@@ -57,10 +64,8 @@ func ProcessData(bn *birdnet.BirdNET, data []byte, startTime time.Time, source s
 	// Get the current settings
 	settings := conf.Setting()
 
-	// Calculate the effective buffer duration
-	bufferDuration := 3 * time.Second // base duration
-	overlapDuration := time.Duration(settings.BirdNET.Overlap * float64(time.Second))
-	effectiveBufferDuration := bufferDuration - overlapDuration
+	// Calculate the effective buffer duration from the model window and overlap
+	effectiveBufferDuration := time.Duration(birdnet.ActiveModel().Step(settings.BirdNET.Overlap) * float64(time.Second))
 
 	// Check if processing time exceeds effective buffer duration
 	if elapsedTime > effectiveBufferDuration {
@@ -74,6 +79,7 @@ func ProcessData(bn *birdnet.BirdNET, data []byte, startTime time.Time, source s
 		ElapsedTime: elapsedTime,
 		PCMdata:     data,
 		Results:     results,
+		Embedding:   embedding,
 		Source:      source,
 	}
 
@@ -91,6 +97,23 @@ func ProcessData(bn *birdnet.BirdNET, data []byte, startTime time.Time, source s
 	return nil
 }
 
+// sendEndOfSource tells the processor that all audio of a source has been analyzed, so it can
+// flush the pending detections of the source without waiting for their deadlines. It waits for
+// room in the results queue so the message is not dropped, returns false if quit is closed first.
+func sendEndOfSource(source string, quit <-chan struct{}) bool {
+	select {
+	case queue.ResultsQueue <- queue.Results{StartTime: SourceNow(source), Source: source, EndOfSource: true}:
+		return true
+	case <-quit:
+		return false
+	}
+}
+
+// ResampleToModel resamples audio at the capture sample rate to the sample rate of the active model
+func ResampleToModel(samples []float32) ([]float32, error) {
+	return ResampleAudio(samples, conf.SampleRate, birdnet.ActiveModel().SampleRate)
+}
+
 func logProcessingTime(startTime time.Time) time.Duration {
 	var elapsedTime = time.Since(startTime)
 	/*if ctx.Settings.Realtime.ProcessingTime || ctx.Settings.Debug {
@@ -159,3 +182,14 @@ func convert32BitToFloat32(sample []byte) []float32 {
 
 	return float32Data
 }
+
+// ConvertFloat32ToPCM16 converts float32 samples in the range [-1, 1] to 16-bit little-endian PCM,
+// samples outside the range are clipped
+func ConvertFloat32ToPCM16(samples []float32) []byte {
+	pcm := make([]byte, len(samples)*2)
+	for i, sample := range samples {
+		value := math.Max(-32768, math.Min(32767, math.Round(float64(sample)*32768)))
+		binary.LittleEndian.PutUint16(pcm[i*2:], uint16(int16(value)))
+	}
+	return pcm
+}
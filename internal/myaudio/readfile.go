@@ -1,18 +1,27 @@
 package myaudio
 
 import (
+	"bytes"
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
+	"github.com/tphakala/birdnet-go/internal/birdnet"
 	"github.com/tphakala/birdnet-go/internal/conf"
 )
 
 // AudioChunkCallback is a function type that processes audio chunks
 type AudioChunkCallback func([]float32) error
 
+// AudioChannelChunkCallback is a function type that processes audio chunks of a single channel,
+// channel is the zero based index of the channel in the audio file
+type AudioChannelChunkCallback func(channel int, chunk []float32) error
+
 // GetAudioInfo returns basic information about the audio file
 type AudioInfo struct {
 	SampleRate   int
@@ -21,10 +30,107 @@ type AudioInfo struct {
 	BitDepth     int
 }
 
-// GetTotalChunks calculates the total number of chunks for a given audio file
+// audioFormat identifies the container format of an audio file
+type audioFormat string
+
+const (
+	formatUnknown audioFormat = ""
+	formatWAV     audioFormat = "wav"
+	formatFLAC    audioFormat = "flac"
+	formatAIFF    audioFormat = "aiff"
+	formatMP3     audioFormat = "mp3"
+	formatOgg     audioFormat = "ogg"
+	formatM4A     audioFormat = "m4a"
+)
+
+// supportedExtensions maps lower case file extensions to their audio formats
+var supportedExtensions = map[string]audioFormat{
+	".wav":  formatWAV,
+	".flac": formatFLAC,
+	".aif":  formatAIFF,
+	".aiff": formatAIFF,
+	".aifc": formatAIFF,
+	".mp3":  formatMP3,
+	".ogg":  formatOgg,
+	".oga":  formatOgg,
+	".opus": formatOgg,
+	".m4a":  formatM4A,
+	".mp4":  formatM4A,
+	".aac":  formatM4A,
+}
+
+// IsSupportedAudioFile reports whether the file extension belongs to a supported audio format
+func IsSupportedAudioFile(filePath string) bool {
+	_, ok := supportedExtensions[strings.ToLower(filepath.Ext(filePath))]
+	return ok
+}
+
+// SupportedAudioExtensions returns a sorted list of supported audio file extensions
+func SupportedAudioExtensions() []string {
+	extensions := make([]string, 0, len(supportedExtensions))
+	for ext := range supportedExtensions {
+		extensions = append(extensions, ext)
+	}
+	sort.Strings(extensions)
+	return extensions
+}
+
+// sniffAudioFormat detects the audio format from the first bytes of a file
+func sniffAudioFormat(header []byte) audioFormat {
+	switch {
+	case len(header) >= 12 && bytes.Equal(header[0:4], []byte("RIFF")) && bytes.Equal(header[8:12], []byte("WAVE")):
+		return formatWAV
+	case len(header) >= 4 && bytes.Equal(header[0:4], []byte("fLaC")):
+		return formatFLAC
+	case len(header) >= 12 && bytes.Equal(header[0:4], []byte("FORM")) &&
+		(bytes.Equal(header[8:12], []byte("AIFF")) || bytes.Equal(header[8:12], []byte("AIFC"))):
+		return formatAIFF
+	case len(header) >= 4 && bytes.Equal(header[0:4], []byte("OggS")):
+		return formatOgg
+	case len(header) >= 8 && bytes.Equal(header[4:8], []byte("ftyp")):
+		return formatM4A
+	case len(header) >= 3 && bytes.Equal(header[0:3], []byte("ID3")):
+		return formatMP3
+	case len(header) >= 2 && header[0] == 0xFF && header[1]&0xF6 == 0xF0:
+		// ADTS AAC frame sync, layer bits are always zero
+		return formatM4A
+	case len(header) >= 2 && header[0] == 0xFF && header[1]&0xE0 == 0xE0:
+		// MPEG audio frame sync without ID3 tag
+		return formatMP3
+	default:
+		return formatUnknown
+	}
+}
+
+// detectAudioFormat determines the audio format by content sniffing, falling back to the
+// file extension if the content is not recognized. The file offset is reset to the start.
+func detectAudioFormat(file *os.File, filePath string) (audioFormat, error) {
+	header := make([]byte, 12)
+	n, err := io.ReadFull(file, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return formatUnknown, fmt.Errorf("error reading file header: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return formatUnknown, fmt.Errorf("error rewinding file: %w", err)
+	}
+
+	if format := sniffAudioFormat(header[:n]); format != formatUnknown {
+		return format, nil
+	}
+
+	ext := strings.ToLower(filepath.Ext(filePath))
+	if format, ok := supportedExtensions[ext]; ok {
+		return format, nil
+	}
+
+	return formatUnknown, fmt.Errorf("unsupported audio format: %s", ext)
+}
+
+// GetTotalChunks calculates the total number of analysis windows of the active model for a given audio file
 func GetTotalChunks(sampleRate, totalSamples int, overlap float64) int {
-	chunkSamples := 3 * sampleRate                          // samples in 3 seconds
-	stepSamples := int((3 - overlap) * float64(sampleRate)) // samples per step based on overlap
+	model := birdnet.ActiveModel()
+	chunkSamples := int(model.WindowSeconds * float64(sampleRate)) // samples in a window
+	stepSamples := int(model.Step(overlap) * float64(sampleRate))  // samples per step based on overlap
 
 	if stepSamples <= 0 {
 		return 0
@@ -41,35 +147,226 @@ func GetAudioInfo(filePath string) (AudioInfo, error) {
 	}
 	defer file.Close()
 
-	ext := strings.ToLower(filepath.Ext(filePath))
+	format, err := detectAudioFormat(file, filePath)
+	if err != nil {
+		return AudioInfo{}, err
+	}
 
-	switch ext {
-	case ".wav":
+	switch format {
+	case formatWAV:
 		return readWAVInfo(file)
-	case ".flac":
+	case formatFLAC:
 		return readFLACInfo(file)
+	case formatAIFF:
+		return readAIFFInfo(file)
+	case formatMP3, formatOgg, formatM4A:
+		return readFFmpegInfo(filePath)
 	default:
-		return AudioInfo{}, fmt.Errorf("unsupported audio format: %s", ext)
+		return AudioInfo{}, fmt.Errorf("unsupported audio format: %s", format)
 	}
 }
 
-// ReadAudioFileBuffered reads and processes audio data in chunks
+// ReadAudioFileBuffered reads and processes audio data in chunks, multi-channel audio is downmixed to mono
 func ReadAudioFileBuffered(settings *conf.Settings, callback AudioChunkCallback) error {
+	return readAudioFile(settings, false, func(_ int, chunk []float32) error {
+		return callback(chunk)
+	})
+}
+
+// ReadAudioFileChannels reads audio data and processes each channel of the file independently
+func ReadAudioFileChannels(settings *conf.Settings, callback AudioChannelChunkCallback) error {
+	return readAudioFile(settings, true, callback)
+}
+
+// readAudioFile detects the audio format of the input file and decodes it with the matching reader
+func readAudioFile(settings *conf.Settings, splitChannels bool, callback AudioChannelChunkCallback) error {
 	file, err := os.Open(settings.Input.Path)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
-	ext := strings.ToLower(filepath.Ext(settings.Input.Path))
+	format, err := detectAudioFormat(file, settings.Input.Path)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case formatWAV:
+		return readWAVBuffered(file, settings, splitChannels, callback)
+	case formatFLAC:
+		return readFLACBuffered(file, settings, splitChannels, callback)
+	case formatAIFF:
+		return readAIFFBuffered(file, settings, splitChannels, callback)
+	case formatMP3, formatOgg, formatM4A:
+		return readFFmpegBuffered(settings.Input.Path, settings, splitChannels, callback)
+	default:
+		return fmt.Errorf("unsupported audio format: %s", format)
+	}
+}
+
+// channelRouter takes interleaved samples from a decoder and either downmixes them to mono
+// or separates the channels, then resamples and chunks each output independently
+type channelRouter struct {
+	sampleRate  int
+	numChannels int
+	chunkers    []*audioChunker
+	resamplers  []*Resampler // resampler of each output, nil if the sample rate needs no resampling
+}
 
-	switch ext {
-	case ".wav":
-		return readWAVBuffered(file, settings, callback)
-	case ".flac":
-		return readFLACBuffered(file, settings, callback)
+// newChannelRouter creates a router for decoded audio of the given sample rate and channel count
+func newChannelRouter(sampleRate, numChannels int, overlap float64, splitChannels bool, callback AudioChannelChunkCallback) *channelRouter {
+	outputs := 1
+	if splitChannels {
+		outputs = numChannels
+	}
+
+	r := &channelRouter{
+		sampleRate:  sampleRate,
+		numChannels: numChannels,
+		chunkers:    make([]*audioChunker, outputs),
+	}
+	for ch := range r.chunkers {
+		r.chunkers[ch] = newAudioChunker(overlap, func(chunk []float32) error {
+			return callback(ch, chunk)
+		})
+	}
+	return r
+}
+
+// write processes a block of interleaved samples, partial frames are ignored
+func (r *channelRouter) write(interleaved []float32) error {
+	frames := len(interleaved) / r.numChannels
+	outputs := make([][]float32, len(r.chunkers))
+	for ch := range outputs {
+		outputs[ch] = make([]float32, frames)
+	}
+
+	downmix := len(r.chunkers) == 1 && r.numChannels > 1
+	for i := 0; i < frames; i++ {
+		frame := interleaved[i*r.numChannels : (i+1)*r.numChannels]
+		if downmix {
+			var sum float32
+			for _, sample := range frame {
+				sum += sample
+			}
+			outputs[0][i] = sum / float32(r.numChannels)
+			continue
+		}
+		for ch := range outputs {
+			outputs[ch][i] = frame[ch]
+		}
+	}
+
+	// Outputs are resampled as continuous streams so there are no artifacts at block boundaries
+	if r.sampleRate != conf.SampleRate && r.resamplers == nil {
+		r.resamplers = make([]*Resampler, len(r.chunkers))
+		for ch := range r.resamplers {
+			resampler, err := NewResampler(r.sampleRate, conf.SampleRate)
+			if err != nil {
+				return fmt.Errorf("error resampling audio: %w", err)
+			}
+			r.resamplers[ch] = resampler
+		}
+	}
+
+	for ch, samples := range outputs {
+		if r.resamplers != nil {
+			samples = r.resamplers[ch].Process(samples)
+		}
+		if err := r.chunkers[ch].write(samples); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flush processes the audio held by the resamplers and the remaining partial chunks of all outputs
+func (r *channelRouter) flush() error {
+	for ch, chunker := range r.chunkers {
+		if r.resamplers != nil {
+			if err := chunker.write(r.resamplers[ch].Flush()); err != nil {
+				return err
+			}
+		}
+		if err := chunker.flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// audioChunker splits a continuous stream of 48 kHz samples into overlapping
+// analysis chunks of the window of the active model and passes them to the callback
+type audioChunker struct {
+	buffer   []float32
+	step     int
+	size     int
+	callback AudioChunkCallback
+}
+
+// newAudioChunker creates a chunker for the given overlap in seconds
+func newAudioChunker(overlap float64, callback AudioChunkCallback) *audioChunker {
+	model := birdnet.ActiveModel()
+	return &audioChunker{
+		step:     int(model.Step(overlap) * conf.SampleRate),
+		size:     int(model.WindowSeconds * conf.SampleRate),
+		callback: callback,
+	}
+}
+
+// write appends samples and processes all complete chunks
+func (c *audioChunker) write(samples []float32) error {
+	c.buffer = append(c.buffer, samples...)
+
+	for len(c.buffer) >= c.size {
+		if err := c.callback(c.buffer[:c.size]); err != nil {
+			return err
+		}
+		c.buffer = c.buffer[c.step:]
+	}
+	return nil
+}
+
+// flush pads and processes the remaining partial chunk, if any
+func (c *audioChunker) flush() error {
+	if len(c.buffer) == 0 {
+		return nil
+	}
+	chunk := c.buffer
+	if len(chunk) < c.size {
+		chunk = append(chunk, make([]float32, c.size-len(chunk))...)
+	}
+	c.buffer = nil
+	return c.callback(chunk)
+}
+
+// decodePCMSample decodes a single 16, 24 or 32 bit signed integer PCM sample
+func decodePCMSample(b []byte, littleEndian bool) int32 {
+	switch len(b) {
+	case 2:
+		if littleEndian {
+			return int32(int16(binary.LittleEndian.Uint16(b)))
+		}
+		return int32(int16(binary.BigEndian.Uint16(b)))
+	case 3:
+		var sample int32
+		if littleEndian {
+			sample = int32(b[0]) | int32(b[1])<<8 | int32(b[2])<<16
+		} else {
+			sample = int32(b[2]) | int32(b[1])<<8 | int32(b[0])<<16
+		}
+		if sample&0x00800000 != 0 {
+			sample |= ^0x00FFFFFF // Two's complement sign extension
+		}
+		return sample
+	case 4:
+		if littleEndian {
+			return int32(binary.LittleEndian.Uint32(b))
+		}
+		return int32(binary.BigEndian.Uint32(b))
 	default:
-		return fmt.Errorf("unsupported audio format: %s", ext)
+		return 0
 	}
 }
 
@@ -0,0 +1,221 @@
+package myaudio
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// aiffMaxCOMMSize is the largest COMM chunk, the AIFF-C fields followed by a compression name
+// of at most 255 characters with its length byte
+const aiffMaxCOMMSize = 22 + 256
+
+// aiffHeader holds the parsed COMM and SSND chunk information of an AIFF or AIFF-C file
+type aiffHeader struct {
+	numChannels  int
+	numFrames    int
+	bitDepth     int
+	sampleRate   int
+	littleEndian bool  // true for AIFF-C files with "sowt" compression
+	dataOffset   int64 // offset of the first sample frame in the file
+}
+
+// parseAIFFHeader walks the chunks of an AIFF or AIFF-C file and returns the audio format
+func parseAIFFHeader(r io.ReadSeeker) (aiffHeader, error) {
+	var header aiffHeader
+
+	form := make([]byte, 12)
+	if _, err := io.ReadFull(r, form); err != nil {
+		return header, fmt.Errorf("error reading AIFF header: %w", err)
+	}
+	if string(form[0:4]) != "FORM" || (string(form[8:12]) != "AIFF" && string(form[8:12]) != "AIFC") {
+		return header, errors.New("invalid AIFF file format")
+	}
+	isAIFC := string(form[8:12]) == "AIFC"
+
+	foundComm, foundData := false, false
+	offset := int64(12)
+	chunkHeader := make([]byte, 8)
+
+	for !(foundComm && foundData) {
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return header, err
+		}
+		if _, err := io.ReadFull(r, chunkHeader); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				break
+			}
+			return header, fmt.Errorf("error reading AIFF chunk: %w", err)
+		}
+		chunkID := string(chunkHeader[0:4])
+		chunkSize := int64(binary.BigEndian.Uint32(chunkHeader[4:8]))
+
+		switch chunkID {
+		case "COMM":
+			// The size is checked before allocating so a corrupt header cannot exhaust memory
+			if chunkSize > aiffMaxCOMMSize {
+				return header, fmt.Errorf("invalid AIFF COMM chunk size: %d", chunkSize)
+			}
+			comm := make([]byte, chunkSize)
+			if _, err := io.ReadFull(r, comm); err != nil {
+				return header, fmt.Errorf("error reading AIFF COMM chunk: %w", err)
+			}
+			if len(comm) < 18 {
+				return header, errors.New("invalid AIFF COMM chunk size")
+			}
+			header.numChannels = int(binary.BigEndian.Uint16(comm[0:2]))
+			header.numFrames = int(binary.BigEndian.Uint32(comm[2:6]))
+			header.bitDepth = int(binary.BigEndian.Uint16(comm[6:8]))
+
+			// Reject malformed formats here so no reader divides by zero or loops on empty reads
+			if header.numChannels < 1 {
+				return header, fmt.Errorf("invalid AIFF number of channels: %d", header.numChannels)
+			}
+			if header.numFrames < 0 {
+				return header, fmt.Errorf("invalid AIFF number of sample frames: %d", header.numFrames)
+			}
+			sampleRate := extendedToFloat64(comm[8:18])
+			if math.IsNaN(sampleRate) || sampleRate < 1 || sampleRate > math.MaxInt32 {
+				return header, fmt.Errorf("invalid AIFF sample rate: %v", sampleRate)
+			}
+			header.sampleRate = int(math.Round(sampleRate))
+
+			if isAIFC {
+				if len(comm) < 22 {
+					return header, errors.New("invalid AIFF-C COMM chunk size")
+				}
+				switch compression := string(comm[18:22]); compression {
+				case "NONE", "twos":
+					header.littleEndian = false
+				case "sowt":
+					header.littleEndian = true
+				default:
+					return header, fmt.Errorf("unsupported AIFF-C compression type: %s", compression)
+				}
+			}
+			foundComm = true
+
+		case "SSND":
+			ssnd := make([]byte, 8)
+			if _, err := io.ReadFull(r, ssnd); err != nil {
+				return header, fmt.Errorf("error reading AIFF SSND chunk: %w", err)
+			}
+			header.dataOffset = offset + 16 + int64(binary.BigEndian.Uint32(ssnd[0:4]))
+			foundData = true
+		}
+
+		// Chunks are padded to an even number of bytes
+		offset += 8 + chunkSize + chunkSize%2
+	}
+
+	if !foundComm {
+		return header, errors.New("AIFF file is missing COMM chunk")
+	}
+	if !foundData {
+		return header, errors.New("AIFF file is missing SSND chunk")
+	}
+
+	return header, nil
+}
+
+// extendedToFloat64 converts an 80-bit IEEE 754 extended precision number to float64
+func extendedToFloat64(b []byte) float64 {
+	sign := 1.0
+	if b[0]&0x80 != 0 {
+		sign = -1.0
+	}
+	exponent := int(binary.BigEndian.Uint16(b[0:2]) & 0x7FFF)
+	mantissa := binary.BigEndian.Uint64(b[2:10])
+
+	if exponent == 0 && mantissa == 0 {
+		return 0
+	}
+	return sign * math.Ldexp(float64(mantissa), exponent-16383-63)
+}
+
+func readAIFFInfo(file *os.File) (AudioInfo, error) {
+	header, err := parseAIFFHeader(file)
+	if err != nil {
+		return AudioInfo{}, err
+	}
+
+	// Additional AIFF-specific validations
+	if header.bitDepth != 16 && header.bitDepth != 24 && header.bitDepth != 32 {
+		return AudioInfo{}, fmt.Errorf("unsupported bit depth: %d", header.bitDepth)
+	}
+
+	if header.numChannels < 1 {
+		return AudioInfo{}, fmt.Errorf("unsupported number of channels: %d", header.numChannels)
+	}
+
+	return AudioInfo{
+		SampleRate:   header.sampleRate,
+		TotalSamples: header.numFrames,
+		NumChannels:  header.numChannels,
+		BitDepth:     header.bitDepth,
+	}, nil
+}
+
+func readAIFFBuffered(file *os.File, settings *conf.Settings, splitChannels bool, callback AudioChannelChunkCallback) error {
+	header, err := parseAIFFHeader(file)
+	if err != nil {
+		return err
+	}
+
+	if settings.Debug {
+		fmt.Println("Sample rate:", header.sampleRate)
+		fmt.Println("Bits per sample:", header.bitDepth)
+		fmt.Println("Channels:", header.numChannels)
+	}
+
+	divisor, err := getAudioDivisor(header.bitDepth)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Seek(header.dataOffset, io.SeekStart); err != nil {
+		return fmt.Errorf("error seeking to AIFF sample data: %w", err)
+	}
+
+	bytesPerSample := header.bitDepth / 8
+	frameSize := bytesPerSample * header.numChannels
+	router := newChannelRouter(header.sampleRate, header.numChannels, settings.BirdNET.Overlap, splitChannels, callback)
+
+	// Read one second of audio per iteration
+	buf := make([]byte, header.sampleRate*frameSize)
+	framesLeft := header.numFrames
+
+	for framesLeft > 0 {
+		toRead := min(len(buf), framesLeft*frameSize)
+		n, err := io.ReadFull(file, buf[:toRead])
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return err
+		}
+
+		frames := n / frameSize
+		floatChunk := make([]float32, frames*header.numChannels)
+		for i := range floatChunk {
+			pos := i * bytesPerSample
+			floatChunk[i] = float32(decodePCMSample(buf[pos:pos+bytesPerSample], header.littleEndian)) / divisor
+		}
+		framesLeft -= frames
+
+		if err := router.write(floatChunk); err != nil {
+			return err
+		}
+
+		if n < toRead {
+			break
+		}
+	}
+
+	return router.flush()
+}
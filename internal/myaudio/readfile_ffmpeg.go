@@ -0,0 +1,252 @@
+package myaudio
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// ffmpegProbeTimeout limits how long FFmpeg may take to read the stream information of a file
+const ffmpegProbeTimeout = 30 * time.Second
+
+var (
+	// ffmpegDurationRegex matches the duration line of FFmpeg input information
+	ffmpegDurationRegex = regexp.MustCompile(`Duration: (\d+):(\d+):(\d+(?:\.\d+)?)`)
+	// ffmpegAudioStreamRegex matches the first audio stream line of FFmpeg input information
+	ffmpegAudioStreamRegex = regexp.MustCompile(`Stream #\d+:\d+.*?: Audio: [^,]+, (\d+) Hz, ([^,]+)`)
+	// ffmpegTagRegex matches the metadata tag lines of FFmpeg input information
+	ffmpegTagRegex = regexp.MustCompile(`(?m)^\s+(\w+)\s*: (.*?)\r?$`)
+)
+
+// getDecoderFFmpegPath returns the FFmpeg path used for decoding audio files
+func getDecoderFFmpegPath() (string, error) {
+	ffmpegPath := conf.Setting().Realtime.Audio.FfmpegPath
+	if err := validateFFmpegPath(ffmpegPath); err != nil {
+		return "", fmt.Errorf("%w, it is required to decode this audio format", err)
+	}
+	return ffmpegPath, nil
+}
+
+// readFFmpegInfo reads audio stream information of a file using FFmpeg
+func readFFmpegInfo(filePath string) (AudioInfo, error) {
+	ffmpegPath, err := getDecoderFFmpegPath()
+	if err != nil {
+		return AudioInfo{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ffmpegProbeTimeout)
+	defer cancel()
+
+	// Without an output file FFmpeg prints input information and exits with an error,
+	// so the exit status is ignored and stderr is parsed instead
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, ffmpegPath, "-hide_banner", "-i", filePath)
+	cmd.Stderr = &stderr
+	_ = cmd.Run()
+
+	if ctx.Err() != nil {
+		return AudioInfo{}, fmt.Errorf("timeout reading audio info with FFmpeg: %w", ctx.Err())
+	}
+
+	return parseFFmpegInfo(stderr.String())
+}
+
+// readFFmpegTags reads the metadata tags of a file using FFmpeg, keys are lower case
+func readFFmpegTags(filePath string) (map[string]string, error) {
+	ffmpegPath, err := getDecoderFFmpegPath()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ffmpegProbeTimeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, ffmpegPath, "-hide_banner", "-i", filePath)
+	cmd.Stderr = &stderr
+	_ = cmd.Run()
+
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("timeout reading metadata with FFmpeg: %w", ctx.Err())
+	}
+
+	return parseFFmpegTags(stderr.String()), nil
+}
+
+// parseFFmpegTags parses metadata tags from FFmpeg input information, the first value of a tag wins
+func parseFFmpegTags(output string) map[string]string {
+	tags := make(map[string]string)
+	for _, match := range ffmpegTagRegex.FindAllStringSubmatch(output, -1) {
+		key := strings.ToLower(match[1])
+		if _, exists := tags[key]; !exists {
+			tags[key] = match[2]
+		}
+	}
+	return tags
+}
+
+// parseFFmpegInfo parses sample rate, channel count and duration from FFmpeg input information
+func parseFFmpegInfo(output string) (AudioInfo, error) {
+	streamMatch := ffmpegAudioStreamRegex.FindStringSubmatch(output)
+	if streamMatch == nil {
+		return AudioInfo{}, errors.New("no audio stream found in file")
+	}
+
+	sampleRate, err := strconv.Atoi(streamMatch[1])
+	if err != nil || sampleRate <= 0 {
+		return AudioInfo{}, fmt.Errorf("invalid sample rate: %s", streamMatch[1])
+	}
+
+	numChannels := parseFFmpegChannelLayout(streamMatch[2])
+	if numChannels <= 0 {
+		return AudioInfo{}, fmt.Errorf("unsupported channel layout: %s", streamMatch[2])
+	}
+
+	durationMatch := ffmpegDurationRegex.FindStringSubmatch(output)
+	if durationMatch == nil {
+		return AudioInfo{}, errors.New("unable to determine audio duration")
+	}
+	hours, _ := strconv.Atoi(durationMatch[1])
+	minutes, _ := strconv.Atoi(durationMatch[2])
+	seconds, _ := strconv.ParseFloat(durationMatch[3], 64)
+	durationSeconds := float64(hours*3600+minutes*60) + seconds
+
+	return AudioInfo{
+		SampleRate:   sampleRate,
+		TotalSamples: int(durationSeconds * float64(sampleRate)),
+		NumChannels:  numChannels,
+		BitDepth:     conf.BitDepth, // FFmpeg decodes to 16-bit PCM
+	}, nil
+}
+
+// parseFFmpegChannelLayout converts an FFmpeg channel layout name to a channel count
+func parseFFmpegChannelLayout(layout string) int {
+	layout = strings.TrimSpace(layout)
+	switch {
+	case layout == "mono":
+		return 1
+	case layout == "stereo", strings.HasPrefix(layout, "downmix"):
+		return 2
+	case strings.HasPrefix(layout, "2.1"), strings.HasPrefix(layout, "3.0"):
+		return 3
+	case layout == "quad", strings.HasPrefix(layout, "4.0"):
+		return 4
+	case strings.HasPrefix(layout, "5.0"):
+		return 5
+	case strings.HasPrefix(layout, "5.1"), strings.HasPrefix(layout, "6.0"):
+		return 6
+	case strings.HasPrefix(layout, "7.1"), layout == "octagonal":
+		return 8
+	case strings.HasSuffix(layout, " channels"):
+		n, err := strconv.Atoi(strings.TrimSuffix(layout, " channels"))
+		if err != nil {
+			return 0
+		}
+		return n
+	default:
+		return 0
+	}
+}
+
+// readFFmpegBuffered decodes an audio file with FFmpeg into 16-bit PCM at the native sample
+// rate of the file and processes it in chunks. Audio is downmixed to mono by FFmpeg unless
+// the channels are analyzed separately.
+func readFFmpegBuffered(filePath string, settings *conf.Settings, splitChannels bool, callback AudioChannelChunkCallback) error {
+	info, err := readFFmpegInfo(filePath)
+	if err != nil {
+		return err
+	}
+
+	if settings.Debug {
+		fmt.Println("Decoding with FFmpeg:", filePath)
+		fmt.Println("Sample rate:", info.SampleRate)
+		fmt.Println("Channels:", info.NumChannels)
+	}
+
+	ffmpegPath, err := getDecoderFFmpegPath()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	numChannels := conf.NumChannels
+	if splitChannels {
+		numChannels = info.NumChannels
+	}
+
+	_, _, ffmpegFormat := getFFmpegFormat(info.SampleRate, numChannels, conf.BitDepth)
+	cmd := exec.CommandContext(ctx, ffmpegPath,
+		"-hide_banner",
+		"-loglevel", "error",
+		"-i", filePath,
+		"-vn",              // Disable video
+		"-f", ffmpegFormat, // Output raw signed 16-bit little-endian PCM
+		"-ac", strconv.Itoa(numChannels), // Downmix to mono unless channels are split
+		"pipe:1",
+	)
+
+	stderrBuf := NewBoundedBuffer(4096)
+	cmd.Stderr = stderrBuf
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("error creating ffmpeg pipe: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("error starting FFmpeg: %w", err)
+	}
+
+	router := newChannelRouter(info.SampleRate, numChannels, settings.BirdNET.Overlap, splitChannels, callback)
+
+	// Read one second of decoded audio per iteration
+	buf := make([]byte, info.SampleRate*numChannels*conf.BitDepth/8)
+	var processErr error
+	for {
+		n, err := io.ReadFull(stdout, buf)
+		if n > 0 {
+			floatChunk := make([]float32, n/2)
+			for i := range floatChunk {
+				floatChunk[i] = float32(int16(binary.LittleEndian.Uint16(buf[i*2:]))) / 32768.0
+			}
+
+			if processErr = router.write(floatChunk); processErr != nil {
+				break
+			}
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+				processErr = fmt.Errorf("error reading from ffmpeg: %w", err)
+			}
+			break
+		}
+	}
+
+	if processErr != nil {
+		// Stop FFmpeg, the rest of the output is not needed
+		cancel()
+		_ = cmd.Wait()
+		return processErr
+	}
+
+	if err := cmd.Wait(); err != nil {
+		if stderr := stderrBuf.String(); stderr != "" {
+			return fmt.Errorf("FFmpeg failed to decode %s: %w\nStderr: %s", filePath, err, stderr)
+		}
+		return fmt.Errorf("FFmpeg failed to decode %s: %w", filePath, err)
+	}
+
+	return router.flush()
+}
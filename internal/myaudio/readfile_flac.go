@@ -1,7 +1,6 @@
 package myaudio
 
 import (
-	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
@@ -22,7 +21,7 @@ func readFLACInfo(file *os.File) (AudioInfo, error) {
 		return AudioInfo{}, fmt.Errorf("unsupported bit depth: %d", decoder.BitsPerSample)
 	}
 
-	if decoder.NChannels != 1 && decoder.NChannels != 2 {
+	if decoder.NChannels < 1 {
 		return AudioInfo{}, fmt.Errorf("unsupported number of channels: %d", decoder.NChannels)
 	}
 
@@ -34,7 +33,7 @@ func readFLACInfo(file *os.File) (AudioInfo, error) {
 	}, nil
 }
 
-func readFLACBuffered(file *os.File, settings *conf.Settings, callback AudioChunkCallback) error {
+func readFLACBuffered(file *os.File, settings *conf.Settings, splitChannels bool, callback AudioChannelChunkCallback) error {
 	decoder, err := flac.NewDecoder(file)
 	if err != nil {
 		return err
@@ -46,23 +45,13 @@ func readFLACBuffered(file *os.File, settings *conf.Settings, callback AudioChun
 		fmt.Println("Channels:", decoder.NChannels)
 	}
 
-	var doResample bool = false
-	var sourceSampleRate int = decoder.SampleRate
-
-	if decoder.SampleRate != conf.SampleRate {
-		doResample = true
-	}
-
 	divisor, err := getAudioDivisor(decoder.BitsPerSample)
 	if err != nil {
 		return err
 	}
 
-	step := int((3 - settings.BirdNET.Overlap) * conf.SampleRate)
-	minLenSamples := int(1.5 * conf.SampleRate)
-	secondsSamples := int(3 * conf.SampleRate)
-
-	var currentChunk []float32
+	bytesPerSample := decoder.BitsPerSample / 8
+	router := newChannelRouter(decoder.SampleRate, decoder.NChannels, settings.BirdNET.Overlap, splitChannels, callback)
 
 	// Process FLAC frames
 	for {
@@ -73,49 +62,17 @@ func readFLACBuffered(file *os.File, settings *conf.Settings, callback AudioChun
 			return err
 		}
 
-		// Convert bytes to float32 samples
-		var floatChunk []float32
-		for i := 0; i < len(frame); i += (decoder.BitsPerSample / 8) * decoder.NChannels {
-			var sample int32
-			switch decoder.BitsPerSample {
-			case 16:
-				sample = int32(int16(binary.LittleEndian.Uint16(frame[i:])))
-			case 24:
-				sample = int32(frame[i]) | int32(frame[i+1])<<8 | int32(frame[i+2])<<16
-			case 32:
-				sample = int32(binary.LittleEndian.Uint32(frame[i:]))
-			}
-			floatChunk = append(floatChunk, float32(sample)/divisor)
-		}
-
-		if doResample {
-			floatChunk, err = ResampleAudio(floatChunk, sourceSampleRate, conf.SampleRate)
-			if err != nil {
-				return fmt.Errorf("error resampling audio: %w", err)
-			}
+		// Convert interleaved little-endian bytes to float32 samples
+		floatChunk := make([]float32, len(frame)/bytesPerSample)
+		for i := range floatChunk {
+			pos := i * bytesPerSample
+			floatChunk[i] = float32(decodePCMSample(frame[pos:pos+bytesPerSample], true)) / divisor
 		}
 
-		currentChunk = append(currentChunk, floatChunk...)
-
-		// Process complete 3-second chunks
-		for len(currentChunk) >= secondsSamples {
-			if err := callback(currentChunk[:secondsSamples]); err != nil {
-				return err
-			}
-			currentChunk = currentChunk[step:]
-		}
-	}
-
-	// Handle the last chunk
-	if len(currentChunk) >= minLenSamples || len(currentChunk) > 0 {
-		if len(currentChunk) < secondsSamples {
-			padding := make([]float32, secondsSamples-len(currentChunk))
-			currentChunk = append(currentChunk, padding...)
-		}
-		if err := callback(currentChunk); err != nil {
+		if err := router.write(floatChunk); err != nil {
 			return err
 		}
 	}
 
-	return nil
+	return router.flush()
 }
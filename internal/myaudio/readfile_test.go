@@ -0,0 +1,230 @@
+package myaudio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// TestSniffAudioFormat tests content based audio format detection
+func TestSniffAudioFormat(t *testing.T) {
+	testCases := []struct {
+		name     string
+		header   []byte
+		expected audioFormat
+	}{
+		{"WAV", []byte("RIFF\x00\x00\x00\x00WAVE"), formatWAV},
+		{"FLAC", []byte("fLaC\x00\x00\x00\x22"), formatFLAC},
+		{"AIFF", []byte("FORM\x00\x00\x00\x00AIFF"), formatAIFF},
+		{"AIFF-C", []byte("FORM\x00\x00\x00\x00AIFC"), formatAIFF},
+		{"Ogg", []byte("OggS\x00\x02"), formatOgg},
+		{"M4A", []byte("\x00\x00\x00\x20ftypM4A "), formatM4A},
+		{"MP3 with ID3", []byte("ID3\x04\x00"), formatMP3},
+		{"MP3 frame sync", []byte{0xFF, 0xFB, 0x90, 0x00}, formatMP3},
+		{"ADTS AAC", []byte{0xFF, 0xF1, 0x50, 0x80}, formatM4A},
+		{"Unknown", []byte("hello world!"), formatUnknown},
+		{"Empty", []byte{}, formatUnknown},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, sniffAudioFormat(tc.header))
+		})
+	}
+}
+
+// TestIsSupportedAudioFile tests extension based format support checks
+func TestIsSupportedAudioFile(t *testing.T) {
+	assert.True(t, IsSupportedAudioFile("recording.WAV"))
+	assert.True(t, IsSupportedAudioFile("/data/recording.mp3"))
+	assert.True(t, IsSupportedAudioFile("recording.opus"))
+	assert.True(t, IsSupportedAudioFile("recording.aiff"))
+	assert.False(t, IsSupportedAudioFile("recording.txt"))
+	assert.False(t, IsSupportedAudioFile("recording"))
+}
+
+// buildAIFF creates an in-memory 16-bit AIFF file from the given samples
+func buildAIFF(t *testing.T, sampleRate, numChannels int, samples []int16) []byte {
+	t.Helper()
+
+	var comm bytes.Buffer
+	require.NoError(t, binary.Write(&comm, binary.BigEndian, int16(numChannels)))
+	require.NoError(t, binary.Write(&comm, binary.BigEndian, uint32(len(samples)/numChannels)))
+	require.NoError(t, binary.Write(&comm, binary.BigEndian, int16(16)))
+	comm.Write(float64ToExtended(float64(sampleRate)))
+
+	var ssnd bytes.Buffer
+	require.NoError(t, binary.Write(&ssnd, binary.BigEndian, uint32(0))) // offset
+	require.NoError(t, binary.Write(&ssnd, binary.BigEndian, uint32(0))) // block size
+	require.NoError(t, binary.Write(&ssnd, binary.BigEndian, samples))
+
+	var body bytes.Buffer
+	body.WriteString("AIFF")
+	body.WriteString("COMM")
+	require.NoError(t, binary.Write(&body, binary.BigEndian, uint32(comm.Len())))
+	body.Write(comm.Bytes())
+	body.WriteString("SSND")
+	require.NoError(t, binary.Write(&body, binary.BigEndian, uint32(ssnd.Len())))
+	body.Write(ssnd.Bytes())
+
+	var file bytes.Buffer
+	file.WriteString("FORM")
+	require.NoError(t, binary.Write(&file, binary.BigEndian, uint32(body.Len())))
+	file.Write(body.Bytes())
+	return file.Bytes()
+}
+
+// float64ToExtended converts a positive integer valued float64 to 80-bit extended precision
+func float64ToExtended(v float64) []byte {
+	b := make([]byte, 10)
+	frac, exp := math.Frexp(v)
+	binary.BigEndian.PutUint16(b[0:2], uint16(exp-1+16383))
+	binary.BigEndian.PutUint64(b[2:10], uint64(frac*(1<<64)))
+	return b
+}
+
+// TestReadAIFFInfo tests parsing of AIFF headers
+func TestReadAIFFInfo(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.aiff")
+	samples := make([]int16, 44100*2)
+	require.NoError(t, os.WriteFile(path, buildAIFF(t, 44100, 2, samples), 0o644))
+
+	info, err := GetAudioInfo(path)
+	require.NoError(t, err)
+	assert.Equal(t, 44100, info.SampleRate)
+	assert.Equal(t, 2, info.NumChannels)
+	assert.Equal(t, 16, info.BitDepth)
+	assert.Equal(t, 44100, info.TotalSamples)
+}
+
+// TestReadAIFFMalformedHeader tests that AIFF files with an invalid format are rejected
+func TestReadAIFFMalformedHeader(t *testing.T) {
+	settings := &conf.Settings{}
+	commData := 20 // offset of the COMM chunk data after the FORM and COMM chunk headers
+
+	testCases := []struct {
+		name   string
+		modify func(data []byte)
+	}{
+		{"zeroed COMM chunk", func(data []byte) { clear(data[commData : commData+18]) }},
+		{"zero sample rate", func(data []byte) { clear(data[commData+8 : commData+18]) }},
+		{"negative sample rate", func(data []byte) { data[commData+8] |= 0x80 }},
+		{"infinite sample rate", func(data []byte) { data[commData+8], data[commData+9] = 0x7F, 0xFF }},
+		{"oversized COMM chunk", func(data []byte) { binary.BigEndian.PutUint32(data[commData-4:commData], 0xFFFFFFF0) }},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data := buildAIFF(t, conf.SampleRate, 1, make([]int16, conf.SampleRate))
+			tc.modify(data)
+			path := filepath.Join(t.TempDir(), "malformed.aiff")
+			require.NoError(t, os.WriteFile(path, data, 0o644))
+
+			_, err := GetAudioInfo(path)
+			assert.Error(t, err)
+
+			settings.Input.Path = path
+			err = ReadAudioFileBuffered(settings, func(chunk []float32) error { return nil })
+			assert.Error(t, err)
+		})
+	}
+}
+
+// TestReadAIFFBuffered tests that AIFF audio is downmixed and split into analysis chunks
+func TestReadAIFFBuffered(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.aif")
+	samples := make([]int16, conf.SampleRate*4*2) // 4 seconds of stereo audio
+	for i := range samples {
+		samples[i] = 16384
+	}
+	require.NoError(t, os.WriteFile(path, buildAIFF(t, conf.SampleRate, 2, samples), 0o644))
+
+	settings := &conf.Settings{}
+	settings.Input.Path = path
+
+	var chunks [][]float32
+	err := ReadAudioFileBuffered(settings, func(chunk []float32) error {
+		chunks = append(chunks, append([]float32(nil), chunk...))
+		return nil
+	})
+	require.NoError(t, err)
+
+	// One full chunk and one padded partial chunk
+	require.Len(t, chunks, 2)
+	assert.Len(t, chunks[0], 3*conf.SampleRate)
+	assert.Len(t, chunks[1], 3*conf.SampleRate)
+	assert.InDelta(t, 0.5, chunks[0][0], 0.0001)
+	assert.InDelta(t, 0.5, chunks[1][conf.SampleRate-1], 0.0001)
+	assert.Zero(t, chunks[1][conf.SampleRate])
+}
+
+// TestReadAudioFileChannels tests that each channel of a multi-channel file is chunked separately
+func TestReadAudioFileChannels(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.aif")
+	samples := make([]int16, conf.SampleRate*3*2) // 3 seconds of stereo audio
+	for i := 0; i < len(samples); i += 2 {
+		samples[i] = 16384   // left channel at half scale
+		samples[i+1] = -8192 // right channel at negative quarter scale
+	}
+	require.NoError(t, os.WriteFile(path, buildAIFF(t, conf.SampleRate, 2, samples), 0o644))
+
+	settings := &conf.Settings{}
+	settings.Input.Path = path
+
+	chunks := make(map[int][][]float32)
+	err := ReadAudioFileChannels(settings, func(channel int, chunk []float32) error {
+		chunks[channel] = append(chunks[channel], append([]float32(nil), chunk...))
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.Len(t, chunks, 2)
+	require.Len(t, chunks[0], 1)
+	require.Len(t, chunks[1], 1)
+	assert.InDelta(t, 0.5, chunks[0][0][100], 0.0001)
+	assert.InDelta(t, -0.25, chunks[1][0][100], 0.0001)
+}
+
+// TestDecodePCMSample tests big and little endian sample decoding
+func TestDecodePCMSample(t *testing.T) {
+	assert.Equal(t, int32(-2), decodePCMSample([]byte{0xFF, 0xFE}, false))
+	assert.Equal(t, int32(-2), decodePCMSample([]byte{0xFE, 0xFF}, true))
+	assert.Equal(t, int32(-1), decodePCMSample([]byte{0xFF, 0xFF, 0xFF}, false))
+	assert.Equal(t, int32(0x123456), decodePCMSample([]byte{0x12, 0x34, 0x56}, false))
+}
+
+// TestParseFFmpegInfo tests parsing of FFmpeg input information
+func TestParseFFmpegInfo(t *testing.T) {
+	output := `Input #0, mp3, from 'test.mp3':
+  Metadata:
+    encoder         : Lavf58.76.100
+  Duration: 00:01:30.50, start: 0.025057, bitrate: 128 kb/s
+  Stream #0:0: Audio: mp3, 44100 Hz, stereo, fltp, 128 kb/s
+At least one output file must be specified`
+
+	info, err := parseFFmpegInfo(output)
+	require.NoError(t, err)
+	assert.Equal(t, 44100, info.SampleRate)
+	assert.Equal(t, 2, info.NumChannels)
+	assert.Equal(t, int(90.5*44100), info.TotalSamples)
+
+	_, err = parseFFmpegInfo("Input #0, image2, from 'test.png':\n  Duration: 00:00:00.04")
+	assert.Error(t, err)
+}
+
+// TestParseFFmpegChannelLayout tests channel layout name conversion
+func TestParseFFmpegChannelLayout(t *testing.T) {
+	assert.Equal(t, 1, parseFFmpegChannelLayout("mono"))
+	assert.Equal(t, 2, parseFFmpegChannelLayout("stereo"))
+	assert.Equal(t, 4, parseFFmpegChannelLayout("quad"))
+	assert.Equal(t, 6, parseFFmpegChannelLayout("5.1(side)"))
+	assert.Equal(t, 12, parseFFmpegChannelLayout("12 channels"))
+	assert.Equal(t, 0, parseFFmpegChannelLayout("unknown"))
+}
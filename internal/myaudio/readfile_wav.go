@@ -7,6 +7,7 @@ import (
 
 	"github.com/go-audio/audio"
 	"github.com/go-audio/wav"
+	"github.com/tphakala/birdnet-go/internal/birdnet"
 	"github.com/tphakala/birdnet-go/internal/conf"
 )
 
@@ -23,7 +24,7 @@ func readWAVInfo(file *os.File) (AudioInfo, error) {
 		return AudioInfo{}, fmt.Errorf("unsupported bit depth: %d", decoder.BitDepth)
 	}
 
-	if decoder.NumChans != 1 && decoder.NumChans != 2 {
+	if decoder.NumChans < 1 {
 		return AudioInfo{}, fmt.Errorf("unsupported number of channels: %d", decoder.NumChans)
 	}
 
@@ -45,7 +46,7 @@ func readWAVInfo(file *os.File) (AudioInfo, error) {
 	}, nil
 }
 
-func readWAVBuffered(file *os.File, settings *conf.Settings, callback AudioChunkCallback) error {
+func readWAVBuffered(file *os.File, settings *conf.Settings, splitChannels bool, callback AudioChannelChunkCallback) error {
 	decoder := wav.NewDecoder(file)
 	decoder.ReadInfo()
 	if !decoder.IsValidFile() {
@@ -59,33 +60,24 @@ func readWAVBuffered(file *os.File, settings *conf.Settings, callback AudioChunk
 		fmt.Println("Channels:", decoder.NumChans)
 	}
 
-	var doResample bool = false
-	var sourceSampleRate int = int(decoder.SampleRate)
-
-	if decoder.SampleRate != conf.SampleRate {
-		doResample = true
-		//return nil, errors.New("input file sample rate is not valid for BirdNet model")
-	}
-
 	divisor, err := getAudioDivisor(int(decoder.BitDepth))
 	if err != nil {
 		return err
 	}
 
-	step := int((3 - settings.BirdNET.Overlap) * conf.SampleRate)
-	minLenSamples := int(1.5 * conf.SampleRate)
-	secondsSamples := int(3 * conf.SampleRate)
+	numChannels := int(decoder.NumChans)
+	router := newChannelRouter(int(decoder.SampleRate), numChannels, settings.BirdNET.Overlap, splitChannels, callback)
 
-	var currentChunk []float32
 	// Calculate buffer size for 8 complete chunks of audio
-	// One chunk = 3 seconds = 48000 * 3 = 144000 samples
-	// Using 8 chunks worth of data = 1,152,000 samples
-	// This provides 24 seconds of buffered audio
+	// One chunk is one analysis window of the active model, 144000 samples for 3 seconds
+	// Using 8 chunks worth of data = 1,152,000 samples for 3 second windows
+	// This provides 24 seconds of buffered mono audio
 	// Memory usage: 1,152,000 samples * 2 bytes = 2.3MB
-	bufferSize := 1_152_000
+	// The size is rounded down to whole frames so channels stay aligned between reads
+	bufferSize := 8 * int(birdnet.ActiveModel().WindowSeconds*conf.SampleRate) / numChannels * numChannels
 	buf := &audio.IntBuffer{
 		Data:   make([]int, bufferSize),
-		Format: &audio.Format{SampleRate: int(conf.SampleRate), NumChannels: conf.NumChannels},
+		Format: &audio.Format{SampleRate: int(decoder.SampleRate), NumChannels: numChannels},
 	}
 
 	for {
@@ -98,39 +90,15 @@ func readWAVBuffered(file *os.File, settings *conf.Settings, callback AudioChunk
 			break
 		}
 
-		var floatChunk []float32
-		for _, sample := range buf.Data[:n] {
-			floatChunk = append(floatChunk, float32(sample)/divisor)
-		}
-
-		if doResample {
-			floatChunk, err = ResampleAudio(floatChunk, sourceSampleRate, conf.SampleRate)
-			if err != nil {
-				return fmt.Errorf("error resampling audio: %w", err)
-			}
+		floatChunk := make([]float32, n)
+		for i, sample := range buf.Data[:n] {
+			floatChunk[i] = float32(sample) / divisor
 		}
 
-		currentChunk = append(currentChunk, floatChunk...)
-
-		// Process complete 3-second chunks
-		for len(currentChunk) >= secondsSamples {
-			if err := callback(currentChunk[:secondsSamples]); err != nil {
-				return err
-			}
-			currentChunk = currentChunk[step:]
-		}
-	}
-
-	// Handle the last chunk
-	if len(currentChunk) >= minLenSamples || len(currentChunk) > 0 {
-		if len(currentChunk) < secondsSamples {
-			padding := make([]float32, secondsSamples-len(currentChunk))
-			currentChunk = append(currentChunk, padding...)
-		}
-		if err := callback(currentChunk); err != nil {
+		if err := router.write(floatChunk); err != nil {
 			return err
 		}
 	}
 
-	return nil
+	return router.flush()
 }
@@ -0,0 +1,183 @@
+package myaudio
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/birdnet"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+const (
+	// replayBlockSamples is the number of samples written per block, the block size in bytes
+	// is a multiple of the capture buffer alignment so writes never cross the end of the buffer
+	replayBlockSamples = 4096
+
+	// replayMinFreeBytes is the free space required in the analysis buffer before a block is
+	// written, playback waits for the analysis to catch up so accelerated replays do not overrun it
+	replayMinFreeBytes = conf.SampleRate * conf.BitDepth / 8
+)
+
+// errReplayStopped is returned by the replay writer when the source is stopped
+var errReplayStopped = errors.New("replay stopped")
+
+// replayWriter writes decoded audio of a replay source to its buffers at the configured pace
+// and advances the synthetic clock of the source
+type replayWriter struct {
+	sourceID       string
+	name           string
+	speed          float64
+	clock          *sampleClock
+	wallStart      time.Time // wall clock time playback started
+	quit           <-chan struct{}
+	audioLevelChan chan AudioLevelData
+}
+
+// ReplayFile plays the file of a replay source into the analysis and capture buffers as if it was
+// captured live. The source runs on a synthetic clock starting at the configured start time, so
+// detections, flush deadlines and audio clips are timed by the position in the file regardless of
+// the playback speed. ReplayFile returns when playback has finished or quit is closed.
+func ReplayFile(source conf.AudioSourceConfig, quit <-chan struct{}, audioLevelChan chan AudioLevelData) error {
+	sourceID := source.SourceID()
+
+	origin, err := source.Replay.ParsedStartTime()
+	if err != nil {
+		return fmt.Errorf("invalid replay start time: %w", err)
+	}
+	if origin.IsZero() {
+		origin = time.Now()
+	}
+
+	speed := source.Replay.Speed
+	if speed == 0 {
+		speed = 1
+	}
+
+	// Buffers and the analysis of the source run on the synthetic clock, the analysis buffer may
+	// hold audio of a previous replay of the source if it was restarted with a new configuration
+	if err := resetAnalysisBuffer(sourceID); err != nil {
+		return err
+	}
+	clock := &sampleClock{origin: origin}
+	SetSourceClock(sourceID, origin, clock.now)
+	if err := SetCaptureBufferClock(sourceID, clock.now); err != nil {
+		return err
+	}
+
+	// Decode the file without overlap to get continuous audio
+	settings := *conf.Setting()
+	settings.Input.Path = source.Replay.Path
+	settings.BirdNET.Overlap = 0
+
+	w := &replayWriter{
+		sourceID:       sourceID,
+		name:           source.DisplayName(),
+		speed:          speed,
+		clock:          clock,
+		wallStart:      time.Now(),
+		quit:           quit,
+		audioLevelChan: audioLevelChan,
+	}
+
+	log.Printf("▶️ Replaying %s at %.1fx speed from %s", source.Replay.Path, speed, origin.Format(time.RFC3339))
+	for {
+		err := ReadAudioFileBuffered(&settings, func(chunk []float32) error {
+			return w.write(ConvertFloat32ToPCM16(chunk))
+		})
+		if errors.Is(err, errReplayStopped) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error replaying %s: %w", source.Replay.Path, err)
+		}
+		if !source.Replay.Loop {
+			break
+		}
+	}
+
+	silence := make([]byte, int(replayTail(&settings).Seconds())*conf.SampleRate*conf.BitDepth/8)
+	if err := w.write(silence); err != nil {
+		if errors.Is(err, errReplayStopped) {
+			return nil
+		}
+		return err
+	}
+
+	// All audio is written, the analysis flushes the pending detections of the source once it
+	// has analyzed the remaining audio
+	endSourceClock(sourceID)
+
+	log.Printf("⏹️ Replay of %s finished", source.Replay.Path)
+	return nil
+}
+
+// replayTail returns the length of the silence played after the file, which completes the audio
+// clips of detections at the end of the file. The clip of a detection can extend up to the
+// longest clip length minus the pre-roll after its start.
+func replayTail(settings *conf.Settings) time.Duration {
+	clip := &settings.Realtime.Audio.Export.Clip
+	seconds := max(float64(clip.MaxClipLength()-clip.PreRoll), birdnet.ActiveModel().WindowSeconds)
+	return time.Duration(math.Ceil(seconds)) * time.Second
+}
+
+// write writes PCM data to the buffers in blocks, waiting between blocks to keep the pace
+func (w *replayWriter) write(pcm []byte) error {
+	const blockSize = replayBlockSamples * conf.BitDepth / 8
+
+	for len(pcm) > 0 {
+		block := pcm[:min(blockSize, len(pcm))]
+		pcm = pcm[len(block):]
+
+		if err := w.waitForBlock(); err != nil {
+			return err
+		}
+
+		// Advance the clock first, it returns the time at the end of the written data
+		w.clock.advance(len(block) / (conf.BitDepth / 8))
+		if err := ApplyFilters(w.sourceID, block); err != nil {
+			return fmt.Errorf("error applying audio filters: %w", err)
+		}
+		if err := WriteToAnalysisBuffer(w.sourceID, block); err != nil {
+			return fmt.Errorf("error writing to analysis buffer: %w", err)
+		}
+		if err := WriteToCaptureBuffer(w.sourceID, block); err != nil {
+			return fmt.Errorf("error writing to capture buffer: %w", err)
+		}
+
+		// Send level to channel (non-blocking)
+		select {
+		case w.audioLevelChan <- calculateAudioLevel(block, w.sourceID, w.name):
+		default:
+		}
+	}
+	return nil
+}
+
+// waitForBlock waits until the next block is due at the playback speed and the analysis
+// buffer has room for it
+func (w *replayWriter) waitForBlock() error {
+	due := w.wallStart.Add(time.Duration(float64(w.clock.elapsed()) / w.speed))
+
+	for {
+		delay := time.Until(due)
+		if delay <= 0 {
+			free, err := analysisBufferFree(w.sourceID)
+			if err != nil {
+				return err
+			}
+			if free >= replayMinFreeBytes {
+				return nil
+			}
+			delay = pollInterval
+		}
+
+		select {
+		case <-w.quit:
+			return errReplayStopped
+		case <-time.After(delay):
+		}
+	}
+}
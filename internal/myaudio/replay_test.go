@@ -0,0 +1,90 @@
+package myaudio
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// TestReplayFile tests that a replayed file is written to the buffers on a synthetic clock
+func TestReplayFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "replay.aiff")
+	samples := make([]int16, conf.SampleRate) // 1 second of audio
+	for i := range samples {
+		samples[i] = 16384
+	}
+	require.NoError(t, os.WriteFile(path, buildAIFF(t, conf.SampleRate, 1, samples), 0o644))
+
+	source := conf.AudioSourceConfig{
+		Type:   conf.SourceTypeReplay,
+		Replay: conf.ReplaySettings{Path: path, Speed: conf.MaxReplaySpeed, StartTime: "2024-05-01T05:00:00Z"},
+	}
+	id := source.SourceID()
+	require.NoError(t, initializeBuffersForSource(id))
+	defer func() {
+		RemoveSourceClock(id)
+		assert.NoError(t, RemoveAnalysisBuffer(id))
+		assert.NoError(t, RemoveCaptureBuffer(id))
+	}()
+
+	// Drain the analysis buffer like the buffer monitor does
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(pollInterval):
+				_, _ = ReadFromAnalysisBuffer(id)
+			}
+		}
+	}()
+
+	quit := make(chan struct{})
+	require.NoError(t, ReplayFile(source, quit, make(chan AudioLevelData, 1)))
+
+	// The file is padded to a full 3 second chunk and followed by trailing silence
+	start := time.Date(2024, 5, 1, 5, 0, 0, 0, time.UTC)
+	assert.True(t, HasSourceClock(id))
+	assert.True(t, SourceEnded(id))
+	assert.Equal(t, start.Add(3*time.Second+replayTail(conf.Setting())), SourceNow(id).UTC())
+
+	segment, err := ReadSegmentFromCaptureBuffer(id, start, 1)
+	require.NoError(t, err)
+	require.NotEmpty(t, segment)
+	assert.Equal(t, int16(16384), int16(binary.LittleEndian.Uint16(segment)))
+}
+
+// TestAnalysisStartTime tests that chunks of sources with a synthetic clock are timed by their
+// position in the audio, however far the writer is ahead of the analysis
+func TestAnalysisStartTime(t *testing.T) {
+	const id = "clock_test"
+	origin := time.Date(2024, 5, 1, 5, 0, 0, 0, time.UTC)
+	require.NoError(t, AllocateAnalysisBuffer(AnalysisBufferCapacity(), id))
+	defer func() { assert.NoError(t, RemoveAnalysisBuffer(id)) }()
+	SetSourceClock(id, origin, func() time.Time { return origin.Add(time.Hour) })
+	defer RemoveSourceClock(id)
+
+	// Fill the buffer before analyzing any of it, like an accelerated replay does
+	windowSize, step := analysisWindow()
+	require.NoError(t, WriteToAnalysisBuffer(id, make([]byte, AnalysisBufferCapacity())))
+	stepDuration := time.Duration(step/(conf.BitDepth/8)) * time.Second / time.Duration(conf.SampleRate)
+
+	var starts []time.Time
+	for len(starts) < 2 {
+		data, offset, err := readAnalysisWindow(id)
+		require.NoError(t, err)
+		if data != nil {
+			assert.Len(t, data, windowSize)
+			starts = append(starts, analysisStartTime(id, offset, -5*time.Second))
+		}
+	}
+	assert.Equal(t, []time.Time{origin, origin.Add(stepDuration)}, starts)
+}
@@ -1,42 +1,198 @@
 package myaudio
 
-// ResampleAudio resamples the given audio slice from the original sample rate to the target sample rate using cubic interpolation.
-func ResampleAudio(audio []float32, originalRate, targetRate int) ([]float32, error) {
-	if originalRate == targetRate {
-		return audio, nil
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	// resamplerAttenuation is the stopband attenuation of the resampling filter in dB, it is
+	// below the quantization noise of 16-bit audio
+	resamplerAttenuation = 100
+
+	// resamplerPassband is the fraction of the lower Nyquist frequency which is passed unchanged,
+	// the transition band above it ends at the Nyquist frequency so nothing aliases into the output
+	resamplerPassband = 0.9
+
+	// resamplerMaxPhases is the maximum number of filter phases, ratios which need more phases,
+	// such as between unrelated sample rates, interpolate between adjacent phases
+	resamplerMaxPhases = 1024
+)
+
+// Resampler converts a stream of audio samples from one sample rate to another with a
+// band-limited windowed-sinc filter in polyphase form. The filter history is kept between
+// calls to Process, so a stream can be resampled in chunks of any size and the result is the
+// same as resampling it at once. Output is aligned with the input: the first output sample is
+// at the time of the first input sample, so output is held back until the filter has enough
+// input to compute it and Flush returns the rest at the end of the stream.
+type Resampler struct {
+	up, down int       // resampling ratio up/down reduced to lowest terms
+	taps     int       // filter length in input samples
+	phases   int       // number of filter phases in the prototype filter
+	filter   []float64 // prototype filter, sampled at phases points per input sample
+
+	buf      []float32 // history of taps-1 input samples followed by new input
+	position int       // index in buf of the newest input sample used for the next output
+	phase    int       // time of the next output after position in units of 1/up input samples
+	inputs   int       // number of input samples received
+	outputs  int       // number of output samples returned
+}
+
+// NewResampler creates a resampler from inputRate to outputRate
+func NewResampler(inputRate, outputRate int) (*Resampler, error) {
+	if inputRate <= 0 || outputRate <= 0 {
+		return nil, fmt.Errorf("invalid resampling from %d Hz to %d Hz", inputRate, outputRate)
 	}
 
-	ratio := float64(targetRate) / float64(originalRate)
-	newLength := int(float64(len(audio)) * ratio)
-	resampled := make([]float32, newLength)
+	divisor := gcd(inputRate, outputRate)
+	r := &Resampler{
+		up:     outputRate / divisor,
+		down:   inputRate / divisor,
+		phases: min(outputRate/divisor, resamplerMaxPhases),
+	}
 
-	// Pre-calculate common terms used in the loop
-	audioLength := len(audio)
-	lastIndex := audioLength - 3
+	// Kaiser window design: the filter length follows from the attenuation and the width of the
+	// transition band, which is relative to the lower of the two Nyquist frequencies
+	nyquist := float64(min(inputRate, outputRate)) / 2 / float64(inputRate) // cycles per input sample
+	transition := (1 - resamplerPassband) * nyquist
+	r.taps = int(math.Ceil((resamplerAttenuation - 8) / (2.285 * 2 * math.Pi * transition)))
+	r.taps += r.taps % 2
+	beta := 0.1102 * (resamplerAttenuation - 8.7)
 
-	for i := 0; i < newLength; i++ {
-		origPos := float64(i) / ratio
-		index := int(origPos)
+	// The cutoff is centered in the transition band
+	cutoff := (1 + resamplerPassband) / 2 * nyquist
+	length := r.taps * r.phases
+	r.filter = make([]float64, length+1)
+	for m := range r.filter {
+		t := float64(m-length/2) / float64(r.phases) // input samples from the center
+		x := 2*float64(m)/float64(length) - 1
+		r.filter[m] = 2 * cutoff * sinc(2*cutoff*t) * besselI0(beta*math.Sqrt(max(1-x*x, 0))) / besselI0(beta)
+	}
 
-		// Clamp index to avoid out-of-bounds access
-		if index < 1 {
-			index = 1
-		} else if index > lastIndex {
-			index = lastIndex
+	// Normalize the gain of each phase to 1 so that every output passes a constant signal
+	// unchanged. The last coefficient continues phase 0 one input sample later for the
+	// interpolation between phases and is scaled with it.
+	for p := 0; p < r.phases; p++ {
+		var sum float64
+		for m := p; m < length; m += r.phases {
+			sum += r.filter[m]
 		}
+		for m := p; m <= length; m += r.phases {
+			r.filter[m] /= sum
+		}
+	}
+
+	r.Reset()
+	return r, nil
+}
 
-		frac := float32(origPos) - float32(index)
+// Reset clears the stream state so the resampler can be used for a new stream
+func (r *Resampler) Reset() {
+	r.buf = make([]float32, r.taps-1, 2*r.taps)
 
-		// Inline cubic interpolation to avoid extra function calls
-		y0, y1, y2, y3 := audio[index-1], audio[index], audio[index+1], audio[index+2]
-		mu2 := frac * frac
-		a0 := -0.5*y0 + 1.5*y1 - 1.5*y2 + 0.5*y3
-		a1 := y0 - 2.5*y1 + 2*y2 - 0.5*y3
-		a2 := -0.5*y0 + 0.5*y2
-		a3 := y1
+	// The first output is computed with the filter centered on the first input sample
+	r.position = r.taps - 1 + r.taps/2
+	r.phase = 0
+	r.inputs, r.outputs = 0, 0
+}
 
-		resampled[i] = a0*frac*mu2 + a1*mu2 + a2*frac + a3
+// Latency returns the number of input samples the resampler needs after the time of an output
+// sample to compute it
+func (r *Resampler) Latency() int {
+	return r.taps / 2
+}
+
+// Process resamples the next chunk of the stream and returns the output samples which can be
+// computed with the input received so far
+func (r *Resampler) Process(input []float32) []float32 {
+	r.buf = append(r.buf, input...)
+	r.inputs += len(input)
+
+	output := make([]float32, 0, (len(input)*r.up)/r.down+1)
+	for r.position < len(r.buf) {
+		output = append(output, r.compute())
+		r.phase += r.down
+		r.position += r.phase / r.up
+		r.phase %= r.up
 	}
+	r.outputs += len(output)
+
+	// Keep the history needed for the next outputs
+	consumed := len(r.buf) - (r.taps - 1)
+	copy(r.buf, r.buf[consumed:])
+	r.buf = r.buf[:r.taps-1]
+	r.position -= consumed
+
+	return output
+}
 
-	return resampled, nil
+// Flush returns the output samples held back at the end of the stream and resets the resampler.
+// The total output length is the input length scaled by the resampling ratio, rounded up.
+func (r *Resampler) Flush() []float32 {
+	expected := (r.inputs*r.up + r.down - 1) / r.down
+	remaining := expected - r.outputs
+	output := r.Process(make([]float32, r.taps/2+1))
+	output = output[:max(min(len(output), remaining), 0)]
+	r.Reset()
+	return output
+}
+
+// compute returns the output sample at the current position and phase
+func (r *Resampler) compute() float32 {
+	// Position of the phase in the prototype filter, between two phases if the ratio needs
+	// more phases than the filter has
+	offset := float64(r.phase) * float64(r.phases) / float64(r.up)
+	index := int(offset)
+	frac := offset - float64(index)
+
+	var sum float64
+	for k := 0; k < r.taps; k++ {
+		coefficient := r.filter[index+k*r.phases]
+		if frac > 0 {
+			coefficient += frac * (r.filter[index+k*r.phases+1] - coefficient)
+		}
+		sum += coefficient * float64(r.buf[r.position-k])
+	}
+	return float32(sum)
+}
+
+// ResampleAudio resamples the given audio slice from the original sample rate to the target
+// sample rate with a band-limited polyphase filter
+func ResampleAudio(audio []float32, originalRate, targetRate int) ([]float32, error) {
+	if originalRate == targetRate {
+		return audio, nil
+	}
+
+	r, err := NewResampler(originalRate, targetRate)
+	if err != nil {
+		return nil, err
+	}
+	resampled := r.Process(audio)
+	return append(resampled, r.Flush()...), nil
+}
+
+// sinc returns the normalized sinc function sin(pi x) / (pi x)
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+// besselI0 returns the modified Bessel function of the first kind of order zero
+func besselI0(x float64) float64 {
+	sum, term := 1.0, 1.0
+	for k := 1; term > sum*1e-12; k++ {
+		term *= (x / (2 * float64(k))) * (x / (2 * float64(k)))
+		sum += term
+	}
+	return sum
+}
+
+// gcd returns the greatest common divisor of a and b
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
 }
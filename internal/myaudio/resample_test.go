@@ -0,0 +1,157 @@
+package myaudio
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tone returns a second of a sine at the frequency and sample rate
+func tone(freq float64, sampleRate int) []float32 {
+	samples := make([]float32, sampleRate)
+	for i := range samples {
+		samples[i] = float32(0.5 * math.Sin(2*math.Pi*freq*float64(i)/float64(sampleRate)))
+	}
+	return samples
+}
+
+// toneGain returns the gain in dB of the component at the frequency of the output relative to
+// the input amplitude of 0.5, the edges with filter transients are skipped
+func toneGain(samples []float32, freq float64, sampleRate int) float64 {
+	edge := len(samples) / 10
+	var re, im float64
+	for i := edge; i < len(samples)-edge; i++ {
+		phase := 2 * math.Pi * freq * float64(i) / float64(sampleRate)
+		re += float64(samples[i]) * math.Cos(phase)
+		im += float64(samples[i]) * math.Sin(phase)
+	}
+	amplitude := 2 * math.Hypot(re, im) / float64(len(samples)-2*edge)
+	return 20 * math.Log10(amplitude/0.5+1e-12)
+}
+
+func TestResamplerFrequencyResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		inputRate  int
+		freq       float64 // frequency of the input tone
+		outputFreq float64 // frequency at which the output is measured
+		minGain    float64 // lower bound of the gain in dB
+		maxGain    float64 // upper bound of the gain in dB
+	}{
+		// Passband of AudioMoth recordings, including the band of small passerines
+		{"96 kHz passband 1 kHz", 96000, 1000, 1000, -0.01, 0.01},
+		{"96 kHz passband 10 kHz", 96000, 10000, 10000, -0.01, 0.01},
+		{"96 kHz passband 20 kHz", 96000, 20000, 20000, -0.01, 0.01},
+		{"192 kHz passband 15 kHz", 192000, 15000, 15000, -0.01, 0.01},
+
+		// Content above the output Nyquist frequency must not alias into the passband
+		{"96 kHz alias of 30 kHz", 96000, 30000, 18000, -200, -90},
+		{"96 kHz alias of 40 kHz", 96000, 40000, 8000, -200, -90},
+		{"192 kHz alias of 60 kHz", 192000, 60000, 12000, -200, -90},
+		{"192 kHz alias of 90 kHz", 192000, 90000, 6000, -200, -90},
+
+		// Rational ratios between unrelated sample rates
+		{"44.1 kHz passband 15 kHz", 44100, 15000, 15000, -0.01, 0.01},
+		{"22.05 kHz passband 8 kHz", 22050, 8000, 8000, -0.01, 0.01},
+		{"32.001 kHz passband with interpolated phases", 32001, 5000, 5000, -0.01, 0.01},
+
+		// Upsampling must not create images above the input Nyquist frequency
+		{"16 kHz image of 5 kHz", 16000, 5000, 11000, -200, -90},
+		{"16 kHz image of 5 kHz at 21 kHz", 16000, 5000, 21000, -200, -90},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := ResampleAudio(tone(tt.freq, tt.inputRate), tt.inputRate, 48000)
+			require.NoError(t, err)
+			require.Len(t, output, 48000)
+
+			gain := toneGain(output, tt.outputFreq, 48000)
+			assert.GreaterOrEqual(t, gain, tt.minGain)
+			assert.LessOrEqual(t, gain, tt.maxGain)
+		})
+	}
+}
+
+func TestResamplerPhaseGain(t *testing.T) {
+	// A constant signal passes unchanged at every output time, including ratios which
+	// interpolate between filter phases
+	for _, rates := range [][2]int{{44100, 48000}, {48000, 32000}, {44100, 47999}} {
+		input := make([]float32, rates[0])
+		for i := range input {
+			input[i] = 0.5
+		}
+		output, err := ResampleAudio(input, rates[0], rates[1])
+		require.NoError(t, err)
+
+		edge := len(output) / 10
+		for i := edge; i < len(output)-edge; i++ {
+			require.InDelta(t, 0.5, output[i], 1e-7, "%d Hz to %d Hz, sample %d", rates[0], rates[1], i)
+		}
+	}
+}
+
+func TestResamplerAlignment(t *testing.T) {
+	// The output is in phase with the input, the filter delay is compensated
+	output, err := ResampleAudio(tone(3000, 96000), 96000, 48000)
+	require.NoError(t, err)
+
+	expected := tone(3000, 48000)
+	var errorPower, signalPower float64
+	for i := 4800; i < 43200; i++ {
+		diff := float64(output[i] - expected[i])
+		errorPower += diff * diff
+		signalPower += float64(expected[i]) * float64(expected[i])
+	}
+	assert.Less(t, 10*math.Log10(errorPower/signalPower), -80.0)
+}
+
+func TestResamplerStreaming(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	input := make([]float32, 96000)
+	for i := range input {
+		input[i] = float32(rng.NormFloat64() * 0.1)
+	}
+
+	for _, inputRate := range []int{96000, 44100, 16000} {
+		whole, err := ResampleAudio(input, inputRate, 48000)
+		require.NoError(t, err)
+
+		// Chunks of random size give the same result as resampling at once
+		r, err := NewResampler(inputRate, 48000)
+		require.NoError(t, err)
+		var chunked []float32
+		for start := 0; start < len(input); {
+			end := min(start+1+rng.Intn(5000), len(input))
+			chunked = append(chunked, r.Process(input[start:end])...)
+			start = end
+		}
+		chunked = append(chunked, r.Flush()...)
+
+		assert.Equal(t, whole, chunked, "input rate %d", inputRate)
+		assert.Len(t, whole, (len(input)*48000+inputRate-1)/inputRate)
+	}
+}
+
+func TestResamplerEdgeCases(t *testing.T) {
+	_, err := NewResampler(0, 48000)
+	assert.Error(t, err)
+
+	// Short input is resampled and an empty stream returns nothing
+	output, err := ResampleAudio([]float32{0.5, 0.5, 0.5}, 24000, 48000)
+	require.NoError(t, err)
+	assert.Len(t, output, 6)
+
+	r, err := NewResampler(96000, 48000)
+	require.NoError(t, err)
+	assert.Empty(t, r.Flush())
+
+	// Audio at the target sample rate is returned unchanged
+	input := tone(1000, 48000)
+	output, err = ResampleAudio(input, 48000, 48000)
+	require.NoError(t, err)
+	assert.Equal(t, input, output)
+}
@@ -0,0 +1,99 @@
+package myaudio
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// sourceClocks holds the synthetic clocks of audio sources which do not run on the wall clock,
+// such as replayed files
+var sourceClocks sync.Map // source ID -> *sourceClock
+
+// sourceClock is the synthetic clock of an audio source
+type sourceClock struct {
+	origin time.Time        // time of the first sample written to the buffers of the source
+	now    func() time.Time // time at the end of the audio written so far
+	ended  atomic.Bool      // true once all audio of the source has been written
+}
+
+// SetSourceClock sets the clock of an audio source, origin is the time of the first sample
+// written to the buffers of the source and the clock must return the time at the end of the
+// audio written to the buffers of the source so far
+func SetSourceClock(source string, origin time.Time, clock func() time.Time) {
+	sourceClocks.Store(source, &sourceClock{origin: origin, now: clock})
+}
+
+// RemoveSourceClock removes the clock of an audio source, which returns it to the wall clock
+func RemoveSourceClock(source string) {
+	sourceClocks.Delete(source)
+}
+
+// HasSourceClock reports whether the audio source runs on a synthetic clock
+func HasSourceClock(source string) bool {
+	_, exists := sourceClocks.Load(source)
+	return exists
+}
+
+// SourceNow returns the current time of an audio source, which is the wall clock unless
+// the source has a synthetic clock
+func SourceNow(source string) time.Time {
+	if clock, exists := sourceClocks.Load(source); exists {
+		return clock.(*sourceClock).now()
+	}
+	return time.Now()
+}
+
+// endSourceClock marks the end of the audio of a source with a synthetic clock, after which no
+// more audio is written to its buffers
+func endSourceClock(source string) {
+	if clock, exists := sourceClocks.Load(source); exists {
+		clock.(*sourceClock).ended.Store(true)
+	}
+}
+
+// SourceEnded reports whether all audio of a source with a synthetic clock has been written,
+// such as a replay which has finished playing
+func SourceEnded(source string) bool {
+	if clock, exists := sourceClocks.Load(source); exists {
+		return clock.(*sourceClock).ended.Load()
+	}
+	return false
+}
+
+// SourceClockOrigin returns the time of the first sample of an audio source with a synthetic
+// clock, false if the source runs on the wall clock
+func SourceClockOrigin(source string) (time.Time, bool) {
+	if clock, exists := sourceClocks.Load(source); exists {
+		return clock.(*sourceClock).origin, true
+	}
+	return time.Time{}, false
+}
+
+// sampleClock is a synthetic clock advanced by the number of samples written
+type sampleClock struct {
+	mu      sync.Mutex
+	origin  time.Time
+	samples int64
+}
+
+// now returns the origin plus the duration of the samples written so far
+func (c *sampleClock) now() time.Time {
+	return c.origin.Add(c.elapsed())
+}
+
+// elapsed returns the duration of the samples written so far
+func (c *sampleClock) elapsed() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return time.Duration(c.samples) * time.Second / time.Duration(conf.SampleRate)
+}
+
+// advance adds written samples to the clock
+func (c *sampleClock) advance(samples int) {
+	c.mu.Lock()
+	c.samples += int64(samples)
+	c.mu.Unlock()
+}
@@ -0,0 +1,476 @@
+package myaudio
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// sourceStopTimeout is the maximum time to wait for a capture goroutine to exit
+const sourceStopTimeout = 15 * time.Second
+
+// activeSource holds the runtime state of a started audio source
+type activeSource struct {
+	config conf.AudioSourceConfig
+	live   bool          // true if audio is pushed by an external producer instead of a capture goroutine
+	quit   chan struct{} // closed to stop the capture goroutine
+	done   chan struct{} // closed when the capture goroutine has exited
+}
+
+// SourceManager starts and stops the capture of individual audio sources. Each source
+// gets its own capture goroutine, analysis buffer and capture buffer, so sources can be
+// added and removed without restarting the rest of the audio pipeline.
+type SourceManager struct {
+	stopMu         sync.Mutex // serializes operations which stop sources, they wait without holding mu
+	mu             sync.Mutex
+	sources        map[string]*activeSource
+	stopping       map[string]bool // sources whose capture goroutines are being stopped
+	settings       *conf.Settings
+	wg             *sync.WaitGroup
+	audioLevelChan chan AudioLevelData
+	onStart        func(sourceID string) // called when the buffers of a source are ready
+	onStop         func(sourceID string) // called before the buffers of a source are released
+}
+
+// NewSourceManager creates a new source manager. All sources are stopped when quitChan is closed.
+func NewSourceManager(settings *conf.Settings, wg *sync.WaitGroup, quitChan chan struct{}, audioLevelChan chan AudioLevelData) *SourceManager {
+	m := &SourceManager{
+		sources:        make(map[string]*activeSource),
+		stopping:       make(map[string]bool),
+		settings:       settings,
+		wg:             wg,
+		audioLevelChan: audioLevelChan,
+	}
+
+	go func() {
+		<-quitChan
+		m.StopAll()
+	}()
+
+	return m
+}
+
+// SetSourceHooks sets functions called when a source has started and before its buffers are
+// released, used to start and stop the analysis of the source
+func (m *SourceManager) SetSourceHooks(onStart, onStop func(sourceID string)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onStart = onStart
+	m.onStop = onStop
+}
+
+// StartSource allocates buffers for the audio source and starts capturing from it.
+// Starting a source which is already running is a no-op.
+func (m *SourceManager) StartSource(source conf.AudioSourceConfig) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.startSource(source)
+}
+
+// startSource starts a source, the caller must hold m.mu
+func (m *SourceManager) startSource(source conf.AudioSourceConfig) error {
+	id := source.SourceID()
+	if _, exists := m.sources[id]; exists {
+		return nil
+	}
+	if m.stopping[id] {
+		return fmt.Errorf("audio source %s is being stopped", id)
+	}
+
+	// Resolve the capture device before allocating buffers so a missing device fails early
+	var device captureSource
+	switch source.Type {
+	case conf.SourceTypeSoundCard:
+		if err := ValidateAudioDevice(source.Device); err != nil {
+			return fmt.Errorf("audio device validation failed for source %s: %w", id, err)
+		}
+		var err error
+		device, err = selectCaptureSource(m.settings, source.Device)
+		if err != nil {
+			return fmt.Errorf("audio device selection failed for source %s: %w", id, err)
+		}
+	case conf.SourceTypeRTSP, conf.SourceTypeStream:
+		if conf.GetFfmpegBinaryName() == "" {
+			return fmt.Errorf("FFmpeg is not available, cannot capture audio from stream source %s", id)
+		}
+	case conf.SourceTypeReplay:
+		if !IsSupportedAudioFile(source.Replay.Path) {
+			return fmt.Errorf("unsupported replay file for source %s: %s", id, source.Replay.Path)
+		}
+		if _, err := os.Stat(source.Replay.Path); err != nil {
+			return fmt.Errorf("replay file for source %s is not accessible: %w", id, err)
+		}
+	default:
+		return fmt.Errorf("unsupported audio source type %q for source %s", source.Type, id)
+	}
+
+	filters := m.filterSettings(&source)
+	if err := UpdateFilterChain(id, &filters); err != nil {
+		return err
+	}
+	if err := initializeBuffersForSource(id); err != nil {
+		return fmt.Errorf("failed to initialize buffers for source %s: %w", id, err)
+	}
+
+	active := &activeSource{
+		config: source,
+		quit:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	m.sources[id] = active
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer close(active.done)
+		m.runSource(id, active, device)
+	}()
+
+	m.updateFFmpegMonitor()
+	registerHealthSource(id)
+	if m.onStart != nil {
+		m.onStart(id)
+	}
+	log.Printf("⬆️ Audio source %s started", id)
+	return nil
+}
+
+// RunLiveSource registers a source whose audio is pushed by an external producer, such as a
+// network ingest connection, and calls run with a channel which is closed when the source is
+// stopped. Buffers and analysis are set up before run is called and released after it returns.
+func (m *SourceManager) RunLiveSource(source conf.AudioSourceConfig, run func(quit <-chan struct{})) error {
+	id := source.SourceID()
+
+	m.mu.Lock()
+	if _, exists := m.sources[id]; exists || m.stopping[id] {
+		m.mu.Unlock()
+		return fmt.Errorf("audio source %s is already active", id)
+	}
+	filters := m.filterSettings(&source)
+	if err := UpdateFilterChain(id, &filters); err != nil {
+		m.mu.Unlock()
+		return err
+	}
+	if err := initializeBuffersForSource(id); err != nil {
+		m.mu.Unlock()
+		return fmt.Errorf("failed to initialize buffers for source %s: %w", id, err)
+	}
+	active := &activeSource{
+		config: source,
+		live:   true,
+		quit:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	m.sources[id] = active
+	registerHealthSource(id)
+	if m.onStart != nil {
+		m.onStart(id)
+	}
+	m.mu.Unlock()
+	log.Printf("⬆️ Live audio source %s connected", id)
+
+	run(active.quit)
+	close(active.done)
+
+	// Release the source unless it was already stopped through the manager
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.sources[id] != active {
+		return nil
+	}
+	delete(m.sources, id)
+	log.Printf("⬇️ Live audio source %s disconnected", id)
+	return m.releaseSource(id)
+}
+
+// runSource runs the capture loop of a source until its quit channel is closed
+func (m *SourceManager) runSource(id string, active *activeSource, device captureSource) {
+	// Each source has its own restart channel so a failing source only restarts itself
+	restartChan := make(chan struct{}, 1)
+
+	for {
+		var retryDelay time.Duration
+		switch active.config.Type {
+		case conf.SourceTypeSoundCard:
+			captureAudioMalgo(m.settings, id, device, active.quit, restartChan, m.audioLevelChan)
+			retryDelay = 1 * time.Second
+		case conf.SourceTypeRTSP, conf.SourceTypeStream:
+			// The FFmpeg lifecycle handles restarts itself within the reconnect policy of the
+			// stream, once it gives up the source stays failed until it is restarted
+			CaptureAudioStream(active.config, active.quit, restartChan, m.audioLevelChan)
+			select {
+			case <-active.quit:
+			default:
+				log.Printf("❌ Audio capture of stream source %s failed, restart the source to try again", conf.SanitizeStreamUrl(id))
+				markSourceFailed(id)
+				<-active.quit
+			}
+			return
+		case conf.SourceTypeReplay:
+			// A replay plays once, the source stays active until it is stopped
+			if err := ReplayFile(active.config, active.quit, m.audioLevelChan); err != nil {
+				log.Printf("❌ Replay of source %s failed: %v", id, err)
+			}
+			<-active.quit
+			return
+		}
+
+		// Restart the capture after a delay unless the source was stopped
+		select {
+		case <-active.quit:
+			return
+		case <-time.After(retryDelay):
+			log.Printf("🔄 Restarting audio capture for source %s", id)
+		}
+	}
+}
+
+// StopSource stops capturing from the audio source and releases its buffers
+func (m *SourceManager) StopSource(id string) error {
+	m.stopMu.Lock()
+	defer m.stopMu.Unlock()
+
+	m.mu.Lock()
+	active, err := m.beginStop(id)
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	waitStopped(map[string]*activeSource{id: active})
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.finishStop(id, false)
+}
+
+// beginStop removes a source and signals its capture goroutine to exit, the caller must hold
+// m.mu. The source is finished with finishStop once waitStopped returns.
+func (m *SourceManager) beginStop(id string) (*activeSource, error) {
+	active, exists := m.sources[id]
+	if !exists {
+		return nil, fmt.Errorf("audio source %s is not running", id)
+	}
+	delete(m.sources, id)
+	m.stopping[id] = true
+
+	close(active.quit)
+	m.updateFFmpegMonitor()
+	return active, nil
+}
+
+// waitStopped waits for the capture goroutines of stopping sources to exit, the caller must not
+// hold m.mu so that other sources can be managed meanwhile
+func waitStopped(sources map[string]*activeSource) {
+	timeout := time.NewTimer(sourceStopTimeout)
+	defer timeout.Stop()
+
+	expired := false
+	for id, active := range sources {
+		if !expired {
+			select {
+			case <-active.done:
+				continue
+			case <-timeout.C:
+				expired = true
+			}
+		}
+		select {
+		case <-active.done:
+		default:
+			log.Printf("⚠️ Timeout waiting for audio source %s to stop", id)
+		}
+	}
+}
+
+// finishStop completes stopping a source, the caller must hold m.mu. Buffers are kept if
+// keepBuffers is true, which is used when a source is restarted with a new configuration.
+func (m *SourceManager) finishStop(id string, keepBuffers bool) error {
+	delete(m.stopping, id)
+	log.Printf("⬇️ Audio source %s stopped", id)
+
+	if keepBuffers {
+		return nil
+	}
+	return m.releaseSource(id)
+}
+
+// releaseSource stops the analysis of a source and removes its buffers, the caller must hold m.mu
+func (m *SourceManager) releaseSource(id string) error {
+	if m.onStop != nil {
+		m.onStop(id)
+	}
+	stopListen(id)
+	unregisterHealthSource(id)
+	ResetSignalLevels(id)
+	RemoveSourceClock(id)
+	removeFilterChain(id)
+
+	var errs []error
+	if err := RemoveAnalysisBuffer(id); err != nil {
+		errs = append(errs, fmt.Errorf("failed to remove analysis buffer for %s: %w", id, err))
+	}
+	if err := RemoveCaptureBuffer(id); err != nil {
+		errs = append(errs, fmt.Errorf("failed to remove capture buffer for %s: %w", id, err))
+	}
+	return errors.Join(errs...)
+}
+
+// Reconcile starts and stops sources so that exactly the given sources are running.
+// Sources whose configuration changed are restarted, unchanged sources keep running.
+func (m *SourceManager) Reconcile(sources []conf.AudioSourceConfig) error {
+	m.stopMu.Lock()
+	defer m.stopMu.Unlock()
+
+	wanted := make(map[string]conf.AudioSourceConfig, len(sources))
+	for _, source := range sources {
+		wanted[source.SourceID()] = source
+	}
+
+	var errs []error
+
+	// Stop sources which were removed or reconfigured, live sources are not part of the configuration.
+	// Reconfigured sources keep their buffers until they are started again.
+	m.mu.Lock()
+	stopped := make(map[string]*activeSource)
+	restarting := make(map[string]bool)
+	for id, active := range m.sources {
+		if active.live {
+			continue
+		}
+		source, keep := wanted[id]
+		if keep && sameCaptureConfig(source, active.config) {
+			// Filter changes are applied without restarting the capture
+			filters := m.filterSettings(&source)
+			if err := UpdateFilterChain(id, &filters); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			active.config = source
+			continue
+		}
+		if _, err := m.beginStop(id); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		stopped[id] = active
+		restarting[id] = keep
+	}
+	m.mu.Unlock()
+
+	// Capture goroutines are waited for without blocking other users of the manager
+	waitStopped(stopped)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id := range stopped {
+		if err := m.finishStop(id, restarting[id]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// Start new and reconfigured sources in configuration order
+	for _, source := range sources {
+		if active, exists := m.sources[source.SourceID()]; exists && active.live {
+			errs = append(errs, fmt.Errorf("audio source %s is in use by a live source", source.SourceID()))
+			continue
+		}
+		if err := m.startSource(source); err != nil {
+			errs = append(errs, err)
+
+			// A reconfigured source which fails to start releases the buffers it kept
+			if restarting[source.SourceID()] {
+				if err := m.releaseSource(source.SourceID()); err != nil {
+					errs = append(errs, err)
+				}
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// sameCaptureConfig reports whether two configurations of a source capture the same audio,
+// they may differ in their filter settings
+func sameCaptureConfig(a, b conf.AudioSourceConfig) bool {
+	a.Filters, b.Filters = nil, nil
+	return reflect.DeepEqual(a, b)
+}
+
+// filterSettings returns the filter chain settings of a source, sources without their own
+// filter settings use the global settings
+func (m *SourceManager) filterSettings(source *conf.AudioSourceConfig) conf.AudioFilterSettings {
+	if source.Filters != nil {
+		return *source.Filters
+	}
+	return m.settings.Realtime.SourceFilters(source.SourceID())
+}
+
+// StopAll stops all running audio sources
+func (m *SourceManager) StopAll() {
+	m.stopMu.Lock()
+	defer m.stopMu.Unlock()
+
+	m.mu.Lock()
+	stopped := make(map[string]*activeSource, len(m.sources))
+	for id := range m.sources {
+		active, err := m.beginStop(id)
+		if err != nil {
+			log.Printf("❌ Error stopping audio source %s: %v", id, err)
+			continue
+		}
+		stopped[id] = active
+	}
+	m.mu.Unlock()
+
+	waitStopped(stopped)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id := range stopped {
+		if err := m.finishStop(id, false); err != nil {
+			log.Printf("❌ Error stopping audio source %s: %v", id, err)
+		}
+	}
+}
+
+// ActiveSources returns the sorted IDs of all running audio sources
+func (m *SourceManager) ActiveSources() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ids := make([]string, 0, len(m.sources))
+	for id := range m.sources {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// updateFFmpegMonitor runs the FFmpeg process monitor while any stream source is active,
+// the caller must hold m.mu
+func (m *SourceManager) updateFFmpegMonitor() {
+	hasStreams := false
+	for _, active := range m.sources {
+		if active.config.IsStream() {
+			hasStreams = true
+			break
+		}
+	}
+
+	switch {
+	case hasStreams && ffmpegMonitor == nil:
+		ffmpegMonitor = NewFFmpegMonitor()
+		ffmpegMonitor.Start()
+	case !hasStreams && ffmpegMonitor != nil:
+		ffmpegMonitor.Stop()
+		ffmpegMonitor = nil
+	}
+}
@@ -0,0 +1,103 @@
+package myaudio
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// testSourceManager creates a source manager which records the sources its hooks are called for
+func testSourceManager(t *testing.T) (m *SourceManager, started, stopped *[]string) {
+	t.Helper()
+	var wg sync.WaitGroup
+	quit := make(chan struct{})
+	m = NewSourceManager(conf.Setting(), &wg, quit, make(chan AudioLevelData, 100))
+
+	var mu sync.Mutex
+	started, stopped = &[]string{}, &[]string{}
+	m.SetSourceHooks(func(id string) {
+		mu.Lock()
+		defer mu.Unlock()
+		*started = append(*started, id)
+	}, func(id string) {
+		mu.Lock()
+		defer mu.Unlock()
+		*stopped = append(*stopped, id)
+	})
+
+	t.Cleanup(func() {
+		m.StopAll()
+		close(quit)
+		wg.Wait()
+	})
+	return m, started, stopped
+}
+
+// testReplaySource writes a short audio file and returns a replay source playing it
+func testReplaySource(t *testing.T, id string) conf.AudioSourceConfig {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "replay.aiff")
+	require.NoError(t, os.WriteFile(path, buildAIFF(t, conf.SampleRate, 1, make([]int16, conf.SampleRate)), 0o644))
+	return conf.AudioSourceConfig{
+		ID:     id,
+		Type:   conf.SourceTypeReplay,
+		Replay: conf.ReplaySettings{Path: path, Speed: 1},
+	}
+}
+
+// TestReconcileFailedRestart tests that a reconfigured source which fails to start releases its buffers
+func TestReconcileFailedRestart(t *testing.T) {
+	m, started, stopped := testSourceManager(t)
+	source := testReplaySource(t, "reconcile-replay")
+
+	require.NoError(t, m.Reconcile([]conf.AudioSourceConfig{source}))
+	assert.Equal(t, []string{"reconcile-replay"}, m.ActiveSources())
+	assert.Equal(t, []string{"reconcile-replay"}, *started)
+	assert.True(t, HasCaptureBuffer("reconcile-replay"))
+
+	// The reconfigured source cannot start because its file is missing
+	source.Replay.Path = filepath.Join(t.TempDir(), "missing.aiff")
+	require.Error(t, m.Reconcile([]conf.AudioSourceConfig{source}))
+	assert.Empty(t, m.ActiveSources())
+	assert.Equal(t, []string{"reconcile-replay"}, *stopped)
+	assert.False(t, HasCaptureBuffer("reconcile-replay"))
+	assert.Error(t, RemoveAnalysisBuffer("reconcile-replay"))
+}
+
+// TestStopSourceDoesNotBlock tests that the manager stays usable while a source is slow to stop
+func TestStopSourceDoesNotBlock(t *testing.T) {
+	m, _, stopped := testSourceManager(t)
+
+	// A live source which takes until release to stop after being asked to
+	running := make(chan struct{})
+	release := make(chan struct{})
+	liveDone := make(chan error, 1)
+	go func() {
+		liveDone <- m.RunLiveSource(conf.AudioSourceConfig{ID: "slow-live"}, func(quit <-chan struct{}) {
+			close(running)
+			<-quit
+			<-release
+		})
+	}()
+	<-running
+
+	stopDone := make(chan error, 1)
+	go func() { stopDone <- m.StopSource("slow-live") }()
+	require.Eventually(t, func() bool { return len(m.ActiveSources()) == 0 }, 5*time.Second, 10*time.Millisecond)
+
+	// The stopping source cannot be started again until it has stopped
+	require.Error(t, m.RunLiveSource(conf.AudioSourceConfig{ID: "slow-live"}, func(<-chan struct{}) {}))
+	assert.True(t, HasCaptureBuffer("slow-live"))
+
+	close(release)
+	require.NoError(t, <-stopDone)
+	require.NoError(t, <-liveDone)
+	assert.Equal(t, []string{"slow-live"}, *stopped)
+	assert.False(t, HasCaptureBuffer("slow-live"))
+}
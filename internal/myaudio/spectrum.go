@@ -0,0 +1,252 @@
+package myaudio
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/myaudio/dsp"
+)
+
+// spectrumFloor is the lowest level reported in spectrum frames in dBFS
+const spectrumFloor = -120
+
+// ErrLiveSpectrumDisabled is returned when subscribing to the live spectrum while it is disabled
+var ErrLiveSpectrumDisabled = errors.New("live spectrum is disabled")
+
+// liveSpectrum is the active live spectrum hub, nil if the live spectrum is disabled
+var liveSpectrum atomic.Pointer[spectrumHub]
+
+// SpectrumInfo describes the frames of the live spectrum stream
+type SpectrumInfo struct {
+	Bands   int     `json:"bands"`   // number of frequency bands per frame
+	MinFreq float64 `json:"minFreq"` // lower edge of the first band in Hz
+	MaxFreq float64 `json:"maxFreq"` // upper edge of the last band in Hz
+	Rate    int     `json:"rate"`    // frames per second and source
+	Floor   int     `json:"floor"`   // lowest level reported in dBFS
+}
+
+// SpectrumFrame holds the band levels of one audio source at one point in time
+type SpectrumFrame struct {
+	Source string    `json:"source"` // source ID
+	Time   time.Time `json:"time"`   // time at the end of the analyzed audio
+	Bands  []int16   `json:"bands"`  // band levels in dBFS from the lowest frequency up
+}
+
+// EnableLiveSpectrum enables or disables the live spectrum of captured audio. Spectra are computed
+// once per source from the capture path and shared by all subscribers, nothing is computed while
+// there are no subscribers.
+func EnableLiveSpectrum(settings *conf.LiveSpectrumSettings) {
+	if !settings.Enabled {
+		liveSpectrum.Store(nil)
+		return
+	}
+	liveSpectrum.Store(newSpectrumHub(settings))
+}
+
+// LiveSpectrumInfo returns the layout of the live spectrum frames, false if the live spectrum is disabled
+func LiveSpectrumInfo() (SpectrumInfo, bool) {
+	h := liveSpectrum.Load()
+	if h == nil {
+		return SpectrumInfo{}, false
+	}
+	return h.info, true
+}
+
+// SubscribeSpectrum subscribes to the live spectrum frames of a source, or of all sources if source
+// is empty. Frames are dropped if the subscriber does not keep up. The returned function cancels
+// the subscription.
+func SubscribeSpectrum(source string) (frames <-chan SpectrumFrame, unsubscribe func(), err error) {
+	h := liveSpectrum.Load()
+	if h == nil {
+		return nil, nil, ErrLiveSpectrumDisabled
+	}
+	sub := h.subscribe(source)
+	return sub.frames, func() { h.unsubscribe(sub) }, nil
+}
+
+// analyzeSpectrum passes audio written to the capture buffer of a source to the live spectrum
+func analyzeSpectrum(sourceID string, data []byte) {
+	h := liveSpectrum.Load()
+	if h == nil || h.subscriberCount.Load() == 0 {
+		return
+	}
+	h.analyzer(sourceID).write(data)
+}
+
+// spectrumSubscriber receives the spectrum frames of one or all sources
+type spectrumSubscriber struct {
+	source string
+	frames chan SpectrumFrame
+}
+
+// spectrumHub computes the live spectra of all sources and distributes them to subscribers
+type spectrumHub struct {
+	info    SpectrumInfo
+	fftSize int
+	hop     int       // samples between frames
+	window  []float64 // analysis window
+	scale   float64   // power scale which makes a full scale sine wave read 0 dBFS
+	bands   [][2]int  // first and last FFT bin of each band
+
+	mu              sync.RWMutex
+	subscribers     map[*spectrumSubscriber]struct{}
+	subscriberCount atomic.Int32
+	analyzers       sync.Map // source ID -> *spectrumAnalyzer
+}
+
+// newSpectrumHub creates a spectrum hub for the given settings
+func newSpectrumHub(settings *conf.LiveSpectrumSettings) *spectrumHub {
+	h := &spectrumHub{
+		info: SpectrumInfo{
+			Bands:   settings.Bands,
+			MaxFreq: min(settings.MaxFreq, conf.SampleRate/2),
+			Rate:    settings.Rate,
+			Floor:   spectrumFloor,
+		},
+		fftSize:     settings.FFTSize,
+		hop:         conf.SampleRate / settings.Rate,
+		window:      dsp.HannWindow(settings.FFTSize),
+		subscribers: make(map[*spectrumSubscriber]struct{}),
+	}
+
+	var windowSum float64
+	for _, w := range h.window {
+		windowSum += w
+	}
+	h.scale = 4 / (windowSum * windowSum)
+
+	// Bands are spaced linearly, bands narrower than a bin use the nearest bin
+	binHz := float64(conf.SampleRate) / float64(h.fftSize)
+	bandHz := h.info.MaxFreq / float64(h.info.Bands)
+	h.bands = make([][2]int, h.info.Bands)
+	for i := range h.bands {
+		first := int(math.Ceil(float64(i) * bandHz / binHz))
+		last := int(math.Ceil(float64(i+1)*bandHz/binHz)) - 1
+		if last < first {
+			first = int(math.Round((float64(i) + 0.5) * bandHz / binHz))
+			last = first
+		}
+		h.bands[i] = [2]int{first, min(last, h.fftSize/2)}
+	}
+	return h
+}
+
+// subscribe adds a subscriber for a source, or all sources if source is empty
+func (h *spectrumHub) subscribe(source string) *spectrumSubscriber {
+	sub := &spectrumSubscriber{
+		source: source,
+		frames: make(chan SpectrumFrame, 2*h.info.Rate),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subscribers[sub] = struct{}{}
+	h.subscriberCount.Store(int32(len(h.subscribers)))
+	return sub
+}
+
+// unsubscribe removes a subscriber, the spectrum analysis stops with the last subscriber
+func (h *spectrumHub) unsubscribe(sub *spectrumSubscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.subscribers, sub)
+	h.subscriberCount.Store(int32(len(h.subscribers)))
+
+	// Discard stale audio so that new subscribers do not receive old spectra
+	if len(h.subscribers) == 0 {
+		h.analyzers.Range(func(key, _ any) bool {
+			h.analyzers.Delete(key)
+			return true
+		})
+	}
+}
+
+// analyzer returns the spectrum analyzer of a source
+func (h *spectrumHub) analyzer(sourceID string) *spectrumAnalyzer {
+	if a, exists := h.analyzers.Load(sourceID); exists {
+		return a.(*spectrumAnalyzer)
+	}
+	a, _ := h.analyzers.LoadOrStore(sourceID, &spectrumAnalyzer{
+		hub:     h,
+		source:  sourceID,
+		samples: make([]float64, h.fftSize),
+		buf:     make([]complex128, h.fftSize),
+	})
+	return a.(*spectrumAnalyzer)
+}
+
+// publish sends a frame to the subscribers of its source without blocking
+func (h *spectrumHub) publish(frame SpectrumFrame) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for sub := range h.subscribers {
+		if sub.source != "" && sub.source != frame.Source {
+			continue
+		}
+		select {
+		case sub.frames <- frame:
+		default:
+			// The subscriber is not keeping up, drop the frame
+		}
+	}
+}
+
+// spectrumAnalyzer computes the spectrum frames of one source
+type spectrumAnalyzer struct {
+	hub    *spectrumHub
+	source string
+
+	mu      sync.Mutex
+	samples []float64 // ring buffer of the latest FFT window of samples
+	pos     int       // next write position in samples
+	pending int       // samples written since the last frame
+	buf     []complex128
+}
+
+// write adds 16-bit PCM audio and publishes a frame every hop samples
+func (a *spectrumAnalyzer) write(data []byte) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for i := 0; i+1 < len(data); i += 2 {
+		a.samples[a.pos] = float64(int16(binary.LittleEndian.Uint16(data[i:]))) / 32768.0
+		a.pos = (a.pos + 1) % len(a.samples)
+		a.pending++
+		if a.pending >= a.hub.hop {
+			a.pending = 0
+			a.hub.publish(a.frame())
+		}
+	}
+}
+
+// frame computes the band levels of the latest FFT window of samples, the caller must hold a.mu
+func (a *spectrumAnalyzer) frame() SpectrumFrame {
+	h := a.hub
+	n := len(a.samples)
+	for i := range a.buf {
+		a.buf[i] = complex(a.samples[(a.pos+i)%n]*h.window[i], 0)
+	}
+	dsp.FFT(a.buf)
+
+	bands := make([]int16, len(h.bands))
+	for i, bins := range h.bands {
+		var power float64
+		for k := bins[0]; k <= bins[1]; k++ {
+			re, im := real(a.buf[k]), imag(a.buf[k])
+			power = max(power, (re*re+im*im)*h.scale)
+		}
+		level := 10 * math.Log10(power+1e-20)
+		bands[i] = int16(math.Round(min(max(level, spectrumFloor), 0)))
+	}
+
+	return SpectrumFrame{
+		Source: a.source,
+		Time:   SourceNow(a.source),
+		Bands:  bands,
+	}
+}
@@ -0,0 +1,104 @@
+package myaudio
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// sinePCM returns one second of 16-bit PCM with a sine wave of the given frequency and amplitude
+func sinePCM(freq, amplitude float64) []byte {
+	pcm := make([]byte, conf.SampleRate*2)
+	for i := 0; i < conf.SampleRate; i++ {
+		s := amplitude * math.Sin(2*math.Pi*freq*float64(i)/conf.SampleRate)
+		binary.LittleEndian.PutUint16(pcm[i*2:], uint16(int16(s*32767)))
+	}
+	return pcm
+}
+
+// testLiveSpectrum enables the live spectrum for the duration of a test
+func testLiveSpectrum(t *testing.T) *conf.LiveSpectrumSettings {
+	t.Helper()
+	settings := &conf.LiveSpectrumSettings{Enabled: true, Rate: 10, Bands: 120, FFTSize: 1024, MaxFreq: 12000}
+	EnableLiveSpectrum(settings)
+	t.Cleanup(func() { EnableLiveSpectrum(&conf.LiveSpectrumSettings{}) })
+	return settings
+}
+
+func TestLiveSpectrumFrames(t *testing.T) {
+	settings := testLiveSpectrum(t)
+
+	info, enabled := LiveSpectrumInfo()
+	require.True(t, enabled)
+	assert.Equal(t, SpectrumInfo{Bands: 120, MaxFreq: 12000, Rate: 10, Floor: spectrumFloor}, info)
+
+	frames, unsubscribe, err := SubscribeSpectrum("")
+	require.NoError(t, err)
+	defer unsubscribe()
+
+	// One second of audio yields one frame per rate interval
+	analyzeSpectrum("mic", sinePCM(3050, 0.5))
+	require.Len(t, frames, settings.Rate)
+
+	frame := <-frames
+	assert.Equal(t, "mic", frame.Source)
+	require.Len(t, frame.Bands, settings.Bands)
+
+	// The tone is in the 3000-3100 Hz band at about -6 dBFS
+	peak := 0
+	for i, level := range frame.Bands {
+		if level > frame.Bands[peak] {
+			peak = i
+		}
+	}
+	assert.Equal(t, 30, peak)
+	assert.InDelta(t, -6, frame.Bands[peak], 1.5)
+	assert.Less(t, frame.Bands[100], int16(-60))
+}
+
+func TestLiveSpectrumSubscriptions(t *testing.T) {
+	testLiveSpectrum(t)
+
+	// Nothing is analyzed without subscribers
+	analyzeSpectrum("mic", sinePCM(1000, 0.5))
+	h := liveSpectrum.Load()
+	_, exists := h.analyzers.Load("mic")
+	assert.False(t, exists)
+
+	// Subscribers of a source only receive frames of that source
+	all, unsubscribeAll, err := SubscribeSpectrum("")
+	require.NoError(t, err)
+	defer unsubscribeAll()
+	mic, unsubscribeMic, err := SubscribeSpectrum("mic")
+	require.NoError(t, err)
+
+	analyzeSpectrum("mic", sinePCM(1000, 0.5)[:conf.SampleRate/5])
+	analyzeSpectrum("rtsp", sinePCM(1000, 0.5)[:conf.SampleRate/5])
+	assert.Len(t, all, 2)
+	assert.Len(t, mic, 1)
+
+	// Frames are dropped for subscribers which do not keep up
+	analyzeSpectrum("mic", sinePCM(1000, 0.5))
+	analyzeSpectrum("mic", sinePCM(1000, 0.5))
+	analyzeSpectrum("mic", sinePCM(1000, 0.5))
+	assert.Equal(t, cap(mic), len(mic))
+
+	// Analyzers are discarded with the last subscriber
+	unsubscribeMic()
+	unsubscribeAll()
+	_, exists = h.analyzers.Load("mic")
+	assert.False(t, exists)
+}
+
+func TestLiveSpectrumDisabled(t *testing.T) {
+	EnableLiveSpectrum(&conf.LiveSpectrumSettings{})
+
+	_, enabled := LiveSpectrumInfo()
+	assert.False(t, enabled)
+	_, _, err := SubscribeSpectrum("")
+	assert.ErrorIs(t, err, ErrLiveSpectrumDisabled)
+}
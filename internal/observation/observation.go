@@ -5,6 +5,8 @@ import (
 	"io"
 	"math"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -44,9 +46,11 @@ func New(settings *conf.Settings, beginTime, endTime time.Time, species string,
 	if settings.Input.Path != "" {
 		audioSource = settings.Input.Path
 	} else {
-		// If source is RTSP, sanitize URL before applying it
+		// If source is a stream URL, sanitize it before applying it
 		if strings.HasPrefix(source, "rtsp://") {
 			audioSource = conf.SanitizeRTSPUrl(source)
+		} else if strings.Contains(source, "://") {
+			audioSource = conf.SanitizeStreamUrl(source)
 		} else {
 			audioSource = source
 		}
@@ -76,10 +80,79 @@ func New(settings *conf.Settings, beginTime, endTime time.Time, species string,
 	}
 }
 
+// channelSourceSeparator separates the audio source from the channel number in a channel tagged source
+const channelSourceSeparator = "#ch"
+
+// ChannelSource tags an audio source with a zero based channel index. The channel is
+// stored 1-based to match the channel numbering of Raven selection tables.
+func ChannelSource(source string, channel int) string {
+	return fmt.Sprintf("%s%s%d", source, channelSourceSeparator, channel+1)
+}
+
+// ParseChannelSource splits a channel tagged source into the audio source and the 1-based
+// channel number. For sources without a channel tag the channel is 0.
+func ParseChannelSource(source string) (audioSource string, channel int) {
+	i := strings.LastIndex(source, channelSourceSeparator)
+	if i < 0 {
+		return source, 0
+	}
+	channel, err := strconv.Atoi(source[i+len(channelSourceSeparator):])
+	if err != nil || channel < 1 {
+		return source, 0
+	}
+	return source[:i], channel
+}
+
+// splitNotesByChannel groups notes by the channel tagged in their source, returning
+// the channel numbers in ascending order along with the notes of each channel. Channels 1 to
+// numChannels are always included so channels without detections still get an output.
+func splitNotesByChannel(notes []datastore.Note, numChannels int) (channels []int, notesByChannel map[int][]datastore.Note) {
+	notesByChannel = make(map[int][]datastore.Note)
+	for channel := 1; channel <= numChannels; channel++ {
+		channels = append(channels, channel)
+		notesByChannel[channel] = nil
+	}
+	for i := range notes {
+		_, channel := ParseChannelSource(notes[i].Source)
+		if _, exists := notesByChannel[channel]; !exists {
+			channels = append(channels, channel)
+		}
+		notesByChannel[channel] = append(notesByChannel[channel], notes[i])
+	}
+	sort.Ints(channels)
+	return channels, notesByChannel
+}
+
+// channelFilename returns the output filename for a single channel, an empty filename
+// is kept empty so the output still goes to stdout
+func channelFilename(filename string, channel int) string {
+	if filename == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s.ch%d", filename, channel)
+}
+
 // WriteNotesTable writes a slice of Note structs to a table-formatted text output.
 // The output can be directed to either stdout or a file specified by the filename.
 // If the filename is an empty string, it writes to stdout.
-func WriteNotesTable(settings *conf.Settings, notes []datastore.Note, filename string) error {
+// When channels are analyzed separately a table is written for each of the numChannels
+// channels of the file, including channels without detections.
+func WriteNotesTable(settings *conf.Settings, notes []datastore.Note, filename string, numChannels int) error {
+	if !settings.Input.SplitChannels {
+		return writeNotesTable(settings, notes, filename)
+	}
+
+	channels, notesByChannel := splitNotesByChannel(notes, numChannels)
+	for _, channel := range channels {
+		if err := writeNotesTable(settings, notesByChannel[channel], channelFilename(filename, channel)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeNotesTable writes the notes of a single output to a table-formatted text output
+func writeNotesTable(settings *conf.Settings, notes []datastore.Note, filename string) error {
 	var w io.Writer
 	// Determine the output destination based on the filename argument.
 	if filename == "" {
@@ -115,9 +188,15 @@ func WriteNotesTable(settings *conf.Settings, notes []datastore.Note, filename s
 			continue // Skip the current iteration as the note doesn't meet the threshold
 		}
 
+		// Notes without a channel tag come from mono or downmixed audio
+		source, channel := ParseChannelSource(notes[i].Source)
+		if channel == 0 {
+			channel = 1
+		}
+
 		// Prepare the line for notes above the threshold, assuming note.BeginTime and note.EndTime are of type time.Time
-		line := fmt.Sprintf("%d\tSpectrogram 1\t1\t%s\t%s\t%s\t0\t15000\t%s\t%s\t%.4f\n",
-			i+1, notes[i].Source, notes[i].BeginTime.Format("15:04:05"), notes[i].EndTime.Format("15:04:05"),
+		line := fmt.Sprintf("%d\tSpectrogram 1\t%d\t%s\t%s\t%s\t0\t15000\t%s\t%s\t%.4f\n",
+			i+1, channel, source, notes[i].BeginTime.Format("15:04:05"), notes[i].EndTime.Format("15:04:05"),
 			notes[i].SpeciesCode, notes[i].CommonName, notes[i].Confidence)
 
 		// Attempt to write the note
@@ -139,8 +218,25 @@ func WriteNotesTable(settings *conf.Settings, notes []datastore.Note, filename s
 
 // WriteNotesCsv writes the slice of notes to the specified destination in CSV format.
 // If filename is an empty string, the function writes to stdout.
+// When channels are analyzed separately a CSV output is written for each of the numChannels
+// channels of the file, including channels without detections.
 // The function returns an error if writing to the destination fails.
-func WriteNotesCsv(settings *conf.Settings, notes []datastore.Note, filename string) error {
+func WriteNotesCsv(settings *conf.Settings, notes []datastore.Note, filename string, numChannels int) error {
+	if !settings.Input.SplitChannels {
+		return writeNotesCsv(settings, notes, filename)
+	}
+
+	channels, notesByChannel := splitNotesByChannel(notes, numChannels)
+	for _, channel := range channels {
+		if err := writeNotesCsv(settings, notesByChannel[channel], channelFilename(filename, channel)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeNotesCsv writes the notes of a single output in CSV format
+func writeNotesCsv(settings *conf.Settings, notes []datastore.Note, filename string) error {
 	// Define an io.Writer to abstract the writing operation.
 	var w io.Writer
 
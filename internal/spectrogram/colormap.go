@@ -0,0 +1,65 @@
+package spectrogram
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+)
+
+// colorMaps holds the color stops of the supported color maps, from the lowest to the highest level.
+// Inferno and viridis are sampled from the matplotlib color maps of the same name.
+var colorMaps = map[string][]color.RGBA{
+	"inferno": {
+		{0, 0, 4, 255}, {31, 12, 72, 255}, {85, 15, 109, 255}, {136, 34, 106, 255}, {186, 54, 85, 255},
+		{227, 89, 51, 255}, {249, 140, 10, 255}, {249, 201, 50, 255}, {252, 255, 164, 255},
+	},
+	"viridis": {
+		{68, 1, 84, 255}, {71, 44, 122, 255}, {59, 81, 139, 255}, {44, 113, 142, 255}, {33, 144, 141, 255},
+		{39, 173, 129, 255}, {92, 200, 99, 255}, {170, 220, 50, 255}, {253, 231, 37, 255},
+	},
+	"grayscale": {
+		{0, 0, 0, 255}, {255, 255, 255, 255},
+	},
+}
+
+// ColorMaps returns the names of the supported color maps
+func ColorMaps() []string {
+	names := make([]string, 0, len(colorMaps))
+	for name := range colorMaps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// palette is a color map interpolated into a lookup table of 256 colors
+type palette [256]color.RGBA
+
+// newPalette interpolates the named color map into a palette
+func newPalette(name string) (*palette, error) {
+	stops, ok := colorMaps[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported color map: %s", name)
+	}
+
+	var p palette
+	for i := range p {
+		pos := float64(i) / 255 * float64(len(stops)-1)
+		lo := min(int(pos), len(stops)-2)
+		t := pos - float64(lo)
+		a, b := stops[lo], stops[lo+1]
+		p[i] = color.RGBA{
+			R: uint8(float64(a.R) + t*(float64(b.R)-float64(a.R)) + 0.5),
+			G: uint8(float64(a.G) + t*(float64(b.G)-float64(a.G)) + 0.5),
+			B: uint8(float64(a.B) + t*(float64(b.B)-float64(a.B)) + 0.5),
+			A: 255,
+		}
+	}
+	return &p, nil
+}
+
+// color returns the color of a level between 0 and 1
+func (p *palette) color(level float64) color.RGBA {
+	level = min(max(level, 0), 1)
+	return p[int(level*255+0.5)]
+}
@@ -0,0 +1,43 @@
+package spectrogram
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+)
+
+// Supported image formats
+const (
+	FormatPNG  = "png"
+	FormatWebP = "webp"
+)
+
+// Encode writes the image in the given format, png or webp
+func Encode(w io.Writer, img image.Image, format string) error {
+	switch format {
+	case FormatPNG, "":
+		encoder := png.Encoder{CompressionLevel: png.BestCompression}
+		return encoder.Encode(w, img)
+	case FormatWebP:
+		return EncodeWebP(w, img)
+	default:
+		return fmt.Errorf("unsupported spectrogram format: %s", format)
+	}
+}
+
+// FileExtension returns the file extension of an image format including the dot
+func FileExtension(format string) string {
+	if format == FormatWebP {
+		return ".webp"
+	}
+	return ".png"
+}
+
+// ContentType returns the MIME type of an image format
+func ContentType(format string) string {
+	if format == FormatWebP {
+		return "image/webp"
+	}
+	return "image/png"
+}
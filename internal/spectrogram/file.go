@@ -0,0 +1,124 @@
+package spectrogram
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/birdnet"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/myaudio"
+)
+
+// detectionWindow returns the length of the analysis window of the active model in which a
+// species is detected
+func detectionWindow() time.Duration {
+	return time.Duration(birdnet.ActiveModel().WindowSeconds * float64(time.Second))
+}
+
+// errEnoughAudio stops reading an audio file once the rendered duration has been read
+var errEnoughAudio = errors.New("enough audio read")
+
+// OptionsFromSettings returns the rendering options configured for the dashboard
+func OptionsFromSettings(settings *conf.Settings, width int) Options {
+	s := &settings.Realtime.Dashboard.Spectrogram
+	opts := Options{
+		Width:        width,
+		MinFreq:      s.MinFreq,
+		MaxFreq:      s.MaxFreq,
+		DynamicRange: s.DynamicRange,
+		Normalize:    s.Normalize,
+		ColorMap:     s.ColorMap,
+		TimeAxis:     s.TimeAxis,
+	}
+	opts.MarkDetection = s.Detection
+	return opts
+}
+
+// clipDetectionWindow returns the detection window within an audio clip, derived from the
+// detection and clip start times recorded in the clip metadata. The window is empty when the
+// times are not recorded, e.g. in clips exported by older versions.
+func clipDetectionWindow(audioPath string) (start, end time.Duration) {
+	detection, clipStart, err := myaudio.ReadClipTimes(audioPath)
+	if err != nil || detection.Before(clipStart) {
+		return 0, 0
+	}
+	start = detection.Sub(clipStart)
+	return start, start + detectionWindow()
+}
+
+// RenderFile renders a spectrogram of an audio file and writes it to outputPath in the given
+// format. Any audio format supported by myaudio can be read, the image is written atomically.
+func RenderFile(audioPath, outputPath, format string, opts Options) error {
+	if opts.MarkDetection {
+		opts.DetectionStart, opts.DetectionEnd = clipDetectionWindow(audioPath)
+	}
+
+	samples, err := readSamples(audioPath, opts.Duration)
+	if err != nil {
+		return fmt.Errorf("error reading audio file: %w", err)
+	}
+
+	img, err := Render(samples, conf.SampleRate, opts)
+	if err != nil {
+		return fmt.Errorf("error rendering spectrogram: %w", err)
+	}
+
+	// Write to a temporary file first so a partially written image is never served
+	tempPath := outputPath + ".temp"
+	file, err := os.Create(tempPath)
+	if err != nil {
+		return fmt.Errorf("error creating spectrogram file: %w", err)
+	}
+	err = Encode(file, img, format)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("error encoding spectrogram: %w", err)
+	}
+	if err := os.Rename(tempPath, outputPath); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("error saving spectrogram: %w", err)
+	}
+	return nil
+}
+
+// readSamples decodes an audio file into mono samples at the BirdNET sample rate, reading at
+// most maxDuration of audio if it is set
+func readSamples(audioPath string, maxDuration time.Duration) ([]float32, error) {
+	// The reader pads the last analysis chunk with silence, the padding is cut off with the
+	// length of the file when it is known
+	limit := -1
+	if info, err := myaudio.GetAudioInfo(audioPath); err == nil && info.SampleRate > 0 {
+		limit = int(int64(info.TotalSamples) * conf.SampleRate / int64(info.SampleRate))
+	}
+	if maxDuration > 0 {
+		maxSamples := int(maxDuration.Seconds() * conf.SampleRate)
+		if limit < 0 || maxSamples < limit {
+			limit = maxSamples
+		}
+	}
+
+	settings := &conf.Settings{}
+	settings.Input.Path = audioPath
+
+	var samples []float32
+	err := myaudio.ReadAudioFileBuffered(settings, func(chunk []float32) error {
+		samples = append(samples, chunk...)
+		if limit >= 0 && len(samples) >= limit {
+			return errEnoughAudio
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errEnoughAudio) {
+		return nil, err
+	}
+
+	if limit >= 0 && len(samples) > limit {
+		samples = samples[:limit]
+	}
+	return samples, nil
+}
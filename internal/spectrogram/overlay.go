@@ -0,0 +1,141 @@
+package spectrogram
+
+import (
+	"image"
+	"image/color"
+	"strconv"
+	"time"
+)
+
+const (
+	// axisHeight is the height in pixels of the time axis strip at the bottom of the image
+	axisHeight = 9
+
+	// minTickSpacing is the minimum distance in pixels between labeled time axis ticks
+	minTickSpacing = 32
+)
+
+var (
+	detectionColor = color.RGBA{255, 255, 255, 255}
+	axisColor      = color.RGBA{230, 230, 230, 255}
+)
+
+// tickIntervals are the candidate time axis label intervals in seconds
+var tickIntervals = []int{1, 2, 5, 10, 15, 30, 60, 120, 300}
+
+// digitGlyphs is a 3x5 pixel font of the characters used in time axis labels, each row is
+// three bits with the most significant bit on the left
+var digitGlyphs = map[rune][5]uint8{
+	'0': {7, 5, 5, 5, 7},
+	'1': {2, 6, 2, 2, 7},
+	'2': {7, 1, 7, 4, 7},
+	'3': {7, 1, 3, 1, 7},
+	'4': {5, 5, 7, 1, 1},
+	'5': {7, 4, 7, 1, 7},
+	'6': {7, 4, 7, 5, 7},
+	'7': {7, 1, 1, 2, 2},
+	'8': {7, 5, 7, 5, 7},
+	'9': {7, 5, 7, 1, 7},
+	's': {0, 3, 4, 1, 6},
+}
+
+// blend mixes c into the pixel at x, y with the given opacity
+func blend(img *image.RGBA, x, y int, c color.RGBA, alpha float64) {
+	if !(image.Point{x, y}.In(img.Rect)) {
+		return
+	}
+	p := img.RGBAAt(x, y)
+	mix := func(a, b uint8) uint8 {
+		return uint8(float64(a)*(1-alpha) + float64(b)*alpha + 0.5)
+	}
+	img.SetRGBA(x, y, color.RGBA{mix(p.R, c.R), mix(p.G, c.G), mix(p.B, c.B), 255})
+}
+
+// timeToX returns the column of a time offset within a clip of the given duration
+func timeToX(img *image.RGBA, t, duration time.Duration) int {
+	return int(float64(t) / float64(duration) * float64(img.Rect.Dx()))
+}
+
+// drawDetectionWindow outlines the part of the clip in which the species was detected
+func drawDetectionWindow(img *image.RGBA, start, end, duration time.Duration) {
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	x0 := max(timeToX(img, start, duration), 0)
+	x1 := min(timeToX(img, end, duration), width-1)
+	if x1 <= x0 {
+		return
+	}
+
+	// Thicker lines on larger images so the window stays visible when scaled down
+	thickness := max(width/400, 1)
+	for t := 0; t < thickness; t++ {
+		for y := 0; y < height; y++ {
+			blend(img, x0+t, y, detectionColor, 0.8)
+			blend(img, x1-t, y, detectionColor, 0.8)
+		}
+		for x := x0 + thickness; x <= x1-thickness; x++ {
+			blend(img, x, t, detectionColor, 0.8)
+			blend(img, x, height-1-t, detectionColor, 0.8)
+		}
+	}
+}
+
+// drawTimeAxis draws second marks and labels along the bottom of the image
+func drawTimeAxis(img *image.RGBA, duration time.Duration) {
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	if height < axisHeight*2 || duration <= 0 {
+		return
+	}
+	top := height - axisHeight
+
+	// Darken the axis strip so the labels are readable on loud parts of the spectrogram
+	for y := top; y < height; y++ {
+		for x := 0; x < width; x++ {
+			blend(img, x, y, color.RGBA{0, 0, 0, 255}, 0.6)
+		}
+	}
+
+	// Pick the shortest label interval that leaves enough room between the labels
+	pixelsPerSecond := float64(width) / duration.Seconds()
+	interval := tickIntervals[len(tickIntervals)-1]
+	for _, candidate := range tickIntervals {
+		if float64(candidate)*pixelsPerSecond >= minTickSpacing {
+			interval = candidate
+			break
+		}
+	}
+
+	for second := 0; time.Duration(second)*time.Second < duration; second++ {
+		x := timeToX(img, time.Duration(second)*time.Second, duration)
+		if second%interval != 0 {
+			// Minor tick on every second when there is room for it
+			if pixelsPerSecond >= 4 {
+				img.SetRGBA(x, top, axisColor)
+			}
+			continue
+		}
+		for y := top; y < top+3; y++ {
+			img.SetRGBA(x, y, axisColor)
+		}
+		if second > 0 {
+			drawText(img, x+2, top+2, strconv.Itoa(second)+"s", axisColor)
+		}
+	}
+}
+
+// drawText draws text with the built-in 3x5 pixel font, the top left corner is at x, y
+func drawText(img *image.RGBA, x, y int, text string, c color.RGBA) {
+	for _, r := range text {
+		glyph, ok := digitGlyphs[r]
+		if !ok {
+			continue
+		}
+		for row, bits := range glyph {
+			for col := 0; col < 3; col++ {
+				if bits&(4>>col) != 0 {
+					blend(img, x+col, y+row, c, 1)
+				}
+			}
+		}
+		x += 4
+	}
+}
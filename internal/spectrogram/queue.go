@@ -0,0 +1,205 @@
+package spectrogram
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/telemetry/metrics"
+)
+
+// Pre-generation queue parameters, a single worker keeps the load low on small boards
+const (
+	queueSize    = 100
+	queueWorkers = 1
+)
+
+// renders deduplicates concurrent renders of the same spectrogram file, whether they are
+// requested by the pre-generation queue or by the web interface
+var renders singleflight.Group
+
+// defaultQueue is the running pre-generation queue, nil if pre-generation is disabled
+var (
+	defaultQueue   *Queue
+	defaultQueueMu sync.RWMutex
+)
+
+// FileName returns the file name of the spectrogram of an audio file for the given width and format
+func FileName(audioFileName string, width int, format string) string {
+	base := strings.TrimSuffix(filepath.Base(audioFileName), filepath.Ext(audioFileName))
+	return fmt.Sprintf("%s_%dpx%s", base, width, FileExtension(format))
+}
+
+// FilePath returns the path of the spectrogram of an audio file, spectrograms are stored next
+// to their audio clips
+func FilePath(audioPath string, width int, format string) string {
+	return filepath.Join(filepath.Dir(audioPath), FileName(audioPath, width, format))
+}
+
+// Generate renders the spectrogram of an audio file unless it already exists. Concurrent calls for
+// the same output path share a single render.
+func Generate(audioPath, outputPath, format string, opts Options) error {
+	_, err, _ := renders.Do(outputPath, func() (interface{}, error) {
+		if _, err := os.Stat(outputPath); err == nil {
+			return nil, nil
+		}
+		return nil, RenderFile(audioPath, outputPath, format, opts)
+	})
+	return err
+}
+
+// Queue renders spectrograms of new audio clips in the background so they are ready before the
+// dashboard requests them. The queue is bounded, clips are dropped when it is full and their
+// spectrograms are rendered on first request instead.
+type Queue struct {
+	jobs     chan string
+	settings *conf.Settings
+	metrics  *metrics.SpectrogramMetrics
+
+	mu     sync.Mutex
+	queued map[string]bool // audio files waiting in the queue
+	wg     sync.WaitGroup
+	done   chan struct{}
+}
+
+// NewQueue creates a pre-generation queue rendering the widths configured in settings, metrics may be nil
+func NewQueue(settings *conf.Settings, m *metrics.SpectrogramMetrics) *Queue {
+	return &Queue{
+		jobs:     make(chan string, queueSize),
+		settings: settings,
+		metrics:  m,
+		queued:   make(map[string]bool),
+		done:     make(chan struct{}),
+	}
+}
+
+// StartQueue starts the pre-generation queue configured in settings, it does nothing if
+// pre-generation is disabled. The returned function stops the queue.
+func StartQueue(settings *conf.Settings, m *metrics.SpectrogramMetrics) (stop func()) {
+	spectrogramSettings := &settings.Realtime.Dashboard.Spectrogram
+	if !spectrogramSettings.PreRender || len(spectrogramSettings.Widths) == 0 {
+		return func() {}
+	}
+
+	q := NewQueue(settings, m)
+	q.Start()
+
+	defaultQueueMu.Lock()
+	defaultQueue = q
+	defaultQueueMu.Unlock()
+
+	return func() {
+		defaultQueueMu.Lock()
+		defaultQueue = nil
+		defaultQueueMu.Unlock()
+		q.Stop()
+	}
+}
+
+// Enqueue adds an audio clip to the running pre-generation queue, if there is one
+func Enqueue(audioPath string) {
+	defaultQueueMu.RLock()
+	q := defaultQueue
+	defaultQueueMu.RUnlock()
+	if q != nil {
+		q.Enqueue(audioPath)
+	}
+}
+
+// Start starts the queue workers
+func (q *Queue) Start() {
+	for i := 0; i < queueWorkers; i++ {
+		q.wg.Add(1)
+		go q.worker()
+	}
+}
+
+// Stop stops the workers after the spectrograms being rendered are finished, queued clips are discarded
+func (q *Queue) Stop() {
+	close(q.done)
+	q.wg.Wait()
+}
+
+// Enqueue adds an audio clip to the queue without blocking. It returns false if the queue is
+// full, clips already waiting in the queue are not added again.
+func (q *Queue) Enqueue(audioPath string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.queued[audioPath] {
+		return true
+	}
+	select {
+	case q.jobs <- audioPath:
+		q.queued[audioPath] = true
+		q.setDepth()
+		return true
+	default:
+		if q.metrics != nil {
+			q.metrics.IncrementDropped()
+		}
+		log.Printf("⚠️ Spectrogram queue is full, skipping pre-generation of %s", audioPath)
+		return false
+	}
+}
+
+// Len returns the number of clips waiting in the queue
+func (q *Queue) Len() int {
+	return len(q.jobs)
+}
+
+// setDepth updates the queue depth metric, the caller must hold q.mu
+func (q *Queue) setDepth() {
+	if q.metrics != nil {
+		q.metrics.SetQueueDepth(float64(len(q.queued)))
+	}
+}
+
+// worker renders the spectrograms of queued clips until the queue is stopped
+func (q *Queue) worker() {
+	defer q.wg.Done()
+	for {
+		select {
+		case <-q.done:
+			return
+		case audioPath := <-q.jobs:
+			q.mu.Lock()
+			delete(q.queued, audioPath)
+			q.setDepth()
+			q.mu.Unlock()
+
+			q.render(audioPath)
+		}
+	}
+}
+
+// render renders all configured widths of an audio clip
+func (q *Queue) render(audioPath string) {
+	format := q.settings.Realtime.Dashboard.Spectrogram.Format
+	for _, width := range q.settings.Realtime.Dashboard.Spectrogram.Widths {
+		outputPath := FilePath(audioPath, width, format)
+		if _, err := os.Stat(outputPath); err == nil {
+			continue
+		}
+
+		start := time.Now()
+		err := Generate(audioPath, outputPath, format, OptionsFromSettings(q.settings, width))
+		if q.metrics != nil {
+			q.metrics.ObserveRender(time.Since(start).Seconds(), err)
+		}
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				log.Printf("❌ Error pre-generating spectrogram for %s: %v", audioPath, err)
+			}
+			return
+		}
+	}
+}
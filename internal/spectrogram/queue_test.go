@@ -0,0 +1,134 @@
+package spectrogram
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/myaudio"
+)
+
+// writeTestClip writes a short WAV clip with a tone and returns its path
+func writeTestClip(t *testing.T, dir, name string) string {
+	t.Helper()
+	samples := sineWave(4000, 0.5, time.Second)
+	pcm := make([]byte, 2*len(samples))
+	for i, s := range samples {
+		binary.LittleEndian.PutUint16(pcm[i*2:], uint16(int16(s*32767)))
+	}
+	path := filepath.Join(dir, name)
+	require.NoError(t, myaudio.SavePCMDataToWAV(path, pcm, nil))
+	return path
+}
+
+// testSpectrogramSettings returns settings with pre-generation of the given widths enabled
+func testSpectrogramSettings(widths ...int) *conf.Settings {
+	settings := &conf.Settings{}
+	s := &settings.Realtime.Dashboard.Spectrogram
+	s.Format = FormatPNG
+	s.MaxFreq = 12000
+	s.DynamicRange = 100
+	s.ColorMap = "viridis"
+	s.PreRender = true
+	s.Widths = widths
+	return settings
+}
+
+func TestFileName(t *testing.T) {
+	assert.Equal(t, "owl_80p_20240101T120000Z_400px.png", FileName("owl_80p_20240101T120000Z.flac", 400, FormatPNG))
+	assert.Equal(t, "owl_400px.webp", FileName("/clips/2024/01/owl.wav", 400, FormatWebP))
+	assert.Equal(t, filepath.Join("clips", "2024", "owl_800px.png"), FilePath(filepath.Join("clips", "2024", "owl.wav"), 800, FormatPNG))
+}
+
+func TestGenerateConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	audioPath := writeTestClip(t, dir, "clip.wav")
+	outputPath := FilePath(audioPath, 200, FormatPNG)
+
+	// Concurrent requests for the same spectrogram share one render and all succeed
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = Generate(audioPath, outputPath, FormatPNG, Options{Width: 200})
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+
+	info, err := os.Stat(outputPath)
+	require.NoError(t, err)
+	modTime := info.ModTime()
+
+	// An existing spectrogram is not rendered again
+	require.NoError(t, Generate(audioPath, outputPath, FormatPNG, Options{Width: 200}))
+	info, err = os.Stat(outputPath)
+	require.NoError(t, err)
+	assert.Equal(t, modTime, info.ModTime())
+
+	// Rendering a missing clip fails
+	assert.Error(t, Generate(filepath.Join(dir, "missing.wav"), filepath.Join(dir, "missing_200px.png"), FormatPNG, Options{Width: 200}))
+}
+
+func TestQueueEnqueue(t *testing.T) {
+	q := NewQueue(testSpectrogramSettings(400), nil)
+
+	// Clips waiting in the queue are not added twice
+	assert.True(t, q.Enqueue("a.wav"))
+	assert.True(t, q.Enqueue("a.wav"))
+	assert.Equal(t, 1, q.Len())
+
+	// The queue is bounded and drops clips when it is full
+	for i := 1; i < queueSize; i++ {
+		require.True(t, q.Enqueue(fmt.Sprintf("clip%d.wav", i)))
+	}
+	assert.False(t, q.Enqueue("overflow.wav"))
+	assert.Equal(t, queueSize, q.Len())
+}
+
+func TestQueuePreRender(t *testing.T) {
+	dir := t.TempDir()
+	settings := testSpectrogramSettings(200, 300)
+
+	stop := StartQueue(settings, nil)
+	defer stop()
+
+	clips := []string{writeTestClip(t, dir, "first.wav"), writeTestClip(t, dir, "second.wav")}
+	for _, clip := range clips {
+		Enqueue(clip)
+	}
+
+	for _, clip := range clips {
+		for _, width := range settings.Realtime.Dashboard.Spectrogram.Widths {
+			path := FilePath(clip, width, FormatPNG)
+			assert.Eventually(t, func() bool {
+				_, err := os.Stat(path)
+				return err == nil
+			}, 10*time.Second, 10*time.Millisecond, "spectrogram %s should be pre-rendered", path)
+		}
+	}
+}
+
+func TestStartQueueDisabled(t *testing.T) {
+	settings := testSpectrogramSettings(400)
+	settings.Realtime.Dashboard.Spectrogram.PreRender = false
+
+	stop := StartQueue(settings, nil)
+	defer stop()
+
+	defaultQueueMu.RLock()
+	defer defaultQueueMu.RUnlock()
+	assert.Nil(t, defaultQueue)
+}
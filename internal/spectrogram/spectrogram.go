@@ -0,0 +1,207 @@
+// Package spectrogram renders spectrogram images of audio clips in-process with a short-time
+// Fourier transform, without external tools such as SoX or FFmpeg.
+package spectrogram
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/myaudio/dsp"
+)
+
+// Default rendering options
+const (
+	DefaultFFTSize      = 1024
+	DefaultMaxFreq      = 12000
+	DefaultDynamicRange = 100
+	DefaultColorMap     = "inferno"
+)
+
+// Options controls how a spectrogram is rendered
+type Options struct {
+	Width        int           // image width in pixels
+	Height       int           // image height in pixels, defaults to half of the width
+	FFTSize      int           // STFT window size in samples, must be a power of two
+	MinFreq      float64       // lowest frequency shown in Hz
+	MaxFreq      float64       // highest frequency shown in Hz, limited to the Nyquist frequency
+	DynamicRange float64       // range of levels shown in dB, quieter levels are drawn with the lowest color
+	Normalize    bool          // true to scale levels to the loudest point, false to scale to 0 dBFS
+	ColorMap     string        // name of the color map, see ColorMaps
+	Duration     time.Duration // length of audio rendered, zero renders the whole clip
+
+	// DetectionStart and DetectionEnd mark the detection window within the clip, the window is
+	// drawn when DetectionEnd is after DetectionStart
+	DetectionStart time.Duration
+	DetectionEnd   time.Duration
+	MarkDetection  bool // true to mark the detection window recorded in the metadata of a rendered clip file
+
+	TimeAxis bool // true to draw a time axis with second marks along the bottom
+}
+
+// withDefaults returns the options with unset values replaced by defaults
+func (o Options) withDefaults() Options {
+	if o.Height == 0 {
+		o.Height = o.Width / 2
+	}
+	if o.FFTSize == 0 {
+		o.FFTSize = DefaultFFTSize
+	}
+	if o.MaxFreq == 0 {
+		o.MaxFreq = DefaultMaxFreq
+	}
+	if o.DynamicRange == 0 {
+		o.DynamicRange = DefaultDynamicRange
+	}
+	if o.ColorMap == "" {
+		o.ColorMap = DefaultColorMap
+	}
+	return o
+}
+
+// validate checks the options for a clip with the given sample rate
+func (o Options) validate(sampleRate int) error {
+	switch {
+	case o.Width <= 0 || o.Height <= 0:
+		return fmt.Errorf("invalid spectrogram size %dx%d", o.Width, o.Height)
+	case !dsp.IsPowerOfTwo(o.FFTSize):
+		return fmt.Errorf("FFT size must be a power of two, got %d", o.FFTSize)
+	case o.MinFreq < 0 || o.MinFreq >= min(o.MaxFreq, float64(sampleRate)/2):
+		return fmt.Errorf("invalid frequency range %.0f-%.0f Hz for sample rate %d", o.MinFreq, o.MaxFreq, sampleRate)
+	case o.DynamicRange < 0:
+		return fmt.Errorf("dynamic range must be positive, got %.0f dB", o.DynamicRange)
+	}
+	return nil
+}
+
+// Render renders a spectrogram of mono audio samples normalized to [-1, 1]
+func Render(samples []float32, sampleRate int, opts Options) (*image.RGBA, error) {
+	opts = opts.withDefaults()
+	if sampleRate <= 0 {
+		return nil, fmt.Errorf("invalid sample rate: %d", sampleRate)
+	}
+	if err := opts.validate(sampleRate); err != nil {
+		return nil, err
+	}
+	if opts.Duration > 0 {
+		if n := int(opts.Duration.Seconds() * float64(sampleRate)); n < len(samples) {
+			samples = samples[:n]
+		}
+	}
+	if len(samples) == 0 {
+		return nil, errors.New("no audio samples to render")
+	}
+
+	pal, err := newPalette(opts.ColorMap)
+	if err != nil {
+		return nil, err
+	}
+
+	levels := stft(samples, sampleRate, opts)
+
+	// Map levels in dB to colors, the top of the scale is either 0 dBFS or the loudest level
+	top := 0.0
+	if opts.Normalize {
+		top = math.Inf(-1)
+		for _, level := range levels {
+			top = max(top, level)
+		}
+	}
+	floor := top - opts.DynamicRange
+
+	img := image.NewRGBA(image.Rect(0, 0, opts.Width, opts.Height))
+	for y := 0; y < opts.Height; y++ {
+		for x := 0; x < opts.Width; x++ {
+			level := (levels[y*opts.Width+x] - floor) / opts.DynamicRange
+			img.SetRGBA(x, y, pal.color(level))
+		}
+	}
+
+	duration := time.Duration(float64(len(samples)) / float64(sampleRate) * float64(time.Second))
+	if opts.DetectionEnd > opts.DetectionStart {
+		drawDetectionWindow(img, opts.DetectionStart, opts.DetectionEnd, duration)
+	}
+	if opts.TimeAxis {
+		drawTimeAxis(img, duration)
+	}
+	return img, nil
+}
+
+// stft computes the levels in dBFS of each pixel, row by row from the highest frequency down.
+// Each column averages the power spectra of the half-overlapping windows within its time span.
+func stft(samples []float32, sampleRate int, opts Options) []float64 {
+	n := opts.FFTSize
+	window := dsp.HannWindow(n)
+
+	// Scale the power so that a full scale sine wave reads 0 dBFS
+	var windowSum float64
+	for _, w := range window {
+		windowSum += w
+	}
+	scale := 4 / (windowSum * windowSum)
+
+	// Frequency range covered by each row in fractional bins
+	maxFreq := min(opts.MaxFreq, float64(sampleRate)/2)
+	binHz := float64(sampleRate) / float64(n)
+	rowHz := (maxFreq - opts.MinFreq) / float64(opts.Height)
+	rowBins := make([][2]float64, opts.Height)
+	for y := range rowBins {
+		hi := maxFreq - float64(y)*rowHz
+		rowBins[y] = [2]float64{(hi - rowHz) / binHz, hi / binHz}
+	}
+
+	levels := make([]float64, opts.Width*opts.Height)
+	buf := make([]complex128, n)
+	power := make([]float64, n/2+1)
+	hop := n / 2
+
+	for x := 0; x < opts.Width; x++ {
+		spanStart := x * len(samples) / opts.Width
+		spanEnd := (x + 1) * len(samples) / opts.Width
+		frames := max((spanEnd-spanStart+hop-1)/hop, 1)
+
+		clear(power)
+		for f := 0; f < frames; f++ {
+			center := spanStart + f*hop + hop/2
+			offset := center - n/2
+			for i := range buf {
+				var s float64
+				if j := offset + i; j >= 0 && j < len(samples) {
+					s = float64(samples[j])
+				}
+				buf[i] = complex(s*window[i], 0)
+			}
+			dsp.FFT(buf)
+			for k := range power {
+				re, im := real(buf[k]), imag(buf[k])
+				power[k] += (re*re + im*im) * scale / float64(frames)
+			}
+		}
+
+		for y, bins := range rowBins {
+			levels[y*opts.Width+x] = 10 * math.Log10(rowPower(power, bins[0], bins[1])+1e-20)
+		}
+	}
+	return levels
+}
+
+// rowPower returns the power of a row spanning the fractional bins lo to hi. Rows wider than a bin
+// take the loudest bin within the row, narrower rows interpolate between the neighbouring bins.
+func rowPower(power []float64, lo, hi float64) float64 {
+	last := len(power) - 1
+	first, end := int(math.Ceil(lo)), int(math.Floor(hi))
+	if end >= first {
+		var p float64
+		for k := max(first, 0); k <= min(end, last); k++ {
+			p = max(p, power[k])
+		}
+		return p
+	}
+
+	center := min(max((lo+hi)/2, 0), float64(last))
+	k := min(int(center), last-1)
+	t := center - float64(k)
+	return power[k]*(1-t) + power[k+1]*t
+}
@@ -0,0 +1,187 @@
+package spectrogram
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/myaudio"
+)
+
+// sineWave returns a sine wave of the given frequency, amplitude and length at 48 kHz
+func sineWave(freq, amplitude float64, duration time.Duration) []float32 {
+	samples := make([]float32, int(duration.Seconds()*conf.SampleRate))
+	for i := range samples {
+		samples[i] = float32(amplitude * math.Sin(2*math.Pi*freq*float64(i)/conf.SampleRate))
+	}
+	return samples
+}
+
+// brightestRow returns the row with the highest summed luminance in a column range
+func brightestRow(t *testing.T, samples []float32, opts Options, x0, x1 int) int {
+	t.Helper()
+	img, err := Render(samples, conf.SampleRate, opts)
+	require.NoError(t, err)
+
+	best, bestSum := -1, -1
+	for y := 0; y < img.Rect.Dy(); y++ {
+		sum := 0
+		for x := x0; x < x1; x++ {
+			c := img.RGBAAt(x, y)
+			sum += int(c.R) + int(c.G) + int(c.B)
+		}
+		if sum > bestSum {
+			best, bestSum = y, sum
+		}
+	}
+	return best
+}
+
+func TestRenderTonePosition(t *testing.T) {
+	opts := Options{Width: 200, Height: 120, MaxFreq: 12000, Normalize: true}
+	samples := sineWave(3000, 0.5, 3*time.Second)
+
+	img, err := Render(samples, conf.SampleRate, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 200, img.Rect.Dx())
+	assert.Equal(t, 120, img.Rect.Dy())
+
+	// 3 kHz is a quarter of the way up from 0 to 12 kHz, rows count down from the top
+	row := brightestRow(t, samples, opts, 10, 190)
+	assert.InDelta(t, 90, row, 1, "tone should be drawn at the row of its frequency")
+
+	// A narrower frequency range moves the tone up in the image
+	opts.MinFreq, opts.MaxFreq = 2000, 4000
+	row = brightestRow(t, samples, opts, 10, 190)
+	assert.InDelta(t, 60, row, 1)
+}
+
+func TestRenderLevels(t *testing.T) {
+	// A full scale sine reads about 0 dBFS, so without normalization a quiet tone is darker
+	loud := sineWave(1000, 1, time.Second)
+	quiet := sineWave(1000, 0.01, time.Second)
+	opts := Options{Width: 50, Height: 50, ColorMap: "grayscale", DynamicRange: 80}
+
+	loudImg, err := Render(loud, conf.SampleRate, opts)
+	require.NoError(t, err)
+	quietImg, err := Render(quiet, conf.SampleRate, opts)
+	require.NoError(t, err)
+
+	row := 45 // 960-1200 Hz
+	loudLevel := loudImg.RGBAAt(25, row).R
+	quietLevel := quietImg.RGBAAt(25, row).R
+	assert.Greater(t, loudLevel, uint8(240), "full scale tone should be near the top of the scale")
+	assert.InDelta(t, float64(loudLevel)-255*40.0/80, float64(quietLevel), 12, "-40 dB tone should be half way down an 80 dB range")
+
+	// Normalized, both tones are drawn at the top of the scale
+	opts.Normalize = true
+	quietImg, err = Render(quiet, conf.SampleRate, opts)
+	require.NoError(t, err)
+	assert.Greater(t, quietImg.RGBAAt(25, row).R, uint8(240))
+}
+
+func TestRenderOverlays(t *testing.T) {
+	samples := make([]float32, 10*conf.SampleRate) // silence renders with the lowest color
+	opts := Options{Width: 400, Height: 100, ColorMap: "grayscale", DetectionStart: 2 * time.Second, DetectionEnd: 5 * time.Second}
+
+	img, err := Render(samples, conf.SampleRate, opts)
+	require.NoError(t, err)
+
+	// The detection window is outlined at 2 and 5 seconds
+	assert.Greater(t, img.RGBAAt(80, 50).R, uint8(150), "window start should be marked")
+	assert.Greater(t, img.RGBAAt(200, 50).R, uint8(150), "window end should be marked")
+	assert.Equal(t, uint8(0), img.RGBAAt(140, 50).R, "inside of the window should not be covered")
+	assert.Equal(t, uint8(0), img.RGBAAt(40, 50).R)
+
+	// The time axis has a labeled tick every second at 40 pixels per second
+	opts = Options{Width: 400, Height: 100, ColorMap: "grayscale", TimeAxis: true}
+	img, err = Render(samples, conf.SampleRate, opts)
+	require.NoError(t, err)
+	assert.Greater(t, img.RGBAAt(40, 100-axisHeight).R, uint8(150), "tick at 1 s")
+	assert.Equal(t, uint8(0), img.RGBAAt(60, 100-axisHeight).R, "no tick between seconds")
+}
+
+func TestRenderInvalidOptions(t *testing.T) {
+	samples := sineWave(1000, 0.5, time.Second)
+
+	_, err := Render(samples, conf.SampleRate, Options{Width: 100, FFTSize: 1000})
+	assert.Error(t, err, "FFT size must be a power of two")
+
+	_, err = Render(samples, conf.SampleRate, Options{Width: 100, MinFreq: 30000, MaxFreq: 40000})
+	assert.Error(t, err, "frequency range must be below the Nyquist frequency")
+
+	_, err = Render(samples, conf.SampleRate, Options{Width: 100, ColorMap: "rainbow"})
+	assert.Error(t, err)
+
+	_, err = Render(nil, conf.SampleRate, Options{Width: 100})
+	assert.Error(t, err)
+}
+
+func TestRenderFile(t *testing.T) {
+	dir := t.TempDir()
+	audioPath := filepath.Join(dir, "clip.wav")
+
+	samples := sineWave(6000, 0.5, 2*time.Second)
+	pcm := make([]byte, 2*len(samples))
+	for i, s := range samples {
+		binary.LittleEndian.PutUint16(pcm[i*2:], uint16(int16(s*32767)))
+	}
+	require.NoError(t, myaudio.SavePCMDataToWAV(audioPath, pcm, nil))
+
+	for _, format := range []string{FormatPNG, FormatWebP} {
+		t.Run(format, func(t *testing.T) {
+			outputPath := filepath.Join(dir, "clip_400"+FileExtension(format))
+			opts := Options{Width: 400, MaxFreq: 12000, Normalize: true, TimeAxis: true}
+			require.NoError(t, RenderFile(audioPath, outputPath, format, opts))
+
+			data, err := os.ReadFile(outputPath)
+			require.NoError(t, err)
+			_, err = os.Stat(outputPath + ".temp")
+			assert.True(t, os.IsNotExist(err), "temporary file should be renamed")
+
+			if format == FormatPNG {
+				img, err := png.Decode(bytes.NewReader(data))
+				require.NoError(t, err)
+				assert.Equal(t, 400, img.Bounds().Dx())
+				assert.Equal(t, 200, img.Bounds().Dy())
+				return
+			}
+			img, err := decodeTestWebP(data)
+			require.NoError(t, err)
+			assert.Equal(t, 400, img.Bounds().Dx())
+			assert.Equal(t, 200, img.Bounds().Dy())
+		})
+	}
+}
+
+func TestClipDetectionWindow(t *testing.T) {
+	dir := t.TempDir()
+	pcm := make([]byte, conf.SampleRate*2*5)
+
+	// The detection window is taken from the times recorded in the clip
+	detected := time.Date(2024, 5, 1, 5, 30, 0, 0, time.UTC)
+	meta := &myaudio.ClipMetadata{CommonName: "Eurasian Blackbird", Time: detected, ClipStart: detected.Add(-2 * time.Second)}
+	audioPath := filepath.Join(dir, "clip.wav")
+	require.NoError(t, myaudio.SavePCMDataToWAV(audioPath, pcm, meta))
+
+	start, end := clipDetectionWindow(audioPath)
+	assert.Equal(t, 2*time.Second, start)
+	assert.Equal(t, start+detectionWindow(), end)
+
+	// Clips without recorded times have no detection window
+	audioPath = filepath.Join(dir, "old.wav")
+	require.NoError(t, myaudio.SavePCMDataToWAV(audioPath, pcm, nil))
+
+	start, end = clipDetectionWindow(audioPath)
+	assert.Zero(t, start)
+	assert.Zero(t, end)
+}
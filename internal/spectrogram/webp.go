@@ -0,0 +1,464 @@
+package spectrogram
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math/bits"
+	"sort"
+)
+
+// VP8L bitstream constants, see https://developers.google.com/speed/webp/docs/webp_lossless_bitstream_specification
+const (
+	vp8lSignature      = 0x2f
+	vp8lMaxDimension   = 1 << 14
+	vp8lMaxCodeLength  = 15
+	vp8lMaxCodeLenCode = 7
+	vp8lNumLiterals    = 256
+	vp8lNumLengthCodes = 24
+	vp8lNumDistCodes   = 40
+	vp8lNumCodeLenSyms = 19
+)
+
+// vp8lCodeLengthOrder is the order in which the code lengths of the code length code are stored
+var vp8lCodeLengthOrder = [vp8lNumCodeLenSyms]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// LZ77 parameters of the encoder
+const (
+	vp8lMinMatch      = 3
+	vp8lMaxMatch      = 4096
+	vp8lMaxDistance   = 1<<20 - 120
+	vp8lHashBits      = 16
+	vp8lMaxChainDepth = 32
+)
+
+// vp8lToken is either a literal pixel or a backward reference to earlier pixels
+type vp8lToken struct {
+	pixel    [4]uint8 // green, red, blue and alpha, the order in which the channels are coded
+	length   int      // length of the backward reference, zero for literals
+	distance int      // distance of the backward reference in pixels
+}
+
+// EncodeWebP writes the image as a lossless WebP file. The encoder uses Huffman coded literals and
+// LZ77 backward references without transforms or a color cache, which compresses the large areas
+// of similar colors in spectrograms well without the complexity of a full encoder.
+func EncodeWebP(w io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width < 1 || height < 1 || width > vp8lMaxDimension || height > vp8lMaxDimension {
+		return fmt.Errorf("invalid WebP image size %dx%d", width, height)
+	}
+
+	// Collect the pixels with non-premultiplied alpha
+	pixels := make([][4]uint8, 0, width*height)
+	opaque := true
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			pixels = append(pixels, [4]uint8{c.G, c.R, c.B, c.A})
+			opaque = opaque && c.A == 255
+		}
+	}
+	tokens := vp8lBackwardReferences(pixels)
+
+	// Count the symbols of the green, red, blue, alpha and distance alphabets, the green
+	// alphabet also holds the length prefix codes of backward references
+	counts := [5][]int{
+		make([]int, vp8lNumLiterals+vp8lNumLengthCodes),
+		make([]int, vp8lNumLiterals),
+		make([]int, vp8lNumLiterals),
+		make([]int, vp8lNumLiterals),
+		make([]int, vp8lNumDistCodes),
+	}
+	for _, t := range tokens {
+		if t.length == 0 {
+			for i, v := range t.pixel {
+				counts[i][v]++
+			}
+			continue
+		}
+		lengthCode, _, _ := vp8lPrefixEncode(t.length)
+		distanceCode, _, _ := vp8lPrefixEncode(t.distance + 120)
+		counts[0][vp8lNumLiterals+lengthCode]++
+		counts[4][distanceCode]++
+	}
+
+	bw := &vp8lBitWriter{}
+	bw.writeBits(vp8lSignature, 8)
+	bw.writeBits(uint32(width-1), 14)
+	bw.writeBits(uint32(height-1), 14)
+	if opaque {
+		bw.writeBits(0, 1)
+	} else {
+		bw.writeBits(1, 1) // alpha is used
+	}
+	bw.writeBits(0, 3) // version
+	bw.writeBits(0, 1) // no transforms
+	bw.writeBits(0, 1) // no color cache
+	bw.writeBits(0, 1) // single prefix code group
+
+	var codes [5]*vp8lPrefixCode
+	for i := range codes {
+		codes[i] = newVP8LPrefixCode(counts[i])
+		codes[i].write(bw)
+	}
+
+	for _, t := range tokens {
+		if t.length == 0 {
+			for i, v := range t.pixel {
+				codes[i].writeSymbol(bw, int(v))
+			}
+			continue
+		}
+		// Distances are coded as plain distances after the 120 codes of the distance map
+		lengthCode, lengthBits, lengthExtra := vp8lPrefixEncode(t.length)
+		codes[0].writeSymbol(bw, vp8lNumLiterals+lengthCode)
+		bw.writeBits(lengthExtra, lengthBits)
+		distanceCode, distanceBits, distanceExtra := vp8lPrefixEncode(t.distance + 120)
+		codes[4].writeSymbol(bw, distanceCode)
+		bw.writeBits(distanceExtra, distanceBits)
+	}
+
+	data := bw.bytes()
+	chunkSize := len(data)
+	if len(data)%2 == 1 {
+		data = append(data, 0)
+	}
+
+	header := make([]byte, 0, 20)
+	header = append(header, "RIFF"...)
+	header = binary.LittleEndian.AppendUint32(header, uint32(4+8+len(data)))
+	header = append(header, "WEBPVP8L"...)
+	header = binary.LittleEndian.AppendUint32(header, uint32(chunkSize))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// vp8lBackwardReferences splits the pixels into literals and backward references with greedy
+// LZ77 matching over hash chains of pixel pairs
+func vp8lBackwardReferences(pixels [][4]uint8) []vp8lToken {
+	hash := func(i int) uint32 {
+		a := binary.LittleEndian.Uint32(pixels[i][:])
+		b := binary.LittleEndian.Uint32(pixels[i+1][:])
+		return (a*0x1e35a7bd ^ b*0x9e3779b1) >> (32 - vp8lHashBits)
+	}
+	head := make([]int32, 1<<vp8lHashBits)
+	for i := range head {
+		head[i] = -1
+	}
+	chain := make([]int32, len(pixels))
+	insert := func(i int) {
+		if i+1 < len(pixels) {
+			h := hash(i)
+			chain[i] = head[h]
+			head[h] = int32(i)
+		}
+	}
+
+	tokens := make([]vp8lToken, 0, len(pixels)/4)
+	for i := 0; i < len(pixels); {
+		bestLength, bestDistance := 0, 0
+		if i+1 < len(pixels) {
+			maxLength := min(len(pixels)-i, vp8lMaxMatch)
+			candidate := head[hash(i)]
+			for depth := 0; candidate >= 0 && depth < vp8lMaxChainDepth; depth++ {
+				distance := i - int(candidate)
+				if distance > vp8lMaxDistance {
+					break
+				}
+				length := 0
+				for length < maxLength && pixels[int(candidate)+length] == pixels[i+length] {
+					length++
+				}
+				if length > bestLength {
+					bestLength, bestDistance = length, distance
+					if length == maxLength {
+						break
+					}
+				}
+				candidate = chain[candidate]
+			}
+		}
+
+		if bestLength < vp8lMinMatch {
+			tokens = append(tokens, vp8lToken{pixel: pixels[i]})
+			insert(i)
+			i++
+			continue
+		}
+		tokens = append(tokens, vp8lToken{length: bestLength, distance: bestDistance})
+		for end := i + bestLength; i < end; i++ {
+			insert(i)
+		}
+	}
+	return tokens
+}
+
+// vp8lPrefixEncode returns the prefix code, number of extra bits and extra bits value of a
+// backward reference length or distance
+func vp8lPrefixEncode(value int) (code int, extraBits uint, extra uint32) {
+	v := value - 1
+	if v < 4 {
+		return v, 0, 0
+	}
+	highest := bits.Len(uint(v)) - 1
+	second := (v >> (highest - 1)) & 1
+	extraBits = uint(highest - 1)
+	return 2*highest + second, extraBits, uint32(v) & (1<<extraBits - 1)
+}
+
+// vp8lPrefixCode is a canonical Huffman code of one alphabet of the VP8L format
+type vp8lPrefixCode struct {
+	symbols []int    // used symbols if the code is stored as a simple code
+	lengths []int    // code length of each symbol if the code is stored normally
+	codes   []uint32 // bit reversed codes of each symbol
+}
+
+// newVP8LPrefixCode builds a prefix code from the symbol counts of an alphabet, up to two symbols
+// below 256 are stored as a simple code and all other alphabets as length limited Huffman codes
+func newVP8LPrefixCode(counts []int) *vp8lPrefixCode {
+	c := &vp8lPrefixCode{}
+	var used []int
+	for symbol, count := range counts {
+		if count > 0 {
+			used = append(used, symbol)
+		}
+	}
+	if len(used) <= 2 && (len(used) == 0 || used[len(used)-1] < 256) {
+		c.symbols = used
+		if len(c.symbols) == 0 {
+			c.symbols = []int{0}
+		}
+		return c
+	}
+
+	c.lengths = huffmanCodeLengths(counts, vp8lMaxCodeLength)
+	c.codes = canonicalCodes(c.lengths)
+	return c
+}
+
+// write stores the prefix code in the bitstream
+func (c *vp8lPrefixCode) write(bw *vp8lBitWriter) {
+	if c.symbols != nil {
+		bw.writeBits(1, 1) // simple code
+		bw.writeBits(uint32(len(c.symbols)-1), 1)
+		if c.symbols[0] < 2 {
+			bw.writeBits(0, 1)
+			bw.writeBits(uint32(c.symbols[0]), 1)
+		} else {
+			bw.writeBits(1, 1)
+			bw.writeBits(uint32(c.symbols[0]), 8)
+		}
+		if len(c.symbols) == 2 {
+			bw.writeBits(uint32(c.symbols[1]), 8)
+		}
+		return
+	}
+
+	bw.writeBits(0, 1) // normal code
+
+	// Run length encode the code lengths with the code length alphabet
+	type token struct{ symbol, extra, extraBits int }
+	var tokens []token
+	for i := 0; i < len(c.lengths); {
+		length := c.lengths[i]
+		run := 1
+		for i+run < len(c.lengths) && c.lengths[i+run] == length {
+			run++
+		}
+		i += run
+
+		if length == 0 {
+			for run >= 3 {
+				if run >= 11 {
+					n := min(run, 138)
+					tokens = append(tokens, token{18, n - 11, 7})
+					run -= n
+				} else {
+					tokens = append(tokens, token{17, run - 3, 3})
+					run = 0
+				}
+			}
+			for ; run > 0; run-- {
+				tokens = append(tokens, token{0, 0, 0})
+			}
+			continue
+		}
+
+		tokens = append(tokens, token{length, 0, 0})
+		for run--; run >= 3; {
+			n := min(run, 6)
+			tokens = append(tokens, token{16, n - 3, 2})
+			run -= n
+		}
+		for ; run > 0; run-- {
+			tokens = append(tokens, token{length, 0, 0})
+		}
+	}
+
+	counts := make([]int, vp8lNumCodeLenSyms)
+	for _, t := range tokens {
+		counts[t.symbol]++
+	}
+	codeLengthLengths := huffmanCodeLengths(counts, vp8lMaxCodeLenCode)
+	codeLengthCodes := canonicalCodes(codeLengthLengths)
+
+	numCodes := vp8lNumCodeLenSyms
+	for numCodes > 4 && codeLengthLengths[vp8lCodeLengthOrder[numCodes-1]] == 0 {
+		numCodes--
+	}
+	bw.writeBits(uint32(numCodes-4), 4)
+	for _, symbol := range vp8lCodeLengthOrder[:numCodes] {
+		bw.writeBits(uint32(codeLengthLengths[symbol]), 3)
+	}
+
+	bw.writeBits(0, 1) // code lengths of the whole alphabet follow
+	for _, t := range tokens {
+		bw.writeBits(codeLengthCodes[t.symbol], uint(codeLengthLengths[t.symbol]))
+		if t.extraBits > 0 {
+			bw.writeBits(uint32(t.extra), uint(t.extraBits))
+		}
+	}
+}
+
+// writeSymbol writes the code of a symbol, simple codes with a single symbol take no bits
+func (c *vp8lPrefixCode) writeSymbol(bw *vp8lBitWriter, symbol int) {
+	if c.symbols != nil {
+		if len(c.symbols) == 2 && symbol == c.symbols[1] {
+			bw.writeBits(1, 1)
+		} else if len(c.symbols) == 2 {
+			bw.writeBits(0, 1)
+		}
+		return
+	}
+	bw.writeBits(c.codes[symbol], uint(c.lengths[symbol]))
+}
+
+// huffmanCodeLengths returns the code lengths of a Huffman code for the symbol counts with no code
+// longer than maxLength. Counts are halved until the code fits. At least two symbols get a code so
+// the code is always complete.
+func huffmanCodeLengths(counts []int, maxLength int) []int {
+	counts = append([]int(nil), counts...)
+	var used []int
+	for symbol, count := range counts {
+		if count > 0 {
+			used = append(used, symbol)
+		}
+	}
+	lengths := make([]int, len(counts))
+	if len(used) < 2 {
+		// A complete code needs two symbols, pair the used symbol with another one
+		first := 0
+		if len(used) == 1 {
+			first = used[0]
+		}
+		second := 1
+		if first == 1 {
+			second = 0
+		}
+		lengths[first], lengths[second] = 1, 1
+		return lengths
+	}
+
+	type node struct {
+		weight      int
+		symbol      int // leaf symbol or -1
+		left, right *node
+	}
+
+	for {
+		nodes := make([]*node, 0, len(used))
+		for _, symbol := range used {
+			nodes = append(nodes, &node{weight: counts[symbol], symbol: symbol})
+		}
+		for len(nodes) > 1 {
+			sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].weight < nodes[j].weight })
+			merged := &node{weight: nodes[0].weight + nodes[1].weight, symbol: -1, left: nodes[0], right: nodes[1]}
+			nodes = append([]*node{merged}, nodes[2:]...)
+		}
+
+		longest := 0
+		var walk func(n *node, depth int)
+		walk = func(n *node, depth int) {
+			if n.symbol >= 0 {
+				lengths[n.symbol] = depth
+				longest = max(longest, depth)
+				return
+			}
+			walk(n.left, depth+1)
+			walk(n.right, depth+1)
+		}
+		walk(nodes[0], 0)
+
+		if longest <= maxLength {
+			return lengths
+		}
+		for _, symbol := range used {
+			counts[symbol] = max(counts[symbol]/2, 1)
+		}
+	}
+}
+
+// canonicalCodes assigns canonical Huffman codes to the code lengths. The codes are bit reversed
+// because VP8L reads codes from the least significant bit of the bitstream.
+func canonicalCodes(lengths []int) []uint32 {
+	var lengthCounts [vp8lMaxCodeLength + 1]uint32
+	for _, l := range lengths {
+		if l > 0 {
+			lengthCounts[l]++
+		}
+	}
+	var next [vp8lMaxCodeLength + 2]uint32
+	code := uint32(0)
+	for l := 1; l <= vp8lMaxCodeLength; l++ {
+		code = (code + lengthCounts[l-1]) << 1
+		next[l] = code
+	}
+
+	codes := make([]uint32, len(lengths))
+	for symbol, l := range lengths {
+		if l == 0 {
+			continue
+		}
+		c := next[l]
+		next[l]++
+		var reversed uint32
+		for i := 0; i < l; i++ {
+			reversed = reversed<<1 | (c>>i)&1
+		}
+		codes[symbol] = reversed
+	}
+	return codes
+}
+
+// vp8lBitWriter accumulates a bitstream with the least significant bit first
+type vp8lBitWriter struct {
+	buf   []byte
+	acc   uint64
+	nbits uint
+}
+
+// writeBits writes the n least significant bits of v
+func (w *vp8lBitWriter) writeBits(v uint32, n uint) {
+	w.acc |= uint64(v&(1<<n-1)) << w.nbits
+	w.nbits += n
+	for w.nbits >= 8 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc >>= 8
+		w.nbits -= 8
+	}
+}
+
+// bytes returns the bitstream padded to whole bytes
+func (w *vp8lBitWriter) bytes() []byte {
+	if w.nbits > 0 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc, w.nbits = 0, 0
+	}
+	return w.buf
+}
@@ -0,0 +1,348 @@
+package spectrogram
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"math/bits"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	xwebp "golang.org/x/image/webp"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// testBitReader reads a VP8L bitstream least significant bit first
+type testBitReader struct {
+	data []byte
+	pos  int // bit position
+}
+
+func (r *testBitReader) read(n int) (uint32, error) {
+	var v uint32
+	for i := 0; i < n; i++ {
+		if r.pos/8 >= len(r.data) {
+			return 0, errors.New("unexpected end of bitstream")
+		}
+		v |= uint32(r.data[r.pos/8]>>(r.pos%8)&1) << i
+		r.pos++
+	}
+	return v, nil
+}
+
+// testHuffman decodes canonical codes by reading one bit at a time
+type testHuffman struct {
+	single  int               // symbol of a zero length code, or -1
+	symbols map[[2]uint32]int // (length, code) to symbol
+}
+
+func newTestHuffman(lengths []int) (*testHuffman, error) {
+	h := &testHuffman{single: -1, symbols: map[[2]uint32]int{}}
+	used := 0
+	for symbol, l := range lengths {
+		if l > 0 {
+			used++
+			h.single = symbol
+		}
+	}
+	if used == 1 {
+		return h, nil
+	}
+	h.single = -1
+
+	// The code must be complete, as required by libwebp
+	var kraft float64
+	for _, l := range lengths {
+		if l > 0 {
+			kraft += 1 / float64(uint32(1)<<l)
+		}
+	}
+	if kraft != 1 {
+		return nil, fmt.Errorf("incomplete prefix code, kraft sum %v", kraft)
+	}
+
+	var counts [16]uint32
+	for _, l := range lengths {
+		counts[l]++
+	}
+	counts[0] = 0
+	var next [16]uint32
+	code := uint32(0)
+	for l := 1; l < 16; l++ {
+		code = (code + counts[l-1]) << 1
+		next[l] = code
+	}
+	for symbol, l := range lengths {
+		if l > 0 {
+			h.symbols[[2]uint32{uint32(l), next[l]}] = symbol
+			next[l]++
+		}
+	}
+	return h, nil
+}
+
+func (h *testHuffman) decode(r *testBitReader) (int, error) {
+	if h.single >= 0 {
+		return h.single, nil
+	}
+	var code uint32
+	for l := uint32(1); l < 16; l++ {
+		bit, err := r.read(1)
+		if err != nil {
+			return 0, err
+		}
+		code = code<<1 | bit
+		if symbol, ok := h.symbols[[2]uint32{l, code}]; ok {
+			return symbol, nil
+		}
+	}
+	return 0, errors.New("invalid prefix code")
+}
+
+// readTestPrefixCode reads a simple or normal prefix code of an alphabet
+func readTestPrefixCode(r *testBitReader, alphabet int) (*testHuffman, error) {
+	lengths := make([]int, alphabet)
+	simple, err := r.read(1)
+	if err != nil {
+		return nil, err
+	}
+	if simple == 1 {
+		numSymbols, _ := r.read(1)
+		firstBits, _ := r.read(1)
+		first, _ := r.read(1 + 7*int(firstBits))
+		lengths[first] = 1
+		if numSymbols == 1 {
+			second, _ := r.read(8)
+			lengths[second] = 1
+		}
+		return newTestHuffman(lengths)
+	}
+
+	numCodes, _ := r.read(4)
+	codeLengthLengths := make([]int, vp8lNumCodeLenSyms)
+	for i := 0; i < int(numCodes)+4; i++ {
+		l, err := r.read(3)
+		if err != nil {
+			return nil, err
+		}
+		codeLengthLengths[vp8lCodeLengthOrder[i]] = int(l)
+	}
+	codeLengthCode, err := newTestHuffman(codeLengthLengths)
+	if err != nil {
+		return nil, err
+	}
+	if limited, _ := r.read(1); limited != 0 {
+		return nil, errors.New("limited code lengths are not produced by the encoder")
+	}
+
+	prev := 8
+	for i := 0; i < alphabet; {
+		symbol, err := codeLengthCode.decode(r)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case symbol < 16:
+			lengths[i] = symbol
+			if symbol != 0 {
+				prev = symbol
+			}
+			i++
+		case symbol == 16:
+			n, _ := r.read(2)
+			for j := 0; j < int(n)+3; j++ {
+				lengths[i] = prev
+				i++
+			}
+		case symbol == 17:
+			n, _ := r.read(3)
+			i += int(n) + 3
+		default:
+			n, _ := r.read(7)
+			i += int(n) + 11
+		}
+	}
+	return newTestHuffman(lengths)
+}
+
+// decodeTestWebP decodes the subset of lossless WebP written by EncodeWebP
+func decodeTestWebP(data []byte) (*image.NRGBA, error) {
+	if len(data) < 21 || string(data[:4]) != "RIFF" || string(data[8:16]) != "WEBPVP8L" {
+		return nil, errors.New("not a lossless WebP file")
+	}
+	if int(binary.LittleEndian.Uint32(data[4:])) != len(data)-8 {
+		return nil, errors.New("invalid RIFF size")
+	}
+	size := int(binary.LittleEndian.Uint32(data[16:]))
+	if data[20] != vp8lSignature {
+		return nil, errors.New("invalid VP8L signature")
+	}
+
+	r := &testBitReader{data: data[21 : 20+size]}
+	width, _ := r.read(14)
+	height, _ := r.read(14)
+	_, _ = r.read(1) // alpha hint
+	if version, _ := r.read(3); version != 0 {
+		return nil, errors.New("invalid version")
+	}
+	for i := 0; i < 3; i++ {
+		if bit, _ := r.read(1); bit != 0 {
+			return nil, errors.New("transforms, color cache and meta codes are not produced by the encoder")
+		}
+	}
+
+	alphabets := []int{vp8lNumLiterals + vp8lNumLengthCodes, vp8lNumLiterals, vp8lNumLiterals, vp8lNumLiterals, vp8lNumDistCodes}
+	codes := make([]*testHuffman, len(alphabets))
+	for i, alphabet := range alphabets {
+		var err error
+		if codes[i], err = readTestPrefixCode(r, alphabet); err != nil {
+			return nil, fmt.Errorf("prefix code %d: %w", i, err)
+		}
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, int(width)+1, int(height)+1))
+	for i := 0; i < len(img.Pix); {
+		green, err := codes[0].decode(r)
+		if err != nil {
+			return nil, err
+		}
+
+		if green >= vp8lNumLiterals {
+			length, err := readTestPrefixValue(r, green-vp8lNumLiterals)
+			if err != nil {
+				return nil, err
+			}
+			distanceCode, err := codes[4].decode(r)
+			if err != nil {
+				return nil, err
+			}
+			distance, err := readTestPrefixValue(r, distanceCode)
+			if err != nil {
+				return nil, err
+			}
+			if distance <= 120 {
+				return nil, errors.New("distance map codes are not produced by the encoder")
+			}
+			distance -= 120
+			if distance*4 > i || i+length*4 > len(img.Pix) {
+				return nil, fmt.Errorf("invalid backward reference %d/%d at pixel %d", length, distance, i/4)
+			}
+			for j := 0; j < length*4; j++ {
+				img.Pix[i+j] = img.Pix[i+j-distance*4]
+			}
+			i += length * 4
+			continue
+		}
+
+		var rba [3]int
+		for c := range rba {
+			if rba[c], err = codes[c+1].decode(r); err != nil {
+				return nil, err
+			}
+		}
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = uint8(rba[0]), uint8(green), uint8(rba[1]), uint8(rba[2])
+		i += 4
+	}
+	return img, nil
+}
+
+// readTestPrefixValue reads the extra bits of a length or distance prefix code
+func readTestPrefixValue(r *testBitReader, code int) (int, error) {
+	if code < 4 {
+		return code + 1, nil
+	}
+	extraBits := (code - 2) >> 1
+	offset := (2 + code&1) << extraBits
+	extra, err := r.read(extraBits)
+	return offset + int(extra) + 1, err
+}
+
+func TestEncodeWebPRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	tests := []struct {
+		name  string
+		fill  func(x, y int) color.NRGBA
+		sizeW int
+		sizeH int
+	}{
+		{"single color", func(_, _ int) color.NRGBA { return color.NRGBA{10, 20, 30, 255} }, 7, 3},
+		{"two colors", func(x, _ int) color.NRGBA { return color.NRGBA{uint8(x % 2), 0, 200, 255} }, 16, 16},
+		{"gradient", func(x, y int) color.NRGBA { return color.NRGBA{uint8(x), uint8(y), uint8(x + y), 255} }, 256, 64},
+		{"random with alpha", func(_, _ int) color.NRGBA {
+			return color.NRGBA{uint8(rng.Intn(256)), uint8(rng.Intn(256)), uint8(rng.Intn(256)), uint8(rng.Intn(256))}
+		}, 50, 40},
+		{"skewed histogram", func(x, y int) color.NRGBA {
+			// Geometrically distributed values need codes longer than the length limit
+			v := uint8(bits.TrailingZeros(uint(y*400 + x + 1)))
+			return color.NRGBA{v, v, v, 255}
+		}, 400, 300},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := image.NewNRGBA(image.Rect(0, 0, tt.sizeW, tt.sizeH))
+			for y := 0; y < tt.sizeH; y++ {
+				for x := 0; x < tt.sizeW; x++ {
+					src.SetNRGBA(x, y, tt.fill(x, y))
+				}
+			}
+
+			var buf bytes.Buffer
+			require.NoError(t, EncodeWebP(&buf, src))
+
+			decoded, err := decodeTestWebP(buf.Bytes())
+			require.NoError(t, err)
+			assert.Equal(t, src.Rect, decoded.Rect)
+			assert.Equal(t, src.Pix, decoded.Pix)
+
+			assertDecodesTo(t, buf.Bytes(), src)
+		})
+	}
+}
+
+// assertDecodesTo checks that an independent WebP decoder decodes data to the pixels of want
+func assertDecodesTo(t *testing.T, data []byte, want image.Image) {
+	t.Helper()
+	decoded, err := xwebp.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	require.Equal(t, want.Bounds(), decoded.Bounds())
+	for y := want.Bounds().Min.Y; y < want.Bounds().Max.Y; y++ {
+		for x := want.Bounds().Min.X; x < want.Bounds().Max.X; x++ {
+			require.Equal(t, color.NRGBAModel.Convert(want.At(x, y)), color.NRGBAModel.Convert(decoded.At(x, y)), "pixel %d,%d", x, y)
+		}
+	}
+}
+
+func TestEncodeWebPSpectrogram(t *testing.T) {
+	opts := Options{Width: 300, TimeAxis: true, DetectionStart: time.Second, DetectionEnd: 2 * time.Second}
+	img, err := Render(sineWave(2000, 0.3, 3*time.Second), conf.SampleRate, opts)
+	require.NoError(t, err)
+
+	var webp, png bytes.Buffer
+	require.NoError(t, Encode(&webp, img, FormatWebP))
+	require.NoError(t, Encode(&png, img, FormatPNG))
+
+	decoded, err := decodeTestWebP(webp.Bytes())
+	require.NoError(t, err)
+	for y := 0; y < img.Rect.Dy(); y++ {
+		for x := 0; x < img.Rect.Dx(); x++ {
+			require.Equal(t, img.RGBAAt(x, y), color.RGBAModel.Convert(decoded.NRGBAAt(x, y)), "pixel %d,%d", x, y)
+		}
+	}
+	assertDecodesTo(t, webp.Bytes(), img)
+	t.Logf("WebP %d bytes, PNG %d bytes", webp.Len(), png.Len())
+}
+
+func TestEncodeWebPInvalidSize(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Error(t, EncodeWebP(&buf, image.NewRGBA(image.Rect(0, 0, 0, 10))))
+	assert.Error(t, EncodeWebP(&buf, image.NewRGBA(image.Rect(0, 0, vp8lMaxDimension+1, 1))))
+}
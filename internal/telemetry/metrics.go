@@ -18,6 +18,8 @@ type Metrics struct {
 	MQTT          *metrics.MQTTMetrics
 	BirdNET       *metrics.BirdNETMetrics
 	ImageProvider *metrics.ImageProviderMetrics
+	Spectrogram   *metrics.SpectrogramMetrics
+	AudioHealth   *metrics.AudioHealthMetrics
 }
 
 // NewMetrics creates a new instance of Metrics, initializing all metric collectors.
@@ -40,11 +42,23 @@ func NewMetrics() (*Metrics, error) {
 		return nil, fmt.Errorf("failed to create ImageProvider metrics: %w", err)
 	}
 
+	spectrogramMetrics, err := metrics.NewSpectrogramMetrics(registry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create spectrogram metrics: %w", err)
+	}
+
+	audioHealthMetrics, err := metrics.NewAudioHealthMetrics(registry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create audio health metrics: %w", err)
+	}
+
 	m := &Metrics{
 		registry:      registry,
 		MQTT:          mqttMetrics,
 		BirdNET:       birdnetMetrics,
 		ImageProvider: imageProviderMetrics,
+		Spectrogram:   spectrogramMetrics,
+		AudioHealth:   audioHealthMetrics,
 	}
 
 	return m, nil
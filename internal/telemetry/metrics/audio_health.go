@@ -0,0 +1,139 @@
+// Package metrics provides custom Prometheus metrics for various components of the BirdNET-Go application.
+package metrics
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// AudioHealthMetrics contains all Prometheus metrics related to the health of audio sources.
+type AudioHealthMetrics struct {
+	Level           *prometheus.GaugeVec
+	ClippingRatio   *prometheus.GaugeVec
+	DCOffset        *prometheus.GaugeVec
+	SampleRateRatio *prometheus.GaugeVec
+	SilenceSeconds  *prometheus.GaugeVec
+	Issue           *prometheus.GaugeVec
+	Underruns       *prometheus.CounterVec
+	FFmpegRestarts  *prometheus.CounterVec
+	registry        *prometheus.Registry
+}
+
+// NewAudioHealthMetrics creates a new instance of AudioHealthMetrics.
+// It requires a Prometheus registry to register the metrics.
+// It returns an error if metric registration fails.
+func NewAudioHealthMetrics(registry *prometheus.Registry) (*AudioHealthMetrics, error) {
+	m := &AudioHealthMetrics{registry: registry}
+	m.initMetrics()
+	if err := registry.Register(m); err != nil {
+		return nil, fmt.Errorf("failed to register audio health metrics: %w", err)
+	}
+	return m, nil
+}
+
+// initMetrics initializes all metrics for AudioHealthMetrics.
+func (m *AudioHealthMetrics) initMetrics() {
+	m.Level = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "audio_level_dbfs",
+		Help: "RMS level of the most recent health check window of an audio source in dBFS.",
+	}, []string{"source"})
+
+	m.ClippingRatio = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "audio_clipping_ratio",
+		Help: "Ratio of clipped samples in the most recent health check window of an audio source.",
+	}, []string{"source"})
+
+	m.DCOffset = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "audio_dc_offset",
+		Help: "DC offset of an audio source relative to full scale.",
+	}, []string{"source"})
+
+	m.SampleRateRatio = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "audio_sample_rate_ratio",
+		Help: "Ratio of received to expected samples in the most recent health check window of an audio source.",
+	}, []string{"source"})
+
+	m.SilenceSeconds = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "audio_silence_seconds",
+		Help: "Duration of the current silence of an audio source in seconds.",
+	}, []string{"source"})
+
+	m.Issue = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "audio_health_issue",
+		Help: "Set to 1 while an audio source has the health issue, 0 otherwise.",
+	}, []string{"source", "issue"})
+
+	m.Underruns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "audio_underruns_total",
+		Help: "Total number of health check windows in which an audio source delivered too few samples.",
+	}, []string{"source"})
+
+	m.FFmpegRestarts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "audio_ffmpeg_restarts_total",
+		Help: "Total number of FFmpeg restarts of a stream source.",
+	}, []string{"source"})
+}
+
+// SetSourceStats updates the measurements of an audio source.
+func (m *AudioHealthMetrics) SetSourceStats(source string, levelDBFS, clippingRatio, dcOffset, sampleRateRatio, silenceSeconds float64) {
+	m.Level.WithLabelValues(source).Set(levelDBFS)
+	m.ClippingRatio.WithLabelValues(source).Set(clippingRatio)
+	m.DCOffset.WithLabelValues(source).Set(dcOffset)
+	m.SampleRateRatio.WithLabelValues(source).Set(sampleRateRatio)
+	m.SilenceSeconds.WithLabelValues(source).Set(silenceSeconds)
+}
+
+// SetIssue sets whether an audio source currently has a health issue.
+func (m *AudioHealthMetrics) SetIssue(source, issue string, active bool) {
+	value := 0.0
+	if active {
+		value = 1
+	}
+	m.Issue.WithLabelValues(source, issue).Set(value)
+}
+
+// IncrementUnderruns increases the underrun counter of an audio source by one.
+func (m *AudioHealthMetrics) IncrementUnderruns(source string) {
+	m.Underruns.WithLabelValues(source).Inc()
+}
+
+// IncrementFFmpegRestarts increases the FFmpeg restart counter of a stream source by one.
+func (m *AudioHealthMetrics) IncrementFFmpegRestarts(source string) {
+	m.FFmpegRestarts.WithLabelValues(source).Inc()
+}
+
+// RemoveSource removes all metrics of an audio source which is no longer active.
+func (m *AudioHealthMetrics) RemoveSource(source string) {
+	labels := prometheus.Labels{"source": source}
+	m.Level.DeletePartialMatch(labels)
+	m.ClippingRatio.DeletePartialMatch(labels)
+	m.DCOffset.DeletePartialMatch(labels)
+	m.SampleRateRatio.DeletePartialMatch(labels)
+	m.SilenceSeconds.DeletePartialMatch(labels)
+	m.Issue.DeletePartialMatch(labels)
+}
+
+// Describe implements the prometheus.Collector interface.
+func (m *AudioHealthMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.Level.Describe(ch)
+	m.ClippingRatio.Describe(ch)
+	m.DCOffset.Describe(ch)
+	m.SampleRateRatio.Describe(ch)
+	m.SilenceSeconds.Describe(ch)
+	m.Issue.Describe(ch)
+	m.Underruns.Describe(ch)
+	m.FFmpegRestarts.Describe(ch)
+}
+
+// Collect implements the prometheus.Collector interface.
+func (m *AudioHealthMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.Level.Collect(ch)
+	m.ClippingRatio.Collect(ch)
+	m.DCOffset.Collect(ch)
+	m.SampleRateRatio.Collect(ch)
+	m.SilenceSeconds.Collect(ch)
+	m.Issue.Collect(ch)
+	m.Underruns.Collect(ch)
+	m.FFmpegRestarts.Collect(ch)
+}
@@ -9,9 +9,13 @@ import (
 
 // BirdNETMetrics contains all Prometheus metrics related to BirdNET operations.
 type BirdNETMetrics struct {
-	DetectionCounter *prometheus.CounterVec
-	ProcessTimeGauge prometheus.Gauge
-	registry         *prometheus.Registry
+	DetectionCounter       *prometheus.CounterVec
+	ProcessTimeGauge       prometheus.Gauge
+	InterpreterPoolSize    prometheus.Gauge
+	InterpreterPoolBusy    prometheus.Gauge
+	InterpreterBusySeconds prometheus.Counter
+	InterpreterWaitSeconds prometheus.Counter
+	registry               *prometheus.Registry
 }
 
 // NewBirdNETMetrics creates a new instance of BirdNETMetrics.
@@ -44,6 +48,30 @@ func (m *BirdNETMetrics) initMetrics() error {
 			Help: "Most recent processing time for a BirdNET detection request in milliseconds.",
 		},
 	)
+	m.InterpreterPoolSize = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "birdnet_interpreter_pool_size",
+			Help: "Number of analysis interpreters in the BirdNET interpreter pool.",
+		},
+	)
+	m.InterpreterPoolBusy = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "birdnet_interpreter_pool_busy",
+			Help: "Number of analysis interpreters currently running inference.",
+		},
+	)
+	m.InterpreterBusySeconds = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "birdnet_interpreter_busy_seconds_total",
+			Help: "Total time analysis interpreters spent running inference in seconds, its rate divided by the pool size is the pool utilization.",
+		},
+	)
+	m.InterpreterWaitSeconds = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "birdnet_interpreter_wait_seconds_total",
+			Help: "Total time predictions waited for an idle analysis interpreter in seconds.",
+		},
+	)
 	return err
 }
 
@@ -58,14 +86,35 @@ func (m *BirdNETMetrics) SetProcessTime(milliseconds float64) {
 	m.ProcessTimeGauge.Set(milliseconds)
 }
 
+// SetInterpreterPool sets the size of the interpreter pool and the number of busy interpreters.
+func (m *BirdNETMetrics) SetInterpreterPool(size, busy int) {
+	m.InterpreterPoolSize.Set(float64(size))
+	m.InterpreterPoolBusy.Set(float64(busy))
+}
+
+// AddInterpreterUsage adds the time a prediction waited for an interpreter and the time
+// the interpreter was busy with it, both in seconds.
+func (m *BirdNETMetrics) AddInterpreterUsage(waitSeconds, busySeconds float64) {
+	m.InterpreterWaitSeconds.Add(waitSeconds)
+	m.InterpreterBusySeconds.Add(busySeconds)
+}
+
 // Describe implements the prometheus.Collector interface.
 func (m *BirdNETMetrics) Describe(ch chan<- *prometheus.Desc) {
 	m.DetectionCounter.Describe(ch)
 	ch <- m.ProcessTimeGauge.Desc()
+	ch <- m.InterpreterPoolSize.Desc()
+	ch <- m.InterpreterPoolBusy.Desc()
+	ch <- m.InterpreterBusySeconds.Desc()
+	ch <- m.InterpreterWaitSeconds.Desc()
 }
 
 // Collect implements the prometheus.Collector interface.
 func (m *BirdNETMetrics) Collect(ch chan<- prometheus.Metric) {
 	m.DetectionCounter.Collect(ch)
 	ch <- m.ProcessTimeGauge
+	ch <- m.InterpreterPoolSize
+	ch <- m.InterpreterPoolBusy
+	ch <- m.InterpreterBusySeconds
+	ch <- m.InterpreterWaitSeconds
 }
@@ -0,0 +1,97 @@
+// Package metrics provides custom Prometheus metrics for various components of the BirdNET-Go application.
+package metrics
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// SpectrogramMetrics contains all Prometheus metrics related to spectrogram pre-generation.
+type SpectrogramMetrics struct {
+	QueueDepth     prometheus.Gauge
+	Rendered       prometheus.Counter
+	RenderErrors   prometheus.Counter
+	Dropped        prometheus.Counter
+	RenderDuration prometheus.Histogram
+	registry       *prometheus.Registry
+}
+
+// NewSpectrogramMetrics creates a new instance of SpectrogramMetrics.
+// It requires a Prometheus registry to register the metrics.
+// It returns an error if metric registration fails.
+func NewSpectrogramMetrics(registry *prometheus.Registry) (*SpectrogramMetrics, error) {
+	m := &SpectrogramMetrics{registry: registry}
+	m.initMetrics()
+	if err := registry.Register(m); err != nil {
+		return nil, fmt.Errorf("failed to register spectrogram metrics: %w", err)
+	}
+	return m, nil
+}
+
+// initMetrics initializes all metrics for SpectrogramMetrics.
+func (m *SpectrogramMetrics) initMetrics() {
+	m.QueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "spectrogram_queue_depth",
+		Help: "Number of audio clips waiting for spectrogram pre-generation.",
+	})
+
+	m.Rendered = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "spectrogram_prerendered_total",
+		Help: "Total number of spectrograms rendered by the pre-generation queue.",
+	})
+
+	m.RenderErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "spectrogram_prerender_errors_total",
+		Help: "Total number of failed spectrogram pre-generations.",
+	})
+
+	m.Dropped = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "spectrogram_queue_dropped_total",
+		Help: "Total number of audio clips skipped because the pre-generation queue was full.",
+	})
+
+	m.RenderDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "spectrogram_render_duration_seconds",
+		Help:    "Duration of spectrogram pre-generation in seconds.",
+		Buckets: prometheus.ExponentialBuckets(0.05, 2, 10),
+	})
+}
+
+// SetQueueDepth updates the number of audio clips waiting in the pre-generation queue.
+func (m *SpectrogramMetrics) SetQueueDepth(depth float64) {
+	m.QueueDepth.Set(depth)
+}
+
+// IncrementDropped increases the counter of audio clips dropped from a full queue by one.
+func (m *SpectrogramMetrics) IncrementDropped() {
+	m.Dropped.Inc()
+}
+
+// ObserveRender records the duration and outcome of a spectrogram pre-generation.
+func (m *SpectrogramMetrics) ObserveRender(seconds float64, err error) {
+	m.RenderDuration.Observe(seconds)
+	if err != nil {
+		m.RenderErrors.Inc()
+		return
+	}
+	m.Rendered.Inc()
+}
+
+// Describe implements the prometheus.Collector interface.
+func (m *SpectrogramMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.QueueDepth.Describe(ch)
+	m.Rendered.Describe(ch)
+	m.RenderErrors.Describe(ch)
+	m.Dropped.Describe(ch)
+	m.RenderDuration.Describe(ch)
+}
+
+// Collect implements the prometheus.Collector interface.
+func (m *SpectrogramMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.QueueDepth.Collect(ch)
+	m.Rendered.Collect(ch)
+	m.RenderErrors.Collect(ch)
+	m.Dropped.Collect(ch)
+	m.RenderDuration.Collect(ch)
+}